@@ -7,6 +7,8 @@
 package cmds
 
 import (
+	"strconv"
+
 	"github.com/GuanceCloud/cliutils/logger"
 	prompt "github.com/c-bata/go-prompt"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
@@ -52,13 +54,28 @@ func ipInfo(ip string) (map[string]string, error) {
 			return nil, err
 		}
 
-		return map[string]string{
+		res := map[string]string{
 			"city":     x.City,
 			"province": x.Region,
 			"country":  x.Country,
 			"isp":      ipdbInstance.SearchIsp(ip),
 			"ip":       ip,
-		}, nil
+		}
+
+		if asn, err := ipdbInstance.ASN(ip); err == nil && asn.ASN > 0 {
+			res["asn"] = strconv.FormatUint(uint64(asn.ASN), 10)
+			res["asn_org"] = asn.Org
+		}
+
+		if attrs, ok := ipdbInstance.Overlay(ip); ok {
+			for k, v := range attrs {
+				if _, ok := res[k]; !ok {
+					res[k] = v
+				}
+			}
+		}
+
+		return res, nil
 	}
 }
 
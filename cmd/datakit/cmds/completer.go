@@ -67,6 +67,9 @@ _datakit()
 				--default-main-conf
 				--dump-samples
 				--ipinfo
+				--keystore-delete
+				--keystore-list
+				--keystore-set
 				--log
 				--prom-conf
 				--setup-completer-script
@@ -33,6 +33,10 @@ func tryLoadMainCfg() {
 	if err := config.Cfg.LoadMainTOML(datakit.MainConfPath); err != nil {
 		cp.Warnf("[W] load config %s failed: %s, ignored\n", datakit.MainConfPath, err)
 	}
+
+	if err := config.Cfg.ResolveSecrets(); err != nil {
+		cp.Warnf("[W] resolve secrets failed: %s, ignored\n", err)
+	}
 }
 
 func getcli() *http.Client {
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/config"
+	cp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/colorprint"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
+	"golang.org/x/term"
+)
+
+func openKeystore() (*secret.Keystore, error) {
+	// only the keystore path is required, secrets in datakit.conf not resolved here
+	if err := config.Cfg.LoadMainTOML(datakit.MainConfPath); err != nil {
+		cp.Warnf("[W] load config %s failed: %s, ignored\n", datakit.MainConfPath, err)
+	}
+
+	if c := config.Cfg.Secret; c != nil {
+		if err := secret.Init(&secret.Config{Keystore: c.Keystore, KeystoreKey: c.KeystoreKey}); err != nil {
+			return nil, err
+		}
+	}
+
+	return secret.OpenKeystore(secret.DefaultKeystorePath(), secret.DefaultKeystoreKeyPath())
+}
+
+// readSecretValue reads the secret from terminal without echo, or from
+// stdin, such as `echo -n xxx | datakit tool --keystore-set name`.
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Printf("Enter secret of %s: ", name)
+		data, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func keystoreSet(name string) error {
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+
+	value, err := readSecretValue(name)
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		return fmt.Errorf("empty secret of %s", name)
+	}
+
+	if err := ks.Set(name, value); err != nil {
+		return err
+	}
+
+	cp.Infof("secret %s saved, reference it as ENC[keystore://%s]\n", name, name)
+	return nil
+}
+
+func keystoreDelete(name string) error {
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+
+	if err := ks.Delete(name); err != nil {
+		return err
+	}
+
+	cp.Infof("secret %s deleted\n", name)
+	return nil
+}
+
+func keystoreList() error {
+	ks, err := openKeystore()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range ks.Names() {
+		fmt.Println(name)
+	}
+	return nil
+}
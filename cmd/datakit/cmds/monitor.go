@@ -41,6 +41,9 @@ var (
 	httpAPIStatCols  = strings.Split(`API,Total,Limited(%),Max Latency,Avg Latency,2xx,3xx,4xx,5xx`, ",")
 	ioStatCols       = strings.Split(`Cat,ChanUsage,pts Send/Failed`, ",")
 	filterRuleCols   = strings.Split("Cat,Total,Filtered(%),Cost,Cost/Pts,Rules", ",")
+	sinkStatCols     = strings.Split(`Sink,ID,Cache/Cap,FailPts,CachedPts,RetryOK,RetryFail,Dropped,Backoff,Error(date)`, ",")
+	multilineCols    = strings.Split(`Source,File,State,Pattern`, ",")
+	inputReloadCols  = strings.Split(`Conf,Started,Stopped,Unchanged,Pending,Reloaded`, ",")
 
 	moduleMap = map[string]string{
 		"G":  "goroutine",
@@ -51,6 +54,9 @@ var (
 		"In": "inputs",
 		"P":  "pipeline",
 		"IO": "io_stats",
+		"S":  "sink",
+		"M":  "multiline",
+		"Re": "reload",
 	}
 )
 
@@ -387,7 +393,7 @@ func (m *monitorAPP) renderHTTPStatTable(ds *dkhttp.DatakitStats, colArr []strin
 }
 
 func (m *monitorAPP) renderGoroutineTable(ds *dkhttp.DatakitStats, colArr []string) {
-	if !exitsStr(*flagMonitorModule, moduleGoroutine) && !*flagMonitorVerbose {
+	if !oneModule(*flagMonitorModule, "G") && !*flagMonitorVerbose {
 		return
 	}
 
@@ -783,6 +789,157 @@ func (m *monitorAPP) renderIOTable(ds *dkhttp.DatakitStats, colArr []string) {
 	}
 }
 
+func (m *monitorAPP) renderSinkTable(ds *dkhttp.DatakitStats, colArr []string) {
+	table := m.sinkStatTable
+
+	if m.anyError != nil {
+		return
+	}
+
+	if ds.IOStats == nil || len(ds.IOStats.SinkStats) == 0 {
+		m.sinkStatTable.SetTitle("[red]S[white]ink Info(no sink configured)")
+		return
+	} else {
+		m.sinkStatTable.SetTitle("[red]S[white]ink Info")
+	}
+
+	// set table header
+	for idx := range colArr {
+		table.SetCell(0, idx, tview.NewTableCell(colArr[idx]).
+			SetMaxWidth(*flagMonitorMaxTableWidth).
+			SetTextColor(tcell.ColorGreen).SetAlign(tview.AlignRight))
+	}
+
+	now := time.Now()
+	for i, v := range ds.IOStats.SinkStats {
+		row := i + 1
+
+		table.SetCell(row, 0, tview.NewTableCell(v.Target).SetMaxWidth(MaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(v.ID).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+
+		cache := "-"
+		if v.Cached {
+			cache = humanize.IBytes(uint64(v.CacheSize)) + "/" + humanize.IBytes(uint64(v.CacheCap))
+		}
+		table.SetCell(row, 2, tview.NewTableCell(cache).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(number(v.WriteFail)).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(number(v.CachedPts)).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 5, tview.NewTableCell(number(v.RetryOK)).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 6, tview.NewTableCell(number(v.RetryFail)).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 7, tview.NewTableCell(number(v.DroppedPts)).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 8, tview.NewTableCell(v.Backoff.String()).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+
+		lastErr := "-"
+		if v.LastErr != "" {
+			lastErr = fmt.Sprintf("%s(%s)", v.LastErr, humanize.RelTime(v.LastErrTS, now, "ago", ""))
+		}
+		table.SetCell(row, 9, tview.NewTableCell(lastErr).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+	}
+}
+
+func (m *monitorAPP) renderMultilineTable(ds *dkhttp.DatakitStats, colArr []string) {
+	table := m.multilineStatTable
+
+	if m.anyError != nil {
+		return
+	}
+
+	if len(ds.AutoMultilineStats) == 0 {
+		m.multilineStatTable.SetTitle("[red]M[white]ultiline Info(no auto detection)")
+		return
+	} else {
+		m.multilineStatTable.SetTitle("[red]M[white]ultiline Info")
+	}
+
+	// set table header
+	for idx := range colArr {
+		table.SetCell(0, idx, tview.NewTableCell(colArr[idx]).
+			SetMaxWidth(*flagMonitorMaxTableWidth).
+			SetTextColor(tcell.ColorGreen).SetAlign(tview.AlignRight))
+	}
+
+	for i, v := range ds.AutoMultilineStats {
+		row := i + 1
+
+		table.SetCell(row, 0, tview.NewTableCell(v.Source).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(v.File).
+			SetMaxWidth(MaxTableWidth).SetAlign(tview.AlignRight))
+
+		state, pattern := "detected", v.Pattern
+		if v.Detecting {
+			state, pattern = fmt.Sprintf("detecting(%d lines)", v.Lines), "-"
+		}
+		table.SetCell(row, 2, tview.NewTableCell(state).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(pattern).
+			SetMaxWidth(MaxTableWidth).SetAlign(tview.AlignLeft))
+	}
+}
+
+func (m *monitorAPP) renderInputReloadTable(ds *dkhttp.DatakitStats, colArr []string) {
+	table := m.inputReloadTable
+
+	if m.anyError != nil {
+		return
+	}
+
+	if len(ds.InputReloadStats) == 0 {
+		m.inputReloadTable.SetTitle("Input [red]Re[white]load(no reload)")
+		return
+	} else {
+		m.inputReloadTable.SetTitle("Input [red]Re[white]load")
+	}
+
+	// set table header
+	for idx := range colArr {
+		table.SetCell(0, idx, tview.NewTableCell(colArr[idx]).
+			SetMaxWidth(*flagMonitorMaxTableWidth).
+			SetTextColor(tcell.ColorGreen).SetAlign(tview.AlignRight))
+	}
+
+	names := func(arr []string) string {
+		if len(arr) == 0 {
+			return "-"
+		}
+		return strings.Join(arr, ",")
+	}
+
+	now := time.Now()
+	for i, v := range ds.InputReloadStats {
+		row := i + 1
+
+		table.SetCell(row, 0, tview.NewTableCell(v.Source).
+			SetMaxWidth(MaxTableWidth).SetAlign(tview.AlignRight))
+
+		if v.Error != "" {
+			table.SetCell(row, 1, tview.NewTableCell(v.Error).
+				SetMaxWidth(MaxTableWidth).SetTextColor(tcell.ColorRed).SetAlign(tview.AlignLeft))
+		} else {
+			table.SetCell(row, 1, tview.NewTableCell(names(v.Started)).
+				SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+			table.SetCell(row, 2, tview.NewTableCell(names(v.Stopped)).
+				SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+			table.SetCell(row, 3, tview.NewTableCell(number(v.Unchanged)).
+				SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+			table.SetCell(row, 4, tview.NewTableCell(names(v.Pending)).
+				SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+		}
+
+		table.SetCell(row, 5, tview.NewTableCell(humanize.RelTime(v.Time, now, "ago", "")).
+			SetMaxWidth(*flagMonitorMaxTableWidth).SetAlign(tview.AlignRight))
+	}
+}
+
 type monitorAPP struct {
 	app *tview.Application
 
@@ -795,6 +952,9 @@ type monitorAPP struct {
 	goroutineStatTable  *tview.Table
 	httpServerStatTable *tview.Table
 	ioStatTable         *tview.Table
+	sinkStatTable       *tview.Table
+	multilineStatTable  *tview.Table
+	inputReloadTable    *tview.Table
 
 	filterStatsTable      *tview.Table
 	filterRulesStatsTable *tview.Table
@@ -859,6 +1019,9 @@ func (m *monitorAPP) setupFlex() {
 				0, 10, false).
 			AddItem(m.plStatTable, 0, 15, false).
 			AddItem(m.ioStatTable, 0, 14, false).
+			AddItem(m.sinkStatTable, 0, 5, false).
+			AddItem(m.multilineStatTable, 0, 5, false).
+			AddItem(m.inputReloadTable, 0, 5, false).
 			AddItem(m.anyErrorPrompt, 0, 1, false).
 			AddItem(m.exitPrompt, 0, 1, false)
 		return
@@ -899,6 +1062,18 @@ func (m *monitorAPP) setupFlex() {
 		if oneModule(*flagMonitorModule, "IO") {
 			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(m.ioStatTable, 0, 10, false), 0, 10, false)
 		}
+
+		if oneModule(*flagMonitorModule, "S") {
+			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(m.sinkStatTable, 0, 10, false), 0, 10, false)
+		}
+
+		if oneModule(*flagMonitorModule, "M") {
+			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(m.multilineStatTable, 0, 10, false), 0, 10, false)
+		}
+
+		if oneModule(*flagMonitorModule, "Re") {
+			flex.AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).AddItem(m.inputReloadTable, 0, 10, false), 0, 10, false)
+		}
 		flex.AddItem(m.anyErrorPrompt, 0, 1, false).AddItem(m.exitPrompt, 0, 1, false)
 
 		return
@@ -951,6 +1126,18 @@ func (m *monitorAPP) setup() {
 	m.ioStatTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
 	m.ioStatTable.SetBorder(true).SetTitle("[red]IO[white] Info").SetTitleAlign(tview.AlignLeft)
 
+	// sink retry queue stats
+	m.sinkStatTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
+	m.sinkStatTable.SetBorder(true).SetTitle("[red]S[white]ink Info").SetTitleAlign(tview.AlignLeft)
+
+	// logging multiline auto detection stats
+	m.multilineStatTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
+	m.multilineStatTable.SetBorder(true).SetTitle("[red]M[white]ultiline Info").SetTitleAlign(tview.AlignLeft)
+
+	// input configs hot reload stats
+	m.inputReloadTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false).SetSeparator(tview.Borders.Vertical)
+	m.inputReloadTable.SetBorder(true).SetTitle("Input [red]Re[white]load").SetTitleAlign(tview.AlignLeft)
+
 	// filter stats
 	m.filterStatsTable = tview.NewTable().SetFixed(1, 1).SetSelectable(true, false).SetBorders(false)
 	m.filterStatsTable.SetBorder(true).SetTitle("[red]F[white]ilter").SetTitleAlign(tview.AlignLeft)
@@ -1013,6 +1200,9 @@ func (m *monitorAPP) render() {
 	m.plStatTable.Clear()
 	m.goroutineStatTable.Clear()
 	m.ioStatTable.Clear()
+	m.sinkStatTable.Clear()
+	m.multilineStatTable.Clear()
+	m.inputReloadTable.Clear()
 	m.filterStatsTable.Clear()
 	m.filterRulesStatsTable.Clear()
 
@@ -1028,6 +1218,9 @@ func (m *monitorAPP) render() {
 	m.renderPLStatTable(m.ds, plStatsCols)
 	m.renderGoroutineTable(m.ds, goroutineCols)
 	m.renderIOTable(m.ds, ioStatCols)
+	m.renderSinkTable(m.ds, sinkStatCols)
+	m.renderMultilineTable(m.ds, multilineCols)
+	m.renderInputReloadTable(m.ds, inputReloadCols)
 
 	if m.ds.HTTPMetrics != nil {
 		m.renderHTTPStatTable(m.ds, httpAPIStatCols)
@@ -83,9 +83,13 @@ var (
 	flagPLTxtFile     = fsPL.StringP("file", "F", "", "text file path for the pipeline or grok(json or raw text)")
 	flagPLTable       = fsPL.Bool("tab", false, "output result in table format")
 	flagPLDate        = fsPL.Bool("date", false, "append date display(according to local timezone) on timestamp")
+	flagPLTest        = fsPL.String("test", "", "run test fixtures(*.test.json) of the pipeline script or scripts under the directory")
+	flagPLTestJUnit   = fsPL.String("junit", "", "write test results in JUnit XML format to the file, used with --test")
+	flagPLTestUpdate  = fsPL.Bool("update", false, "update test fixtures with the actual results, used with --test")
 	fsPLUsage         = func() {
-		fmt.Printf("usage: datakit pipeline [pipeline-script-name.p] [options]\n\n")
-		fmt.Printf("Pipeline used to debug exists pipeline script.\n\n")
+		fmt.Printf("usage: datakit pipeline [pipeline-script-name.p] [options]\n")
+		fmt.Printf("       datakit pipeline --test [pipeline-script-name.p|dir] [options]\n\n")
+		fmt.Printf("Pipeline used to debug exists pipeline script, or run test fixtures of pipeline scripts.\n\n")
 		fmt.Println(fsPL.FlagUsagesWrapped(0))
 	}
 
@@ -178,6 +182,9 @@ var (
 	flagToolCompleterScripts      = fsTool.Bool("completer-script", false, "show completion script(Linux only)")
 	flagToolPromConf              = fsTool.String("prom-conf", "", "specify the prom input conf to debug")
 	flagToolParseLineProtocol     = fsTool.String("parse-lp", "", "parse line-protocol file")
+	flagToolKeystoreSet           = fsTool.String("keystore-set", "", "add or update secret in local keystore, the secret read from terminal or stdin")
+	flagToolKeystoreDelete        = fsTool.String("keystore-delete", "", "delete secret from local keystore")
+	flagToolKeystoreList          = fsTool.Bool("keystore-list", false, "list secret names in local keystore")
 
 	fsToolUsage = func() {
 		fmt.Printf("usage: datakit tool [options]\n\n")
@@ -311,6 +318,28 @@ func doParseAndRunFlags() {
 			setCmdRootLog(*flagPLLogPath)
 			tryLoadMainCfg()
 
+			// test mode: datakit pipeline --test path/to/scripts [options]
+			if len(os.Args) > 2 && strings.HasPrefix(os.Args[2], "-") {
+				if err := fsPL.Parse(os.Args[2:]); err != nil {
+					cp.Errorf("[E] Parse: %s\n", err)
+					fsPLUsage()
+					os.Exit(-1)
+				}
+
+				if *flagPLTest == "" {
+					cp.Errorf("[E] missing pipeline name or --test.\n")
+					fsPLUsage()
+					os.Exit(-1)
+				}
+
+				if err := runPLTestFlags(); err != nil {
+					cp.Errorf("[E] %s\n", err)
+					os.Exit(-1)
+				}
+
+				os.Exit(0)
+			}
+
 			if len(os.Args) <= 3 {
 				cp.Errorf("[E] missing pipeline name and/or testing text.\n")
 				fsPLUsage()
@@ -64,20 +64,10 @@ func pipelineDebugger(category, plname, ns, txt string, isPt bool) error {
 		return err
 	}
 
-	if err := pipeline.Init(config.Cfg.Pipeline); err != nil {
+	if err := initDebugPipeline(); err != nil {
 		return err
 	}
 
-	if config.Cfg.Pipeline != nil &&
-		config.Cfg.Pipeline.ReferTableURL != "" {
-		ok := refertable.InitFinished(time.Second * 20)
-		if ok {
-			l.Info("Initialize Reference Table: Done")
-		} else {
-			l.Error("Initialize Reference Table: Timeout")
-		}
-	}
-
 	scriptTmpStore, errScripts := plScriptTmpStore(category)
 
 	if m, ok := errScripts[ns]; ok {
@@ -99,32 +89,9 @@ func pipelineDebugger(category, plname, ns, txt string, isPt bool) error {
 		Time:     time.Now(),
 	}
 
-	measurementName := "default"
-
-	var pt *point.Point
-
-	switch category {
-	case datakit.Logging:
-		fieldsSrc := map[string]interface{}{pipeline.FieldMessage: txt}
-		newPt, err := point.NewPoint(measurementName, nil, fieldsSrc, opt)
-		if err != nil {
-			return err
-		}
-		pt = newPt
-	case datakit.Metric:
-		pts, err := lp.ParsePoints([]byte(txt), &lp.Option{EnablePointInKey: true})
-		if err != nil {
-			return err
-		}
-		ptsW := point.WrapPoint(pts)
-		pt = ptsW[0]
-	default:
-		pts, err := lp.ParsePoints([]byte(txt), nil)
-		if err != nil {
-			return err
-		}
-		ptsW := point.WrapPoint(pts)
-		pt = ptsW[0]
+	pt, err := newDebugPoint(category, txt, opt)
+	if err != nil {
+		return err
 	}
 
 	res, dropFlag, err := (&pipeline.Pipeline{
@@ -171,7 +138,7 @@ func pipelineDebugger(category, plname, ns, txt string, isPt bool) error {
 		}
 	}
 
-	measurementName = res.Name()
+	measurementName := res.Name()
 
 	if *flagPLTable {
 		fmtStr := fmt.Sprintf("%% %ds: %%v", maxWidth)
@@ -202,6 +169,46 @@ func pipelineDebugger(category, plname, ns, txt string, isPt bool) error {
 	return nil
 }
 
+func initDebugPipeline() error {
+	if err := pipeline.Init(config.Cfg.Pipeline); err != nil {
+		return err
+	}
+
+	if config.Cfg.Pipeline != nil &&
+		config.Cfg.Pipeline.ReferTableURL != "" {
+		ok := refertable.InitFinished(time.Second * 20)
+		if ok {
+			l.Info("Initialize Reference Table: Done")
+		} else {
+			l.Error("Initialize Reference Table: Timeout")
+		}
+	}
+
+	return nil
+}
+
+// newDebugPoint builds the input point of the pipeline, txt is the message
+// of logging, or line protocol of other categories.
+func newDebugPoint(category, txt string, opt *point.PointOption) (*point.Point, error) {
+	switch category {
+	case datakit.Logging:
+		fieldsSrc := map[string]interface{}{pipeline.FieldMessage: txt}
+		return point.NewPoint("default", nil, fieldsSrc, opt)
+	case datakit.Metric:
+		pts, err := lp.ParsePoints([]byte(txt), &lp.Option{EnablePointInKey: true})
+		if err != nil {
+			return nil, err
+		}
+		return point.WrapPoint(pts)[0], nil
+	default:
+		pts, err := lp.ParsePoints([]byte(txt), nil)
+		if err != nil {
+			return nil, err
+		}
+		return point.WrapPoint(pts)[0], nil
+	}
+}
+
 func plScriptTmpStore(category string) (*script.ScriptStore, map[string]map[string]error) {
 	store := script.NewScriptStore(category)
 
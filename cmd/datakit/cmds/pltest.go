@@ -0,0 +1,500 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	cp "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/colorprint"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/convertutil"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline"
+)
+
+// plTestFileSuffix is the suffix of the fixture file, the fixture of
+// nginx.p is nginx.test.json in the same directory.
+const plTestFileSuffix = ".test.json"
+
+// plTestSuite is the fixture of a pipeline script.
+type plTestSuite struct {
+	// Category is the category of the script, if not set, it's the name of
+	// the directory(logging, metric, ...) or the category of the command line.
+	Category string        `json:"category,omitempty"`
+	Cases    []*plTestCase `json:"cases"`
+}
+
+// plTestCase is one input of the script and the result expected, nil tags
+// or fields are not checked.
+type plTestCase struct {
+	Name        string            `json:"name"`
+	Input       string            `json:"input"`
+	Measurement string            `json:"measurement,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Fields      map[string]any    `json:"fields,omitempty"`
+	Drop        bool              `json:"drop"`
+}
+
+type plTestResult struct {
+	script   string
+	category string
+	cases    []*plTestCaseResult
+	err      error // failed to load or run the suite
+	cost     time.Duration
+}
+
+type plTestCaseResult struct {
+	name  string
+	diffs []string
+	err   error
+	cost  time.Duration
+}
+
+func (r *plTestResult) failures() (n int) {
+	for _, c := range r.cases {
+		if len(c.diffs) > 0 {
+			n++
+		}
+	}
+	return
+}
+
+func (r *plTestResult) errors() (n int) {
+	for _, c := range r.cases {
+		if c.err != nil {
+			n++
+		}
+	}
+	return
+}
+
+func runPLTestFlags() error {
+	scripts, err := findPLTestScripts(*flagPLTest)
+	if err != nil {
+		return err
+	}
+
+	if len(scripts) == 0 {
+		return fmt.Errorf("no pipeline script with %s fixture found in %s", plTestFileSuffix, *flagPLTest)
+	}
+
+	if err := initDebugPipeline(); err != nil {
+		return err
+	}
+
+	var (
+		results []*plTestResult
+		failed  int
+	)
+
+	for _, script := range scripts {
+		res := runPLTestSuite(script, *flagPLCategory, *flagPLTestUpdate)
+		printPLTestResult(res)
+		results = append(results, res)
+
+		if res.err != nil || res.failures() > 0 || res.errors() > 0 {
+			failed++
+		}
+	}
+
+	if *flagPLTestJUnit != "" {
+		data, err := plTestJUnitReport(results)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*flagPLTestJUnit, data, 0o600); err != nil {
+			return fmt.Errorf("write JUnit report: %w", err)
+		}
+	}
+
+	cp.Infof("---------------\n")
+	if failed > 0 {
+		return fmt.Errorf("%d of %d pipeline test suites failed", failed, len(results))
+	}
+
+	cp.Infof("%d pipeline test suites passed\n", len(results))
+	return nil
+}
+
+// findPLTestScripts returns scripts having fixture under path, path is a
+// script or a directory searched recursively.
+func findPLTestScripts(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		if _, err := os.Stat(plTestFile(path)); err != nil {
+			return nil, fmt.Errorf("fixture of %s: %w", path, err)
+		}
+		return []string{path}, nil
+	}
+
+	var scripts []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(p) != ".p" {
+			return nil
+		}
+
+		if _, err := os.Stat(plTestFile(p)); err == nil {
+			scripts = append(scripts, p)
+		}
+		return nil
+	})
+
+	sort.Strings(scripts)
+	return scripts, err
+}
+
+func plTestFile(script string) string {
+	return strings.TrimSuffix(script, filepath.Ext(script)) + plTestFileSuffix
+}
+
+// plTestCategory returns the category of the script: the category of the
+// fixture, the category directory of the script, or the default.
+func plTestCategory(script, suiteCategory, defaultCategory string) (string, error) {
+	if suiteCategory != "" {
+		return convertutil.GetMapCategoryShortToFull(suiteCategory)
+	}
+
+	dir := filepath.Base(filepath.Dir(script))
+	for category, dirName := range datakit.CategoryDirName() {
+		if dir == dirName {
+			return category, nil
+		}
+	}
+
+	return convertutil.GetMapCategoryShortToFull(defaultCategory)
+}
+
+func loadPLTestSuite(script string) (*plTestSuite, error) {
+	data, err := ioutil.ReadFile(plTestFile(script)) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	var suite plTestSuite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", plTestFile(script), err)
+	}
+
+	return &suite, nil
+}
+
+// runPLTestSuite runs cases of the script, if update is true, the fixture is
+// rewritten with the results.
+func runPLTestSuite(script, defaultCategory string, update bool) *plTestResult {
+	start := time.Now()
+	res := &plTestResult{script: script}
+	defer func() {
+		res.cost = time.Since(start)
+	}()
+
+	suite, err := loadPLTestSuite(script)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	if res.category, err = plTestCategory(script, suite.Category, defaultCategory); err != nil {
+		res.err = err
+		return res
+	}
+
+	pl, err := pipeline.NewPipelineFromFile(res.category, script)
+	if err != nil {
+		res.err = err
+		return res
+	}
+
+	for i, tc := range suite.Cases {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("case-%d", i)
+		}
+
+		caseStart := time.Now()
+		actual, err := runPLTestCase(pl, res.category, tc.Input)
+		caseRes := &plTestCaseResult{name: name, err: err}
+
+		if err == nil {
+			if update {
+				actual.Name, actual.Input = tc.Name, tc.Input
+				suite.Cases[i] = actual
+			} else {
+				caseRes.diffs = diffPLTestCase(tc, actual)
+			}
+		}
+
+		caseRes.cost = time.Since(caseStart)
+		res.cases = append(res.cases, caseRes)
+	}
+
+	if update {
+		data, err := json.MarshalIndent(suite, "", defaultJSONIndent)
+		if err != nil {
+			res.err = err
+			return res
+		}
+		if err := ioutil.WriteFile(plTestFile(script), append(data, '\n'), 0o600); err != nil {
+			res.err = err
+		}
+	}
+
+	return res
+}
+
+func runPLTestCase(pl *pipeline.Pipeline, category, input string) (*plTestCase, error) {
+	opt := &point.PointOption{
+		Category: category,
+		Time:     time.Now(),
+	}
+
+	pt, err := newDebugPoint(category, input, opt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	res, drop, err := pl.Run(pt, nil, opt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("run pipeline failed: %w", err)
+	}
+
+	fields, err := res.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	// values are compared in form of JSON, as they are in the fixture
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var jsonFields map[string]any
+	if err := json.Unmarshal(data, &jsonFields); err != nil {
+		return nil, err
+	}
+
+	return &plTestCase{
+		Measurement: res.Name(),
+		Tags:        res.Tags(),
+		Fields:      jsonFields,
+		Drop:        drop,
+	}, nil
+}
+
+func diffPLTestCase(expected, actual *plTestCase) []string {
+	var diffs []string
+
+	if expected.Measurement != "" && expected.Measurement != actual.Measurement {
+		diffs = append(diffs, fmt.Sprintf("measurement: expected %q, got %q",
+			expected.Measurement, actual.Measurement))
+	}
+
+	if expected.Drop != actual.Drop {
+		diffs = append(diffs, fmt.Sprintf("drop: expected %v, got %v", expected.Drop, actual.Drop))
+	}
+
+	if expected.Tags != nil {
+		exp, act := map[string]any{}, map[string]any{}
+		for k, v := range expected.Tags {
+			exp[k] = v
+		}
+		for k, v := range actual.Tags {
+			act[k] = v
+		}
+		diffs = append(diffs, diffPLTestMap("tags", exp, act)...)
+	}
+
+	if expected.Fields != nil {
+		diffs = append(diffs, diffPLTestMap("fields", expected.Fields, actual.Fields)...)
+	}
+
+	return diffs
+}
+
+func diffPLTestMap(kind string, expected, actual map[string]any) []string {
+	keys := map[string]bool{}
+	for k := range expected {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var diffs []string
+	for _, k := range sortedKeys {
+		exp, expOK := expected[k]
+		act, actOK := actual[k]
+
+		switch {
+		case !actOK:
+			diffs = append(diffs, fmt.Sprintf("- %s.%s: %s", kind, k, plTestJSON(exp)))
+		case !expOK:
+			diffs = append(diffs, fmt.Sprintf("+ %s.%s: %s", kind, k, plTestJSON(act)))
+		case !reflect.DeepEqual(exp, act):
+			diffs = append(diffs, fmt.Sprintf("~ %s.%s: expected %s, got %s",
+				kind, k, plTestJSON(exp), plTestJSON(act)))
+		}
+	}
+
+	return diffs
+}
+
+func plTestJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func printPLTestResult(res *plTestResult) {
+	if res.err != nil {
+		cp.Errorf("[E] %s: %s\n", res.script, res.err)
+		return
+	}
+
+	if res.failures() == 0 && res.errors() == 0 {
+		cp.Infof("[ok] %s: %d cases, cost %v\n", res.script, len(res.cases), res.cost)
+		return
+	}
+
+	cp.Errorf("[E] %s: %d of %d cases failed\n", res.script, res.failures()+res.errors(), len(res.cases))
+	for _, c := range res.cases {
+		switch {
+		case c.err != nil:
+			cp.Errorf("  %s: %s\n", c.name, c.err)
+		case len(c.diffs) > 0:
+			cp.Errorf("  %s:\n", c.name)
+			for _, d := range c.diffs {
+				cp.Warnf("    %s\n", d)
+			}
+		}
+	}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func plTestJUnitReport(results []*plTestResult) ([]byte, error) {
+	report := &junitTestSuites{Name: "pipeline"}
+	var total time.Duration
+
+	for _, res := range results {
+		suite := &junitTestSuite{
+			Name: res.script,
+			Time: junitSeconds(res.cost),
+		}
+
+		if res.err != nil {
+			// the suite failed to load or run, reported as an error case
+			suite.Cases = append(suite.Cases, &junitTestCase{
+				Name:      filepath.Base(res.script),
+				ClassName: res.script,
+				Time:      junitSeconds(res.cost),
+				Error:     &junitFailure{Message: res.err.Error()},
+			})
+			suite.Errors = 1
+		}
+
+		for _, c := range res.cases {
+			tc := &junitTestCase{
+				Name:      c.name,
+				ClassName: res.script,
+				Time:      junitSeconds(c.cost),
+			}
+
+			switch {
+			case c.err != nil:
+				tc.Error = &junitFailure{Message: c.err.Error()}
+				suite.Errors++
+			case len(c.diffs) > 0:
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%d differences", len(c.diffs)),
+					Text:    strings.Join(c.diffs, "\n"),
+				}
+				suite.Failures++
+			}
+
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Suites = append(report.Suites, suite)
+		total += res.cost
+	}
+
+	report.Time = junitSeconds(total)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package cmds
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+)
+
+const plTestScript = `json(_, level, status)
+add_key(service, "web")
+set_tag(service)
+if status == "debug" {
+	drop()
+}
+`
+
+const plTestFixture = `{
+  "cases": [
+    {
+      "name": "info",
+      "input": "{\"level\": \"info\", \"code\": 200}",
+      "measurement": "default",
+      "tags": {"service": "web"},
+      "fields": {"message": "{\"level\": \"info\", \"code\": 200}", "status": "info"}
+    },
+    {
+      "name": "debug",
+      "input": "{\"level\": \"debug\"}",
+      "drop": true
+    }
+  ]
+}`
+
+func writePLTestFiles(t *testing.T, dir, script, fixture string) string {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	p := filepath.Join(dir, "app.p")
+	require.NoError(t, ioutil.WriteFile(p, []byte(script), 0o600))
+	if fixture != "" {
+		require.NoError(t, ioutil.WriteFile(plTestFile(p), []byte(fixture), 0o600))
+	}
+	return p
+}
+
+func TestFindPLTestScripts(t *testing.T) {
+	dir := t.TempDir()
+	a := writePLTestFiles(t, filepath.Join(dir, "logging"), plTestScript, plTestFixture)
+	_ = writePLTestFiles(t, filepath.Join(dir, "metric"), plTestScript, "")
+
+	scripts, err := findPLTestScripts(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a}, scripts)
+
+	scripts, err = findPLTestScripts(a)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a}, scripts)
+
+	_, err = findPLTestScripts(filepath.Join(dir, "metric", "app.p"))
+	assert.Error(t, err)
+}
+
+func TestPLTestCategory(t *testing.T) {
+	c, err := plTestCategory("/pipeline/metric/a.p", "", "logging")
+	require.NoError(t, err)
+	assert.Equal(t, datakit.Metric, c)
+
+	c, err = plTestCategory("/pipeline/metric/a.p", "object", "logging")
+	require.NoError(t, err)
+	assert.Equal(t, datakit.Object, c)
+
+	c, err = plTestCategory("/scripts/a.p", "", "logging")
+	require.NoError(t, err)
+	assert.Equal(t, datakit.Logging, c)
+
+	_, err = plTestCategory("/scripts/a.p", "unknown", "logging")
+	assert.Error(t, err)
+}
+
+func TestRunPLTestSuite(t *testing.T) {
+	t.Run("pass", func(t *testing.T) {
+		p := writePLTestFiles(t, filepath.Join(t.TempDir(), "logging"), plTestScript, plTestFixture)
+
+		res := runPLTestSuite(p, "logging", false)
+		require.NoError(t, res.err)
+		assert.Equal(t, datakit.Logging, res.category)
+		require.Len(t, res.cases, 2)
+		for _, c := range res.cases {
+			assert.NoError(t, c.err)
+			assert.Empty(t, c.diffs, c.name)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		p := writePLTestFiles(t, filepath.Join(t.TempDir(), "logging"), plTestScript, `{
+  "cases": [
+    {
+      "name": "wrong",
+      "input": "{\"level\": \"warn\"}",
+      "measurement": "nginx",
+      "tags": {"service": "api"},
+      "fields": {"status": "error", "code": 500}
+    },
+    {"name": "plain-text", "input": "x y z", "drop": false}
+  ]
+}`)
+
+		res := runPLTestSuite(p, "logging", false)
+		require.NoError(t, res.err)
+		require.Len(t, res.cases, 2)
+		assert.Equal(t, []string{
+			`measurement: expected "nginx", got "default"`,
+			`~ tags.service: expected "api", got "web"`,
+			`- fields.code: 500`,
+			`+ fields.message: "{\"level\": \"warn\"}"`,
+			`~ fields.status: expected "error", got "warning"`,
+		}, res.cases[0].diffs)
+		assert.Equal(t, 1, res.failures())
+
+		data, err := plTestJUnitReport([]*plTestResult{res})
+		require.NoError(t, err)
+
+		var report junitTestSuites
+		require.NoError(t, xml.Unmarshal(data, &report))
+		assert.Equal(t, 2, report.Tests)
+		assert.Equal(t, 1, report.Failures)
+		require.Len(t, report.Suites, 1)
+		require.NotNil(t, report.Suites[0].Cases[0].Failure)
+		assert.Contains(t, report.Suites[0].Cases[0].Failure.Text, "tags.service")
+		assert.Nil(t, report.Suites[0].Cases[1].Failure)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		p := writePLTestFiles(t, filepath.Join(t.TempDir(), "logging"), plTestScript, `{
+  "cases": [{"name": "warn", "input": "{\"level\": \"warn\"}"}]
+}`)
+
+		res := runPLTestSuite(p, "logging", true)
+		require.NoError(t, res.err)
+
+		suite, err := loadPLTestSuite(p)
+		require.NoError(t, err)
+		assert.Equal(t, &plTestCase{
+			Name:        "warn",
+			Input:       `{"level": "warn"}`,
+			Measurement: "default",
+			Tags:        map[string]string{"service": "web"},
+			Fields:      map[string]any{"message": `{"level": "warn"}`, "status": "warning"},
+		}, suite.Cases[0])
+
+		res = runPLTestSuite(p, "logging", false)
+		require.NoError(t, res.err)
+		assert.Empty(t, res.cases[0].diffs)
+	})
+
+	t.Run("invalid-script", func(t *testing.T) {
+		p := writePLTestFiles(t, filepath.Join(t.TempDir(), "logging"), "json(_", plTestFixture)
+
+		res := runPLTestSuite(p, "logging", false)
+		assert.Error(t, res.err)
+
+		data, err := plTestJUnitReport([]*plTestResult{res})
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "<error message=")
+	})
+}
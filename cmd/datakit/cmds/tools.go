@@ -88,6 +88,27 @@ func runToolFlags() error {
 
 		os.Exit(0)
 
+	case *flagToolKeystoreSet != "":
+		if err := keystoreSet(*flagToolKeystoreSet); err != nil {
+			cp.Errorf("[E] set secret failed: %s\n", err.Error())
+			os.Exit(-1)
+		}
+		os.Exit(0)
+
+	case *flagToolKeystoreDelete != "":
+		if err := keystoreDelete(*flagToolKeystoreDelete); err != nil {
+			cp.Errorf("[E] delete secret failed: %s\n", err.Error())
+			os.Exit(-1)
+		}
+		os.Exit(0)
+
+	case *flagToolKeystoreList:
+		if err := keystoreList(); err != nil {
+			cp.Errorf("[E] list secrets failed: %s\n", err.Error())
+			os.Exit(-1)
+		}
+		os.Exit(0)
+
 	case *flagToolWorkspaceInfo:
 		tryLoadMainCfg()
 		requrl := fmt.Sprintf("http://%s%s", config.Cfg.HTTPAPI.Listen, workspace)
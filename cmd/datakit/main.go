@@ -229,6 +229,8 @@ func doRun() error {
 				l.Error("error running inputs: %v", err)
 				return err
 			}
+
+			config.StartInputReloadWatcher()
 		}
 	}
 
@@ -250,6 +252,8 @@ func startDKHttp() {
 		DataWay:     config.Cfg.DataWay,
 		PProf:       config.Cfg.EnablePProf,
 		PProfListen: config.Cfg.PProfListen,
+
+		ReloadInputs: config.ReloadInputs,
 	})
 
 	time.Sleep(time.Second) // wait http server ok
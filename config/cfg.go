@@ -24,6 +24,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	dkhttp "gitlab.jiagouyun.com/cloudcare-tools/datakit/http"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/cgroup"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/election"
@@ -92,6 +93,12 @@ type Config struct {
 	// confd config
 	Confds []*ConfdCfg `toml:"confds"`
 
+	// secret references, such as ENC[keystore://dataway-token]
+	Secret *secret.Config `toml:"secret"`
+
+	// hot reload of input configs
+	InputReload *InputReloadCfg `toml:"input_reload"`
+
 	// DCA config
 	DCAConfig *dkhttp.DCAConfig `toml:"dca"`
 
@@ -144,6 +151,36 @@ type Config struct {
 	Ulimit uint64 `toml:"ulimit"`
 }
 
+// removeDeprecatedUUID removes deprecated UUID field in main configure. It
+// must be called before ResolveSecrets, or the resolved secrets written to disk.
+func (c *Config) removeDeprecatedUUID() {
+	if c.UUIDDeprecated == "" {
+		return
+	}
+
+	c.UUIDDeprecated = "" // clear deprecated UUID field
+	buf := new(bytes.Buffer)
+	if err := bstoml.NewEncoder(buf).Encode(c); err != nil {
+		l.Fatalf("encode main configure failed: %s", err.Error())
+	}
+	if err := ioutil.WriteFile(datakit.MainConfPath, buf.Bytes(), datakit.ConfPerm); err != nil {
+		l.Fatalf("refresh main configure failed: %s", err.Error())
+	}
+
+	l.Info("refresh main configure ok")
+}
+
+// ResolveSecrets replaces the secret references in the main configure. The
+// references are kept in datakit.conf, so the resolved configure should never
+// be written back.
+func (c *Config) ResolveSecrets() error {
+	if err := secret.Init(c.Secret); err != nil {
+		return err
+	}
+
+	return secret.ResolveStruct(c)
+}
+
 func (c *Config) String() string {
 	buf := new(bytes.Buffer)
 	if err := bstoml.NewEncoder(buf).Encode(c); err != nil {
@@ -393,20 +430,6 @@ func (c *Config) ApplyMainConfig() error {
 
 	c.setupGlobalTags()
 
-	// remove deprecated UUID field in main configure
-	if c.UUIDDeprecated != "" {
-		c.UUIDDeprecated = "" // clear deprecated UUID field
-		buf := new(bytes.Buffer)
-		if err := bstoml.NewEncoder(buf).Encode(c); err != nil {
-			l.Fatalf("encode main configure failed: %s", err.Error())
-		}
-		if err := ioutil.WriteFile(datakit.MainConfPath, buf.Bytes(), datakit.ConfPerm); err != nil {
-			l.Fatalf("refresh main configure failed: %s", err.Error())
-		}
-
-		l.Info("refresh main configure ok")
-	}
-
 	InitGitreposDir()
 
 	return nil
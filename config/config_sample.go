@@ -32,6 +32,18 @@ protect_mode = true
 #
 ulimit = 64000
 
+## input_reload: 采集器配置热加载
+## 开启 watch 后，DataKit 定期检查 conf.d 下的采集器配置，仅重启有变更的采集器，其它采集器不受影响
+#
+[input_reload]
+  ## watch: bool, 是否监听采集器配置变更，默认 false
+  #
+  watch = false
+
+  ## interval: string, 检查采集器配置的间隔，默认 10s
+  #
+  interval = "10s"
+
 ## dca: DCA 服务配置，为 DCA 提供 DataKit 的管理 API
 #
 [dca]
@@ -63,7 +75,7 @@ ulimit = 64000
   #
   remote_pull_interval = "1m"
 
-  ## refer_table_url: string, 当前支持的 scheme: http,https
+  ## refer_table_url: string, 当前支持的 scheme: http,https,file
   ## refer_table_pull_interval: string, 数据拉取间隔
   ## use_sqlite: bool, 使用 SQLite 保存拉取的表数据
   ## sqlite_mem_mode: bool, 当使用 SQLite 保存拉取的数据时，使用 SQLite 内存模式，默认使用磁盘模式
@@ -73,6 +85,29 @@ ulimit = 64000
   use_sqlite = false
   sqlite_mem_mode = false
 
+  ## metric_interval: string, Pipeline 脚本中生成的指标（metric_counter() 等函数）的聚合上报间隔
+  ## metric_max_series: int, 每个聚合周期内指标的最大时间线数量，超出后新的时间线被丢弃
+  #
+  metric_interval = "10s"
+  metric_max_series = 10000
+
+  ## kv_default_ttl: string, Pipeline 脚本中 kv_set() 写入的数据的默认过期时间
+  ## kv_max_bytes: int, 所有脚本的 kv 存储的总内存上限（字节），超出后按最近最少使用的顺序淘汰写入脚本自身的数据，默认 16MiB
+  #
+  kv_default_ttl = "10m"
+  kv_max_bytes = 16777216
+
+  ## ipdb_overlay_file: string, 自定义 CIDR 属性的 CSV 文件，相对路径基于 <DataKit 安装目录>/data/ipdb
+  ## CSV 首列为 CIDR，其余列名为属性名，如 country/province/city/isp/asn/asn_org/site 等
+  ## 命中的 CIDR（最长前缀匹配）优先于 ipdb 的查询结果
+  #
+  # ipdb_overlay_file = "overlay.csv"
+
+  ## ipdb_overlay: 直接在配置中定义 CIDR 属性，会覆盖 ipdb_overlay_file 中相同 CIDR 的配置
+  #
+  # [pipeline.ipdb_overlay]
+  #   "10.1.0.0/16" = { site = "shanghai-office", isp = "office" }
+
 ## http_api: HTTP 服务设置
 #
 [http_api]
@@ -183,6 +218,31 @@ ulimit = 64000
   #
   max_fail = 20
 
+  ## content_encoding: 上传数据的编码方式，line-protocol(默认) 或 protobuf
+  ## protobuf 编码避免了行协议的转义开销，且能保留数据类型
+  #
+  # content_encoding = "line-protocol"
+
+## secret: 密钥引用配置
+## 配置中可以用 ENC[<provider>://<key>] 引用密钥，如 ENC[file:///run/secrets/mysql-password]、
+## ENC[keystore://dataway-token]，keystore 通过 datakit tool --keystore-set 管理
+#
+#[secret]
+#  ## keystore: 本地加密 keystore 路径，默认为 data/secret.keystore
+#  ## keystore_key: keystore 密钥文件路径，默认为 <keystore>.key
+#  #
+#  # keystore = ""
+#  # keystore_key = ""
+#
+#  ## backends: confd 后端，以 ENC[<name>://<key>] 引用，如 ENC[vault:///datakit/mysql/password]
+#  #
+#  #[[secret.backends]]
+#  #  name = "vault"
+#  #  backend = "vault"
+#  #  nodes = ["http://127.0.0.1:8200"]
+#  #  auth_type = "token"
+#  #  auth_token = "ENC[file:///run/secrets/vault-token]"
+
 ## logging: 日志配置
 #
 [logging]
@@ -73,6 +73,8 @@ func DefaultConfig() *Config {
 			RemotePullInterval:     "1m",
 			ReferTableURL:          "",
 			ReferTablePullInterval: "5m",
+			MetricInterval:         "10s",
+			KVDefaultTTL:           "10m",
 		},
 		Logging: &LoggerCfg{
 			Level:  "info",
@@ -89,6 +91,11 @@ func DefaultConfig() *Config {
 			MemMax: 4096, // MB
 		},
 
+		InputReload: &InputReloadCfg{
+			Watch:    false,
+			Interval: "10s",
+		},
+
 		GitRepos: &GitRepost{
 			PullInterval: "1m",
 			Repos: []*GitRepository{
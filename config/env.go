@@ -259,6 +259,10 @@ func (c *Config) LoadEnvs() error {
 		c.DataWayCfg.EnableHTTPTrace = true
 	}
 
+	if v := datakit.GetEnv("ENV_DATAWAY_CONTENT_ENCODING"); v != "" {
+		c.DataWayCfg.ContentEncoding = v
+	}
+
 	if v := datakit.GetEnv("ENV_DATAWAY_HTTP_PROXY"); v != "" {
 		c.DataWayCfg.HTTPProxy = v
 		c.DataWayCfg.Proxy = true
@@ -358,6 +362,20 @@ func (c *Config) LoadEnvs() error {
 		c.ProtectMode = false
 	}
 
+	if v := datakit.GetEnv("ENV_INPUT_RELOAD_WATCH"); v != "" {
+		if c.InputReload == nil {
+			c.InputReload = &InputReloadCfg{}
+		}
+		c.InputReload.Watch = true
+	}
+
+	if v := datakit.GetEnv("ENV_INPUT_RELOAD_INTERVAL"); v != "" {
+		if c.InputReload == nil {
+			c.InputReload = &InputReloadCfg{}
+		}
+		c.InputReload.Interval = v
+	}
+
 	for _, x := range []string{
 		"ENV_DEFAULT_ENABLED_INPUTS",
 		"ENV_ENABLE_INPUTS", // Deprecated
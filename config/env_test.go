@@ -41,10 +41,13 @@ func TestLoadEnv(t *testing.T) {
 				"ENV_REQUEST_RATE_LIMIT":              "1234",
 				"ENV_DATAWAY_ENABLE_HTTPTRACE":        "any",
 				"ENV_DATAWAY_HTTP_PROXY":              "http://1.2.3.4:1234",
+				"ENV_DATAWAY_CONTENT_ENCODING":        "protobuf",
 				"ENV_HTTP_CLOSE_IDLE_CONNECTION":      "on",
 				"ENV_HTTP_TIMEOUT":                    "10s",
 				"ENV_ENABLE_ELECTION_NAMESPACE_TAG":   "ok",
 				"ENV_LOG_SINK_DETAIL":                 "true",
+				"ENV_INPUT_RELOAD_WATCH":              "on",
+				"ENV_INPUT_RELOAD_INTERVAL":           "30s",
 			},
 			expect: func() *Config {
 				cfg := DefaultConfig()
@@ -56,6 +59,7 @@ func TestLoadEnv(t *testing.T) {
 					HTTPProxy:           "http://1.2.3.4:1234",
 					Proxy:               true,
 					EnableHTTPTrace:     true,
+					ContentEncoding:     "protobuf",
 				}
 
 				cfg.HTTPAPI.RUMOriginIPHeader = "not-set"
@@ -87,6 +91,9 @@ func TestLoadEnv(t *testing.T) {
 
 				cfg.LogSinkDetail = true
 
+				cfg.InputReload.Watch = true
+				cfg.InputReload.Interval = "30s"
+
 				return cfg
 			}(),
 		},
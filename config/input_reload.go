@@ -0,0 +1,273 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	dkhttp "gitlab.jiagouyun.com/cloudcare-tools/datakit/http"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/election"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+)
+
+const defaultInputReloadInterval = 10 * time.Second
+
+// inputReloadMtx serializes the reloads from API and watcher, the loading
+// shares global states such as inputs.ConfigFileHash.
+var inputReloadMtx sync.Mutex
+
+// InputReloadCfg is the hot reload of input configs under conf.d.
+type InputReloadCfg struct {
+	// Watch checks the input configs every interval, and hot reloads the
+	// changed ones.
+	Watch    bool   `toml:"watch"`
+	Interval string `toml:"interval"`
+}
+
+func checkInputReloadable() error {
+	if IsUseConfd() {
+		return fmt.Errorf("inputs are managed by confd")
+	}
+
+	if GitHasEnabled() {
+		return fmt.Errorf("inputs are managed by git repos")
+	}
+
+	return nil
+}
+
+// ReloadInputs hot reloads inputs of the config file fp, or of all config
+// files under conf.d if fp is empty. Inputs not changed keep running.
+func ReloadInputs(fp string) ([]*inputs.ReloadStat, error) {
+	if err := checkInputReloadable(); err != nil {
+		return nil, err
+	}
+
+	inputReloadMtx.Lock()
+	defer inputReloadMtx.Unlock()
+
+	var stats []*inputs.ReloadStat
+
+	if fp == "" {
+		stats = reloadAllInputConfs()
+	} else {
+		fp = filepath.Clean(fp)
+		if err := checkInputConfPath(fp); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, reloadInputConfFile(fp, true))
+	}
+
+	applyInputReload(stats)
+	return stats, nil
+}
+
+func checkInputConfPath(fp string) error {
+	if !strings.HasSuffix(fp, ".conf") || filepath.Base(fp) == datakit.StrDefaultConfFile {
+		return fmt.Errorf("invalid input conf %s", fp)
+	}
+
+	for _, rp := range getConfRootPaths() {
+		if rel, err := filepath.Rel(rp, fp); err == nil && !strings.HasPrefix(rel, "..") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("input conf %s not under %s", fp, strings.Join(getConfRootPaths(), ","))
+}
+
+// reloadInputConfFile reloads the config file, inputs of the file are all
+// stopped if it's removed. If the file failed to load, its inputs keep running.
+func reloadInputConfFile(fp string, skipChecksum bool) *inputs.ReloadStat {
+	if _, err := os.Stat(fp); err != nil && os.IsNotExist(err) {
+		return inputs.ReloadSource(fp, nil)
+	}
+
+	x, err := loadSingleConfFile(fp, inputs.Inputs, skipChecksum)
+	if err != nil {
+		l.Warnf("load conf(%s) failed: %s, inputs keep running", fp, err)
+		return inputs.ReloadFailed(fp, err)
+	}
+
+	var loaded []*inputs.LoadedInput
+	for _, arr := range x {
+		loaded = append(loaded, arr...)
+	}
+
+	return inputs.ReloadSource(fp, loaded)
+}
+
+func searchInputConfs() []string {
+	var confs []string
+	for _, rp := range getConfRootPaths() {
+		for _, fp := range SearchDir(rp, ".conf", ".git") {
+			if filepath.Base(fp) == datakit.StrDefaultConfFile {
+				continue
+			}
+			confs = append(confs, fp)
+		}
+	}
+	return confs
+}
+
+func reloadAllInputConfs() []*inputs.ReloadStat {
+	var stats []*inputs.ReloadStat
+
+	// config files with the same check sum are ignored, same as the first load
+	inputs.ConfigFileHash = map[string]struct{}{}
+
+	found := map[string]bool{}
+	for _, fp := range searchInputConfs() {
+		found[fp] = true
+		stats = append(stats, reloadInputConfFile(fp, false))
+	}
+
+	// config files removed
+	for _, src := range inputs.Sources() {
+		if !found[src] {
+			stats = append(stats, inputs.ReloadSource(src, nil))
+		}
+	}
+
+	return stats
+}
+
+func applyInputReload(stats []*inputs.ReloadStat) {
+	changed, httpChanged := false, false
+
+	for _, s := range stats {
+		if s.Error != "" {
+			l.Warnf("reload %s failed: %s", s.Source, s.Error)
+			continue
+		}
+
+		if len(s.Started) > 0 || len(s.Stopped) > 0 {
+			changed = true
+			l.Infof("reload %s: started %v, stopped %v, %d unchanged", s.Source, s.Started, s.Stopped, s.Unchanged)
+		}
+
+		if len(s.Pending) > 0 {
+			l.Warnf("reload %s: inputs %v can't be terminated, restart DataKit to apply the changes", s.Source, s.Pending)
+		}
+
+		if s.HTTPChanged() {
+			httpChanged = true
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	// HTTP routes of removed inputs should be dropped, the HTTP server reloaded
+	if httpChanged {
+		dkhttp.CleanHTTPHandler()
+		if err := inputs.RunInputExtra(); err != nil {
+			l.Errorf("RunInputExtra: %s", err)
+		}
+		dkhttp.ReloadTheNormalServer()
+	}
+
+	election.ReloadInputs()
+}
+
+func inputConfChecksums() map[string]string {
+	sums := map[string]string{}
+	for _, fp := range searchInputConfs() {
+		data, err := ioutil.ReadFile(filepath.Clean(fp))
+		if err != nil {
+			l.Warnf("ioutil.ReadFile: %s, ignored", err)
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		sums[fp] = hex.EncodeToString(sum[:])
+	}
+	return sums
+}
+
+// StartInputReloadWatcher watches the input configs if enabled, changed
+// config files are hot reloaded.
+func StartInputReloadWatcher() {
+	c := Cfg.InputReload
+	if c == nil || !c.Watch {
+		return
+	}
+
+	if err := checkInputReloadable(); err != nil {
+		l.Warnf("input reload watcher disabled: %s", err)
+		return
+	}
+
+	interval := defaultInputReloadInterval
+	if c.Interval != "" {
+		du, err := time.ParseDuration(c.Interval)
+		if err != nil || du <= 0 {
+			l.Warnf("invalid input reload interval %q, use default %s", c.Interval, interval)
+		} else {
+			interval = du
+		}
+	}
+
+	l.Infof("watch input configs every %s", interval)
+
+	g := datakit.G("input_reload")
+	g.Go(func(ctx context.Context) error {
+		sums := inputConfChecksums()
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-datakit.Exit.Wait():
+				l.Info("input reload watcher exit")
+				return nil
+
+			case <-tick.C:
+				sums = reloadChangedInputConfs(sums)
+			}
+		}
+	})
+}
+
+// reloadChangedInputConfs reloads the config files changed or removed since
+// the checksums sums, and returns the current checksums.
+func reloadChangedInputConfs(sums map[string]string) map[string]string {
+	inputReloadMtx.Lock()
+	defer inputReloadMtx.Unlock()
+
+	cur := inputConfChecksums()
+
+	var stats []*inputs.ReloadStat
+	for fp, sum := range cur {
+		if sums[fp] != sum {
+			l.Infof("input conf %s changed, reload...", fp)
+			stats = append(stats, reloadInputConfFile(fp, true))
+		}
+	}
+
+	for fp := range sums {
+		if _, ok := cur[fp]; !ok {
+			l.Infof("input conf %s removed, reload...", fp)
+			stats = append(stats, reloadInputConfFile(fp, true))
+		}
+	}
+
+	applyInputReload(stats)
+	return cur
+}
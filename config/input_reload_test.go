@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+)
+
+type reloadInput struct {
+	Interval string `toml:"interval"`
+
+	semStop chan struct{}
+}
+
+func (*reloadInput) Catalog() string                         { return "test" }
+func (*reloadInput) SampleConfig() string                    { return "" }
+func (*reloadInput) SampleMeasurement() []inputs.Measurement { return nil }
+func (*reloadInput) AvailableArchs() []string                { return nil }
+func (i *reloadInput) Run()                                  { <-i.semStop }
+func (i *reloadInput) Terminate()                            { close(i.semStop) }
+
+func TestReloadInputs(t *testing.T) {
+	confd := datakit.ConfdDir
+	datakit.ConfdDir = t.TempDir()
+	defer func() { datakit.ConfdDir = confd }()
+
+	inputs.Inputs["reload-test"] = func() inputs.Input { return &reloadInput{semStop: make(chan struct{})} }
+	defer delete(inputs.Inputs, "reload-test")
+
+	inputs.ResetInputs()
+	defer inputs.ResetInputs()
+
+	fp := filepath.Join(datakit.ConfdDir, "test", "reload.conf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(fp), os.ModePerm))
+
+	write := func(conf string) {
+		require.NoError(t, os.WriteFile(fp, []byte(conf), 0o600))
+	}
+
+	write(`
+[[inputs.reload-test]]
+  interval = "10s"
+[[inputs.reload-test]]
+  interval = "20s"`)
+
+	stats, err := ReloadInputs(fp)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, []string{"reload-test", "reload-test"}, stats[0].Started)
+	assert.Equal(t, 2, inputs.InputEnabled("reload-test"))
+
+	// only the 2nd input restarted
+	write(`
+[[inputs.reload-test]]
+  interval = "10s"
+[[inputs.reload-test]]
+  interval = "30s"`)
+
+	stats, err = ReloadInputs("")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Unchanged)
+	assert.Equal(t, []string{"reload-test"}, stats[0].Started)
+	assert.Equal(t, []string{"reload-test"}, stats[0].Stopped)
+	assert.Equal(t, 2, inputs.InputEnabled("reload-test"))
+
+	// invalid TOML, inputs keep running
+	write(`[[inputs.reload-test]`)
+	stats, err = ReloadInputs(fp)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats[0].Error)
+	assert.Equal(t, 2, inputs.InputEnabled("reload-test"))
+
+	// file removed
+	require.NoError(t, os.Remove(fp))
+	stats, err = ReloadInputs("")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Len(t, stats[0].Stopped, 2)
+	assert.Equal(t, 0, inputs.InputEnabled("reload-test"))
+
+	// conf not under conf.d
+	_, err = ReloadInputs("/etc/passwd.conf")
+	assert.Error(t, err)
+
+	_, err = ReloadInputs(filepath.Join(datakit.ConfdDir, datakit.StrDefaultConfFile))
+	assert.Error(t, err)
+}
+
+func TestReloadInputsConcurrently(t *testing.T) {
+	confd := datakit.ConfdDir
+	datakit.ConfdDir = t.TempDir()
+	defer func() { datakit.ConfdDir = confd }()
+
+	inputs.Inputs["reload-test"] = func() inputs.Input { return &reloadInput{semStop: make(chan struct{})} }
+	defer delete(inputs.Inputs, "reload-test")
+
+	inputs.ResetInputs()
+	defer inputs.ResetInputs()
+
+	fp := filepath.Join(datakit.ConfdDir, "test", "reload.conf")
+	require.NoError(t, os.MkdirAll(filepath.Dir(fp), os.ModePerm))
+	require.NoError(t, os.WriteFile(fp, []byte(`
+[[inputs.reload-test]]
+  interval = "10s"`), 0o600))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, err := ReloadInputs("")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := ReloadInputs(fp)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			reloadChangedInputConfs(map[string]string{})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, inputs.InputEnabled("reload-test"))
+}
@@ -127,10 +127,10 @@ func loadInputsConfFromDirs(paths []string) {
 
 	l.Infof("load input confs from %s", paths)
 	for _, rp := range paths {
-		for name, arr := range LoadInputConf(rp) {
+		for name, arr := range loadInputConf(rp) {
 			for _, x := range arr {
 				l.Infof("load inputs from file add input: %s", name)
-				inputs.AddInput(name, x)
+				inputs.AddLoadedInput(x)
 			}
 		}
 	}
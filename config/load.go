@@ -18,6 +18,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/checkutil"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/dkstring"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/path"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/script"
 )
 
@@ -96,6 +97,12 @@ func LoadCfg(c *Config, mcp string) error {
 		return err
 	}
 
+	c.removeDeprecatedUUID()
+
+	if err := c.ResolveSecrets(); err != nil {
+		return fmt.Errorf("resolve secrets: %w", err)
+	}
+
 	l.Debugf("apply main configure...")
 
 	if err := c.ApplyMainConfig(); err != nil {
@@ -112,7 +119,7 @@ func LoadCfg(c *Config, mcp string) error {
 		return true
 	})
 
-	l.Infof("loaded main cfg: \n%s", c.String())
+	l.Infof("loaded main cfg: \n%s", secret.Redact(c.String()))
 
 	// clear all samples before loading
 	removeSamples()
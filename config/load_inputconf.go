@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -17,15 +18,45 @@ import (
 
 	bstoml "github.com/BurntSushi/toml"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
 )
 
 func LoadSingleConf(confData string, creators map[string]inputs.Creator) (map[string][]inputs.Input, error) {
+	x, err := loadSingleConf(confData, creators)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadedInputs(x), nil
+}
+
+func loadedInputs(x map[string][]*inputs.LoadedInput) map[string][]inputs.Input {
 	ret := map[string][]inputs.Input{}
+	for k, arr := range x {
+		for _, li := range arr {
+			ret[k] = append(ret[k], li.Input)
+		}
+	}
+	return ret
+}
+
+// loadSingleConf loads inputs with the checksum of each input's config, so
+// inputs not changed can be found on hot reload.
+func loadSingleConf(confData string, creators map[string]inputs.Creator) (map[string][]*inputs.LoadedInput, error) {
+	ret := map[string][]*inputs.LoadedInput{}
 
 	var res map[string]interface{}
 
-	if _, err := bstoml.Decode(confData, &res); err != nil {
+	// secret references are resolved on each load, so reload gets the
+	// latest secrets.
+	data, err := secret.ResolveTOML([]byte(confData))
+	if err != nil {
+		l.Warnf("secret.ResolveTOML: %s, ignored", err)
+		return nil, err
+	}
+
+	if _, err := bstoml.Decode(string(data), &res); err != nil {
 		l.Warnf("bstoml.Decode: %s, ignored, confData:\n%s", err, confData)
 		return nil, err
 	}
@@ -56,20 +87,20 @@ func LoadSingleConf(confData string, creators map[string]inputs.Creator) (map[st
 				switch y := b.(type) { // 第三层
 				case []map[string]interface{}: // it's a inputs array: [[inputs.xxx]]
 					for _, input := range y {
-						l.Debugf("input: %+#v", input)
+						l.Debugf("input: %s", secret.Redact(fmt.Sprintf("%+#v", input)))
 
-						if i, err := constructInput(input, c); err != nil {
+						if i, fingerprint, err := constructInput(input, c); err != nil {
 							l.Errorf("constructInput: %s, ignored", err)
 						} else {
-							ret[inputName] = append(ret[inputName], i)
+							ret[inputName] = append(ret[inputName], &inputs.LoadedInput{Name: inputName, Input: i, Fingerprint: fingerprint})
 						}
 					}
 
 				case map[string]interface{}: // it's a single input: [inputs.xxx]
-					if i, err := constructInput(y, c); err != nil {
+					if i, fingerprint, err := constructInput(y, c); err != nil {
 						l.Errorf("constructInput: %s, ignored", err)
 					} else {
-						ret[inputName] = append(ret[inputName], i)
+						ret[inputName] = append(ret[inputName], &inputs.LoadedInput{Name: inputName, Input: i, Fingerprint: fingerprint})
 					}
 
 				default:
@@ -135,6 +166,15 @@ func CheckConfFileDupOrSet(data []byte) bool {
 }
 
 func LoadSingleConfFile(fp string, creators map[string]inputs.Creator, skipChecksum bool) (map[string][]inputs.Input, error) {
+	x, err := loadSingleConfFile(fp, creators, skipChecksum)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadedInputs(x), nil
+}
+
+func loadSingleConfFile(fp string, creators map[string]inputs.Creator, skipChecksum bool) (map[string][]*inputs.LoadedInput, error) {
 	data, err := ioutil.ReadFile(filepath.Clean(fp))
 	if err != nil {
 		l.Errorf("ioutil.ReadFile: %s", err.Error())
@@ -149,15 +189,29 @@ func LoadSingleConfFile(fp string, creators map[string]inputs.Creator, skipCheck
 
 	data = feedEnvs(data)
 
-	return LoadSingleConf(string(data), creators)
+	x, err := loadSingleConf(string(data), creators)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arr := range x {
+		for _, li := range arr {
+			li.Source = fp
+		}
+	}
+	return x, nil
 }
 
 // LoadInputConf read all inputs configures(toml) from @root,
 // then create various inputs object.
 func LoadInputConf(root string) map[string][]inputs.Input {
+	return loadedInputs(loadInputConf(root))
+}
+
+func loadInputConf(root string) map[string][]*inputs.LoadedInput {
 	confs := SearchDir(root, ".conf", ".git")
 
-	ret := map[string][]inputs.Input{}
+	ret := map[string][]*inputs.LoadedInput{}
 
 	l.Infof("find %d confs:  %s", len(confs), strings.Join(confs, "<\t\t>"))
 	for _, fp := range confs {
@@ -165,7 +219,7 @@ func LoadInputConf(root string) map[string][]inputs.Input {
 			continue
 		}
 
-		x, err := LoadSingleConfFile(fp, inputs.Inputs, false)
+		x, err := loadSingleConfFile(fp, inputs.Inputs, false)
 		if err != nil {
 			l.Warnf("load conf(%s) failed: %s, ignored", fp, err)
 			continue
@@ -174,7 +228,7 @@ func LoadInputConf(root string) map[string][]inputs.Input {
 		for k, arr := range x {
 			loaded := false
 			for _, collector := range ret[k] {
-				if _, ok := collector.(inputs.Singleton); ok {
+				if _, ok := collector.Input.(inputs.Singleton); ok {
 					loaded = true
 					l.Warnf("the collector [%s] is singleton, allow only one instant running", k)
 					break
@@ -182,7 +236,7 @@ func LoadInputConf(root string) map[string][]inputs.Input {
 			}
 			if !loaded {
 				if len(arr) > 1 {
-					if _, ok := arr[0].(inputs.Singleton); ok {
+					if _, ok := arr[0].Input.(inputs.Singleton); ok {
 						arr = arr[:1]
 						l.Warnf("the collector [%s] is singleton but finding multi instant config, reserve the first only", k)
 					}
@@ -196,7 +250,8 @@ func LoadInputConf(root string) map[string][]inputs.Input {
 	return ret
 }
 
-func constructInput(x interface{}, c inputs.Creator) (inputs.Input, error) {
+// constructInput creates the input, and returns the checksum of its config.
+func constructInput(x interface{}, c inputs.Creator) (inputs.Input, string, error) {
 	i := c()
 	var buf bytes.Buffer
 
@@ -204,15 +259,16 @@ func constructInput(x interface{}, c inputs.Creator) (inputs.Input, error) {
 
 	if err := bstoml.NewEncoder(&buf).Encode((x)); err != nil {
 		l.Errorf("Encode: %s", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	l.Debugf("buf: %s", buf.String())
+	l.Debugf("buf: %s", secret.Redact(buf.String()))
 
 	if _, err := bstoml.Decode(buf.String(), i); err != nil {
 		l.Errorf("Decode: %s", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	return i, nil
+	sum := sha256.Sum256(buf.Bytes())
+	return i, hex.EncodeToString(sum[:]), nil
 }
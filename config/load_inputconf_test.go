@@ -14,6 +14,10 @@ import (
 
 	tu "github.com/GuanceCloud/cliutils/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/dataway"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
 )
 
@@ -251,6 +255,63 @@ percpu = false
 	}
 }
 
+func TestLoadConfWithSecret(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "interval")
+	require.NoError(t, os.WriteFile(f, []byte("10s\n"), 0o600))
+
+	creators := map[string]inputs.Creator{
+		"cpu": func() inputs.Input {
+			return &cpu{}
+		},
+	}
+
+	ret, err := LoadSingleConf(`
+[[inputs.cpu]]
+  interval = "ENC[file://`+f+`]"`, creators)
+	require.NoError(t, err)
+	assert.True(t, eq(&cpu{Interval: "10s"}, ret["cpu"][0]))
+
+	_, err = LoadSingleConf(`
+[[inputs.cpu]]
+  interval = "ENC[file://`+filepath.Join(dir, "not-exist")+`]"`, creators)
+	assert.Error(t, err)
+
+	t.Run("main-conf", func(t *testing.T) {
+		token := filepath.Join(dir, "token")
+		require.NoError(t, os.WriteFile(token, []byte("tkn_0123456789"), 0o600))
+
+		c := DefaultConfig()
+		c.DataWayCfg = &dataway.DataWayCfg{URLs: []string{"https://openway.guance.com?token=ENC[file://" + token + "]"}}
+
+		require.NoError(t, c.ResolveSecrets())
+		assert.Equal(t, "https://openway.guance.com?token=tkn_0123456789", c.DataWayCfg.URLs[0])
+		assert.NotContains(t, secret.Redact(c.String()), "tkn_0123456789")
+	})
+
+	t.Run("remove-deprecated-uuid", func(t *testing.T) {
+		token := filepath.Join(dir, "token")
+		require.NoError(t, os.WriteFile(token, []byte("tkn_0123456789"), 0o600))
+
+		mcp := datakit.MainConfPath
+		datakit.MainConfPath = filepath.Join(dir, "datakit.conf")
+		defer func() { datakit.MainConfPath = mcp }()
+
+		c := DefaultConfig()
+		c.UUIDDeprecated = "dkid_xxx"
+		c.DataWayCfg = &dataway.DataWayCfg{URLs: []string{"https://openway.guance.com?token=ENC[file://" + token + "]"}}
+
+		c.removeDeprecatedUUID()
+		require.NoError(t, c.ResolveSecrets())
+
+		data, err := os.ReadFile(datakit.MainConfPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "tkn_0123456789")
+		assert.NotContains(t, string(data), "dkid_xxx")
+		assert.Contains(t, string(data), "ENC[file://"+token+"]")
+	})
+}
+
 // go test -v -timeout 30s -run ^Test_SearchDir$ gitlab.jiagouyun.com/cloudcare-tools/datakit/config
 func Test_SearchDir(t *testing.T) {
 	cases := []struct {
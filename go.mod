@@ -75,6 +75,7 @@ require (
 	github.com/ugorji/go/codec v1.2.6
 	github.com/vjeantet/grok v1.0.0
 	github.com/whilp/git-urls v1.0.0
+	github.com/xdg-go/scram v1.1.1
 	go.etcd.io/bbolt v1.3.6
 	go.mercari.io/go-dnscache v0.0.0-20220124075326-2701c2ab5df5
 	go.uber.org/atomic v1.10.0
@@ -114,6 +115,8 @@ require (
 	github.com/itchyny/timefmt-go v0.1.5
 	github.com/klauspost/compress v1.15.9
 	github.com/ory/dockertest/v3 v3.9.1
+	github.com/pierrec/lz4 v2.6.1+incompatible
+	github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring v0.51.2
 	github.com/prometheus-operator/prometheus-operator/pkg/client v0.51.2
 	github.com/prometheus/client_golang v1.14.0
 	github.com/r3labs/diff/v3 v3.0.0
@@ -213,8 +216,6 @@ require (
 	github.com/opentracing-contrib/go-grpc v0.0.0-20210225150812-73cb765af46e // indirect
 	github.com/opentracing-contrib/go-stdlib v1.0.0 // indirect
 	github.com/opentracing/opentracing-go v1.2.1-0.20220228012449-10b1cf09e00b // indirect
-	github.com/pierrec/lz4 v2.6.1+incompatible // indirect
-	github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring v0.51.2 // indirect
 	github.com/prometheus/node_exporter v1.0.0-rc.0.0.20200428091818-01054558c289 // indirect
 	github.com/pyroscope-io/jfr-parser v0.5.2 // indirect
 	github.com/rcrowley/go-metrics v0.0.0-20201227073835-cf1acfcdf475 // indirect
@@ -229,7 +230,6 @@ require (
 	github.com/weaveworks/common v0.0.0-20210419092856-009d1eebd624 // indirect
 	github.com/weaveworks/promrus v1.2.0 // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
-	github.com/xdg-go/scram v1.1.1 // indirect
 	github.com/xdg-go/stringprep v1.0.3 // indirect
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
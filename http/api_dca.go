@@ -28,6 +28,7 @@ import (
 	"github.com/influxdata/toml"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/path"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/secret"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
@@ -282,7 +283,10 @@ func dcaGetConfig(c *gin.Context) {
 		context.fail(dcaError{ErrorCode: "invalid.path", ErrorMsg: "invalid path"})
 		return
 	}
-	context.success(string(content))
+
+	// references such as ENC[keystore://xxx] are kept, only plain secrets
+	// resolved are redacted
+	context.success(secret.Redact(string(content)))
 }
 
 func dcaDeleteConfig(c *gin.Context) {
@@ -15,6 +15,7 @@ import (
 
 	dt "github.com/GuanceCloud/cliutils/dialtesting"
 	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/dialtesting"
 )
 
 type dialtestingDebugRequest struct {
@@ -55,6 +56,8 @@ func apiDebugDialtestingHandler(w http.ResponseWriter, req *http.Request, whatev
 		t = &dt.WebsocketTask{}
 	case dt.ClassICMP:
 		t = &dt.ICMPTask{}
+	case dt.ClassDNS:
+		t = &dialtesting.DNSTask{}
 	default:
 		l.Errorf("unknown task type: %s", taskType)
 		return nil, uhttp.Error(ErrInvalidRequest, fmt.Sprintf("unknown task type:%s", taskType))
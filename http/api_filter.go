@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/filter"
+)
+
+var filterReload = filter.Reload
+
+// request body.
+type filterReloadRequest struct {
+	Filters map[string][]string `json:"filters"`
+	DryRun  bool                `json:"dry_run"`
+}
+
+// apiFilterReload hot reloads the filters, invalid filter rejected and
+// current filters kept.
+func apiFilterReload(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		l.Errorf("ioutil.ReadAll: %s", err)
+		return nil, uhttp.Error(ErrHTTPReadErr, err.Error())
+	}
+
+	var reqBody filterReloadRequest
+	if err := json.Unmarshal(body, &reqBody); err != nil {
+		l.Errorf("json.Unmarshal: %s", err)
+		return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+	}
+
+	if err := filterReload(reqBody.Filters, reqBody.DryRun); err != nil {
+		l.Errorf("filter reload: %s", err)
+		return nil, uhttp.Error(ErrInvalidFilter, err.Error())
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/filter"
+)
+
+func TestAPIFilterReload(t *testing.T) {
+	var (
+		gotFilters map[string][]string
+		gotDryRun  bool
+	)
+
+	filterReload = func(filters map[string][]string, dryRun bool) error {
+		if len(filters["logging"]) != 0 && filters["logging"][0] == "invalid" {
+			return errors.New("invalid filter")
+		}
+
+		gotFilters, gotDryRun = filters, dryRun
+		return nil
+	}
+	defer func() { filterReload = filter.Reload }()
+
+	cases := []struct {
+		name       string
+		body       string
+		fail       bool
+		expectDry  bool
+		expectRule string
+	}{
+		{
+			name:       "reload",
+			body:       `{"filters":{"logging":["{ source = 'nginx' }"]}}`,
+			expectRule: "{ source = 'nginx' }",
+		},
+		{
+			name:       "dry-run",
+			body:       `{"filters":{"logging":["{ source = 'redis' }"]},"dry_run":true}`,
+			expectDry:  true,
+			expectRule: "{ source = 'redis' }",
+		},
+		{
+			name: "invalid-json",
+			body: `{"filters":`,
+			fail: true,
+		},
+		{
+			name: "invalid-filter",
+			body: `{"filters":{"logging":["invalid"]}}`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotFilters, gotDryRun = nil, false
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/filter", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+
+			_, err = apiFilterReload(nil, req)
+			if tc.fail {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusBadRequest, getStatusCode(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectDry, gotDryRun)
+			assert.Equal(t, []string{tc.expectRule}, gotFilters["logging"])
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+)
+
+// reloadInputs set by Option, it's in package config.
+var reloadInputs func(path string) ([]*inputs.ReloadStat, error)
+
+// request body.
+type inputReloadRequest struct {
+	Path string `json:"path"`
+}
+
+// apiInputReload hot reloads inputs of the config file, or all config files
+// if path not set. Only changed inputs restarted.
+func apiInputReload(w http.ResponseWriter, req *http.Request, whatever ...interface{}) (interface{}, error) {
+	if reloadInputs == nil {
+		return nil, uhttp.Error(ErrReloadInputFailed, "input reload not supported")
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		l.Errorf("ioutil.ReadAll: %s", err)
+		return nil, uhttp.Error(ErrHTTPReadErr, err.Error())
+	}
+
+	var reqBody inputReloadRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &reqBody); err != nil {
+			l.Errorf("json.Unmarshal: %s", err)
+			return nil, uhttp.Error(ErrInvalidRequest, err.Error())
+		}
+	}
+
+	stats, err := reloadInputs(reqBody.Path)
+	if err != nil {
+		l.Errorf("reload inputs: %s", err)
+		return nil, uhttp.Error(ErrReloadInputFailed, err.Error())
+	}
+
+	return stats, nil
+}
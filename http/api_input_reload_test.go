@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+)
+
+func TestAPIInputReload(t *testing.T) {
+	var gotPath string
+
+	reloadInputs = func(path string) ([]*inputs.ReloadStat, error) {
+		if path == "/invalid.conf" {
+			return nil, errors.New("invalid input conf")
+		}
+
+		gotPath = path
+		return []*inputs.ReloadStat{{Source: path, Started: []string{"cpu"}}}, nil
+	}
+	defer func() { reloadInputs = nil }()
+
+	cases := []struct {
+		name       string
+		body       string
+		fail       bool
+		expectPath string
+	}{
+		{
+			name:       "reload-file",
+			body:       `{"path":"/usr/local/datakit/conf.d/host/cpu.conf"}`,
+			expectPath: "/usr/local/datakit/conf.d/host/cpu.conf",
+		},
+		{
+			name: "reload-all",
+			body: ``,
+		},
+		{
+			name: "invalid-json",
+			body: `{"path":`,
+			fail: true,
+		},
+		{
+			name: "invalid-path",
+			body: `{"path":"/invalid.conf"}`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotPath = "-"
+
+			req, err := http.NewRequest(http.MethodPost, "/v1/input/reload", bytes.NewBufferString(tc.body))
+			assert.NoError(t, err)
+
+			res, err := apiInputReload(nil, req)
+			if tc.fail {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusBadRequest, getStatusCode(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectPath, gotPath)
+
+			stats, ok := res.([]*inputs.ReloadStat)
+			assert.True(t, ok)
+			assert.Len(t, stats, 1)
+		})
+	}
+}
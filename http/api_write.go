@@ -14,6 +14,8 @@ import (
 
 	lp "github.com/GuanceCloud/cliutils/lineproto"
 	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	pbpoint "github.com/GuanceCloud/cliutils/point"
+	influxdb "github.com/influxdata/influxdb1-client/v2"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
@@ -179,11 +181,15 @@ func apiWrite(w http.ResponseWriter, req *http.Request, x ...interface{}) (inter
 		return nil, ErrEmptyBody
 	}
 
-	isjson := (strings.Contains(req.Header.Get("Content-Type"), "application/json"))
-
 	var pts []*point.Point
 
-	pts, err = handleWriteBody(body, isjson, opt)
+	contentType := req.Header.Get("Content-Type")
+	if strings.Contains(contentType, pbpoint.PBContentType) {
+		pts, err = pbPoints(body, opt)
+	} else {
+		isjson := (strings.Contains(contentType, "application/json"))
+		pts, err = handleWriteBody(body, isjson, opt)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -272,6 +278,107 @@ func jsonPoints(body []byte, opt *lp.Option) ([]*point.Point, error) {
 	return pts, nil
 }
 
+// pbPoints convert protobuf encoded points(such as sent by datakit
+// configured with protobuf dataway content encoding) to lineproto points.
+// The points are checked with opt as lineproto and JSON points do, but field
+// types such as uint64 are kept. opt not modified.
+func pbPoints(body []byte, opt *lp.Option) ([]*point.Point, error) {
+	dec := pbpoint.GetDecoder(pbpoint.WithDecEncoding(pbpoint.Protobuf))
+	defer pbpoint.PutDecoder(dec)
+
+	pbpts, err := dec.Decode(body)
+	if err != nil {
+		l.Error(err)
+		return nil, uhttp.Error(ErrInvalidPBPoint, err.Error())
+	}
+
+	if opt == nil {
+		opt = lp.DefaultOption
+	}
+
+	pts := make([]*point.Point, 0, len(pbpts))
+	for _, pbpt := range pbpts {
+		p, err := pbPoint(pbpt, opt)
+		if err != nil {
+			l.Error(err)
+			return nil, uhttp.Error(ErrInvalidPBPoint, err.Error())
+		}
+
+		pts = append(pts, &point.Point{Point: p})
+	}
+
+	return pts, nil
+}
+
+// pbPoint checks the protobuf point with opt. uint64 fields are checked as
+// int64 placeholders, since the checker converts them to int64 and rejects
+// values larger than math.MaxInt64, then restored after checked.
+func pbPoint(pbpt *pbpoint.Point, opt *lp.Option) (*influxdb.Point, error) {
+	tags := pbpt.InfluxTags()
+	if tags == nil {
+		tags = map[string]string{} // extra tags merged into tags, not the map of opt
+	}
+
+	fields := pbFields(pbpt)
+	uints := map[string]uint64{}
+	for k, v := range fields {
+		if u, ok := v.(uint64); ok {
+			uints[k] = u
+			fields[k] = int64(0)
+		}
+	}
+
+	// time of protobuf point is always in nanoseconds
+	o := *opt
+	o.Time = pbpt.Time()
+
+	p, err := lp.MakeLineProtoPoint(string(pbpt.Name()), tags, fields, &o)
+	if err != nil || len(uints) == 0 {
+		return p, err
+	}
+
+	checked, err := p.Fields()
+	if err != nil {
+		return nil, err
+	}
+
+	for k, u := range uints {
+		if o.MaxFieldKeyLen > 0 && len(k) > o.MaxFieldKeyLen {
+			k = k[:o.MaxFieldKeyLen]
+		}
+		if _, ok := checked[k]; ok {
+			checked[k] = u
+		}
+	}
+
+	return influxdb.NewPoint(p.Name(), p.Tags(), checked, p.Time())
+}
+
+// pbFields get fields of protobuf point with types kept, fields not
+// supported by lineproto(such as array) are dropped.
+func pbFields(pt *pbpoint.Point) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	for _, kv := range pt.Fields() {
+		switch x := kv.Val.(type) {
+		case *pbpoint.Field_I:
+			fields[string(kv.Key)] = x.I
+		case *pbpoint.Field_U:
+			fields[string(kv.Key)] = x.U
+		case *pbpoint.Field_F:
+			fields[string(kv.Key)] = x.F
+		case *pbpoint.Field_B:
+			fields[string(kv.Key)] = x.B
+		case *pbpoint.Field_D:
+			fields[string(kv.Key)] = string(x.D)
+		default:
+			l.Debugf("field %s of %s not supported by lineproto, dropped", kv.Key, pt.Name())
+		}
+	}
+
+	return fields
+}
+
 func getTimeFromInt64(n int64, opt *lp.Option) time.Time {
 	if opt != nil {
 		switch opt.Precision {
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -18,10 +19,12 @@ import (
 
 	lp "github.com/GuanceCloud/cliutils/lineproto"
 	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	pbpoint "github.com/GuanceCloud/cliutils/point"
 	tu "github.com/GuanceCloud/cliutils/testutil"
 	"github.com/gin-gonic/gin"
 	"github.com/influxdata/influxdb1-client/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
@@ -230,6 +233,93 @@ test,t1=abc f1=1i,f2=2,f3="str"`),
 	}
 }
 
+func pbBody(t *testing.T, name string, tags map[string]string, fields map[string]interface{}, tm time.Time) []byte {
+	t.Helper()
+
+	pt, err := pbpoint.NewPoint(name, tags, fields, pbpoint.WithTime(tm))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc := pbpoint.GetEncoder(pbpoint.WithEncEncoding(pbpoint.Protobuf))
+	defer pbpoint.PutEncoder(enc)
+
+	bodies, err := enc.Encode([]*pbpoint.Point{pt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bodies[0]
+}
+
+func TestPBPoints(t *testing.T) {
+	tm := time.Unix(0, 123456789)
+	body := pbBody(t, "measurement",
+		map[string]string{"t1": "1", "host": "from-pb"},
+		map[string]interface{}{
+			"i": int64(-1), "u": uint64(math.MaxUint64), "f": 3.14, "b": true, "s": "str",
+		}, tm)
+
+	opt := lp.NewDefaultOption()
+	opt.ExtraTags = map[string]string{"host": "my-testing", "cluster": "my-cluster"}
+	optTime := opt.Time
+	defaultTime := lp.DefaultOption.Time
+
+	for _, o := range []*lp.Option{opt, nil} {
+		pts, err := pbPoints(body, o)
+		assert.NoError(t, err)
+		assert.Len(t, pts, 1)
+
+		fields, err := pts[0].Fields()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"i": int64(-1), "u": uint64(math.MaxUint64), "f": 3.14, "b": true, "s": "str",
+		}, fields)
+		assert.Equal(t, tm.UnixNano(), pts[0].Time().UnixNano())
+
+		if o != nil {
+			assert.Equal(t, map[string]string{"t1": "1", "host": "from-pb", "cluster": "my-cluster"}, pts[0].Tags())
+		} else {
+			assert.Equal(t, map[string]string{"t1": "1", "host": "from-pb"}, pts[0].Tags())
+		}
+	}
+
+	// options shared by requests not modified
+	assert.Equal(t, optTime, opt.Time)
+	assert.Equal(t, defaultTime, lp.DefaultOption.Time)
+
+	t.Run("metric", func(t *testing.T) {
+		opt := lp.NewDefaultOption()
+		opt.MaxTagKeyLen = 8
+		opt.MaxFieldKeyLen = 8
+		opt.EnablePointInKey = true
+		opt.DisableStringField = true
+
+		body := pbBody(t, "measurement",
+			map[string]string{"long-tag-key": "1", "t.2": "2"},
+			map[string]interface{}{"s": "str", "too-long-uint": uint64(math.MaxUint64), "f": 3.14}, tm)
+
+		pts, err := pbPoints(body, opt)
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+
+		fields, err := pts[0].Fields()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"too-long": uint64(math.MaxUint64), "f": 3.14}, fields)
+		assert.Equal(t, map[string]string{"long-tag": "1", "t.2": "2"}, pts[0].Tags())
+
+		// rejected as lineproto and JSON points
+		opt.EnablePointInKey = false
+		_, err = pbPoints(body, opt)
+		assert.Error(t, err)
+
+		opt.EnablePointInKey = true
+		opt.DisabledFieldKeys = []string{"f"}
+		_, err = pbPoints(body, opt)
+		assert.Error(t, err)
+	})
+}
+
 type apiWriteMock struct {
 	t *testing.T
 }
@@ -466,6 +556,38 @@ func TestAPIWrite(t *testing.T) {
 			},
 		},
 
+		{
+			name:             `metric-protobuf-point`,
+			method:           "POST",
+			url:              "/v1/write/metric?echo_line_proto=1",
+			contentType:      pbpoint.PBContentType,
+			body:             pbBody(t, "measurement", map[string]string{"t1": "1"}, map[string]interface{}{"f1": 1, "f3.14": 3.14}, time.Unix(timestamp, 0)),
+			expectStatusCode: 200,
+			expectBody: &uhttp.BodyResp{
+				Content: []*sinkcommon.JSONPoint{
+					{
+						Measurement: "measurement",
+						Tags: map[string]string{
+							"t1": "1",
+						},
+						Fields: map[string]interface{}{
+							"f1": 1, "f3.14": 3.14,
+						},
+						Time: time.Unix(timestamp, 0).UTC(),
+					},
+				},
+			},
+		},
+		{
+			name:             `metric-invalid-protobuf-point`,
+			method:           "POST",
+			url:              "/v1/write/metric",
+			contentType:      pbpoint.PBContentType,
+			body:             []byte(`measurement,t1=1,t2=2 f1=1,f2=2`),
+			expectStatusCode: 400,
+			expectBody:       ErrInvalidPBPoint,
+		},
+
 		//--------------------------------------------
 		// object cases
 		//--------------------------------------------
@@ -22,6 +22,9 @@ var (
 	ErrInvalidPipeline = newErr(errors.New("invalid pipeline"), http.StatusBadRequest)
 	ErrInvalidData     = newErr(errors.New("invalid data"), http.StatusBadRequest)
 	ErrCompiledFailed  = newErr(errors.New("pipeline compile failed"), http.StatusBadRequest)
+	ErrInvalidFilter   = newErr(errors.New("invalid filter"), http.StatusBadRequest)
+
+	ErrReloadInputFailed = newErr(errors.New("reload input failed"), http.StatusBadRequest)
 
 	ErrInvalidPrecision       = newErr(errors.New("invalid precision"), http.StatusBadRequest)
 	ErrHTTPReadErr            = newErr(errors.New("HTTP read error"), http.StatusInternalServerError)
@@ -40,6 +43,7 @@ var (
 	// write body error.
 	ErrInvalidJSONPoint = newErr(errors.New("invalid json point"), http.StatusBadRequest)
 	ErrInvalidLinePoint = newErr(errors.New("invalid line point"), http.StatusBadRequest)
+	ErrInvalidPBPoint   = newErr(errors.New("invalid protobuf point"), http.StatusBadRequest)
 )
 
 func newErr(err error, code int) *uhttp.HttpError {
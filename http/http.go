@@ -33,6 +33,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/git"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/dataway"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -77,6 +78,10 @@ type Option struct {
 
 	PProf       bool
 	PProfListen string
+
+	// ReloadInputs hot reloads inputs of the config file, or all config files
+	// if the path is empty.
+	ReloadInputs func(path string) ([]*inputs.ReloadStat, error)
 }
 
 type APIConfig struct {
@@ -126,6 +131,7 @@ func Start(o *Option) {
 
 	dw = o.DataWay
 	dcaConfig = o.DCAConfig
+	reloadInputs = o.ReloadInputs
 
 	// start HTTP server
 	g.Go(func(ctx context.Context) error {
@@ -290,6 +296,8 @@ func setupRouter() *gin.Engine {
 
 	router.POST("/v1/pipeline/debug", rawHTTPWraper(reqLimiter, apiPipelineDebugHandler))
 	router.POST("/v1/dialtesting/debug", rawHTTPWraper(reqLimiter, apiDebugDialtestingHandler))
+	router.POST("/v1/filter", rawHTTPWraper(reqLimiter, apiFilterReload))
+	router.POST("/v1/input/reload", rawHTTPWraper(reqLimiter, apiInputReload))
 	return router
 }
 
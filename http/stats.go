@@ -25,6 +25,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/git"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/cgroup"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/election"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/filter"
@@ -111,6 +112,9 @@ type DatakitStats struct {
 	AutoUpdate   bool                `json:"auto_update"`
 	FilterStats  *filter.FilterStats `json:"filter_stats"`
 
+	AutoMultilineStats []*tailer.AutoMultilineStat `json:"auto_multiline_stats"`
+	InputReloadStats   []*inputs.ReloadStat        `json:"input_reload_stats"`
+
 	// markdown options
 	DisableMonofont bool `json:"-"`
 }
@@ -343,6 +347,12 @@ func GetStats() (*DatakitStats, error) {
 	l.Debugf("io.GetFilterStats()...")
 	stats.FilterStats = filter.GetFilterStats()
 
+	l.Debugf("tailer.GetAutoMultilineStats()...")
+	stats.AutoMultilineStats = tailer.GetAutoMultilineStats()
+
+	l.Debugf("inputs.GetReloadStats()...")
+	stats.InputReloadStats = inputs.GetReloadStats()
+
 	l.Debugf("OpenFiles()...")
 	stats.OpenFiles = datakit.OpenFiles()
 
@@ -479,6 +489,9 @@ type StatMetric struct {
 	PLStats        []plstats.ScriptStatsROnly `json:"pl_stats"`
 	HTTPMetrics    map[string]*APIStat        `json:"http_metrics"`
 	FilterStats    *filter.FilterStats        `json:"filter_stats"`
+
+	AutoMultilineStats []*tailer.AutoMultilineStat `json:"auto_multiline_stats"`
+	InputReloadStats   []*inputs.ReloadStat        `json:"input_reload_stats"`
 }
 
 // getStatInfo return stat info.
@@ -521,6 +534,8 @@ func getStatMetric() *StatMetric {
 		metricStat.PLStats = s.PLStats
 		metricStat.HTTPMetrics = s.HTTPMetrics
 		metricStat.FilterStats = s.FilterStats
+		metricStat.AutoMultilineStats = s.AutoMultilineStats
+		metricStat.InputReloadStats = s.InputReloadStats
 	}
 
 	return metricStat
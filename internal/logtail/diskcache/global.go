@@ -135,6 +135,7 @@ func HandleFeedIO(p *PBData) error {
 		res,
 		&iod.Option{
 			PlScript: map[string]string{cfg.Source: cfg.Pipeline},
+			PlChain:  cfg.PipelineChain,
 			PlOption: &script.Option{
 				DisableAddStatusField: cfg.DisableAddStatusField,
 				IgnoreStatus:          cfg.IgnoreStatus,
@@ -36,6 +36,7 @@ type PBConfig struct {
 	Blocking              bool     `protobuf:"varint,3,opt,name=blocking,proto3" json:"blocking,omitempty"`
 	DisableAddStatusField bool     `protobuf:"varint,4,opt,name=disable_add_status_field,json=disableAddStatusField,proto3" json:"disable_add_status_field,omitempty"`
 	IgnoreStatus          []string `protobuf:"bytes,5,rep,name=ignore_status,json=ignoreStatus,proto3" json:"ignore_status,omitempty"`
+	PipelineChain         []string `protobuf:"bytes,6,rep,name=pipeline_chain,json=pipelineChain,proto3" json:"pipeline_chain,omitempty"`
 }
 
 func (x *PBConfig) Reset() {
@@ -105,6 +106,13 @@ func (x *PBConfig) GetIgnoreStatus() []string {
 	return nil
 }
 
+func (x *PBConfig) GetPipelineChain() []string {
+	if x != nil {
+		return x.PipelineChain
+	}
+	return nil
+}
+
 // PBData
 type PBData struct {
 	state         protoimpl.MessageState
@@ -165,7 +173,7 @@ var File_pbdata_proto protoreflect.FileDescriptor
 
 var file_pbdata_proto_rawDesc = []byte{
 	0x0a, 0x0c, 0x70, 0x62, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09,
-	0x64, 0x69, 0x73, 0x6b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x22, 0xb8, 0x01, 0x0a, 0x08, 0x50, 0x42,
+	0x64, 0x69, 0x73, 0x6b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x22, 0xdf, 0x01, 0x0a, 0x08, 0x50, 0x42,
 	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1a,
 	0x0a, 0x08, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
@@ -177,13 +185,16 @@ var file_pbdata_proto_rawDesc = []byte{
 	0x65, 0x41, 0x64, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12,
 	0x23, 0x0a, 0x0d, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
 	0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x22, 0x4d, 0x0a, 0x06, 0x50, 0x42, 0x44, 0x61, 0x74, 0x61, 0x12, 0x16,
-	0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06,
-	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x64, 0x69, 0x73, 0x6b, 0x63, 0x61, 0x63,
-	0x68, 0x65, 0x2e, 0x50, 0x42, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e,
-	0x66, 0x69, 0x67, 0x42, 0x0e, 0x5a, 0x0c, 0x2e, 0x2f, 0x3b, 0x64, 0x69, 0x73, 0x6b, 0x63, 0x61,
-	0x63, 0x68, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65,
+	0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x69,
+	0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22, 0x4d, 0x0a, 0x06, 0x50,
+	0x42, 0x44, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a,
+	0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x64, 0x69, 0x73, 0x6b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x2e, 0x50, 0x42, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x52, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x42, 0x0e, 0x5a, 0x0c, 0x2e, 0x2f,
+	0x3b, 0x64, 0x69, 0x73, 0x6b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
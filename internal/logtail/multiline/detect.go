@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package multiline
+
+import (
+	"regexp"
+)
+
+const (
+	defaultDetectLines = 100
+
+	// DefaultPattern 是默认的行首规则，即行首非空白字符，探测不到任何模式时使用
+	DefaultPattern = `^\S`
+
+	// 最佳模式的匹配行数，至少要达到样本行数的这一比例（且不少于 minDetectMatches 行）才会被采用
+	// 堆栈等多行日志中，行首模式只匹配少部分行，所以这个比例不宜太大
+	minDetectRatio   = 0.05
+	minDetectMatches = 2
+)
+
+// DetectPatterns 是自动探测时的候选行首模式，按从严格到宽松的顺序排列，匹配行数相同时靠前者优先.
+var DetectPatterns = append([]string{
+	// [2021-07-08 05:08:19] or [2021-07-08T05:08:19Z]
+	`^\[\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`,
+	// syslog, "Jan  2 15:04:05"
+	`^[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2}`,
+	// glog, "I0708 05:08:19.214"
+	`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}`,
+	// level prefix, "ERROR ..." or "[WARN] ..."
+	`^\[?(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|CRITICAL|FATAL|PANIC)\]?[\s:|]`,
+	// JSON object start
+	`^\{`,
+}, GlobalPatterns...)
+
+// detector 统计样本行中各候选模式的匹配行数，样本足够后选出行首模式.
+type detector struct {
+	candidates []*regexp.Regexp
+	counts     []int
+	lines      int
+	maxLines   int
+}
+
+func newDetector(extraPatterns []string, maxLines int) (*detector, error) {
+	if maxLines <= 0 {
+		maxLines = defaultDetectLines
+	}
+
+	d := &detector{maxLines: maxLines}
+
+	// 用户配置的模式优先
+	for _, pattern := range DetectPatternsWith(extraPatterns) {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		d.candidates = append(d.candidates, r)
+	}
+	d.counts = make([]int, len(d.candidates))
+
+	return d, nil
+}
+
+// feed 统计一行文本，样本足够时返回探测结果.
+func (d *detector) feed(text []byte) (string, bool) {
+	// 空行不计入样本
+	if len(text) == 0 {
+		return "", false
+	}
+
+	d.lines++
+	for idx, r := range d.candidates {
+		if r.Match(text) {
+			d.counts[idx]++
+		}
+	}
+
+	if d.lines < d.maxLines {
+		return "", false
+	}
+
+	return d.result(), true
+}
+
+// result 返回匹配行数最多的候选模式，如果都达不到最小匹配行数，返回 DefaultPattern.
+func (d *detector) result() string {
+	best := -1
+	for idx, n := range d.counts {
+		if best == -1 || n > d.counts[best] {
+			best = idx
+		}
+	}
+
+	if best == -1 {
+		return DefaultPattern
+	}
+
+	if n := d.counts[best]; n < minDetectMatches || float64(n) < float64(d.lines)*minDetectRatio {
+		return DefaultPattern
+	}
+
+	return d.candidates[best].String()
+}
@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package multiline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func javaLogs(n int) []string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		lines = append(lines,
+			"2021-07-08 05:08:19,214 ERROR [main] demo.App - failed",
+			"java.lang.IllegalStateException: boom",
+			"\tat demo.App.run(App.java:10)",
+			"\tat demo.App.main(App.java:5)",
+			"Caused by: java.io.IOException: closed",
+			"\t... 2 more",
+		)
+	}
+	return lines
+}
+
+func TestDetector(t *testing.T) {
+	cases := []struct {
+		name  string
+		extra []string
+		in    []string
+		out   string
+	}{
+		{
+			name: "timestamp",
+			in:   javaLogs(5),
+			out:  `^\d+-\d+-\d+ \d+:\d+:\d+(,\d+)?`,
+		},
+		{
+			name: "level",
+			in: []string{
+				"[ERROR] request failed",
+				"Traceback (most recent call last):",
+				"  File \"app.py\", line 1, in <module>",
+				"ValueError: bad",
+				"INFO: done",
+				"",
+				"WARN| slow",
+			},
+			out: `^\[?(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERROR|CRITICAL|FATAL|PANIC)\]?[\s:|]`,
+		},
+		{
+			name: "json",
+			in: []string{
+				`{"level":"info",`,
+				`  "msg":"hello"}`,
+				`{"level":"error",`,
+				`  "msg":"world"}`,
+			},
+			out: `^\{`,
+		},
+		{
+			name: "bracket-timestamp",
+			in: []string{
+				"[2021-07-08 05:08:19] app.ERROR: failed",
+				"#0 /app/index.php(10): run()",
+				"[2021-07-08 05:08:20] app.INFO: ok",
+			},
+			out: `^\[\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`,
+		},
+		{
+			name:  "extra-pattern-preferred",
+			extra: []string{`^\d{4}-\d{2}-\d{2}`},
+			in:    javaLogs(5),
+			out:   `^\d{4}-\d{2}-\d{2}`,
+		},
+		{
+			name: "no-pattern",
+			in:   []string{"hello", "  world", "foo", "bar"},
+			out:  DefaultPattern,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := newDetector(tc.extra, len(tc.in))
+			require.NoError(t, err)
+
+			var (
+				res string
+				ok  bool
+			)
+			for _, line := range tc.in {
+				res, ok = d.feed([]byte(line))
+			}
+			// the empty line is not sampled
+			if !ok {
+				res = d.result()
+			}
+			assert.Equal(t, tc.out, res)
+		})
+	}
+}
+
+func TestAutoDetect(t *testing.T) {
+	t.Run("detect-and-lock", func(t *testing.T) {
+		m, err := New(nil, &Option{AutoDetect: true, AutoDetectLines: 12})
+		require.NoError(t, err)
+
+		var out []string
+		for i, line := range javaLogs(4) {
+			if res := m.ProcessLineString(line); res != "" {
+				out = append(out, res)
+			}
+
+			detecting, n := m.Detecting()
+			_, detected := m.DetectedPattern()
+			if i < 11 {
+				assert.True(t, detecting)
+				assert.Equal(t, i+1, n)
+				assert.False(t, detected)
+			} else {
+				assert.False(t, detecting)
+				assert.True(t, detected)
+			}
+		}
+		out = append(out, m.FlushString())
+
+		pattern, ok := m.DetectedPattern()
+		assert.True(t, ok)
+		assert.Equal(t, `^\d+-\d+-\d+ \d+:\d+:\d+(,\d+)?`, pattern)
+
+		// the candidates are matched while detecting
+		require.Len(t, out, 4)
+		for _, res := range out {
+			assert.Equal(t, strings.Join(javaLogs(1), "\n"), res)
+		}
+	})
+
+	t.Run("detected-pattern", func(t *testing.T) {
+		m, err := New(nil, &Option{AutoDetect: true, DetectedPattern: `^\d{4}`})
+		require.NoError(t, err)
+
+		detecting, _ := m.Detecting()
+		assert.False(t, detecting)
+
+		pattern, ok := m.DetectedPattern()
+		assert.True(t, ok)
+		assert.Equal(t, `^\d{4}`, pattern)
+
+		assert.Equal(t, "", m.ProcessLineString("2021-07-08 line1"))
+		assert.Equal(t, "", m.ProcessLineString("Caused by: xxx"))
+		assert.Equal(t, "2021-07-08 line1\nCaused by: xxx", m.ProcessLineString("2021-07-08 line2"))
+	})
+
+	t.Run("invalid-extra-pattern", func(t *testing.T) {
+		_, err := New([]string{"(("}, &Option{AutoDetect: true})
+		assert.Error(t, err)
+	})
+}
@@ -26,6 +26,14 @@ type Option struct {
 	// 限制一段多行数据的最大存在时长，即从第一条匹配成功开始到现在，超出限制会执行 flush
 	// 避免出现一条匹配成功，N 条匹配失败然后追加写入到 buff，导致数据全部堆积的情况
 	MaxLifeDuration time.Duration
+
+	// 自动探测行首模式：从前 AutoDetectLines 行（不含空行）中学习行首模式，之后固定使用该模式
+	// 探测期间使用 patterns 和 DetectPatterns 进行匹配，patterns 作为候选模式优先于 DetectPatterns
+	AutoDetect      bool
+	AutoDetectLines int
+
+	// 已探测到的行首模式（例如从 register 中恢复），不为空时直接使用，不再探测
+	DetectedPattern string
 }
 
 func initOption(opt *Option) *Option {
@@ -53,22 +61,95 @@ type Multiline struct {
 
 	// 记录最后一次匹配成功并写入到 buff 的时间
 	lastWriteTime time.Time
+
+	// 自动探测中的 detector，探测完成后置为 nil
+	detector *detector
+	detected string
 }
 
 func New(patterns []string, opt *Option) (*Multiline, error) {
+	opt = initOption(opt)
+
+	if opt.AutoDetect {
+		return newAutoDetect(patterns, opt)
+	}
+
 	match, err := NewMatcher(patterns)
 	if err != nil {
 		return nil, err
 	}
 
-	opt = initOption(opt)
-
 	return &Multiline{
 		Matcher: match,
 		opt:     opt,
 	}, err
 }
 
+func newAutoDetect(patterns []string, opt *Option) (*Multiline, error) {
+	if opt.DetectedPattern != "" {
+		match, err := NewMatcher([]string{opt.DetectedPattern})
+		if err != nil {
+			return nil, err
+		}
+		return &Multiline{Matcher: match, opt: opt, detected: opt.DetectedPattern}, nil
+	}
+
+	d, err := newDetector(patterns, opt.AutoDetectLines)
+	if err != nil {
+		return nil, err
+	}
+
+	// 探测期间使用全部候选模式匹配
+	match, err := NewMatcher(DetectPatternsWith(patterns))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Multiline{Matcher: match, opt: opt, detector: d}, nil
+}
+
+// DetectPatternsWith 返回 patterns 与 DetectPatterns 去重合并后的结果，patterns 在前.
+func DetectPatternsWith(patterns []string) []string {
+	var res []string
+	exists := map[string]bool{}
+	for _, pattern := range append(append([]string{}, patterns...), DetectPatterns...) {
+		if !exists[pattern] {
+			exists[pattern] = true
+			res = append(res, pattern)
+		}
+	}
+	return res
+}
+
+// DetectedPattern 返回自动探测到的行首模式，探测未完成或未开启自动探测时返回 false.
+func (m *Multiline) DetectedPattern() (string, bool) {
+	return m.detected, m.detected != ""
+}
+
+// Detecting 返回是否正在探测行首模式，以及已统计的样本行数.
+func (m *Multiline) Detecting() (bool, int) {
+	if m.detector == nil {
+		return false, 0
+	}
+	return true, m.detector.lines
+}
+
+func (m *Multiline) detect(text []byte) {
+	pattern, ok := m.detector.feed(text)
+	if !ok {
+		return
+	}
+
+	match, err := NewMatcher([]string{pattern})
+	if err != nil { // unreachable, the candidates have been compiled
+		return
+	}
+
+	m.Matcher = match
+	m.detected = pattern
+	m.detector = nil
+}
+
 func (m *Multiline) ProcessLineString(text string) string {
 	textBytes := []byte(text)
 	return string(m.ProcessLine(textBytes))
@@ -77,6 +158,10 @@ func (m *Multiline) ProcessLineString(text string) string {
 var newLine = []byte{'\n'}
 
 func (m *Multiline) ProcessLine(text []byte) []byte {
+	if m.detector != nil {
+		m.detect(text)
+	}
+
 	// --匹配成功--
 	// 清空 buff 并写入新的文本，符合多行行为。记录当前时间。
 	if m.Match(text) {
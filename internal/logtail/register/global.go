@@ -51,3 +51,13 @@ func Get(key string) *MetaData {
 	}
 	return globalRegister.Get(key)
 }
+
+func GetByFingerprint(fingerprint string) *MetaData {
+	if assertTesting {
+		return nil
+	}
+	if globalRegister == nil || fingerprint == "" {
+		return nil
+	}
+	return globalRegister.GetByFingerprint(fingerprint)
+}
@@ -21,15 +21,25 @@ const defaultFlushFactor = 2
 type MetaData struct {
 	Source string `json:"source"`
 	Offset int64  `json:"offset"`
+	// Fingerprint identifies the file content, used to find the position of
+	// the file after it's rotated and compressed.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Finished is true if the compressed file has been read completely.
+	Finished bool `json:"finished,omitempty"`
+	// MultilinePattern is the line-start pattern detected automatically,
+	// it's used directly when the file with the same fingerprint is collected again.
+	MultilinePattern string `json:"multiline_pattern,omitempty"`
 }
 
 func (m *MetaData) String() string {
-	return fmt.Sprintf("source: %s, offset: %d", m.Source, m.Offset)
+	return fmt.Sprintf("source: %s, offset: %d, fingerprint: %s, finished: %v, multiline_pattern: %s",
+		m.Source, m.Offset, m.Fingerprint, m.Finished, m.MultilinePattern)
 }
 
 type Register interface {
 	Set(string, *MetaData) error
 	Get(string) *MetaData
+	GetByFingerprint(string) *MetaData
 }
 
 type register struct {
@@ -130,6 +140,25 @@ func (r *register) Get(key string) *MetaData {
 	return v
 }
 
+// GetByFingerprint returns the metadata with max offset of the fingerprint,
+// nil for empty fingerprint.
+func (r *register) GetByFingerprint(fingerprint string) *MetaData {
+	if fingerprint == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var res *MetaData
+	for _, v := range r.Data {
+		if v.Fingerprint == fingerprint && (res == nil || v.Offset > res.Offset) {
+			res = v
+		}
+	}
+	return res
+}
+
 func parse(b []byte) (*register, error) {
 	r := register{}
 	if len(b) != 0 {
@@ -188,3 +188,20 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestGetByFingerprint(t *testing.T) {
+	r := &register{
+		Data: map[string]*MetaData{
+			"a.log::1": {Source: "a", Offset: 100, Fingerprint: "fp1"},
+			"a.log::2": {Source: "a", Offset: 300, Fingerprint: "fp1"},
+			"b.log::3": {Source: "b", Offset: 200, Fingerprint: "fp2"},
+			"c.log::4": {Source: "c", Offset: 400},
+		},
+		flushFactor: 2,
+	}
+
+	assert.Equal(t, int64(300), r.GetByFingerprint("fp1").Offset)
+	assert.Equal(t, int64(200), r.GetByFingerprint("fp2").Offset)
+	assert.Nil(t, r.GetByFingerprint("fp3"))
+	assert.Nil(t, r.GetByFingerprint(""))
+}
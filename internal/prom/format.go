@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+// Scrape protocols used in content negotiation.
+const (
+	ProtocolProtobuf    = "protobuf"
+	ProtocolOpenMetrics = "openmetrics"
+	ProtocolText        = "text"
+)
+
+var protocolAccepts = map[string]string{
+	ProtocolProtobuf:    expfmt.ProtoFmt + "encoding=delimited",
+	ProtocolOpenMetrics: expfmt.OpenMetricsType + ";version=1.0.0",
+	ProtocolText:        "text/plain;version=" + expfmt.TextVersion,
+}
+
+// checkScrapeProtocols checks the protocols and normalizes them to lower case.
+func checkScrapeProtocols(protocols []string) error {
+	seen := map[string]bool{}
+	for i, proto := range protocols {
+		proto = strings.ToLower(strings.TrimSpace(proto))
+		if _, ok := protocolAccepts[proto]; !ok {
+			return fmt.Errorf("unknown scrape protocol %q, expect one of protobuf, openmetrics and text", protocols[i])
+		}
+		if seen[proto] {
+			return fmt.Errorf("duplicated scrape protocol %q", protocols[i])
+		}
+		seen[proto] = true
+		protocols[i] = proto
+	}
+	return nil
+}
+
+// acceptHeader builds the Accept header of the protocols, the former ones are
+// preferred. Empty is returned if no protocol configured.
+func acceptHeader(protocols []string) string {
+	if len(protocols) == 0 {
+		return ""
+	}
+
+	var arr []string
+	weight := len(protocols) + 1
+	for i, proto := range protocols {
+		arr = append(arr, fmt.Sprintf("%s;q=0.%d", protocolAccepts[proto], weight-i))
+	}
+	arr = append(arr, "*/*;q=0.1")
+	return strings.Join(arr, ",")
+}
+
+// body2Metrics converts the scraped body to points according to its content type:
+// delimited protobuf, OpenMetrics text or else Prometheus text format. The body
+// is always parsed as Prometheus text format if no protocol configured.
+func (p *Prom) body2Metrics(in io.Reader, contentType, u string) ([]*point.Point, error) {
+	if len(p.opt.ScrapeProtocols) == 0 {
+		return p.text2Metrics(in, u)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return p.text2Metrics(in, u)
+	}
+
+	switch mediaType {
+	case expfmt.ProtoType:
+		if params["proto"] != expfmt.ProtoProtocol || params["encoding"] != "delimited" {
+			return nil, fmt.Errorf("unsupported protobuf content type %q", contentType)
+		}
+		return p.protobuf2Metrics(in, u)
+
+	case expfmt.OpenMetricsType:
+		return p.openMetrics2Metrics(in, u)
+
+	default:
+		return p.text2Metrics(in, u)
+	}
+}
+
+// protobuf2Metrics converts delimited protobuf metric families to points.
+func (p *Prom) protobuf2Metrics(in io.Reader, u string) ([]*point.Point, error) {
+	metricFamilies := map[string]*dto.MetricFamily{}
+
+	dec := expfmt.NewDecoder(in, expfmt.FmtProtoDelim)
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode protobuf: %w", err)
+		}
+
+		if exist, ok := metricFamilies[mf.GetName()]; ok {
+			exist.Metric = append(exist.Metric, mf.Metric...)
+		} else {
+			metricFamilies[mf.GetName()] = mf
+		}
+	}
+
+	return p.families2Points(p.filterMetricFamilies(metricFamilies), u)
+}
+
+// openMetrics2Metrics converts OpenMetrics text to points.
+func (p *Prom) openMetrics2Metrics(in io.Reader, u string) ([]*point.Point, error) {
+	metricFamilies, err := parseOpenMetrics(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.families2Points(p.filterMetricFamilies(metricFamilies), u)
+}
+
+// file2Metrics converts the metric file to points, the file is treated as
+// OpenMetrics text if it ends with "# EOF".
+func (p *Prom) file2Metrics(in io.Reader) ([]*point.Point, error) {
+	if len(p.opt.ScrapeProtocols) == 0 {
+		return p.text2Metrics(in, "")
+	}
+
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasSuffix(bytes.TrimSpace(data), []byte(omEOF)) {
+		return p.openMetrics2Metrics(bytes.NewReader(data), "")
+	}
+	return p.text2Metrics(bytes.NewReader(data), "")
+}
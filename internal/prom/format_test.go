@@ -0,0 +1,334 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+// pointLines returns the points in line protocol without timestamp, sorted.
+func pointLines(pts []*point.Point) []string {
+	var arr []string
+	for _, pt := range pts {
+		s := pt.String()
+		arr = append(arr, s[:strings.LastIndex(s, " ")])
+	}
+	sort.Strings(arr)
+	return arr
+}
+
+func TestScrapeProtocols(t *testing.T) {
+	protocols := []string{"Protobuf", " openmetrics", "text"}
+	require.NoError(t, checkScrapeProtocols(protocols))
+	assert.Equal(t, []string{"protobuf", "openmetrics", "text"}, protocols)
+
+	assert.Equal(t, "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.4,"+
+		"application/openmetrics-text;version=1.0.0;q=0.3,"+
+		"text/plain;version=0.0.4;q=0.2,"+
+		"*/*;q=0.1", acceptHeader(protocols))
+	assert.Equal(t, "", acceptHeader(nil))
+
+	assert.Error(t, checkScrapeProtocols([]string{"json"}))
+	assert.Error(t, checkScrapeProtocols([]string{"text", "Text"}))
+
+	p, err := NewProm(&Option{URL: promURL, ScrapeProtocols: []string{"openmetrics"}})
+	require.NoError(t, err)
+	req, err := p.GetReq(promURL)
+	require.NoError(t, err)
+	assert.Equal(t, "application/openmetrics-text;version=1.0.0;q=0.2,*/*;q=0.1", req.Header.Get("Accept"))
+
+	// overwritten by http_headers
+	p, err = NewProm(&Option{
+		URL:             promURL,
+		ScrapeProtocols: []string{"openmetrics"},
+		HTTPHeaders:     map[string]string{"Accept": "text/plain"},
+	})
+	require.NoError(t, err)
+	req, err = p.GetReq(promURL)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", req.Header.Get("Accept"))
+
+	_, err = NewProm(&Option{URL: promURL, ScrapeProtocols: []string{"json"}})
+	assert.Error(t, err)
+}
+
+func TestNativeHistogramBuckets(t *testing.T) {
+	t.Run("integer", func(t *testing.T) {
+		h := &dto.Histogram{
+			SampleCount:   proto.Uint64(7),
+			SampleSum:     proto.Float64(10),
+			Schema:        proto.Int32(0),
+			ZeroThreshold: proto.Float64(0.001),
+			ZeroCount:     proto.Uint64(1),
+			NegativeSpan:  []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}},
+			NegativeDelta: []int64{1},
+			PositiveSpan: []*dto.BucketSpan{
+				{Offset: proto.Int32(0), Length: proto.Uint32(2)},
+				{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+			},
+			PositiveDelta: []int64{2, -1, 1},
+		}
+		require.True(t, isNativeHistogram(h))
+
+		assert.Equal(t, []*cumulativeBucket{
+			{upperBound: -1, count: uint64(1)},
+			{upperBound: 0.001, count: uint64(2)},
+			{upperBound: 1, count: uint64(4)},
+			{upperBound: 2, count: uint64(5)},
+			{upperBound: 8, count: uint64(7)},
+			{upperBound: math.Inf(1), count: uint64(7)},
+		}, nativeHistogramBuckets(h))
+	})
+
+	t.Run("float", func(t *testing.T) {
+		h := &dto.Histogram{
+			SampleCountFloat: proto.Float64(4.5),
+			Schema:           proto.Int32(1),
+			ZeroCountFloat:   proto.Float64(0.5),
+			PositiveSpan:     []*dto.BucketSpan{{Offset: proto.Int32(2), Length: proto.Uint32(2)}},
+			PositiveCount:    []float64{1.5, 2.5},
+		}
+
+		assert.Equal(t, []*cumulativeBucket{
+			{upperBound: 0, count: 0.5},
+			{upperBound: 2, count: 2.0},
+			{upperBound: math.Exp2(1.5), count: 4.5},
+			{upperBound: math.Inf(1), count: 4.5},
+		}, nativeHistogramBuckets(h))
+	})
+
+	t.Run("classic", func(t *testing.T) {
+		assert.False(t, isNativeHistogram(&dto.Histogram{
+			Schema: proto.Int32(0),
+			Bucket: []*dto.Bucket{{UpperBound: proto.Float64(1), CumulativeCount: proto.Uint64(1)}},
+		}))
+	})
+}
+
+func TestCollectProtobuf(t *testing.T) {
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("http_requests_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label: []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("200")}},
+				Counter: &dto.Counter{
+					Value: proto.Float64(3),
+					Exemplar: &dto.Exemplar{
+						Label: []*dto.LabelPair{{Name: proto.String("trace_id"), Value: proto.String("4bf92f3577b34da6")}},
+						Value: proto.Float64(1),
+					},
+				},
+			}},
+		},
+		{
+			Name: proto.String("rpc_duration_seconds"),
+			Type: dto.MetricType_HISTOGRAM.Enum(),
+			Metric: []*dto.Metric{{
+				Histogram: &dto.Histogram{
+					SampleCount:   proto.Uint64(3),
+					SampleSum:     proto.Float64(2.5),
+					Schema:        proto.Int32(0),
+					ZeroThreshold: proto.Float64(0.001),
+					ZeroCount:     proto.Uint64(1),
+					PositiveSpan:  []*dto.BucketSpan{{Offset: proto.Int32(1), Length: proto.Uint32(1)}},
+					PositiveDelta: []int64{2},
+				},
+			}},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Accept"), expfmt.ProtoType) {
+			w.Header().Set("Content-Type", string(expfmt.FmtText))
+			_, _ = w.Write([]byte("# TYPE http_requests_total counter\nhttp_requests_total{code=\"200\"} 3\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", string(expfmt.FmtProtoDelim))
+		enc := expfmt.NewEncoder(w, expfmt.FmtProtoDelim)
+		for _, mf := range families {
+			assert.NoError(t, enc.Encode(mf))
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewProm(&Option{URL: srv.URL, ScrapeProtocols: []string{"protobuf", "text"}})
+	require.NoError(t, err)
+
+	pts, err := p.CollectFromHTTP(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`http,code=200 requests_total=3,requests_total_exemplar=1,requests_total_exemplar_trace_id="4bf92f3577b34da6"`,
+		`rpc duration_seconds_count=3,duration_seconds_sum=2.5`,
+		`rpc,le=+Inf duration_seconds_bucket=3i`,
+		`rpc,le=0.001 duration_seconds_bucket=1i`,
+		`rpc,le=2 duration_seconds_bucket=3i`,
+	}, pointLines(pts))
+
+	// text is used without negotiation
+	p, err = NewProm(&Option{URL: srv.URL})
+	require.NoError(t, err)
+
+	pts, err = p.CollectFromHTTP(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`http,code=200 requests_total=3`}, pointLines(pts))
+}
+
+const openMetricsText = `# TYPE http_requests counter
+# HELP http_requests Total requests.
+http_requests_total{code="200",path="/a\"b"} 3 # {trace_id="4bf92f3577b34da6",span_id="00f067aa0ba902b7"} 1 1520879607.789
+http_requests_created{code="200",path="/a\"b"} 1520879600
+# TYPE rpc_duration_seconds histogram
+# UNIT rpc_duration_seconds seconds
+rpc_duration_seconds_bucket{le="0.1"} 1 # {trace_id="a"} 0.05
+rpc_duration_seconds_bucket{le="+Inf"} 2
+rpc_duration_seconds_count 2
+rpc_duration_seconds_sum 0.3
+# TYPE queue_size gaugehistogram
+queue_size_bucket{le="10"} 4
+queue_size_bucket{le="+Inf"} 5
+queue_size_gcount 5
+queue_size_gsum 20
+# TYPE rpc_latency summary
+rpc_latency{quantile="0.5"} 0.2
+rpc_latency_count 10
+rpc_latency_sum 3
+# TYPE build info
+build_info{version="1.0"} 1
+# TYPE feature stateset
+feature{feature="a"} 1
+feature{feature="b"} 0
+# TYPE mem_free gauge
+mem_free 1024 1520879607.789
+node_up 1
+# EOF
+`
+
+func TestParseOpenMetrics(t *testing.T) {
+	families, err := parseOpenMetrics(strings.NewReader(openMetricsText))
+	require.NoError(t, err)
+
+	var names []string
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{
+		"build_info", "feature", "http_requests_total", "mem_free", "node_up",
+		"queue_size", "rpc_duration_seconds", "rpc_latency",
+	}, names)
+
+	counter := families["http_requests_total"]
+	assert.Equal(t, dto.MetricType_COUNTER, counter.GetType())
+	assert.Equal(t, "Total requests.", counter.GetHelp())
+	require.Len(t, counter.GetMetric(), 1)
+	assert.Equal(t, `/a"b`, counter.GetMetric()[0].GetLabel()[1].GetValue())
+	e := counter.GetMetric()[0].GetCounter().GetExemplar()
+	require.NotNil(t, e)
+	assert.Equal(t, 1.0, e.GetValue())
+	assert.Equal(t, int64(1520879607), e.GetTimestamp().GetSeconds())
+	assert.Len(t, e.GetLabel(), 2)
+
+	assert.Equal(t, dto.MetricType_GAUGE_HISTOGRAM, families["queue_size"].GetType())
+	assert.Equal(t, dto.MetricType_GAUGE, families["build_info"].GetType())
+	assert.Equal(t, dto.MetricType_UNTYPED, families["node_up"].GetType())
+	assert.Len(t, families["feature"].GetMetric(), 2)
+	assert.Equal(t, int64(1520879607789), families["mem_free"].GetMetric()[0].GetTimestampMs())
+
+	summary := families["rpc_latency"].GetMetric()[0].GetSummary()
+	assert.Equal(t, uint64(10), summary.GetSampleCount())
+	assert.Len(t, summary.GetQuantile(), 1)
+
+	for _, text := range []string{
+		"# TYPE a foo\n",
+		"a{b=\"c} 1\n",
+		"a{b=c} 1\n",
+		"a 1 2 3\n",
+		"a x\n",
+		"# TYPE a counter\na_total 1 # trace_id=\"x\" 1\n",
+		"# TYPE a gauge\na_bucket 1\n# TYPE a_bucket gauge\na_bucket 2\na 1\n",
+	} {
+		_, err := parseOpenMetrics(strings.NewReader(text))
+		assert.Error(t, err, text)
+	}
+}
+
+func TestCollectOpenMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		_, _ = w.Write([]byte(openMetricsText))
+	}))
+	defer srv.Close()
+
+	p, err := NewProm(&Option{
+		URL:              srv.URL,
+		ScrapeProtocols:  []string{"openmetrics"},
+		MetricNameFilter: []string{"http_.*", "rpc_.*", "queue_.*"},
+	})
+	require.NoError(t, err)
+
+	pts, err := p.CollectFromHTTP(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`http,code=200,path=/a"b requests_total=3,requests_total_exemplar=1,requests_total_exemplar_span_id="00f067aa0ba902b7",requests_total_exemplar_trace_id="4bf92f3577b34da6"`,
+		`queue size_gcount=5,size_gsum=20`,
+		`queue,le=+Inf size_bucket=5i`,
+		`queue,le=10 size_bucket=4i`,
+		`rpc duration_seconds_count=2,duration_seconds_sum=0.3`,
+		`rpc latency_count=10,latency_sum=3`,
+		`rpc,le=+Inf duration_seconds_bucket=2i`,
+		`rpc,le=0.1 duration_seconds_bucket=1i,duration_seconds_bucket_exemplar=0.05,duration_seconds_bucket_exemplar_trace_id="a"`,
+		`rpc,quantile=0.5 latency=0.2`,
+	}, pointLines(pts))
+
+	// collected from file
+	f := filepath.Join(t.TempDir(), "metrics")
+	require.NoError(t, os.WriteFile(f, []byte(openMetricsText), 0o600))
+	pts, err = p.CollectFromFile(f)
+	require.NoError(t, err)
+	assert.Len(t, pts, 9)
+
+	// parsed as text without protocols configured, even if the content type is
+	// OpenMetrics, the exemplars are invalid in text format
+	p, err = NewProm(&Option{URL: srv.URL, MetricNameFilter: []string{"http_.*"}})
+	require.NoError(t, err)
+	_, err = p.CollectFromHTTP(srv.URL)
+	assert.ErrorContains(t, err, "text format parsing error")
+
+	_, err = p.CollectFromFile(f)
+	assert.ErrorContains(t, err, "text format parsing error")
+
+	// exemplars are kept when fed as logging
+	p, err = NewProm(&Option{
+		URL:              srv.URL,
+		ScrapeProtocols:  []string{"openmetrics"},
+		MetricNameFilter: []string{"http_.*"},
+		AsLogging:        &AsLogging{Enable: true},
+	})
+	require.NoError(t, err)
+	pts, err = p.body2Metrics(bytes.NewBufferString(openMetricsText), expfmt.OpenMetricsType, "")
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+	fields, err := pts[0].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6", fields["requests_total_exemplar_trace_id"])
+}
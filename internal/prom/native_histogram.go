@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"math"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// cumulativeBucket is the bucket of upper bound le, count is uint64 or
+// float64 for float histograms.
+type cumulativeBucket struct {
+	upperBound float64
+	count      interface{}
+}
+
+type nativeBucket struct {
+	index int32
+	count float64
+}
+
+// isNativeHistogram returns true if the histogram is a native (sparse) one
+// without classic buckets.
+func isNativeHistogram(h *dto.Histogram) bool {
+	return len(h.GetBucket()) == 0 &&
+		(h.Schema != nil || len(h.GetPositiveSpan()) > 0 || len(h.GetNegativeSpan()) > 0)
+}
+
+func isFloatHistogram(h *dto.Histogram) bool {
+	return h.GetSampleCountFloat() > 0 || len(h.GetPositiveCount()) > 0 || len(h.GetNegativeCount()) > 0
+}
+
+// nativeHistogramBuckets converts the native histogram to cumulative buckets
+// ordered by upper bound. With base = 2^(2^-schema), the buckets are:
+//   - negative bucket of index i: le = -base^(i-1)
+//   - zero bucket: le = zero_threshold
+//   - positive bucket of index i: le = base^i
+//   - le = +Inf: the sample count
+func nativeHistogramBuckets(h *dto.Histogram) []*cumulativeBucket {
+	isFloat := isFloatHistogram(h)
+	schema := h.GetSchema()
+
+	var (
+		res []*cumulativeBucket
+		cum float64
+	)
+
+	add := func(upperBound, count float64) {
+		cum += count
+		b := &cumulativeBucket{upperBound: upperBound}
+		if isFloat {
+			b.count = cum
+		} else {
+			b.count = uint64(cum)
+		}
+		res = append(res, b)
+	}
+
+	negative := expandNativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta(), h.GetNegativeCount(), isFloat)
+	for i := len(negative) - 1; i >= 0; i-- {
+		add(-nativeBucketBound(schema, negative[i].index-1), negative[i].count)
+	}
+
+	if isFloat {
+		add(h.GetZeroThreshold(), h.GetZeroCountFloat())
+	} else {
+		add(h.GetZeroThreshold(), float64(h.GetZeroCount()))
+	}
+
+	for _, b := range expandNativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta(), h.GetPositiveCount(), isFloat) {
+		add(nativeBucketBound(schema, b.index), b.count)
+	}
+
+	infBucket := &cumulativeBucket{upperBound: math.Inf(1)}
+	if isFloat {
+		infBucket.count = h.GetSampleCountFloat()
+	} else {
+		infBucket.count = h.GetSampleCount()
+	}
+	return append(res, infBucket)
+}
+
+// nativeBucketBound returns base^index, which is 2^(index*2^-schema).
+func nativeBucketBound(schema, index int32) float64 {
+	return math.Exp2(float64(index) * math.Exp2(-float64(schema)))
+}
+
+// expandNativeBuckets expands the spans to buckets with absolute counts, the
+// counts of integer histograms are delta encoded.
+func expandNativeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64, isFloat bool) []*nativeBucket {
+	var (
+		res   []*nativeBucket
+		index int32
+		k     int
+		cur   int64
+	)
+
+	for _, span := range spans {
+		index += span.GetOffset()
+		for j := uint32(0); j < span.GetLength(); j++ {
+			b := &nativeBucket{index: index}
+			if isFloat {
+				if k < len(counts) {
+					b.count = counts[k]
+				}
+			} else if k < len(deltas) {
+				cur += deltas[k]
+				b.count = float64(cur)
+			}
+
+			res = append(res, b)
+			index++
+			k++
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,487 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+const omEOF = "# EOF"
+
+// OpenMetrics metric types, see
+// https://github.com/OpenObservability/OpenMetrics/blob/main/specification/OpenMetrics.md
+const (
+	omCounter        = "counter"
+	omGauge          = "gauge"
+	omHistogram      = "histogram"
+	omGaugeHistogram = "gaugehistogram"
+	omSummary        = "summary"
+	omInfo           = "info"
+	omStateSet       = "stateset"
+	omUnknown        = "unknown"
+)
+
+type omFamily struct {
+	name    string // name in the TYPE line
+	typ     string
+	mf      *dto.MetricFamily
+	metrics map[string]*dto.Metric // keyed by the label signature
+}
+
+type omSample struct {
+	name     string
+	labels   []*dto.LabelPair
+	value    float64
+	tsMs     *int64
+	exemplar *dto.Exemplar
+}
+
+// omParser parses the OpenMetrics text into metric families, which are
+// compatible with the Prometheus text format:
+//   - counter family is named with suffix _total, and info family with _info.
+//   - stateset and info are gauges.
+//   - gaugehistogram is GAUGE_HISTOGRAM with _gcount and _gsum as the count and sum.
+//   - _created samples are ignored.
+type omParser struct {
+	families map[string]*dto.MetricFamily
+	byName   map[string]*omFamily
+	cur      *omFamily
+}
+
+func parseOpenMetrics(in io.Reader) (map[string]*dto.MetricFamily, error) {
+	p := &omParser{
+		families: map[string]*dto.MetricFamily{},
+		byName:   map[string]*omFamily{},
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if line == omEOF {
+			break
+		}
+
+		var err error
+		if strings.HasPrefix(line, "#") {
+			err = p.parseMetadata(line)
+		} else {
+			err = p.parseSample(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("openmetrics line %d: %w", lineNum, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return p.families, nil
+}
+
+func (p *omParser) parseMetadata(line string) error {
+	arr := strings.SplitN(line, " ", 4)
+	if len(arr) < 3 || arr[0] != "#" {
+		return nil // comment
+	}
+
+	keyword, name := arr[1], arr[2]
+	if keyword != "TYPE" && keyword != "HELP" && keyword != "UNIT" {
+		return nil
+	}
+
+	f := p.family(name)
+	switch keyword {
+	case "TYPE":
+		if len(arr) < 4 {
+			return fmt.Errorf("missing type of %s", name)
+		}
+		if len(f.metrics) > 0 {
+			return fmt.Errorf("TYPE of %s after its samples", name)
+		}
+		if err := f.setType(arr[3]); err != nil {
+			return err
+		}
+
+	case "HELP":
+		if len(arr) == 4 {
+			f.mf.Help = proto.String(unescapeOMHelp(arr[3]))
+		}
+	}
+
+	p.cur = f
+	return nil
+}
+
+// family returns the family of the metadata name, it's created if not exist.
+func (p *omParser) family(name string) *omFamily {
+	if f, ok := p.byName[name]; ok {
+		return f
+	}
+
+	f := &omFamily{name: name, typ: omUnknown, metrics: map[string]*dto.Metric{}}
+	f.mf = &dto.MetricFamily{Name: proto.String(name), Type: dto.MetricType_UNTYPED.Enum()}
+	p.byName[name] = f
+	return f
+}
+
+func (f *omFamily) setType(typ string) error {
+	f.typ = typ
+	switch typ {
+	case omCounter:
+		f.mf.Type = dto.MetricType_COUNTER.Enum()
+		f.mf.Name = proto.String(strings.TrimSuffix(f.name, "_total") + "_total")
+	case omGauge, omStateSet:
+		f.mf.Type = dto.MetricType_GAUGE.Enum()
+	case omInfo:
+		f.mf.Type = dto.MetricType_GAUGE.Enum()
+		f.mf.Name = proto.String(f.name + "_info")
+	case omHistogram:
+		f.mf.Type = dto.MetricType_HISTOGRAM.Enum()
+	case omGaugeHistogram:
+		f.mf.Type = dto.MetricType_GAUGE_HISTOGRAM.Enum()
+	case omSummary:
+		f.mf.Type = dto.MetricType_SUMMARY.Enum()
+	case omUnknown:
+		f.mf.Type = dto.MetricType_UNTYPED.Enum()
+	default:
+		return fmt.Errorf("unknown metric type %q", typ)
+	}
+	return nil
+}
+
+// suffix returns the suffix of the sample name in the family, false if the
+// sample does not belong to the family.
+func (f *omFamily) suffix(sampleName string) (string, bool) {
+	name := f.name
+	if f.typ == omCounter {
+		name = strings.TrimSuffix(name, "_total")
+	}
+
+	if !strings.HasPrefix(sampleName, name) {
+		return "", false
+	}
+	suffix := sampleName[len(name):]
+
+	var valid []string
+	switch f.typ {
+	case omCounter:
+		valid = []string{"_total", "_created"}
+	case omInfo:
+		valid = []string{"_info"}
+	case omHistogram:
+		valid = []string{"_bucket", "_count", "_sum", "_created"}
+	case omGaugeHistogram:
+		valid = []string{"_bucket", "_gcount", "_gsum"}
+	case omSummary:
+		valid = []string{"", "_count", "_sum", "_created"}
+	default:
+		valid = []string{""}
+	}
+
+	for _, s := range valid {
+		if suffix == s {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+func (p *omParser) parseSample(line string) error {
+	s, err := parseOMSample(line)
+	if err != nil {
+		return err
+	}
+
+	f := p.cur
+	suffix, ok := "", false
+	if f != nil {
+		suffix, ok = f.suffix(s.name)
+	}
+	if !ok {
+		// samples without metadata are unknown
+		f = p.family(s.name)
+		if f.typ != omUnknown {
+			return fmt.Errorf("unexpected sample %s", s.name)
+		}
+		p.cur = f
+	}
+
+	if suffix == "_created" {
+		return nil
+	}
+
+	p.families[f.mf.GetName()] = f.mf
+	return f.add(suffix, s)
+}
+
+// add adds the sample to the metric of the same labels, le and quantile are
+// excluded for histograms and summaries.
+func (f *omFamily) add(suffix string, s *omSample) error {
+	var le, quantile string
+	labels := make([]*dto.LabelPair, 0, len(s.labels))
+	for _, lp := range s.labels {
+		switch {
+		case lp.GetName() == model.BucketLabel && suffix == "_bucket":
+			le = lp.GetValue()
+		case lp.GetName() == model.QuantileLabel && f.typ == omSummary && suffix == "":
+			quantile = lp.GetValue()
+		default:
+			labels = append(labels, lp)
+		}
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+
+	sig := labelSignature(labels)
+	m, ok := f.metrics[sig]
+	if !ok {
+		m = &dto.Metric{Label: labels, TimestampMs: s.tsMs}
+		f.metrics[sig] = m
+		f.mf.Metric = append(f.mf.Metric, m)
+	}
+
+	switch f.typ {
+	case omCounter:
+		m.Counter = &dto.Counter{Value: proto.Float64(s.value), Exemplar: s.exemplar}
+
+	case omGauge, omStateSet, omInfo:
+		m.Gauge = &dto.Gauge{Value: proto.Float64(s.value)}
+
+	case omSummary:
+		if m.Summary == nil {
+			m.Summary = &dto.Summary{}
+		}
+		switch suffix {
+		case "_count":
+			m.Summary.SampleCount = proto.Uint64(uint64(s.value))
+		case "_sum":
+			m.Summary.SampleSum = proto.Float64(s.value)
+		default:
+			q, err := parseOMFloat(quantile)
+			if err != nil {
+				return fmt.Errorf("invalid quantile of %s: %w", s.name, err)
+			}
+			m.Summary.Quantile = append(m.Summary.Quantile, &dto.Quantile{
+				Quantile: proto.Float64(q),
+				Value:    proto.Float64(s.value),
+			})
+		}
+
+	case omHistogram, omGaugeHistogram:
+		if m.Histogram == nil {
+			m.Histogram = &dto.Histogram{}
+		}
+		switch suffix {
+		case "_count", "_gcount":
+			m.Histogram.SampleCount = proto.Uint64(uint64(s.value))
+		case "_sum", "_gsum":
+			m.Histogram.SampleSum = proto.Float64(s.value)
+		default:
+			ub, err := parseOMFloat(le)
+			if err != nil {
+				return fmt.Errorf("invalid le of %s: %w", s.name, err)
+			}
+			m.Histogram.Bucket = append(m.Histogram.Bucket, &dto.Bucket{
+				UpperBound:      proto.Float64(ub),
+				CumulativeCount: proto.Uint64(uint64(s.value)),
+				Exemplar:        s.exemplar,
+			})
+		}
+
+	default:
+		m.Untyped = &dto.Untyped{Value: proto.Float64(s.value)}
+	}
+
+	return nil
+}
+
+func labelSignature(labels []*dto.LabelPair) string {
+	var sb strings.Builder
+	for _, lp := range labels {
+		sb.WriteString(lp.GetName())
+		sb.WriteByte(0xff)
+		sb.WriteString(lp.GetValue())
+		sb.WriteByte(0xff)
+	}
+	return sb.String()
+}
+
+// parseOMSample parses the sample line:
+//
+//	name{label="value",...} value [timestamp] [# {label="value",...} value [timestamp]]
+func parseOMSample(line string) (*omSample, error) {
+	s := &omSample{}
+
+	idx := strings.IndexAny(line, "{ ")
+	if idx <= 0 {
+		return nil, fmt.Errorf("invalid sample %q", line)
+	}
+	s.name = line[:idx]
+	rest := line[idx:]
+
+	if rest[0] == '{' {
+		labels, n, err := parseOMLabels(rest)
+		if err != nil {
+			return nil, err
+		}
+		s.labels = labels
+		rest = rest[n:]
+	}
+
+	var exemplar string
+	if i := strings.Index(rest, " # "); i >= 0 {
+		exemplar = rest[i+3:]
+		rest = rest[:i]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid value of sample %q", line)
+	}
+
+	v, err := parseOMFloat(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of sample %q: %w", line, err)
+	}
+	s.value = v
+
+	if len(fields) == 2 {
+		ts, err := parseOMFloat(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp of sample %q: %w", line, err)
+		}
+		s.tsMs = proto.Int64(int64(ts * 1000))
+	}
+
+	if exemplar != "" {
+		e, err := parseOMExemplar(exemplar)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exemplar of sample %q: %w", line, err)
+		}
+		s.exemplar = e
+	}
+
+	return s, nil
+}
+
+// parseOMExemplar parses the exemplar: {label="value",...} value [timestamp].
+func parseOMExemplar(str string) (*dto.Exemplar, error) {
+	if !strings.HasPrefix(str, "{") {
+		return nil, fmt.Errorf("labels expected")
+	}
+
+	labels, n, err := parseOMLabels(str)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(str[n:])
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid exemplar value")
+	}
+
+	v, err := parseOMFloat(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	e := &dto.Exemplar{Label: labels, Value: proto.Float64(v)}
+	if len(fields) == 2 {
+		ts, err := parseOMFloat(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		sec, frac := math.Modf(ts)
+		e.Timestamp = &timestamp.Timestamp{Seconds: int64(sec), Nanos: int32(frac * 1e9)}
+	}
+	return e, nil
+}
+
+// parseOMLabels parses the labels starting with '{', it returns the labels and
+// the length consumed.
+func parseOMLabels(str string) ([]*dto.LabelPair, int, error) {
+	var labels []*dto.LabelPair
+
+	i := 1 // skip '{'
+	for {
+		if i >= len(str) {
+			return nil, 0, fmt.Errorf("unterminated labels %q", str)
+		}
+		if str[i] == '}' {
+			return labels, i + 1, nil
+		}
+
+		eq := strings.IndexByte(str[i:], '=')
+		if eq <= 0 {
+			return nil, 0, fmt.Errorf("invalid labels %q", str)
+		}
+		name := str[i : i+eq]
+		if !model.LabelName(name).IsValid() {
+			return nil, 0, fmt.Errorf("invalid label name %q", name)
+		}
+		i += eq + 1
+
+		if i >= len(str) || str[i] != '"' {
+			return nil, 0, fmt.Errorf("label value of %s not quoted", name)
+		}
+		i++
+
+		var sb strings.Builder
+		for ; i < len(str) && str[i] != '"'; i++ {
+			if str[i] == '\\' && i+1 < len(str) {
+				i++
+				switch str[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case '\\', '"':
+					sb.WriteByte(str[i])
+				default:
+					return nil, 0, fmt.Errorf("invalid escape in label value of %s", name)
+				}
+				continue
+			}
+			sb.WriteByte(str[i])
+		}
+		if i >= len(str) {
+			return nil, 0, fmt.Errorf("unterminated label value of %s", name)
+		}
+		i++ // skip '"'
+
+		labels = append(labels, &dto.LabelPair{Name: proto.String(name), Value: proto.String(sb.String())})
+
+		if i < len(str) && str[i] == ',' {
+			i++
+		}
+	}
+}
+
+func parseOMFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func unescapeOMHelp(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\"`, `"`).Replace(s)
+}
@@ -81,6 +81,12 @@ type Option struct {
 	// drop scraped prom data if tag key's value matched
 	IgnoreTagKV IgnoreTagKeyValMatch
 
+	// applied to each sample with its metric name as label __name__
+	MetricRelabelConfigs RelabelConfigs `toml:"metric_relabel_configs"`
+
+	// negotiated by the Accept header in order, such as protobuf, openmetrics and text
+	ScrapeProtocols []string `toml:"scrape_protocols"`
+
 	Election bool
 	pointOpt *point.PointOption
 
@@ -128,6 +134,10 @@ type Prom struct {
 	opt    *Option
 	client *http.Client
 	parser expfmt.TextParser
+	accept string
+
+	// used by metric points with string fields, such as exemplar trace ID
+	stringFieldPointOpt *point.PointOption
 }
 
 func NewProm(opt *Option) (*Prom, error) {
@@ -153,12 +163,20 @@ func NewProm(opt *Option) (*Prom, error) {
 		}
 	}
 
+	if err := opt.MetricRelabelConfigs.Compile(); err != nil {
+		return nil, fmt.Errorf("metric_relabel_configs: %w", err)
+	}
+
+	if err := checkScrapeProtocols(opt.ScrapeProtocols); err != nil {
+		return nil, err
+	}
+
 	timeout, err := time.ParseDuration(opt.Timeout)
 	if err != nil || timeout < httpTimeout {
 		timeout = httpTimeout
 	}
 
-	p := Prom{opt: opt}
+	p := Prom{opt: opt, accept: acceptHeader(opt.ScrapeProtocols)}
 
 	var dialContext func(_ context.Context, _ string, _ string) (net.Conn, error)
 	if p.opt.UDSPath != "" {
@@ -199,6 +217,10 @@ func NewProm(opt *Option) (*Prom, error) {
 		p.opt.pointOpt = point.LOptElectionV2(p.opt.Election)
 	} else {
 		p.opt.pointOpt = point.MOptElectionV2(p.opt.Election)
+
+		stringFieldPointOpt := *p.opt.pointOpt
+		stringFieldPointOpt.EnableStringField = true
+		p.stringFieldPointOpt = &stringFieldPointOpt
 	}
 
 	return &p, nil
@@ -229,6 +251,13 @@ func (p *Prom) GetReq(url string) (*http.Request, error) {
 	} else {
 		req, err = http.NewRequest("GET", url, nil)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept != "" && req != nil {
+		req.Header.Set("Accept", p.accept)
+	}
 	for k, v := range p.opt.HTTPHeaders {
 		req.Header.Set(k, v)
 	}
@@ -259,7 +288,7 @@ func (p *Prom) CollectFromHTTP(u string) ([]*point.Point, error) {
 		}
 	}
 	defer resp.Body.Close() //nolint:errcheck
-	pts, err := p.text2Metrics(resp.Body, u)
+	pts, err := p.body2Metrics(resp.Body, resp.Header.Get("Content-Type"), u)
 	if err != nil {
 		return nil, err
 	}
@@ -272,7 +301,7 @@ func (p *Prom) CollectFromFile(filepath string) ([]*point.Point, error) {
 		return nil, err
 	}
 	defer f.Close() //nolint:errcheck,gosec
-	return p.text2Metrics(f, "")
+	return p.file2Metrics(f)
 }
 
 // WriteMetricText2File scrapes raw prometheus metric text from u
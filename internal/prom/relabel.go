@@ -0,0 +1,239 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// Relabel actions, same as Prometheus relabel_config.
+const (
+	RelabelReplace   = "replace"
+	RelabelKeep      = "keep"
+	RelabelDrop      = "drop"
+	RelabelKeepEqual = "keepequal"
+	RelabelDropEqual = "dropequal"
+	RelabelHashMod   = "hashmod"
+	RelabelLabelMap  = "labelmap"
+	RelabelLabelDrop = "labeldrop"
+	RelabelLabelKeep = "labelkeep"
+	RelabelLowercase = "lowercase"
+	RelabelUppercase = "uppercase"
+
+	defaultRelabelSeparator   = ";"
+	defaultRelabelRegex       = "(.*)"
+	defaultRelabelReplacement = "$1"
+)
+
+// RelabelConfig is the relabel rule of Prometheus relabel_config, see
+// https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config
+type RelabelConfig struct {
+	SourceLabels []string `toml:"source_labels" json:"source_labels"`
+	// Separator default ";".
+	Separator *string `toml:"separator" json:"separator"`
+	// Regex is fully anchored, default "(.*)".
+	Regex       string `toml:"regex" json:"regex"`
+	Modulus     uint64 `toml:"modulus" json:"modulus"`
+	TargetLabel string `toml:"target_label" json:"target_label"`
+	// Replacement default "$1".
+	Replacement *string `toml:"replacement" json:"replacement"`
+	// Action default replace.
+	Action string `toml:"action" json:"action"`
+
+	regex *regexp.Regexp
+}
+
+// RelabelConfigs are applied in order.
+type RelabelConfigs []*RelabelConfig
+
+// Compile checks the configs and compiles the regexes, it's required before
+// Process.
+func (cfgs RelabelConfigs) Compile() error {
+	for idx, c := range cfgs {
+		if c == nil {
+			return fmt.Errorf("relabel config #%d is empty", idx)
+		}
+
+		if err := c.compile(); err != nil {
+			return fmt.Errorf("relabel config #%d: %w", idx, err)
+		}
+	}
+	return nil
+}
+
+func (c *RelabelConfig) compile() error {
+	c.Action = strings.ToLower(c.Action)
+	if c.Action == "" {
+		c.Action = RelabelReplace
+	}
+
+	regex := c.Regex
+	if regex == "" {
+		regex = defaultRelabelRegex
+	}
+
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid regex %q: %w", c.Regex, err)
+	}
+	c.regex = re
+
+	switch c.Action {
+	case RelabelReplace, RelabelLowercase, RelabelUppercase, RelabelKeepEqual, RelabelDropEqual:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("target_label is required for action %s", c.Action)
+		}
+
+	case RelabelHashMod:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("target_label is required for action %s", c.Action)
+		}
+		if c.Modulus == 0 {
+			return fmt.Errorf("modulus is required for action %s", c.Action)
+		}
+
+	case RelabelKeep, RelabelDrop, RelabelLabelMap, RelabelLabelDrop, RelabelLabelKeep:
+
+	default:
+		return fmt.Errorf("unknown relabel action %q", c.Action)
+	}
+
+	return nil
+}
+
+func (c *RelabelConfig) separator() string {
+	if c.Separator == nil {
+		return defaultRelabelSeparator
+	}
+	return *c.Separator
+}
+
+func (c *RelabelConfig) replacement() string {
+	if c.Replacement == nil {
+		return defaultRelabelReplacement
+	}
+	return *c.Replacement
+}
+
+// Process applies the configs to labels in place, false is returned if the
+// labels should be dropped. Labels with empty value are removed.
+func (cfgs RelabelConfigs) Process(labels map[string]string) bool {
+	for _, c := range cfgs {
+		if c == nil || c.regex == nil {
+			continue // not compiled
+		}
+
+		if !c.process(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *RelabelConfig) process(labels map[string]string) bool {
+	values := make([]string, 0, len(c.SourceLabels))
+	for _, ln := range c.SourceLabels {
+		values = append(values, labels[ln])
+	}
+	val := strings.Join(values, c.separator())
+
+	switch c.Action {
+	case RelabelDrop:
+		if c.regex.MatchString(val) {
+			return false
+		}
+
+	case RelabelKeep:
+		if !c.regex.MatchString(val) {
+			return false
+		}
+
+	case RelabelDropEqual:
+		if labels[c.TargetLabel] == val {
+			return false
+		}
+
+	case RelabelKeepEqual:
+		if labels[c.TargetLabel] != val {
+			return false
+		}
+
+	case RelabelReplace:
+		indexes := c.regex.FindStringSubmatchIndex(val)
+		if indexes == nil {
+			break
+		}
+
+		target := string(c.regex.ExpandString([]byte{}, c.TargetLabel, val, indexes))
+		if !model.LabelName(target).IsValid() {
+			break
+		}
+
+		res := c.regex.ExpandString([]byte{}, c.replacement(), val, indexes)
+		setLabel(labels, target, string(res))
+
+	case RelabelLowercase:
+		setLabel(labels, c.TargetLabel, strings.ToLower(val))
+
+	case RelabelUppercase:
+		setLabel(labels, c.TargetLabel, strings.ToUpper(val))
+
+	case RelabelHashMod:
+		mod := sum64(md5.Sum([]byte(val))) % c.Modulus //nolint:gosec
+		setLabel(labels, c.TargetLabel, fmt.Sprint(mod))
+
+	case RelabelLabelMap:
+		mapped := map[string]string{}
+		for k, v := range labels {
+			if c.regex.MatchString(k) {
+				mapped[c.regex.ReplaceAllString(k, c.replacement())] = v
+			}
+		}
+		for k, v := range mapped {
+			setLabel(labels, k, v)
+		}
+
+	case RelabelLabelDrop:
+		for k := range labels {
+			if c.regex.MatchString(k) {
+				delete(labels, k)
+			}
+		}
+
+	case RelabelLabelKeep:
+		for k := range labels {
+			if !c.regex.MatchString(k) {
+				delete(labels, k)
+			}
+		}
+	}
+
+	return true
+}
+
+func setLabel(labels map[string]string, k, v string) {
+	if v == "" {
+		delete(labels, k)
+		return
+	}
+	labels[k] = v
+}
+
+// sum64 sums the md5 hash to an uint64, same as Prometheus.
+func sum64(hash [md5.Size]byte) uint64 {
+	var s uint64
+
+	for i, b := range hash {
+		shift := uint64((md5.Size - 1 - i) * 8)
+		s |= uint64(b) << shift
+	}
+	return s
+}
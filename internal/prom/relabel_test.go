@@ -0,0 +1,322 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestRelabel(t *testing.T) {
+	cases := []struct {
+		name     string
+		cfgs     RelabelConfigs
+		in       map[string]string
+		expected map[string]string // nil means dropped
+	}{
+		{
+			name: "replace",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a", "b"}, Regex: "(.+);(.+)", TargetLabel: "c", Replacement: strPtr("$2-$1")},
+			},
+			in:       map[string]string{"a": "foo", "b": "bar"},
+			expected: map[string]string{"a": "foo", "b": "bar", "c": "bar-foo"},
+		},
+		{
+			name: "replace-default",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "b"},
+			},
+			in:       map[string]string{"a": "foo"},
+			expected: map[string]string{"a": "foo", "b": "foo"},
+		},
+		{
+			name: "replace-separator",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a", "b"}, Separator: strPtr(""), TargetLabel: "c"},
+			},
+			in:       map[string]string{"a": "foo", "b": "bar"},
+			expected: map[string]string{"a": "foo", "b": "bar", "c": "foobar"},
+		},
+		{
+			name: "replace-not-match",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, Regex: "f", TargetLabel: "b"},
+			},
+			in:       map[string]string{"a": "foo"},
+			expected: map[string]string{"a": "foo"},
+		},
+		{
+			name: "replace-empty-deletes",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "b", Replacement: strPtr("")},
+			},
+			in:       map[string]string{"a": "foo", "b": "bar"},
+			expected: map[string]string{"a": "foo"},
+		},
+		{
+			name: "replace-target-expanded",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, Regex: "(.*)-(.*)", TargetLabel: "l_$1", Replacement: strPtr("$2")},
+			},
+			in:       map[string]string{"a": "x-y"},
+			expected: map[string]string{"a": "x-y", "l_x": "y"},
+		},
+		{
+			name: "keep",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, Regex: "fo+", Action: "keep"},
+			},
+			in:       map[string]string{"a": "foo"},
+			expected: map[string]string{"a": "foo"},
+		},
+		{
+			name: "keep-anchored",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, Regex: "fo", Action: "keep"},
+			},
+			in: map[string]string{"a": "foo"},
+		},
+		{
+			name: "drop",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "Drop"},
+			},
+			in: map[string]string{"__name__": "go_goroutines"},
+		},
+		{
+			name: "keepequal",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "b", Action: "keepequal"},
+			},
+			in:       map[string]string{"a": "foo", "b": "foo"},
+			expected: map[string]string{"a": "foo", "b": "foo"},
+		},
+		{
+			name: "dropequal",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "b", Action: "dropequal"},
+			},
+			in: map[string]string{"a": "foo", "b": "foo"},
+		},
+		{
+			name: "hashmod",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "shard", Modulus: 1000, Action: "hashmod"},
+			},
+			in:       map[string]string{"a": "foo"},
+			expected: map[string]string{"a": "foo", "shard": "696"}, // low 64 bits of md5("foo") % 1000
+		},
+		{
+			name: "labelmap",
+			cfgs: RelabelConfigs{
+				{Regex: "__meta_(.+)", Action: "labelmap"},
+			},
+			in:       map[string]string{"__meta_zone": "z1", "a": "foo"},
+			expected: map[string]string{"__meta_zone": "z1", "zone": "z1", "a": "foo"},
+		},
+		{
+			name: "labeldrop",
+			cfgs: RelabelConfigs{
+				{Regex: "a|b", Action: "labeldrop"},
+			},
+			in:       map[string]string{"a": "1", "b": "2", "c": "3"},
+			expected: map[string]string{"c": "3"},
+		},
+		{
+			name: "labelkeep",
+			cfgs: RelabelConfigs{
+				{Regex: "a|b", Action: "labelkeep"},
+			},
+			in:       map[string]string{"a": "1", "b": "2", "c": "3"},
+			expected: map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name: "lowercase-uppercase",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "lower", Action: "lowercase"},
+				{SourceLabels: []string{"a"}, TargetLabel: "upper", Action: "uppercase"},
+			},
+			in:       map[string]string{"a": "FoO"},
+			expected: map[string]string{"a": "FoO", "lower": "foo", "upper": "FOO"},
+		},
+		{
+			name: "in-order",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"a"}, TargetLabel: "b"},
+				{SourceLabels: []string{"b"}, Regex: "foo", Action: "drop"},
+			},
+			in: map[string]string{"a": "foo"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.cfgs.Compile())
+
+			ok := tc.cfgs.Process(tc.in)
+			if tc.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, tc.in)
+		})
+	}
+}
+
+func TestRelabelCompile(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  *RelabelConfig
+	}{
+		{name: "regex", cfg: &RelabelConfig{Regex: "(", TargetLabel: "a"}},
+		{name: "action", cfg: &RelabelConfig{Action: "unknown"}},
+		{name: "replace-target", cfg: &RelabelConfig{}},
+		{name: "hashmod-modulus", cfg: &RelabelConfig{Action: "hashmod", TargetLabel: "a"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Error(t, RelabelConfigs{tc.cfg}.Compile())
+		})
+	}
+
+	_, err := NewProm(&Option{URL: "http://localhost", MetricRelabelConfigs: RelabelConfigs{{Action: "unknown"}}})
+	assert.Error(t, err)
+}
+
+func TestMetricRelabel(t *testing.T) {
+	text := `
+# TYPE go_goroutines gauge
+go_goroutines 10
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="GET",pod="web-1"} 3
+http_requests_total{code="500",method="GET",pod="web-2"} 1
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 1
+http_request_duration_seconds_bucket{le="+Inf"} 2
+http_request_duration_seconds_sum 0.3
+http_request_duration_seconds_count 2
+`
+
+	p, err := NewProm(&Option{
+		URL:  "http://localhost",
+		Tags: map[string]string{"env": "prod"},
+		MetricRelabelConfigs: RelabelConfigs{
+			// drop go metrics
+			{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"},
+			// drop the +Inf buckets
+			{SourceLabels: []string{"le"}, Regex: `\+Inf`, Action: "drop"},
+			// rename the metric
+			{SourceLabels: []string{"__name__"}, Regex: "http_(.*)", TargetLabel: "__name__", Replacement: strPtr("web_$1")},
+			{SourceLabels: []string{"pod"}, Regex: "(.*)-(.*)", TargetLabel: "__tmp_index", Replacement: strPtr("$2")},
+			{SourceLabels: []string{"__tmp_index"}, TargetLabel: "index"},
+			{Regex: "method", Action: "labeldrop"},
+		},
+	})
+	require.NoError(t, err)
+
+	pts, err := p.text2Metrics(bytes.NewBufferString(text), "")
+	require.NoError(t, err)
+
+	var arr []string
+	for _, pt := range pts {
+		arr = append(arr, strings.Split(pt.String(), " ")[0]+" "+strings.Split(pt.String(), " ")[1])
+	}
+	sort.Strings(arr)
+
+	assert.Equal(t, []string{
+		`web,code=200,env=prod,index=1,pod=web-1 requests_total=3`,
+		`web,code=500,env=prod,index=2,pod=web-2 requests_total=1`,
+		`web,env=prod request_duration_seconds_count=2,request_duration_seconds_sum=0.3`,
+		`web,env=prod,le=0.1 request_duration_seconds_bucket=1i`,
+	}, arr)
+}
+
+func TestMetricRelabelSample(t *testing.T) {
+	text := `
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{le="0.1"} 1
+http_request_duration_seconds_bucket{le="+Inf"} 2
+http_request_duration_seconds_sum 0.3
+http_request_duration_seconds_count 2
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds_sum 1.5
+rpc_duration_seconds_count 10
+`
+
+	cases := []struct {
+		name     string
+		cfgs     RelabelConfigs
+		expected []string
+	}{
+		{
+			name: "drop-buckets",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"__name__"}, Regex: ".*_bucket", Action: "drop"},
+			},
+			expected: []string{
+				`http request_duration_seconds_count=2,request_duration_seconds_sum=0.3`,
+				`rpc duration_seconds_count=10,duration_seconds_sum=1.5`,
+				`rpc,quantile=0.5 duration_seconds=0.05`,
+			},
+		},
+		{
+			name: "drop-sum",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"__name__"}, Regex: ".*_sum", Action: "drop"},
+				{SourceLabels: []string{"__name__"}, Regex: "rpc_duration_seconds", Action: "drop"},
+			},
+			expected: []string{
+				`http request_duration_seconds_count=2`,
+				`http,le=+Inf request_duration_seconds_bucket=2i`,
+				`http,le=0.1 request_duration_seconds_bucket=1i`,
+				`rpc duration_seconds_count=10`,
+			},
+		},
+		{
+			name: "label-by-sample",
+			cfgs: RelabelConfigs{
+				{SourceLabels: []string{"__name__"}, Regex: ".*_(count|sum)", TargetLabel: "sample", Replacement: strPtr("$1")},
+				{Regex: "le", Action: "labeldrop"},
+				{SourceLabels: []string{"__name__"}, Regex: "rpc_duration_seconds|.*_bucket", Action: "drop"},
+			},
+			expected: []string{
+				`http,sample=count request_duration_seconds_count=2`,
+				`http,sample=sum request_duration_seconds_sum=0.3`,
+				`rpc,sample=count duration_seconds_count=10`,
+				`rpc,sample=sum duration_seconds_sum=1.5`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewProm(&Option{URL: "http://localhost", MetricRelabelConfigs: tc.cfgs})
+			require.NoError(t, err)
+
+			pts, err := p.text2Metrics(bytes.NewBufferString(text), "")
+			require.NoError(t, err)
+
+			var arr []string
+			for _, pt := range pts {
+				arr = append(arr, strings.Split(pt.String(), " ")[0]+" "+strings.Split(pt.String(), " ")[1])
+			}
+			sort.Strings(arr)
+
+			assert.Equal(t, tc.expected, arr)
+		})
+	}
+}
@@ -13,11 +13,14 @@ import (
 	"math"
 	"net"
 	"net/url"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 )
 
@@ -37,8 +40,7 @@ func (p *Prom) getMetricTypeName(familyType dto.MetricType) string {
 	case dto.MetricType_UNTYPED:
 		metricTypeName = "untyped"
 	case dto.MetricType_GAUGE_HISTOGRAM:
-		// TODO
-		// passed lint
+		metricTypeName = "gaugehistogram"
 	}
 	return metricTypeName
 }
@@ -193,7 +195,7 @@ func setHostTagIfNotLoopback(tags map[string]string, u string) {
 	}
 }
 
-func (p *Prom) getTagsWithLE(labels []*dto.LabelPair, measurementName string, b *dto.Bucket) map[string]string {
+func (p *Prom) getTagsWithLE(labels []*dto.LabelPair, measurementName string, upperBound float64) map[string]string {
 	tags := map[string]string{}
 
 	// Add custom tags.
@@ -206,7 +208,7 @@ func (p *Prom) getTagsWithLE(labels []*dto.LabelPair, measurementName string, b
 		tags[lab.GetName()] = lab.GetValue()
 	}
 
-	tags["le"] = fmt.Sprint(b.GetUpperBound())
+	tags["le"] = fmt.Sprint(upperBound)
 
 	p.removeIgnoredTags(tags)
 	p.renameTags(tags)
@@ -359,17 +361,28 @@ func newTokenIterator(text []byte, start int) tokenIterator {
 }
 
 // doText2Metrics converts raw prometheus metric text to line protocol point.
-func (p *Prom) doText2Metrics(in io.Reader, u string) (pts []*point.Point, lastErr error) {
+func (p *Prom) doText2Metrics(in io.Reader, u string) ([]*point.Point, error) {
 	metricFamilies, err := p.parser.TextToMetricFamilies(in)
 	if err != nil {
 		return nil, err
 	}
 
-	filteredMetricFamilies := p.filterMetricFamilies(metricFamilies)
+	return p.families2Points(p.filterMetricFamilies(metricFamilies), u)
+}
 
-	for name, value := range filteredMetricFamilies {
+// families2Points converts metric families to line protocol points.
+func (p *Prom) families2Points(metricFamilies map[string]*dto.MetricFamily, u string) (pts []*point.Point, lastErr error) {
+	for name, value := range metricFamilies {
 		measurementName, fieldName := p.getNames(name)
 
+		addPoint := func(tags map[string]string, fields map[string]interface{}) {
+			arr, err := p.newPoints(name, measurementName, fieldName, tags, fields)
+			if err != nil {
+				lastErr = err
+			}
+			pts = append(pts, arr...)
+		}
+
 		switch value.GetType() {
 		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED, dto.MetricType_COUNTER:
 			for _, m := range value.GetMetric() {
@@ -379,117 +392,230 @@ func (p *Prom) doText2Metrics(in io.Reader, u string) (pts []*point.Point, lastE
 				}
 
 				fields := map[string]interface{}{
-					fieldName: v,
-				}
-				if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
-					fields["status"] = statusInfo
+					"": v,
 				}
-				tags := p.getTags(m.GetLabel(), measurementName, u)
+				addExemplarFields(fields, "", m.GetCounter().GetExemplar())
 
-				if !p.tagKVMatched(tags) {
-					pt, err := point.NewPoint(measurementName, tags, fields, p.opt.pointOpt)
-					if err != nil {
-						lastErr = err
-					} else {
-						pts = append(pts, pt)
-					}
-				}
+				addPoint(p.getTags(m.GetLabel(), measurementName, u), fields)
 			}
 
 		case dto.MetricType_SUMMARY:
 			for _, m := range value.GetMetric() {
-				fields := map[string]interface{}{
-					fieldName + "_count": float64(m.GetSummary().GetSampleCount()),
-					fieldName + "_sum":   m.GetSummary().GetSampleSum(),
-				}
-				if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
-					fields["status"] = statusInfo
-				}
-				tags := p.getTags(m.GetLabel(), measurementName, u)
-
-				if !p.tagKVMatched(tags) {
-					pt, err := point.NewPoint(measurementName, tags, fields, p.opt.pointOpt)
-					if err != nil {
-						lastErr = err
-					} else {
-						pts = append(pts, pt)
-					}
-				}
+				addPoint(p.getTags(m.GetLabel(), measurementName, u), map[string]interface{}{
+					"_count": float64(m.GetSummary().GetSampleCount()),
+					"_sum":   m.GetSummary().GetSampleSum(),
+				})
 
 				for _, q := range m.GetSummary().Quantile {
-					fields := map[string]interface{}{
-						fieldName: q.GetValue(),
-					}
-					if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
-						fields["status"] = statusInfo
-					}
-
 					tags := p.getTags(m.GetLabel(), measurementName, u)
 					tags["quantile"] = fmt.Sprint(q.GetQuantile())
 
-					if !p.tagKVMatched(tags) {
-						pt, err := point.NewPoint(measurementName, tags, fields, p.opt.pointOpt)
-						if err != nil {
-							lastErr = err
-						} else {
-							pts = append(pts, pt)
-						}
-					}
+					addPoint(tags, map[string]interface{}{
+						"": q.GetValue(),
+					})
 				}
 			}
 
-		case dto.MetricType_HISTOGRAM:
-			for _, m := range value.GetMetric() {
-				fields := map[string]interface{}{
-					fieldName + "_count": float64(m.GetHistogram().GetSampleCount()),
-					fieldName + "_sum":   m.GetHistogram().GetSampleSum(),
-				}
-				if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
-					fields["status"] = statusInfo
-				}
+		case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+			countSuffix, sumSuffix := "_count", "_sum"
+			if value.GetType() == dto.MetricType_GAUGE_HISTOGRAM {
+				countSuffix, sumSuffix = "_gcount", "_gsum"
+			}
 
-				tags := p.getTags(m.GetLabel(), measurementName, u)
+			for _, m := range value.GetMetric() {
+				h := m.GetHistogram()
 
-				if !p.tagKVMatched(tags) {
-					pt, err := point.NewPoint(measurementName, tags, fields, p.opt.pointOpt)
-					if err != nil {
-						lastErr = err
-					} else {
-						pts = append(pts, pt)
+				count := float64(h.GetSampleCount())
+				if isFloatHistogram(h) {
+					count = h.GetSampleCountFloat()
+				}
+				addPoint(p.getTags(m.GetLabel(), measurementName, u), map[string]interface{}{
+					countSuffix: count,
+					sumSuffix:   h.GetSampleSum(),
+				})
+
+				if isNativeHistogram(h) {
+					for _, b := range nativeHistogramBuckets(h) {
+						addPoint(p.getTagsWithLE(m.GetLabel(), measurementName, b.upperBound), map[string]interface{}{
+							"_bucket": b.count,
+						})
 					}
+					continue
 				}
 
-				for _, b := range m.GetHistogram().GetBucket() {
+				for _, b := range h.GetBucket() {
 					fields := map[string]interface{}{
-						fieldName + "_bucket": b.GetCumulativeCount(),
-					}
-					if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
-						fields["status"] = statusInfo
+						"_bucket": b.GetCumulativeCount(),
 					}
+					addExemplarFields(fields, "_bucket", b.GetExemplar())
 
-					tags := p.getTagsWithLE(m.GetLabel(), measurementName, b)
-
-					if !p.tagKVMatched(tags) {
-						pt, err := point.NewPoint(measurementName, tags, fields, p.opt.pointOpt)
-						if err != nil {
-							lastErr = err
-						} else {
-							pts = append(pts, pt)
-						}
-					}
+					addPoint(p.getTagsWithLE(m.GetLabel(), measurementName, b.GetUpperBound()), fields)
 				}
 			}
-		case dto.MetricType_GAUGE_HISTOGRAM:
-			// TODO
-			// passed lint
 		}
 	}
 	if lastErr != nil {
-		return pts, fmt.Errorf("doText2Metrics encountered make point error: %w", lastErr)
+		return pts, fmt.Errorf("families2Points encountered make point error: %w", lastErr)
 	}
 	return pts, nil
 }
 
+// addExemplarFields adds the exemplar value as field <suffix>_exemplar and
+// its labels, such as trace_id, as fields <suffix>_exemplar_<label>.
+func addExemplarFields(fields map[string]interface{}, suffix string, e *dto.Exemplar) {
+	if e == nil {
+		return
+	}
+
+	fields[suffix+"_exemplar"] = e.GetValue()
+	for _, lp := range e.GetLabel() {
+		fields[suffix+"_exemplar_"+lp.GetName()] = lp.GetValue()
+	}
+}
+
+// newPoints makes points of the metric, fields are keyed by the suffix of
+// the field name. With metric relabeling, each sample, such as foo_bucket,
+// foo_sum and foo_count of histogram foo, is relabeled with its own name, and
+// samples with the same measurement and tags after relabeling are kept in one
+// point. Points dropped by metric relabeling or ignore_tag_kv_match are not
+// returned.
+func (p *Prom) newPoints(name, measurementName, fieldName string,
+	tags map[string]string, suffixFields map[string]interface{},
+) ([]*point.Point, error) {
+	if len(p.opt.MetricRelabelConfigs) == 0 {
+		fields := make(map[string]interface{}, len(suffixFields))
+		for suffix, v := range suffixFields {
+			fields[fieldName+suffix] = v
+		}
+
+		pt, err := p.newPoint(measurementName, tags, fields)
+		if err != nil || pt == nil {
+			return nil, err
+		}
+		return []*point.Point{pt}, nil
+	}
+
+	type sample struct {
+		measurementName string
+		tags            map[string]string
+		fields          map[string]interface{}
+	}
+
+	suffixes := make([]string, 0, len(suffixFields))
+	for suffix := range suffixFields {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+
+	var samples []*sample
+
+	for _, suffix := range suffixes {
+		sampleSuffix := suffix
+		if i := strings.Index(suffix, "_exemplar"); i >= 0 {
+			sampleSuffix = suffix[:i] // exemplar fields follow their sample
+		}
+
+		sampleTags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			sampleTags[k] = v
+		}
+
+		newName, ok := p.relabel(name+sampleSuffix, measurementName, sampleTags)
+		if !ok {
+			continue
+		}
+
+		m, field := measurementName, fieldName+suffix
+		if newName != name+sampleSuffix {
+			m, field = p.getNames(newName)
+			field += suffix[len(sampleSuffix):]
+		}
+
+		var s *sample
+		for _, x := range samples {
+			if x.measurementName == m && reflect.DeepEqual(x.tags, sampleTags) {
+				s = x
+				break
+			}
+		}
+		if s == nil {
+			s = &sample{measurementName: m, tags: sampleTags, fields: map[string]interface{}{}}
+			samples = append(samples, s)
+		}
+		s.fields[field] = suffixFields[suffix]
+	}
+
+	var (
+		pts     []*point.Point
+		lastErr error
+	)
+	for _, s := range samples {
+		pt, err := p.newPoint(s.measurementName, s.tags, s.fields)
+		if err != nil {
+			lastErr = err
+		} else if pt != nil {
+			pts = append(pts, pt)
+		}
+	}
+	return pts, lastErr
+}
+
+// newPoint makes the point, nil is returned if dropped by ignore_tag_kv_match.
+func (p *Prom) newPoint(measurementName string, tags map[string]string, fields map[string]interface{}) (*point.Point, error) {
+	if p.tagKVMatched(tags) {
+		return nil, nil
+	}
+
+	if p.opt.AsLogging != nil && p.opt.AsLogging.Enable {
+		fields["status"] = statusInfo
+	}
+
+	opt := p.opt.pointOpt
+	if p.stringFieldPointOpt != nil && hasStringField(fields) {
+		opt = p.stringFieldPointOpt
+	}
+
+	return point.NewPoint(measurementName, tags, fields, opt)
+}
+
+func hasStringField(fields map[string]interface{}) bool {
+	for _, v := range fields {
+		if _, ok := v.(string); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relabel applies metric relabeling to tags with label __name__ set to the
+// sample name, it returns the sample name relabeled and false if dropped. Labels prefixed with "__"
+// are removed after relabeling.
+func (p *Prom) relabel(name, measurementName string, tags map[string]string) (string, bool) {
+	tags[model.MetricNameLabel] = name
+	if !p.opt.MetricRelabelConfigs.Process(tags) {
+		return "", false
+	}
+
+	newName := tags[model.MetricNameLabel]
+	for k := range tags {
+		if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+			delete(tags, k)
+		}
+	}
+
+	if newName == "" {
+		return "", false
+	}
+
+	// the service tag follows the measurement renamed
+	if newName != name && p.opt.AsLogging != nil && p.opt.AsLogging.Enable &&
+		p.opt.AsLogging.Service == "" && tags["service"] == measurementName {
+		tags["service"], _ = p.getNames(newName)
+	}
+
+	return newName, true
+}
+
 func getValue(m *dto.Metric, metricType dto.MetricType) float64 {
 	switch metricType { //nolint:exhaustive
 	case dto.MetricType_GAUGE:
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package secret
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/GuanceCloud/confd/backends"
+)
+
+// BackendCfg is the confd backend, such as vault and ssm, secrets are
+// referenced as ENC[<name>://<key>].
+type BackendCfg struct {
+	Name         string   `toml:"name"`    // default the backend
+	Backend      string   `toml:"backend"` // vault, ssm, consul, etcdv3, redis, zookeeper, nacos and aws
+	BackendNodes []string `toml:"nodes"`
+
+	AuthType     string `toml:"auth_type"`
+	AuthToken    string `toml:"auth_token"`
+	BasicAuth    bool   `toml:"basic_auth"`
+	Username     string `toml:"username"`
+	Password     string `toml:"password"`
+	AppID        string `toml:"app_id"`
+	UserID       string `toml:"user_id"`
+	RoleID       string `toml:"role_id"`
+	SecretID     string `toml:"secret_id"`
+	ClientCaKeys string `toml:"client_ca_keys"`
+	ClientCert   string `toml:"client_cert"`
+	ClientKey    string `toml:"client_key"`
+	Scheme       string `toml:"scheme"`
+	Separator    string `toml:"separator"`
+	Path         string `toml:"path"`
+
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	Region    string `toml:"region"`
+}
+
+// backendProvider gets secrets from the confd backend, the client is
+// created on first use.
+type backendProvider struct {
+	cfg backends.Config
+
+	mu     sync.Mutex
+	client backends.StoreClient
+	newFn  func(backends.Config) (backends.StoreClient, error)
+}
+
+func newBackendProvider(c *BackendCfg, r *resolver) (*backendProvider, error) {
+	if c.Backend == "" {
+		return nil, fmt.Errorf("backend not set")
+	}
+
+	if c.Backend == "vault" && len(c.BackendNodes) == 0 {
+		return nil, fmt.Errorf("nodes not set")
+	}
+
+	cfg := backends.Config{
+		AuthType:     c.AuthType,
+		AuthToken:    c.AuthToken,
+		Backend:      c.Backend,
+		BasicAuth:    c.BasicAuth,
+		BackendNodes: c.BackendNodes,
+		Username:     c.Username,
+		Password:     c.Password,
+		AppID:        c.AppID,
+		UserID:       c.UserID,
+		RoleID:       c.RoleID,
+		SecretID:     c.SecretID,
+		ClientCaKeys: c.ClientCaKeys,
+		ClientCert:   c.ClientCert,
+		ClientKey:    c.ClientKey,
+		Scheme:       c.Scheme,
+		Separator:    c.Separator,
+		Path:         c.Path,
+		AccessKey:    c.AccessKey,
+		SecretKey:    c.SecretKey,
+		Region:       c.Region,
+	}
+
+	// credentials of the backend can be references of file or keystore
+	for _, s := range []*string{&cfg.AuthToken, &cfg.Password, &cfg.SecretID, &cfg.SecretKey} {
+		v, err := r.resolve(*s)
+		if err != nil {
+			return nil, err
+		}
+		*s = v
+	}
+
+	return &backendProvider{cfg: cfg, newFn: backends.New}, nil
+}
+
+func (p *backendProvider) Get(key string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		cli, err := p.newFn(p.cfg)
+		if err != nil {
+			return "", fmt.Errorf("create %s client: %w", p.cfg.Backend, err)
+		}
+		p.client = cli
+	}
+
+	values, err := p.client.GetValues([]string{key})
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+	}
+	return v, nil
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreKeySize = 32 // AES-256
+	keystorePerm    = 0o600
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Keystore is the local keystore, secrets are encrypted by AES-256-GCM with
+// the key in a separate key file, which is created on first write.
+type Keystore struct {
+	path    string
+	keyPath string
+
+	key     []byte
+	Version int               `json:"version"`
+	Secrets map[string]string `json:"secrets"` // name: base64(nonce + ciphertext)
+}
+
+// OpenKeystore opens the keystore, it's empty if the keystore file not exist.
+func OpenKeystore(path, keyPath string) (*Keystore, error) {
+	ks := &Keystore{
+		path:    path,
+		keyPath: keyPath,
+		Version: keystoreVersion,
+		Secrets: map[string]string{},
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ks, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, ks); err != nil {
+		return nil, fmt.Errorf("invalid keystore %s: %w", path, err)
+	}
+
+	if ks.Secrets == nil {
+		ks.Secrets = map[string]string{}
+	}
+	return ks, nil
+}
+
+func (ks *Keystore) loadKey(create bool) error {
+	if ks.key != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(ks.keyPath))
+	switch {
+	case err == nil:
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != keystoreKeySize {
+			return fmt.Errorf("invalid keystore key %s", ks.keyPath)
+		}
+		ks.key = key
+		return nil
+
+	case os.IsNotExist(err) && create:
+		key := make([]byte, keystoreKeySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(ks.keyPath), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(ks.keyPath, []byte(hex.EncodeToString(key)), keystorePerm); err != nil {
+			return err
+		}
+		ks.key = key
+		return nil
+
+	default:
+		return err
+	}
+}
+
+func (ks *Keystore) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(ks.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get decrypts the secret of the name.
+func (ks *Keystore) Get(name string) (string, error) {
+	enc, ok := ks.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	if err := ks.loadKey(false); err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret %s: %w", name, err)
+	}
+
+	aead, err := ks.aead()
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid secret %s", name)
+	}
+
+	// the name is the additional data, so secrets can't be swapped
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret %s: %w", name, err)
+	}
+	return string(plain), nil
+}
+
+// Set encrypts and saves the secret of the name.
+func (ks *Keystore) Set(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("empty secret name")
+	}
+
+	if err := ks.loadKey(true); err != nil {
+		return err
+	}
+
+	aead, err := ks.aead()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	ks.Secrets[name] = base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name)))
+	return ks.save()
+}
+
+// Delete removes the secret of the name.
+func (ks *Keystore) Delete(name string) error {
+	if _, ok := ks.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+
+	delete(ks.Secrets, name)
+	return ks.save()
+}
+
+// Names returns the sorted names of the secrets.
+func (ks *Keystore) Names() []string {
+	names := make([]string, 0, len(ks.Secrets))
+	for name := range ks.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (ks *Keystore) save() error {
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0o700); err != nil {
+		return err
+	}
+
+	// write to temp file then rename, the keystore is never half written
+	tmp := ks.path + ".tmp"
+	if err := os.WriteFile(tmp, data, keystorePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+func getKeystoreSecret(path, keyPath, name string) (string, error) {
+	ks, err := OpenKeystore(path, keyPath)
+	if err != nil {
+		return "", err
+	}
+	return ks.Get(name)
+}
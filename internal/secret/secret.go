@@ -0,0 +1,282 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package secret resolves secret references in configs, such as
+// ENC[file:///run/secrets/mysql-password], and redacts the resolved values.
+package secret
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+)
+
+// Built-in providers, other providers are the confd backends named in config.
+const (
+	ProviderFile     = "file"
+	ProviderKeystore = "keystore"
+
+	// Redacted replaces the secrets resolved.
+	Redacted = "******"
+
+	// secrets shorter than minRedactLen are not redacted, or too much
+	// text would be replaced.
+	minRedactLen = 4
+)
+
+var (
+	// refRe matches ENC[<provider>://<key>].
+	refRe = regexp.MustCompile(`ENC\[([a-zA-Z][a-zA-Z0-9_-]*)://([^\]]*)\]`)
+
+	defaultResolver = newResolver()
+)
+
+// Provider gets the secret of the key.
+type Provider interface {
+	Get(key string) (string, error)
+}
+
+// ProviderFunc is an adapter to use a function as Provider.
+type ProviderFunc func(key string) (string, error)
+
+func (f ProviderFunc) Get(key string) (string, error) { return f(key) }
+
+// Config is the [secret] section of datakit.conf.
+type Config struct {
+	// Keystore is the path of the encrypted keystore, default
+	// <datakit-install-dir>/data/secret.keystore.
+	Keystore string `toml:"keystore"`
+	// KeystoreKey is the path of the keystore key file, default
+	// <keystore>.key.
+	KeystoreKey string `toml:"keystore_key"`
+
+	Backends []*BackendCfg `toml:"backends"`
+}
+
+type resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+
+	// values resolved, used for redaction
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+func newResolver() *resolver {
+	r := &resolver{
+		providers: map[string]Provider{},
+		secrets:   map[string]struct{}{},
+	}
+
+	r.providers[ProviderFile] = ProviderFunc(readSecretFile)
+	r.providers[ProviderKeystore] = ProviderFunc(func(key string) (string, error) {
+		return getKeystoreSecret(DefaultKeystorePath(), DefaultKeystoreKeyPath(), key)
+	})
+	return r
+}
+
+// readSecretFile reads the secret from file, such as Kubernetes secrets
+// mounted, the trailing newline is trimmed.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+var (
+	keystorePath, keystoreKeyPath string
+	keystoreMu                    sync.RWMutex
+)
+
+// DefaultKeystorePath returns the keystore configured, or the default one
+// under the data dir.
+func DefaultKeystorePath() string {
+	keystoreMu.RLock()
+	defer keystoreMu.RUnlock()
+
+	if keystorePath != "" {
+		return keystorePath
+	}
+	return filepath.Join(datakit.DataDir, "secret.keystore")
+}
+
+// DefaultKeystoreKeyPath returns the keystore key file configured, or
+// <keystore>.key.
+func DefaultKeystoreKeyPath() string {
+	keystoreMu.RLock()
+	kp := keystoreKeyPath
+	keystoreMu.RUnlock()
+
+	if kp != "" {
+		return kp
+	}
+	return DefaultKeystorePath() + ".key"
+}
+
+// Init sets up the keystore and the backends, secrets of the backends, such
+// as the Vault token, can be references of file and keystore.
+func Init(c *Config) error {
+	return defaultResolver.init(c)
+}
+
+func (r *resolver) init(c *Config) error {
+	if c == nil {
+		return nil
+	}
+
+	keystoreMu.Lock()
+	keystorePath, keystoreKeyPath = c.Keystore, c.KeystoreKey
+	keystoreMu.Unlock()
+
+	for _, b := range c.Backends {
+		if b == nil {
+			continue
+		}
+
+		if b.Name == "" {
+			b.Name = b.Backend
+		}
+
+		if b.Name == ProviderFile || b.Name == ProviderKeystore {
+			return fmt.Errorf("secret backend name %q is reserved", b.Name)
+		}
+
+		p, err := newBackendProvider(b, r)
+		if err != nil {
+			return fmt.Errorf("secret backend %s: %w", b.Name, err)
+		}
+
+		r.mu.Lock()
+		r.providers[b.Name] = p
+		r.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Register adds or replaces the provider of the name.
+func Register(name string, p Provider) {
+	defaultResolver.mu.Lock()
+	defer defaultResolver.mu.Unlock()
+	defaultResolver.providers[name] = p
+}
+
+// HasRef returns true if s contains secret references.
+func HasRef(s string) bool {
+	return refRe.MatchString(s)
+}
+
+// ResolveString replaces the references in s with the secrets.
+func ResolveString(s string) (string, error) {
+	return defaultResolver.resolve(s)
+}
+
+// ResolveTOML replaces the references within TOML strings with the secrets.
+// Secrets are escaped in basic strings, and kept as is in literal strings.
+// References in comments are ignored.
+func ResolveTOML(data []byte) ([]byte, error) {
+	if !refRe.Match(data) {
+		return data, nil
+	}
+
+	res, err := defaultResolver.resolveTOML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(res), nil
+}
+
+// Redact replaces the secrets resolved in s with Redacted.
+func Redact(s string) string {
+	return defaultResolver.redact(s)
+}
+
+func (r *resolver) resolve(s string) (string, error) {
+	var lastErr error
+
+	res := refRe.ReplaceAllStringFunc(s, func(ref string) string {
+		match := refRe.FindStringSubmatch(ref)
+		v, err := r.get(match[1], match[2])
+		if err != nil {
+			lastErr = fmt.Errorf("resolve %s: %w", ref, err)
+			return ref
+		}
+		return v
+	})
+
+	if lastErr != nil {
+		return "", lastErr
+	}
+	return res, nil
+}
+
+func (r *resolver) get(provider, key string) (string, error) {
+	r.mu.RLock()
+	p, ok := r.providers[provider]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %q", provider)
+	}
+
+	v, err := p.Get(key)
+	if err != nil {
+		return "", err
+	}
+
+	r.addSecret(v)
+	return v, nil
+}
+
+func (r *resolver) addSecret(v string) {
+	if len(v) < minRedactLen {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.secrets[v]; ok {
+		return
+	}
+	r.secrets[v] = struct{}{}
+
+	// longer secrets go first
+	arr := make([]string, 0, len(r.secrets))
+	for s := range r.secrets {
+		arr = append(arr, s)
+	}
+	sort.Slice(arr, func(i, j int) bool {
+		if len(arr[i]) != len(arr[j]) {
+			return len(arr[i]) > len(arr[j])
+		}
+		return arr[i] < arr[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(arr))
+	for _, s := range arr {
+		oldnew = append(oldnew, s, Redacted)
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+func (r *resolver) redact(s string) string {
+	r.mu.RLock()
+	replacer := r.replacer
+	r.mu.RUnlock()
+
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
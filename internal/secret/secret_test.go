@@ -0,0 +1,274 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bstoml "github.com/BurntSushi/toml"
+	"github.com/GuanceCloud/confd/backends"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	f := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(f, []byte("pa\"ss\\word\n"), 0o600))
+
+	Register("mock", ProviderFunc(func(key string) (string, error) {
+		if key == "token" {
+			return "tkn_0123456789", nil
+		}
+		return "", ErrSecretNotFound
+	}))
+
+	t.Run("string", func(t *testing.T) {
+		s, err := ResolveString("https://openway.guance.com?token=ENC[mock://token]")
+		require.NoError(t, err)
+		assert.Equal(t, "https://openway.guance.com?token=tkn_0123456789", s)
+
+		s, err = ResolveString("no reference")
+		require.NoError(t, err)
+		assert.Equal(t, "no reference", s)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		data, err := ResolveTOML([]byte(`
+[[inputs.mysql]]
+  user = "datakit"
+  pass = "ENC[file://` + f + `]"
+`))
+		require.NoError(t, err)
+		assert.Equal(t, `
+[[inputs.mysql]]
+  user = "datakit"
+  pass = "pa\"ss\\word"
+`, string(data))
+	})
+
+	t.Run("toml-strings", func(t *testing.T) {
+		const multiline = "line1\nline2\r\n\t\"q\" \\ \x01\x7f"
+
+		Register("mock-toml", ProviderFunc(func(key string) (string, error) {
+			switch key {
+			case "multiline":
+				return multiline, nil
+			case "quote":
+				return `it's`, nil
+			default:
+				return `C:\secret\pass`, nil
+			}
+		}))
+
+		data, err := ResolveTOML([]byte(`
+# pass = "ENC[mock-toml-not-exist://x]"
+basic = "ENC[mock-toml://multiline]" # ENC[mock-toml-not-exist://x]
+escaped = "\"ENC[mock-toml://multiline]\""
+ml_basic = """
+ENC[mock-toml://multiline]"""
+literal = 'ENC[mock-toml://literal]'
+ml_literal = '''
+ENC[mock-toml://literal]'''
+`))
+		require.NoError(t, err)
+
+		var x struct {
+			Basic     string `toml:"basic"`
+			Escaped   string `toml:"escaped"`
+			MLBasic   string `toml:"ml_basic"`
+			Literal   string `toml:"literal"`
+			MLLiteral string `toml:"ml_literal"`
+		}
+		_, err = bstoml.Decode(string(data), &x)
+		require.NoError(t, err, string(data))
+
+		assert.Equal(t, multiline, x.Basic)
+		assert.Equal(t, `"`+multiline+`"`, x.Escaped)
+		assert.Equal(t, multiline, x.MLBasic)
+		assert.Equal(t, `C:\secret\pass`, x.Literal)
+		assert.Equal(t, `C:\secret\pass`, x.MLLiteral)
+
+		_, err = ResolveTOML([]byte(`pass = 'ENC[mock-toml://quote]'`))
+		assert.Error(t, err)
+
+		_, err = ResolveTOML([]byte(`pass = 'ENC[mock-toml://multiline]'`))
+		assert.Error(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := ResolveString("ENC[unknown://x]")
+		assert.Error(t, err)
+
+		_, err = ResolveString("ENC[mock://not-exist]")
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+
+		_, err = ResolveTOML([]byte(`pass = "ENC[file://` + filepath.Join(dir, "not-exist") + `]"`))
+		assert.Error(t, err)
+	})
+
+	t.Run("redact", func(t *testing.T) {
+		assert.Equal(t, "token="+Redacted+", pass="+Redacted,
+			Redact(`token=tkn_0123456789, pass=pa"ss\word`))
+		assert.Equal(t, "ENC[mock://token]", Redact("ENC[mock://token]"))
+	})
+}
+
+func TestResolveStruct(t *testing.T) {
+	Register("mock", ProviderFunc(func(key string) (string, error) {
+		return "secret-" + key, nil
+	}))
+
+	type inner struct {
+		Pass string
+	}
+
+	x := &struct {
+		URLs     []string
+		Inner    *inner
+		Headers  map[string]string
+		Sinks    []map[string]interface{}
+		Port     int
+		Empty    *inner
+		internal string
+	}{
+		URLs:     []string{"http://a?token=ENC[mock://a]", "http://b"},
+		Inner:    &inner{Pass: "ENC[mock://b]"},
+		Headers:  map[string]string{"Authorization": "Bearer ENC[mock://c]"},
+		Sinks:    []map[string]interface{}{{"target": "influxdb", "password": "ENC[mock://d]", "port": 8086}},
+		Port:     80,
+		internal: "ENC[mock://e]",
+	}
+
+	require.NoError(t, ResolveStruct(x))
+	assert.Equal(t, []string{"http://a?token=secret-a", "http://b"}, x.URLs)
+	assert.Equal(t, "secret-b", x.Inner.Pass)
+	assert.Equal(t, "Bearer secret-c", x.Headers["Authorization"])
+	assert.Equal(t, "secret-d", x.Sinks[0]["password"])
+	assert.Equal(t, 8086, x.Sinks[0]["port"])
+	assert.Equal(t, "ENC[mock://e]", x.internal)
+
+	assert.Error(t, ResolveStruct(*x))
+}
+
+type mockStoreClient struct {
+	values map[string]string
+}
+
+func (c *mockStoreClient) GetValues(keys []string) (map[string]string, error) {
+	res := map[string]string{}
+	for _, k := range keys {
+		if v, ok := c.values[k]; ok {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+func (c *mockStoreClient) WatchPrefix(string, []string, uint64, chan bool) (uint64, error) {
+	return 0, nil
+}
+
+func (c *mockStoreClient) Close() {}
+
+func TestBackendProvider(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "vault-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s.vault-token\n"), 0o600))
+
+	r := newResolver()
+	p, err := newBackendProvider(&BackendCfg{
+		Name:         "vault",
+		Backend:      "vault",
+		BackendNodes: []string{"http://127.0.0.1:8200"},
+		AuthType:     "token",
+		AuthToken:    "ENC[file://" + tokenFile + "]",
+	}, r)
+	require.NoError(t, err)
+	assert.Equal(t, "s.vault-token", p.cfg.AuthToken)
+
+	created := 0
+	p.newFn = func(cfg backends.Config) (backends.StoreClient, error) {
+		created++
+		return &mockStoreClient{values: map[string]string{"/datakit/mysql/password": "mysql-pass"}}, nil
+	}
+
+	v, err := p.Get("/datakit/mysql/password")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql-pass", v)
+
+	_, err = p.Get("/datakit/redis/password")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	assert.Equal(t, 1, created)
+
+	p.client = nil
+	p.newFn = func(cfg backends.Config) (backends.StoreClient, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err = p.Get("/datakit/mysql/password")
+	assert.Error(t, err)
+
+	_, err = newBackendProvider(&BackendCfg{Backend: "vault"}, r)
+	assert.Error(t, err)
+
+	assert.Error(t, r.init(&Config{Backends: []*BackendCfg{{Name: "file", Backend: "ssm"}}}))
+}
+
+func TestKeystore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.keystore")
+	keyPath := path + ".key"
+
+	ks, err := OpenKeystore(path, keyPath)
+	require.NoError(t, err)
+	assert.Empty(t, ks.Names())
+
+	require.NoError(t, ks.Set("mysql", "mysql-pass"))
+	require.NoError(t, ks.Set("redis", "redis-pass"))
+	assert.FileExists(t, keyPath)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "mysql-pass")
+
+	ks, err = OpenKeystore(path, keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql", "redis"}, ks.Names())
+
+	v, err := ks.Get("mysql")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql-pass", v)
+
+	_, err = ks.Get("oracle")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	// secrets can't be swapped
+	ks.Secrets["oracle"] = ks.Secrets["mysql"]
+	_, err = ks.Get("oracle")
+	assert.Error(t, err)
+
+	require.NoError(t, ks.Delete("redis"))
+	assert.ErrorIs(t, ks.Delete("redis"), ErrSecretNotFound)
+
+	// resolved by the provider
+	require.NoError(t, Init(&Config{Keystore: path, KeystoreKey: keyPath}))
+	defer Init(&Config{}) //nolint:errcheck
+
+	s, err := ResolveString("ENC[keystore://mysql]")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql-pass", s)
+
+	_, err = ResolveString("ENC[keystore://redis]")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+
+	// wrong key
+	require.NoError(t, os.WriteFile(keyPath, []byte("00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"), 0o600))
+	_, err = ResolveString("ENC[keystore://mysql]")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package secret
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const maxResolveDepth = 32
+
+// ResolveStruct replaces the references in the exported string fields of the
+// struct pointed by v, including strings in slices and map values.
+func ResolveStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("pointer expected, got %T", v)
+	}
+
+	return resolveValue(rv, 0)
+}
+
+func resolveValue(v reflect.Value, depth int) error {
+	if depth > maxResolveDepth {
+		return nil
+	}
+
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveValue(v.Elem(), depth+1)
+
+	case reflect.String:
+		if !v.CanSet() || !HasRef(v.String()) {
+			return nil
+		}
+		s, err := ResolveString(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath != "" { // unexported
+				continue
+			}
+			if err := resolveValue(v.Field(i), depth+1); err != nil {
+				return fmt.Errorf("%s: %w", t.Field(i).Name, err)
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+
+			// strings in interface{} are not settable, resolve them directly
+			if elem.Kind() == reflect.Interface && !elem.IsNil() && elem.Elem().Kind() == reflect.String {
+				s := elem.Elem().String()
+				if !HasRef(s) {
+					continue
+				}
+				res, err := ResolveString(s)
+				if err != nil {
+					return fmt.Errorf("%v: %w", iter.Key(), err)
+				}
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(res))
+				continue
+			}
+
+			if err := resolveValue(elem, depth+1); err != nil {
+				return fmt.Errorf("%v: %w", iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package secret
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tomlState is where the scanner is within the TOML text.
+type tomlState int
+
+const (
+	tomlBare tomlState = iota
+	tomlComment
+	tomlBasic     // "..."
+	tomlMLBasic   // """..."""
+	tomlLiteral   // '...'
+	tomlMLLiteral // '''...'''
+)
+
+var refPrefixRe = regexp.MustCompile(`^` + refRe.String())
+
+// resolveTOML scans the TOML text, and resolves the references within
+// strings. The text is not required to be valid TOML, invalid ones are left
+// to the TOML parser.
+func (r *resolver) resolveTOML(s string) (string, error) {
+	var (
+		buf   strings.Builder
+		state = tomlBare
+	)
+
+	buf.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		switch state {
+		case tomlBare:
+			switch {
+			case s[i] == '#':
+				state = tomlComment
+			case strings.HasPrefix(s[i:], `"""`):
+				buf.WriteString(`"""`)
+				i += 3
+				state = tomlMLBasic
+				continue
+			case s[i] == '"':
+				state = tomlBasic
+			case strings.HasPrefix(s[i:], `'''`):
+				buf.WriteString(`'''`)
+				i += 3
+				state = tomlMLLiteral
+				continue
+			case s[i] == '\'':
+				state = tomlLiteral
+			}
+
+		case tomlComment:
+			if s[i] == '\n' {
+				state = tomlBare
+			}
+
+		case tomlBasic, tomlMLBasic, tomlLiteral, tomlMLLiteral:
+			if loc := refPrefixRe.FindStringSubmatchIndex(s[i:]); loc != nil {
+				ref := s[i : i+loc[1]]
+				v, err := r.get(s[i+loc[2]:i+loc[3]], s[i+loc[4]:i+loc[5]])
+				if err != nil {
+					return "", fmt.Errorf("resolve %s: %w", ref, err)
+				}
+
+				x, err := tomlQuote(v, state)
+				if err != nil {
+					return "", fmt.Errorf("resolve %s: %w", ref, err)
+				}
+
+				buf.WriteString(x)
+				i += loc[1]
+				continue
+			}
+
+			if n := tomlStringEnd(s[i:], state); n > 0 {
+				buf.WriteString(s[i : i+n])
+				i += n
+				state = tomlBare
+				continue
+			}
+
+			// escaped char in basic strings, such as \" and \\
+			if (state == tomlBasic || state == tomlMLBasic) && s[i] == '\\' && i+1 < len(s) {
+				buf.WriteString(s[i : i+2])
+				i += 2
+				continue
+			}
+		}
+
+		buf.WriteByte(s[i])
+		i++
+	}
+
+	return buf.String(), nil
+}
+
+// tomlStringEnd returns the length of the closing delimiter at the start of
+// s, or 0 if the string not closed here.
+func tomlStringEnd(s string, state tomlState) int {
+	switch state {
+	case tomlBasic:
+		if s[0] == '"' || s[0] == '\n' {
+			return 1
+		}
+	case tomlLiteral:
+		if s[0] == '\'' || s[0] == '\n' {
+			return 1
+		}
+	case tomlMLBasic, tomlMLLiteral:
+		q := byte('"')
+		if state == tomlMLLiteral {
+			q = '\''
+		}
+
+		// up to 2 quotes allowed before the closing delimiter, such as """"a""""
+		n := 0
+		for n < len(s) && n < 5 && s[n] == q {
+			n++
+		}
+		if n >= 3 {
+			return n
+		}
+	case tomlBare, tomlComment:
+	}
+	return 0
+}
+
+// tomlQuote returns the secret within the string of state.
+func tomlQuote(v string, state tomlState) (string, error) {
+	switch state {
+	case tomlLiteral:
+		if strings.ContainsRune(v, '\'') || hasControl(v, "\t") {
+			return "", fmt.Errorf("secret can't be put in TOML literal string, use basic string instead")
+		}
+		return v, nil
+
+	case tomlMLLiteral:
+		if strings.Contains(v, "'''") || hasControl(v, "\t\n") {
+			return "", fmt.Errorf("secret can't be put in TOML multi-line literal string, use basic string instead")
+		}
+		return v, nil
+
+	case tomlBare, tomlComment, tomlBasic, tomlMLBasic:
+	}
+
+	return escapeTOMLBasic(v), nil
+}
+
+func hasControl(v, allowed string) bool {
+	for _, c := range v {
+		if (c < 0x20 || c == 0x7f) && !strings.ContainsRune(allowed, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeTOMLBasic escapes v for TOML basic strings.
+func escapeTOMLBasic(v string) string {
+	var buf strings.Builder
+	for _, c := range v {
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&buf, `\u%04X`, c)
+			} else {
+				buf.WriteRune(c)
+			}
+		}
+	}
+	return buf.String()
+}
@@ -35,7 +35,8 @@ type SkyAPI struct {
 // InitApiPluginAges 可以初始化多次, 用 name 区分.
 //nolint:gofumpt,stylecheck
 func InitApiPluginAges(pls []string, localCacheConfig *storage.StorageConfig, closeResource map[string][]string,
-	keepRareResource bool, sampler *itrace.Sampler, customerTags []string, itags map[string]string, name string) *SkyAPI {
+	keepRareResource bool, sampler *itrace.Sampler, tailSampler *itrace.TailSampler, customerTags []string,
+	itags map[string]string, name string) *SkyAPI {
 	api := &SkyAPI{inputName: name, plugins: pls, tags: itags}
 	api.log = logger.SLogger(name)
 	if localCacheConfig != nil {
@@ -75,6 +76,15 @@ func InitApiPluginAges(pls []string, localCacheConfig *storage.StorageConfig, cl
 		afterGather.AppendFilter(iCloseResource.Close)
 	}
 
+	// add tail sampler, it holds traces and skips all filters afterwards
+	if tailSampler != nil {
+		if err := tailSampler.Start(name, afterGather); err != nil {
+			api.log.Errorf("### start tail sampler failed: %s", err.Error())
+		} else {
+			afterGather.AppendFilter(tailSampler.Sample)
+		}
+	}
+
 	// add error status penetration
 	afterGather.AppendFilter(itrace.PenetrateErrorTracing)
 	// add rare resource keeper
@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+)
+
+// fingerprintSize is the max length of file head used as fingerprint.
+const fingerprintSize = 1024
+
+// IsCompressedFile returns true if the file is compressed(.gz/.zst/.bz2),
+// compressed file is decompressed and read once.
+func IsCompressedFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gz", ".zst", ".bz2":
+		return true
+	default:
+		return false
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+func newDecompressReader(filename string, r io.Reader) (io.Reader, io.Closer, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gz":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gr, gr, nil
+
+	case ".zst":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, closerFunc(func() error { zr.Close(); return nil }), nil
+
+	case ".bz2":
+		return bzip2.NewReader(r), nopCloser{}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported compressed file %s", filename)
+	}
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// fingerprint returns md5 of the first line of head, the line is truncated
+// to fingerprintSize. Empty returned if the first line is incomplete.
+func fingerprint(head []byte) string {
+	if len(head) > fingerprintSize {
+		head = head[:fingerprintSize]
+	}
+
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	} else if len(head) < fingerprintSize {
+		return ""
+	}
+
+	return fmt.Sprintf("%x", md5.Sum(head)) //nolint:gosec
+}
+
+// archiveFinished returns true if the compressed file has been read completely.
+func archiveFinished(filename string) bool {
+	data := register.Get(getFileKey(filename))
+	return data != nil && data.Finished
+}
+
+// openArchive opens decompress reader of the compressed file and skips the
+// content has been read. The position is from:
+//   - the record of the compressed file itself, if interrupted last time.
+//   - the record of the file with same fingerprint, i.e. the file before it's
+//     rotated and compressed.
+//   - 0 if from_beginning or backfill_archives enabled, otherwise the whole file
+//     is skipped.
+func (t *Single) openArchive() error {
+	r, closer, err := newDecompressReader(t.filepath, t.file)
+	if err != nil {
+		return err
+	}
+	t.decompressor = closer
+	t.reader = bufio.NewReaderSize(r, readBuffSize)
+
+	head, _ := t.reader.Peek(fingerprintSize)
+	fp := fingerprint(head)
+
+	// files too short to be fingerprinted never match records of others,
+	// such as records without fingerprint
+	var same *register.MetaData
+	if fp != "" {
+		same = register.GetByFingerprint(fp)
+	}
+
+	var pos int64
+	switch data := register.Get(getFileKey(t.filepath)); {
+	case data != nil:
+		pos = data.Offset
+		t.opt.log.Debugf("hit offset %d from compressed file %s", pos, t.filepath)
+
+	case same != nil:
+		pos = same.Offset
+		t.opt.log.Infof("compressed file %s has same content with file read before, offset %d", t.filepath, pos)
+
+	case t.opt.FromBeginning || t.opt.BackfillArchives:
+		t.opt.log.Infof("backfill compressed file %s", t.filepath)
+
+	default:
+		t.opt.log.Infof("compressed file %s exists before, skip", t.filepath)
+		t.finished = true
+		return nil
+	}
+
+	n, err := io.CopyN(ioutil.Discard, t.reader, pos)
+	t.offset = n
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// forwardArchive reads the compressed file until EOF, the file is recorded
+// as finished in the register.
+func (t *Single) forwardArchive() {
+	b := &buffer{}
+
+	for !t.finished {
+		select {
+		case <-datakit.Exit.Wait():
+			t.opt.log.Infof("exiting: file %s", t.filepath)
+			t.offset -= int64(len(b.previousBlock))
+			return
+		case <-t.opt.Done:
+			t.opt.log.Infof("exiting: file %s", t.filepath)
+			t.offset -= int64(len(b.previousBlock))
+			return
+		default: // nil
+		}
+
+		buf, readNum, err := t.read()
+		if err != nil {
+			// may be compressing, retry on next scan
+			t.opt.log.Warnf("failed to read data from compressed file %s, error: %s", t.filename, err)
+			t.flushMultiline()
+			t.offset -= int64(len(b.previousBlock))
+			return
+		}
+
+		if readNum == 0 {
+			// the last line without newline
+			if len(b.previousBlock) != 0 {
+				t.handle([]string{string(b.previousBlock)})
+			}
+			t.flushMultiline()
+			t.finished = true
+			t.opt.log.Infof("read EOF from compressed file %s, offset %d", t.filepath, t.offset)
+			break
+		}
+
+		t.readTime = time.Now()
+		b.buf = buf
+		t.handle(b.split())
+
+		t.offset += int64(readNum)
+		t.recordingCache()
+	}
+}
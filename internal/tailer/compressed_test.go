@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+)
+
+const archiveContent = "line1\nline2\nline3\nline4"
+
+func writeArchive(t *testing.T, filename, content string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch filepath.Ext(filename) {
+	case ".gz":
+		w = gzip.NewWriter(&buf)
+	case ".zst":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	}
+
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, ioutil.WriteFile(filename, buf.Bytes(), 0o600))
+}
+
+func readArchive(t *testing.T, filename string, opt *Option) []string {
+	t.Helper()
+
+	var got []string
+	opt.ForwardFunc = func(_, text string) error {
+		got = append(got, text)
+		return nil
+	}
+	opt.Mode = FileMode
+	require.NoError(t, opt.Init())
+
+	tl, err := NewTailerSingle(filename, opt)
+	require.NoError(t, err)
+	tl.Run()
+
+	return got
+}
+
+func TestTailCompressedFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, register.Init(filepath.Join(dir, "logtail.history")))
+
+	t.Run("backfill-gz", func(t *testing.T) {
+		filename := filepath.Join(dir, "app.log.1.gz")
+		writeArchive(t, filename, archiveContent)
+
+		got := readArchive(t, filename, &Option{BackfillArchives: true})
+		assert.Equal(t, []string{"line1", "line2", "line3", "line4"}, got)
+		assert.True(t, archiveFinished(filename))
+	})
+
+	t.Run("backfill-zst", func(t *testing.T) {
+		filename := filepath.Join(dir, "app.log.2.zst")
+		writeArchive(t, filename, archiveContent)
+
+		got := readArchive(t, filename, &Option{FromBeginning: true})
+		assert.Equal(t, []string{"line1", "line2", "line3", "line4"}, got)
+		assert.True(t, archiveFinished(filename))
+	})
+
+	t.Run("skip-existing", func(t *testing.T) {
+		filename := filepath.Join(dir, "app.log.3.gz")
+		writeArchive(t, filename, archiveContent)
+
+		got := readArchive(t, filename, &Option{})
+		assert.Empty(t, got)
+		assert.True(t, archiveFinished(filename))
+	})
+
+	t.Run("resume-by-fingerprint", func(t *testing.T) {
+		// app.log read 2 lines before rotated and compressed
+		content := "resume1\nresume2\nresume3\n"
+		require.NoError(t, register.Set("app.log::1", &register.MetaData{
+			Offset:      int64(len("resume1\nresume2\n")),
+			Fingerprint: fingerprint([]byte(content)),
+		}))
+
+		filename := filepath.Join(dir, "app.log.4.gz")
+		writeArchive(t, filename, content)
+
+		got := readArchive(t, filename, &Option{})
+		assert.Equal(t, []string{"resume3"}, got)
+		assert.True(t, archiveFinished(filename))
+	})
+
+	t.Run("short-without-fingerprint", func(t *testing.T) {
+		// legacy record without fingerprint
+		require.NoError(t, register.Set("legacy.log::1", &register.MetaData{Offset: 3}))
+
+		filename := filepath.Join(dir, "app.log.5.gz")
+		writeArchive(t, filename, "short")
+
+		got := readArchive(t, filename, &Option{BackfillArchives: true})
+		assert.Equal(t, []string{"short"}, got)
+		assert.True(t, archiveFinished(filename))
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Empty(t, fingerprint([]byte("no newline")))
+	assert.Equal(t, fingerprint([]byte("line1\n")), fingerprint([]byte("line1\nline2\n")))
+	assert.NotEqual(t, fingerprint([]byte("line1\n")), fingerprint([]byte("line2\n")))
+	assert.NotEmpty(t, fingerprint(bytes.Repeat([]byte("a"), fingerprintSize+1)))
+}
+
+func TestIsCompressedFile(t *testing.T) {
+	assert.True(t, IsCompressedFile("/var/log/app.log.1.gz"))
+	assert.True(t, IsCompressedFile("/var/log/app.log.1.ZST"))
+	assert.True(t, IsCompressedFile("/var/log/app.log.1.bz2"))
+	assert.False(t, IsCompressedFile("/var/log/app.log.1"))
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// RateLimitDrop drops the lines over the rate limit.
+	RateLimitDrop = "drop"
+	// RateLimitSample keeps one of every 1/RateLimitSampleRate lines over the rate limit.
+	RateLimitSample = "sample"
+
+	// 超出限制时，汇总日志的最小间隔
+	suppressedSummaryInterval = time.Second * 10
+
+	fieldRepeatCount     = "repeat_count"
+	fieldSuppressedLines = "suppressed_lines"
+)
+
+// message is a log text with extra fields.
+type message struct {
+	text   string
+	fields map[string]interface{}
+}
+
+// logLimiter deduplicates consecutive identical messages and limits the rate
+// of messages of a file.
+type logLimiter struct {
+	limiter     *rate.Limiter
+	limit       float64
+	sampleEvery int64 // 0 means dropping all lines over limit
+	overCount   int64
+
+	suppressed      int64
+	suppressedSince time.Time
+
+	dedupWindow time.Duration
+	last        *message
+	lastRepeat  int64
+	lastTime    time.Time
+}
+
+func newLogLimiter(opt *Option) *logLimiter {
+	if opt.RateLimit <= 0 && opt.DedupWindow <= 0 {
+		return nil
+	}
+
+	lmt := &logLimiter{dedupWindow: opt.DedupWindow}
+
+	if opt.RateLimit > 0 {
+		burst := opt.RateLimitBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(opt.RateLimit))
+		}
+		lmt.limit = opt.RateLimit
+		lmt.limiter = rate.NewLimiter(rate.Limit(opt.RateLimit), burst)
+
+		if opt.RateLimitMode == RateLimitSample && opt.RateLimitSampleRate > 0 {
+			lmt.sampleEvery = int64(math.Max(1, math.Round(1/opt.RateLimitSampleRate)))
+		}
+	}
+
+	return lmt
+}
+
+// process returns messages of texts to be sent, the last message is kept
+// if deduplication is enabled.
+func (lmt *logLimiter) process(texts []string, now time.Time) []*message {
+	res := []*message{}
+
+	for _, text := range texts {
+		if lmt.dedupWindow <= 0 {
+			res = lmt.allow(res, &message{text: text}, now)
+			continue
+		}
+
+		if lmt.last != nil && lmt.last.text == text && now.Sub(lmt.lastTime) < lmt.dedupWindow {
+			lmt.lastRepeat++
+			continue
+		}
+
+		res = lmt.flushLast(res, now)
+		lmt.last, lmt.lastRepeat, lmt.lastTime = &message{text: text}, 1, now
+	}
+
+	return res
+}
+
+// flush returns the message kept by deduplication if the window expired,
+// and the summary of suppressed lines if any.
+func (lmt *logLimiter) flush(now time.Time, force bool) []*message {
+	res := []*message{}
+
+	if lmt.last != nil && (force || now.Sub(lmt.lastTime) >= lmt.dedupWindow) {
+		res = lmt.flushLast(res, now)
+	}
+
+	if lmt.suppressed > 0 && (force || now.Sub(lmt.suppressedSince) >= suppressedSummaryInterval) {
+		res = append(res, lmt.summary(now))
+	}
+
+	return res
+}
+
+func (lmt *logLimiter) flushLast(res []*message, now time.Time) []*message {
+	if lmt.last == nil {
+		return res
+	}
+
+	msg := lmt.last
+	if lmt.lastRepeat > 1 {
+		msg.fields = map[string]interface{}{fieldRepeatCount: lmt.lastRepeat}
+	}
+	lmt.last, lmt.lastRepeat = nil, 0
+
+	return lmt.allow(res, msg, now)
+}
+
+func (lmt *logLimiter) allow(res []*message, msg *message, now time.Time) []*message {
+	if lmt.limiter == nil || lmt.limiter.AllowN(now, 1) {
+		return append(res, msg)
+	}
+
+	lmt.overCount++
+	if lmt.sampleEvery > 0 && lmt.overCount%lmt.sampleEvery == 0 {
+		return append(res, msg)
+	}
+
+	if lmt.suppressed == 0 {
+		lmt.suppressedSince = now
+	}
+	lmt.suppressed++
+
+	if now.Sub(lmt.suppressedSince) >= suppressedSummaryInterval {
+		res = append(res, lmt.summary(now))
+	}
+
+	return res
+}
+
+func (lmt *logLimiter) summary(now time.Time) *message {
+	msg := &message{
+		text: fmt.Sprintf("%d lines suppressed by rate limit %g lines/s in the last %s",
+			lmt.suppressed, lmt.limit, now.Sub(lmt.suppressedSince).Round(time.Second)),
+		fields: map[string]interface{}{fieldSuppressedLines: lmt.suppressed},
+	}
+	lmt.suppressed = 0
+	return msg
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func msgTexts(msgs []*message) []string {
+	var res []string
+	for _, msg := range msgs {
+		res = append(res, msg.text)
+	}
+	return res
+}
+
+func TestLogLimiter(t *testing.T) {
+	now := time.Now()
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, newLogLimiter(&Option{}))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		lmt := newLogLimiter(&Option{RateLimit: 2, RateLimitMode: RateLimitDrop})
+
+		res := lmt.process([]string{"a", "b", "c", "d"}, now)
+		assert.Equal(t, []string{"a", "b"}, msgTexts(res))
+		assert.Equal(t, int64(2), lmt.suppressed)
+
+		// tokens refilled after 1s
+		res = lmt.process([]string{"e", "f", "g"}, now.Add(time.Second))
+		assert.Equal(t, []string{"e", "f"}, msgTexts(res))
+
+		// no summary before the interval
+		assert.Empty(t, lmt.flush(now.Add(time.Second), false))
+
+		res = lmt.flush(now.Add(suppressedSummaryInterval), false)
+		require.Len(t, res, 1)
+		assert.Equal(t, "3 lines suppressed by rate limit 2 lines/s in the last 10s", res[0].text)
+		assert.Equal(t, int64(3), res[0].fields[fieldSuppressedLines])
+		assert.Equal(t, int64(0), lmt.suppressed)
+	})
+
+	t.Run("summary-while-limited", func(t *testing.T) {
+		lmt := newLogLimiter(&Option{RateLimit: 1})
+
+		assert.Equal(t, []string{"a"}, msgTexts(lmt.process([]string{"a", "b"}, now)))
+
+		res := lmt.process([]string{"c"}, now.Add(suppressedSummaryInterval))
+		assert.Equal(t, []string{"c"}, msgTexts(res))
+
+		res = lmt.process([]string{"d"}, now.Add(suppressedSummaryInterval))
+		require.Len(t, res, 1)
+		assert.Equal(t, int64(2), res[0].fields[fieldSuppressedLines])
+	})
+
+	t.Run("sample", func(t *testing.T) {
+		lmt := newLogLimiter(&Option{
+			RateLimit:           1,
+			RateLimitMode:       RateLimitSample,
+			RateLimitSampleRate: 0.5,
+		})
+
+		res := lmt.process([]string{"a", "b", "c", "d", "e"}, now)
+		assert.Equal(t, []string{"a", "c", "e"}, msgTexts(res))
+		assert.Equal(t, int64(2), lmt.suppressed)
+	})
+
+	t.Run("dedup", func(t *testing.T) {
+		lmt := newLogLimiter(&Option{DedupWindow: time.Second})
+
+		res := lmt.process([]string{"a", "a", "a", "b", "c", "c"}, now)
+		require.Len(t, res, 2)
+		assert.Equal(t, "a", res[0].text)
+		assert.Equal(t, int64(3), res[0].fields[fieldRepeatCount])
+		assert.Equal(t, "b", res[1].text)
+		assert.Nil(t, res[1].fields)
+
+		// the window of "c" not expired
+		assert.Empty(t, lmt.flush(now.Add(time.Second/2), false))
+
+		// "c" after the window is a new message
+		res = lmt.process([]string{"c"}, now.Add(time.Second))
+		require.Len(t, res, 1)
+		assert.Equal(t, int64(2), res[0].fields[fieldRepeatCount])
+
+		res = lmt.flush(now.Add(time.Second), true)
+		require.Len(t, res, 1)
+		assert.Equal(t, "c", res[0].text)
+		assert.Nil(t, res[0].fields)
+	})
+
+	t.Run("dedup-before-limit", func(t *testing.T) {
+		lmt := newLogLimiter(&Option{RateLimit: 1, DedupWindow: time.Minute})
+
+		res := lmt.process([]string{"a", "a", "a", "b", "c"}, now)
+		assert.Equal(t, []string{"a"}, msgTexts(res))
+		assert.Equal(t, int64(1), lmt.suppressed)
+
+		// the token is refilled when "c" is flushed
+		res = lmt.flush(now.Add(suppressedSummaryInterval), true)
+		assert.Equal(t, []string{"c", "1 lines suppressed by rate limit 1 lines/s in the last 10s"}, msgTexts(res))
+	})
+}
+
+func TestOptionRateLimit(t *testing.T) {
+	opt := &Option{}
+	require.NoError(t, opt.Init())
+	assert.Equal(t, RateLimitDrop, opt.RateLimitMode)
+
+	assert.Error(t, (&Option{RateLimitMode: "unknown"}).Init())
+	assert.Error(t, (&Option{RateLimitMode: RateLimitSample}).Init())
+	assert.NoError(t, (&Option{RateLimitMode: RateLimitSample, RateLimitSampleRate: 0.1}).Init())
+}
+
+func TestFeedWithLimiter(t *testing.T) {
+	var got []string
+	opt := &Option{
+		Source:      "limit",
+		DedupWindow: time.Minute,
+		ForwardFunc: func(_, text string) error {
+			got = append(got, text)
+			return nil
+		},
+	}
+	require.NoError(t, opt.Init())
+
+	tl := &Single{opt: opt, limiter: newLogLimiter(opt)}
+	tl.feed([]string{"a", "a", "b"})
+	assert.Equal(t, []string{"a"}, got)
+
+	tl.flushLimiter(true)
+	assert.Equal(t, []string{"a", "b"}, got)
+}
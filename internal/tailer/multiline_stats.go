@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"sort"
+	"sync"
+)
+
+// AutoMultilineStat is the state of multiline auto detection of a file.
+type AutoMultilineStat struct {
+	Source string `json:"source"`
+	File   string `json:"file"`
+	// Pattern is the line-start pattern detected, empty if detecting.
+	Pattern string `json:"pattern"`
+	// Lines is the number of lines sampled while detecting.
+	Lines     int  `json:"lines"`
+	Detecting bool `json:"detecting"`
+}
+
+var autoMultilineStats = struct {
+	mu    sync.RWMutex
+	files map[string]*AutoMultilineStat
+}{files: map[string]*AutoMultilineStat{}}
+
+// GetAutoMultilineStats returns states of all files with multiline auto
+// detection enabled, sorted by source and file.
+func GetAutoMultilineStats() []*AutoMultilineStat {
+	autoMultilineStats.mu.RLock()
+	defer autoMultilineStats.mu.RUnlock()
+
+	res := make([]*AutoMultilineStat, 0, len(autoMultilineStats.files))
+	for _, stat := range autoMultilineStats.files {
+		x := *stat
+		res = append(res, &x)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Source != res[j].Source {
+			return res[i].Source < res[j].Source
+		}
+		return res[i].File < res[j].File
+	})
+
+	return res
+}
+
+func (t *Single) updateAutoMultilineStat() {
+	if t.mult == nil {
+		return
+	}
+
+	stat := &AutoMultilineStat{Source: t.opt.Source, File: t.filepath}
+	stat.Detecting, stat.Lines = t.mult.Detecting()
+	stat.Pattern, _ = t.mult.DetectedPattern()
+
+	autoMultilineStats.mu.Lock()
+	defer autoMultilineStats.mu.Unlock()
+
+	if old, ok := autoMultilineStats.files[t.filepath]; ok && old.Detecting && !stat.Detecting {
+		t.opt.log.Infof("file %s, multiline pattern detected: %s", t.filepath, stat.Pattern)
+	}
+	autoMultilineStats.files[t.filepath] = stat
+}
+
+func removeAutoMultilineStat(file string) {
+	autoMultilineStats.mu.Lock()
+	defer autoMultilineStats.mu.Unlock()
+
+	delete(autoMultilineStats.files, file)
+}
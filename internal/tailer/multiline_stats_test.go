@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package tailer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+)
+
+func TestAutoMultiline(t *testing.T) {
+	dir := t.TempDir()
+	// the register may have been initialized by other tests
+	require.NoError(t, register.Init(filepath.Join(dir, "logtail.history")))
+
+	lines := []string{
+		"2021-07-08 05:08:19,214 ERROR failed",
+		"java.lang.IllegalStateException: boom",
+		"\tat demo.App.run(App.java:10)",
+		"2021-07-08 05:08:20,214 INFO ok",
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	filename := filepath.Join(dir, "auto.log")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0o600))
+
+	newTailer := func() *Single {
+		opt := &Option{
+			Source:                      "auto",
+			Mode:                        FileMode,
+			FromBeginning:               true,
+			AutoMultilineLockPattern:    true,
+			AutoMultilineDetectionLines: len(lines),
+		}
+		require.NoError(t, opt.Init())
+
+		tl, err := NewTailerSingle(filename, opt)
+		require.NoError(t, err)
+		return tl
+	}
+
+	findStat := func(file string) *AutoMultilineStat {
+		for _, stat := range GetAutoMultilineStats() {
+			if stat.File == file {
+				return stat
+			}
+		}
+		return nil
+	}
+
+	tl := newTailer()
+	stat := findStat(tl.filepath)
+	require.NotNil(t, stat)
+	assert.True(t, stat.Detecting)
+	assert.Equal(t, "auto", stat.Source)
+
+	for _, line := range lines {
+		tl.multiline(line)
+	}
+
+	stat = findStat(tl.filepath)
+	require.NotNil(t, stat)
+	assert.False(t, stat.Detecting)
+	assert.Equal(t, `^\d+-\d+-\d+ \d+:\d+:\d+(,\d+)?`, stat.Pattern)
+
+	// all lines read
+	tl.offset = int64(len(content))
+	tl.Close()
+	assert.Nil(t, findStat(tl.filepath))
+
+	data := register.Get(getFileKey(tl.filepath))
+	require.NotNil(t, data)
+	assert.Equal(t, stat.Pattern, data.MultilinePattern)
+
+	// the detected pattern is restored from register
+	tl = newTailer()
+
+	pattern, ok := tl.mult.DetectedPattern()
+	assert.True(t, ok)
+	assert.Equal(t, stat.Pattern, pattern)
+
+	stat = findStat(tl.filepath)
+	require.NotNil(t, stat)
+	assert.False(t, stat.Detecting)
+	tl.Close()
+
+	// rotated with the same name, the pattern is detected again
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`{"msg": "rotated"}`+"\n"), 0o600))
+
+	tl = newTailer()
+	defer tl.Close()
+
+	_, ok = tl.mult.DetectedPattern()
+	assert.False(t, ok)
+
+	stat = findStat(tl.filepath)
+	require.NotNil(t, stat)
+	assert.True(t, stat.Detecting)
+}
@@ -262,9 +262,10 @@ func (sl *socketLogger) feed(pending []string) {
 	}
 
 	var ioOpt *iod.Option
-	if sl.opt.Pipeline != "" {
+	if sl.opt.Pipeline != "" || len(sl.opt.PipelineChain) > 0 {
 		ioOpt = &iod.Option{
 			PlScript: map[string]string{sl.opt.Source: sl.opt.Pipeline},
+			PlChain:  sl.opt.PipelineChain,
 			PlOption: &script.Option{
 				DisableAddStatusField: sl.opt.DisableAddStatusField,
 				IgnoreStatus:          sl.ignorePatterns,
@@ -44,6 +44,8 @@ type Option struct {
 	Service string
 	// pipeline脚本路径，如果为空则不使用pipeline
 	Pipeline string
+	// 在 Pipeline 之后依次执行的脚本，用于多个数据源共用的处理
+	PipelineChain []string
 	// 解释文件内容时所使用的的字符编码，如果设置为空，将不进行转码处理
 	// ex: "utf-8"
 	//     "utf-16le"
@@ -64,6 +66,11 @@ type Option struct {
 	// 这是一个列表
 	MultilinePatterns []string
 
+	// 是否锁定行首模式，开启后从每个文件的前 AutoMultilineDetectionLines 行学习行首模式并固定使用
+	// 此时 MultilinePatterns 作为优先的候选模式，探测到的模式会记录到 register 中，只在同一个文件（指纹相同）再次采集时使用
+	AutoMultilineLockPattern    bool
+	AutoMultilineDetectionLines int
+
 	log *logger.Logger
 
 	// 添加tag
@@ -71,6 +78,8 @@ type Option struct {
 	// 是否从文件起始处开始读取
 	// 注意，如果打开此项，可能会导致大量数据重复
 	FromBeginning bool
+	// 是否读取首次发现的已有压缩文件（.gz/.zst/.bz2），每个压缩文件只读取一次
+	BackfillArchives bool
 	// 是否删除文本中的ansi转义码，默认为false，即不删除
 	RemoveAnsiEscapeCodes bool
 	// 是否关闭添加默认status字段列，包括status字段的固定转换行为，例如'd'->'debug'
@@ -87,6 +96,16 @@ type Option struct {
 	// 是否使用磁盘缓存
 	EnableDiskCache bool
 
+	// 每个文件每秒最多采集的日志条数，0 表示不限制，超出的日志按 RateLimitMode 丢弃或采样
+	// 被丢弃的日志条数会定期以一条汇总日志发送，汇总日志带有 suppressed_lines 字段
+	RateLimit      float64
+	RateLimitBurst int
+	// "drop"（默认）或 "sample"，sample 时超出限制的日志按 RateLimitSampleRate 的比例保留
+	RateLimitMode       string
+	RateLimitSampleRate float64
+	// 去重窗口，窗口内连续相同的日志合并为一条，重复次数记录在 repeat_count 字段，0 表示不去重
+	DedupWindow time.Duration
+
 	MinFlushInterval         time.Duration
 	MaxMultilineLifeDuration time.Duration
 
@@ -120,6 +139,17 @@ func (opt *Option) Init() error {
 		opt.MinFlushInterval = minflushInterval
 	}
 
+	switch opt.RateLimitMode {
+	case "":
+		opt.RateLimitMode = RateLimitDrop
+	case RateLimitDrop, RateLimitSample:
+	default:
+		return fmt.Errorf("invalid rate limit mode %q, expect %q or %q", opt.RateLimitMode, RateLimitDrop, RateLimitSample)
+	}
+	if opt.RateLimitMode == RateLimitSample && (opt.RateLimitSampleRate <= 0 || opt.RateLimitSampleRate > 1) {
+		return fmt.Errorf("invalid rate limit sample rate %v, expect (0, 1]", opt.RateLimitSampleRate)
+	}
+
 	if opt.GlobalTags == nil {
 		opt.GlobalTags = make(map[string]string)
 	}
@@ -210,7 +240,12 @@ func (t *Tailer) scan() {
 	}
 
 	for _, filename := range filelist {
-		if t.opt.IgnoreDeadLog > 0 && !FileIsActive(filename, t.opt.IgnoreDeadLog) {
+		if IsCompressedFile(filename) {
+			// compressed file is read only once, and not affected by ignore_dead_log
+			if archiveFinished(filename) {
+				continue
+			}
+		} else if t.opt.IgnoreDeadLog > 0 && !FileIsActive(filename, t.opt.IgnoreDeadLog) {
 			continue
 		}
 
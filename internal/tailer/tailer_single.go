@@ -6,6 +6,7 @@
 package tailer
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -44,6 +45,7 @@ type Single struct {
 
 	decoder *encoding.Decoder
 	mult    *multiline.Multiline
+	limiter *logLimiter
 
 	readBuff  []byte
 	readLines int64
@@ -54,6 +56,14 @@ type Single struct {
 	partialContentBuff bytes.Buffer
 
 	tags map[string]string
+
+	// fingerprint of the file content, see fingerprint()
+	fingerprint string
+
+	// decompressed reader of the compressed file
+	reader       *bufio.Reader
+	decompressor io.Closer
+	finished     bool
 }
 
 func NewTailerSingle(filename string, opt *Option) (*Single, error) {
@@ -80,13 +90,7 @@ func NewTailerSingle(filename string, opt *Option) (*Single, error) {
 			return nil, err
 		}
 	}
-	t.mult, err = multiline.New(
-		opt.MultilinePatterns,
-		&multiline.Option{MaxLifeDuration: opt.MaxMultilineLifeDuration},
-	)
-	if err != nil {
-		return nil, err
-	}
+	t.limiter = newLogLimiter(opt)
 
 	t.file, err = os.Open(filename) //nolint:gosec
 	if err != nil {
@@ -95,24 +99,63 @@ func NewTailerSingle(filename string, opt *Option) (*Single, error) {
 	t.filepath = t.file.Name()
 	t.filename = filepath.Base(t.filepath)
 
-	if err := t.seekOffset(); err != nil {
+	if IsCompressedFile(t.filepath) {
+		if err := t.openArchive(); err != nil {
+			t.closeFile()
+			return nil, err
+		}
+	} else if err := t.seekOffset(); err != nil {
+		return nil, err
+	}
+
+	if err := t.initMultiline(); err != nil {
+		t.closeFile()
 		return nil, err
 	}
 
 	t.readBuff = make([]byte, readBuffSize)
 	t.tags = t.buildTags(opt.GlobalTags)
 
+	if opt.AutoMultilineLockPattern {
+		t.updateAutoMultilineStat()
+	}
+
 	return t, nil
 }
 
+func (t *Single) initMultiline() error {
+	opt := &multiline.Option{MaxLifeDuration: t.opt.MaxMultilineLifeDuration}
+	if t.opt.AutoMultilineLockPattern {
+		opt.AutoDetect = true
+		opt.AutoDetectLines = t.opt.AutoMultilineDetectionLines
+
+		// the file may be rotated with the same name, only the pattern
+		// detected from the same file is used
+		if data := register.Get(getFileKey(t.filepath)); data != nil &&
+			data.MultilinePattern != "" && data.Fingerprint != "" && data.Fingerprint == t.getFingerprint() {
+			opt.DetectedPattern = data.MultilinePattern
+		}
+	}
+
+	var err error
+	t.mult, err = multiline.New(t.opt.MultilinePatterns, opt)
+	return err
+}
+
 func (t *Single) Run() {
-	t.forwardMessage()
+	if t.reader != nil {
+		t.forwardArchive()
+	} else {
+		t.forwardMessage()
+	}
 	t.Close()
 }
 
 func (t *Single) Close() {
+	t.flushLimiter(true)
 	t.recordingCache()
 	t.closeFile()
+	removeAutoMultilineStat(t.filepath)
 	t.opt.log.Infof("closing: file %s", t.filepath)
 }
 
@@ -166,11 +209,17 @@ func (t *Single) getPosition() (pos int64) {
 }
 
 func (t *Single) recordingCache() {
-	if t.offset <= 0 {
+	if t.offset <= 0 && !t.finished {
 		return
 	}
 
-	c := &register.MetaData{Source: t.opt.Source, Offset: t.offset}
+	c := &register.MetaData{Source: t.opt.Source, Offset: t.offset, Finished: t.finished}
+	if t.reader == nil {
+		c.Fingerprint = t.getFingerprint()
+	}
+	if t.mult != nil {
+		c.MultilinePattern, _ = t.mult.DetectedPattern()
+	}
 
 	if err := register.Set(getFileKey(t.filepath), c); err != nil {
 		t.opt.log.Warnf("recording cache %s err: %s", c, err)
@@ -180,7 +229,31 @@ func (t *Single) recordingCache() {
 	t.opt.log.Debugf("recording cache %s success", c)
 }
 
+// getFingerprint returns fingerprint of the file, it's cached once got.
+func (t *Single) getFingerprint() string {
+	if t.fingerprint != "" || t.file == nil {
+		return t.fingerprint
+	}
+
+	head := make([]byte, fingerprintSize)
+	n, err := t.file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		t.opt.log.Debugf("read head of file %s err: %s, ignored", t.filepath, err)
+		return ""
+	}
+
+	t.fingerprint = fingerprint(head[:n])
+	return t.fingerprint
+}
+
 func (t *Single) closeFile() {
+	if t.decompressor != nil {
+		if err := t.decompressor.Close(); err != nil {
+			t.opt.log.Warnf("close decompressor err: %s, ignored", err)
+		}
+		t.decompressor = nil
+	}
+
 	if t.file == nil {
 		return
 	}
@@ -205,6 +278,7 @@ func (t *Single) reopen() error {
 	}
 
 	t.offset = ret
+	t.fingerprint = ""
 	t.opt.log.Infof("reopen file %s, offset %d", t.filepath, t.offset)
 	return nil
 }
@@ -213,7 +287,6 @@ func (t *Single) reopen() error {
 func (t *Single) forwardMessage() {
 	var (
 		b       = &buffer{}
-		lines   []string
 		readNum int
 		err     error
 
@@ -233,9 +306,8 @@ func (t *Single) forwardMessage() {
 			return
 
 		case <-flushTicker.C:
-			if t.mult != nil && t.mult.BuffLength() > 0 {
-				t.feed([]string{t.mult.FlushString()})
-			}
+			t.flushMultiline()
+			t.flushLimiter(false)
 
 		case <-checkTicker.C:
 			did, _ := DidRotate(t.file, t.offset)
@@ -258,17 +330,7 @@ func (t *Single) forwardMessage() {
 					}
 					t.readTime = time.Now()
 
-					lines = b.split()
-					switch t.opt.Mode {
-					case FileMode:
-						t.defaultHandler(lines)
-					case DockerMode:
-						t.dockerHandler(lines)
-					case ContainerdMode:
-						t.containerdHandler(lines)
-					default:
-						t.defaultHandler(lines)
-					}
+					t.handle(b.split())
 					// 数据处理完成，再记录 offset
 					t.offset += int64(readNum)
 					// 记录 cache
@@ -307,18 +369,7 @@ func (t *Single) forwardMessage() {
 		t.readTime = time.Now()
 		flushTicker.Reset(t.opt.MinFlushInterval)
 
-		lines = b.split()
-
-		switch t.opt.Mode {
-		case FileMode:
-			t.defaultHandler(lines)
-		case DockerMode:
-			t.dockerHandler(lines)
-		case ContainerdMode:
-			t.containerdHandler(lines)
-		default:
-			t.defaultHandler(lines)
-		}
+		t.handle(b.split())
 
 		// 数据处理完成，再记录 offset
 		t.offset += int64(readNum)
@@ -327,6 +378,25 @@ func (t *Single) forwardMessage() {
 	}
 }
 
+func (t *Single) handle(lines []string) {
+	switch t.opt.Mode {
+	case FileMode:
+		t.defaultHandler(lines)
+	case DockerMode:
+		t.dockerHandler(lines)
+	case ContainerdMode:
+		t.containerdHandler(lines)
+	default:
+		t.defaultHandler(lines)
+	}
+}
+
+func (t *Single) flushMultiline() {
+	if t.mult != nil && t.mult.BuffLength() > 0 {
+		t.feed([]string{t.mult.FlushString()})
+	}
+}
+
 type dockerMessage struct {
 	Log    string `json:"log"`
 	Stream string `json:"stream"`
@@ -459,7 +529,34 @@ func (t *Single) defaultHandler(lines []string) {
 	t.feed(pending)
 }
 
+// flushLimiter sends the message kept by deduplication and the summary of
+// suppressed lines, force is true on closing.
+func (t *Single) flushLimiter(force bool) {
+	if t.limiter == nil {
+		return
+	}
+	t.feedMessages(t.limiter.flush(time.Now(), force))
+}
+
 func (t *Single) feed(pending []string) {
+	var msgs []*message
+	if t.limiter != nil {
+		msgs = t.limiter.process(pending, time.Now())
+	} else {
+		msgs = make([]*message, 0, len(pending))
+		for _, text := range pending {
+			msgs = append(msgs, &message{text: text})
+		}
+	}
+
+	t.feedMessages(msgs)
+}
+
+func (t *Single) feedMessages(pending []*message) {
+	if len(pending) == 0 {
+		return
+	}
+
 	// feed to remote
 	if t.opt.ForwardFunc != nil {
 		t.feedToRemote(pending)
@@ -473,31 +570,24 @@ func (t *Single) feed(pending []string) {
 	}
 }
 
-func (t *Single) feedToRemote(pending []string) {
-	for _, text := range pending {
-		err := t.opt.ForwardFunc(t.filename, text)
+func (t *Single) feedToRemote(pending []*message) {
+	for _, msg := range pending {
+		err := t.opt.ForwardFunc(t.filename, msg.text)
 		if err != nil {
 			t.opt.log.Warnf("failed to forward text from file %s, error: %s", t.filename, err)
 		}
 	}
 }
 
-func (t *Single) feedToCache(pending []string) {
+func (t *Single) feedToCache(pending []*message) {
 	res := []*pbpoint.Point{}
 	// -1ns
 	timeNow := time.Now().Add(-time.Duration(len(pending)))
 
-	for i, cnt := range pending {
+	for i, msg := range pending {
 		t.readLines++
 
-		fields := map[string]interface{}{
-			"log_read_lines":      t.readLines,
-			"log_read_offset":     t.offset,
-			"log_read_time":       t.readTime.UnixNano(),
-			"message_length":      len(cnt),
-			pipeline.FieldMessage: cnt,
-			pipeline.FieldStatus:  pipeline.DefaultStatus,
-		}
+		fields := t.buildFields(msg)
 
 		pt := pbpoint.NewPointV2(
 			[]byte(t.opt.Source),
@@ -528,6 +618,7 @@ func (t *Single) feedToCache(pending []string) {
 		Config: &diskcache.PBConfig{
 			Source:                t.opt.Source,
 			Pipeline:              t.opt.Pipeline,
+			PipelineChain:         t.opt.PipelineChain,
 			Blocking:              t.opt.BlockingMode,
 			DisableAddStatusField: t.opt.DisableAddStatusField,
 			IgnoreStatus:          t.opt.IgnoreStatus,
@@ -546,23 +637,31 @@ func (t *Single) feedToCache(pending []string) {
 	}
 }
 
-func (t *Single) feedToIO(pending []string) {
+func (t *Single) buildFields(msg *message) map[string]interface{} {
+	fields := map[string]interface{}{
+		"log_read_lines":      t.readLines,
+		"log_read_offset":     t.offset,
+		"log_read_time":       t.readTime.UnixNano(),
+		"message_length":      len(msg.text),
+		pipeline.FieldMessage: msg.text,
+		pipeline.FieldStatus:  pipeline.DefaultStatus,
+	}
+	for k, v := range msg.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+func (t *Single) feedToIO(pending []*message) {
 	res := []*point.Point{}
 	// -1ns
 	timeNow := time.Now().Add(-time.Duration(len(pending)))
-	for i, cnt := range pending {
+	for i, msg := range pending {
 		t.readLines++
 		pt, err := point.NewPoint(
 			t.opt.Source,
 			t.tags,
-			map[string]interface{}{
-				"log_read_lines":      t.readLines,
-				"log_read_offset":     t.offset,
-				"log_read_time":       t.readTime.UnixNano(),
-				"message_length":      len(cnt),
-				pipeline.FieldMessage: cnt,
-				pipeline.FieldStatus:  pipeline.DefaultStatus,
-			},
+			t.buildFields(msg),
 			&point.PointOption{Time: timeNow.Add(time.Duration(i)), Category: datakit.Logging, Strict: true},
 		)
 		if err != nil {
@@ -578,6 +677,7 @@ func (t *Single) feedToIO(pending []string) {
 
 	if err := iod.Feed("logging/"+t.opt.Source, datakit.Logging, res, &iod.Option{
 		PlScript: map[string]string{t.opt.Source: t.opt.Pipeline},
+		PlChain:  t.opt.PipelineChain,
 		PlOption: &script.Option{
 			DisableAddStatusField: t.opt.DisableAddStatusField,
 			IgnoreStatus:          t.opt.IgnoreStatus,
@@ -589,7 +689,12 @@ func (t *Single) feedToIO(pending []string) {
 }
 
 func (t *Single) read() ([]byte, int, error) {
-	n, err := t.file.Read(t.readBuff)
+	var r io.Reader = t.file
+	if t.reader != nil {
+		r = t.reader
+	}
+
+	n, err := r.Read(t.readBuff)
 	if err != nil && err != io.EOF {
 		// an unexpected error occurred, stop the tailor
 		t.opt.log.Warnf("Unexpected error occurred while reading file: %s", err)
@@ -627,6 +732,11 @@ func (t *Single) multiline(text string) string {
 	if t.mult == nil {
 		return text
 	}
+
+	if detecting, _ := t.mult.Detecting(); detecting {
+		defer t.updateAutoMultilineStat()
+	}
+
 	return t.mult.ProcessLineString(text)
 }
 
@@ -104,7 +104,13 @@ func (aga *AfterGather) Run(inputName string, dktraces DatakitTraces, stricktMod
 		return
 	}
 
-	if pts := aga.BuildPointsBatch(afterFilters, stricktMod); len(pts) != 0 {
+	aga.Feed(inputName, afterFilters, stricktMod)
+}
+
+// Feed builds points from dktraces and feed them into io without running any filters,
+// it's used by filters that hold traces and send them afterwards such as TailSampler.
+func (aga *AfterGather) Feed(inputName string, dktraces DatakitTraces, stricktMod bool) {
+	if pts := aga.BuildPointsBatch(dktraces, stricktMod); len(pts) != 0 {
 		var (
 			start = time.Now()
 			opt   = &dkio.Option{Blocking: aga.BlockIOModel}
@@ -0,0 +1,347 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defTailDecisionWait = 10 * time.Second
+	defTailMaxTraces    = 50000
+	tailFlushInterval   = time.Second
+)
+
+// TailSampler buffers spans by trace ID for a decision window(decision_wait), then keep or
+// drop the whole trace according to the policies below:
+//   - keep_error: keep traces that contain error span.
+//   - latency_threshold: keep traces whose duration is not less than the threshold.
+//   - keep_resource: keep traces that contain resource matching the regular expressions
+//     under service, service "*" stands for all services.
+//   - sampling_rate: traces that not hit by policies above are sampled with this rate,
+//     all of them are kept if not set.
+//   - service_rate_limit: max traces per second kept for service of the root span,
+//     service "*" is the default limit of every service, zero or negative means no limit.
+//
+// TailSampler.Sample holds all the incoming traces, so filters appended after it will not
+// be executed. Kept traces are sent by the AfterGather passed to Start.
+type TailSampler struct {
+	DecisionWait     time.Duration       `toml:"decision_wait" json:"decision_wait"`
+	MaxTraces        int                 `toml:"max_traces" json:"max_traces"`
+	KeepError        bool                `toml:"keep_error" json:"keep_error"`
+	LatencyThreshold time.Duration       `toml:"latency_threshold" json:"latency_threshold"`
+	KeepResource     map[string][]string `toml:"keep_resource" json:"keep_resource"`
+	SamplingRate     *float64            `toml:"sampling_rate" json:"sampling_rate"`
+	ServiceRateLimit map[string]float64  `toml:"service_rate_limit" json:"service_rate_limit"`
+
+	mu           sync.Mutex
+	inputName    string
+	samplingRate float64
+	aga          *AfterGather
+	keepRes      map[string][]*regexp.Regexp
+	limiters     map[string]*rate.Limiter
+	traces       map[string]*tailTrace
+	queue        []*tailTrace
+	decided      map[string]*tailDecision
+	stopped      bool
+	stop         chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+}
+
+type tailTrace struct {
+	traceID  string
+	spans    DatakitTrace
+	deadline time.Time
+}
+
+type tailDecision struct {
+	keep   bool
+	expire time.Time
+}
+
+// Start checks the configuration and starts the decision routine, kept traces will be
+// sent by aga with inputName.
+func (ts *TailSampler) Start(inputName string, aga *AfterGather) error {
+	if aga == nil {
+		return fmt.Errorf("tail sampler: nil AfterGather")
+	}
+	if ts.DecisionWait <= 0 {
+		ts.DecisionWait = defTailDecisionWait
+	}
+	if ts.MaxTraces <= 0 {
+		ts.MaxTraces = defTailMaxTraces
+	}
+	ts.samplingRate = 1
+	if ts.SamplingRate != nil {
+		ts.samplingRate = *ts.SamplingRate
+	}
+	if ts.samplingRate < 0 || ts.samplingRate > 1 {
+		return fmt.Errorf("tail sampler: invalid sampling_rate %f, should be within [0, 1]", ts.samplingRate)
+	}
+
+	ts.keepRes = make(map[string][]*regexp.Regexp)
+	for service, resList := range ts.KeepResource {
+		for i := range resList {
+			reg, err := regexp.Compile(resList[i])
+			if err != nil {
+				return fmt.Errorf("tail sampler: invalid keep_resource %q: %w", resList[i], err)
+			}
+			ts.keepRes[service] = append(ts.keepRes[service], reg)
+		}
+	}
+
+	ts.limiters = make(map[string]*rate.Limiter)
+	for service, limit := range ts.ServiceRateLimit {
+		if limit > 0 && service != "*" {
+			ts.limiters[service] = newTailLimiter(limit)
+		}
+	}
+
+	ts.inputName = inputName
+	ts.aga = aga
+	ts.traces = make(map[string]*tailTrace)
+	ts.decided = make(map[string]*tailDecision)
+	ts.stop = make(chan struct{})
+	ts.done = make(chan struct{})
+
+	g := goroutine.NewGroup(goroutine.Option{Name: "internal_trace"})
+	g.Go(func(ctx context.Context) error {
+		defer close(ts.done)
+
+		tick := time.NewTicker(tailFlushInterval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-datakit.Exit.Wait():
+				ts.stopAndFlush()
+				return nil
+			case <-ts.stop:
+				ts.stopAndFlush()
+				return nil
+			case now := <-tick.C:
+				ts.flush(now, false)
+			}
+		}
+	})
+
+	return nil
+}
+
+// Stop stops the decision routine and makes decision on all the buffered traces,
+// it returns after the kept traces sent. Traces sampled after Stop are decided at once.
+func (ts *TailSampler) Stop() {
+	if ts.stop == nil {
+		return
+	}
+
+	ts.stopOnce.Do(func() { close(ts.stop) })
+	<-ts.done
+}
+
+func (ts *TailSampler) stopAndFlush() {
+	ts.mu.Lock()
+	ts.stopped = true
+	ts.mu.Unlock()
+
+	ts.flush(time.Now(), true)
+}
+
+// Sample is the FilterFunc of TailSampler, it always returns nil and skips the filters afterwards.
+func (ts *TailSampler) Sample(log *logger.Logger, dktrace DatakitTrace) (DatakitTrace, bool) {
+	if len(dktrace) == 0 {
+		return nil, true
+	}
+
+	var (
+		now  = time.Now()
+		late = make(map[string]DatakitTrace)
+		kept DatakitTraces
+	)
+
+	ts.mu.Lock()
+	for _, dkspan := range dktrace {
+		tid := dkspan.TraceID
+		if d, ok := ts.decided[tid]; ok {
+			if d.keep {
+				late[tid] = append(late[tid], dkspan)
+			} else {
+				log.Debugf("tail sampler drop late span of tid: %s service: %s resource: %s", tid, dkspan.Service, dkspan.Resource)
+			}
+			continue
+		}
+
+		if tt, ok := ts.traces[tid]; ok {
+			tt.spans = append(tt.spans, dkspan)
+			continue
+		}
+
+		// buffer is full, make decision on the oldest trace in advance
+		if len(ts.traces) >= ts.MaxTraces && len(ts.queue) != 0 {
+			tt := ts.queue[0]
+			ts.queue = ts.queue[1:]
+			if ts.decide(log, tt, now) {
+				kept = append(kept, tt.spans)
+			}
+		}
+
+		tt := &tailTrace{traceID: tid, spans: DatakitTrace{dkspan}, deadline: now.Add(ts.DecisionWait)}
+		if ts.stopped {
+			// no decision routine, spans after this one are late spans
+			if ts.decide(log, tt, now) {
+				late[tid] = append(late[tid], tt.spans...)
+			}
+			continue
+		}
+		ts.traces[tid] = tt
+		ts.queue = append(ts.queue, tt)
+	}
+	ts.mu.Unlock()
+
+	for _, spans := range late {
+		kept = append(kept, spans)
+	}
+	if len(kept) != 0 {
+		ts.aga.Feed(ts.inputName, kept, false)
+	}
+
+	return nil, true
+}
+
+// flush makes decision on traces which reach the deadline, or all buffered traces if all is true.
+func (ts *TailSampler) flush(now time.Time, all bool) {
+	var kept DatakitTraces
+
+	ts.mu.Lock()
+	var i int
+	for ; i < len(ts.queue); i++ {
+		if !all && ts.queue[i].deadline.After(now) {
+			break
+		}
+		if ts.decide(ts.aga.log, ts.queue[i], now) {
+			kept = append(kept, ts.queue[i].spans)
+		}
+	}
+	ts.queue = ts.queue[i:]
+
+	for tid, d := range ts.decided {
+		if !d.expire.After(now) {
+			delete(ts.decided, tid)
+		}
+	}
+	ts.mu.Unlock()
+
+	if len(kept) != 0 {
+		ts.aga.Feed(ts.inputName, kept, false)
+	}
+}
+
+// decide returns true if the trace should be kept, it should be called with lock held.
+func (ts *TailSampler) decide(log *logger.Logger, tt *tailTrace, now time.Time) bool {
+	delete(ts.traces, tt.traceID)
+
+	var (
+		root       *DatakitSpan
+		hasErr     bool
+		hitRes     bool
+		start, end int64
+	)
+	for i, dkspan := range tt.spans {
+		if root == nil || (!IsRootSpan(root) && (IsRootSpan(dkspan) || dkspan.Start < root.Start)) {
+			root = dkspan
+		}
+		switch dkspan.Status {
+		case STATUS_ERR, STATUS_CRITICAL:
+			hasErr = true
+		}
+		if i == 0 || dkspan.Start < start {
+			start = dkspan.Start
+		}
+		if i == 0 || dkspan.Start+dkspan.Duration > end {
+			end = dkspan.Start + dkspan.Duration
+		}
+		if !hitRes {
+			hitRes = ts.matchResource(dkspan)
+		}
+	}
+
+	var keep bool
+	reason := "sampling rate"
+	switch {
+	case ts.KeepError && hasErr:
+		keep, reason = true, "error status"
+	case ts.LatencyThreshold > 0 && time.Duration(end-start) >= ts.LatencyThreshold:
+		keep, reason = true, "latency threshold"
+	case hitRes:
+		keep, reason = true, "keep resource"
+	default:
+		keep = multiplicativeHashFunc(UnifyToUint64ID(tt.traceID), ts.samplingRate)
+	}
+
+	if keep {
+		if limiter := ts.limiter(root.Service); limiter != nil && !limiter.AllowN(now, 1) {
+			keep, reason = false, "service rate limit"
+		}
+	}
+
+	if keep {
+		log.Debugf("tail sampler keep tid: %s service: %s resource: %s according to %s",
+			tt.traceID, root.Service, root.Resource, reason)
+	} else {
+		log.Debugf("tail sampler drop tid: %s service: %s resource: %s according to %s",
+			tt.traceID, root.Service, root.Resource, reason)
+	}
+	ts.decided[tt.traceID] = &tailDecision{keep: keep, expire: now.Add(ts.DecisionWait)}
+
+	return keep
+}
+
+func (ts *TailSampler) matchResource(dkspan *DatakitSpan) bool {
+	for service, resList := range ts.keepRes {
+		if service == "*" || service == dkspan.Service {
+			for i := range resList {
+				if resList[i].MatchString(dkspan.Resource) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (ts *TailSampler) limiter(service string) *rate.Limiter {
+	if limiter, ok := ts.limiters[service]; ok {
+		return limiter
+	}
+	if limit, ok := ts.ServiceRateLimit["*"]; ok && limit > 0 {
+		limiter := newTailLimiter(limit)
+		ts.limiters[service] = limiter
+
+		return limiter
+	}
+
+	return nil
+}
+
+func newTailLimiter(limit float64) *rate.Limiter {
+	burst := int(limit)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
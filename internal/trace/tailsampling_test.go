@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package trace
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	bstoml "github.com/BurntSushi/toml"
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+func tailTestTrace(tid, service, resource, status string, duration time.Duration) DatakitTrace {
+	start := time.Now().UnixNano()
+
+	return DatakitTrace{
+		{
+			TraceID: tid, ParentID: "0", SpanID: tid + "1", Service: service, Resource: resource,
+			Source: "tail_test", Status: STATUS_OK, Start: start, Duration: int64(duration),
+		},
+		{
+			TraceID: tid, ParentID: tid + "1", SpanID: tid + "2", Service: service + "-db", Resource: "select",
+			Source: "tail_test", Status: status, Start: start + int64(time.Millisecond), Duration: int64(time.Millisecond),
+		},
+	}
+}
+
+func TestTailSampler(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got = map[string]int{}
+	)
+	dkioFeed = func(name, category string, pts []*point.Point, opt *dkio.Option) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, pt := range pts {
+			fields, err := pt.Fields()
+			require.NoError(t, err)
+			got[fields[FIELD_TRACEID].(string)]++
+		}
+
+		return nil
+	}
+	defer func() { dkioFeed = dkio.Feed }()
+
+	noSampling := 0.0
+	ts := &TailSampler{
+		DecisionWait:     time.Hour,
+		SamplingRate:     &noSampling,
+		KeepError:        true,
+		LatencyThreshold: time.Second,
+		KeepResource:     map[string][]string{"*": {"^/checkout"}},
+		ServiceRateLimit: map[string]float64{"limited": 1},
+	}
+	require.NoError(t, ts.Start("tail_test", NewAfterGather()))
+	defer ts.Stop()
+
+	log := logger.DefaultSLogger("tail-sampler")
+	traces := DatakitTraces{
+		tailTestTrace("1001", "web", "/index", STATUS_ERR, time.Millisecond*10),
+		tailTestTrace("1002", "web", "/index", STATUS_OK, time.Second*2),
+		tailTestTrace("1003", "web", "/checkout/pay", STATUS_OK, time.Millisecond*10),
+		tailTestTrace("1004", "web", "/index", STATUS_OK, time.Millisecond*10),
+		tailTestTrace("1005", "limited", "/index", STATUS_ERR, time.Millisecond*10),
+		tailTestTrace("1006", "limited", "/index", STATUS_ERR, time.Millisecond*10),
+	}
+	for _, dktrace := range traces {
+		// spans of one trace arrive separately
+		for _, dkspan := range dktrace {
+			res, skip := ts.Sample(log, DatakitTrace{dkspan})
+			assert.Nil(t, res)
+			assert.True(t, skip)
+		}
+	}
+
+	// nothing sent before decision
+	mu.Lock()
+	assert.Empty(t, got)
+	mu.Unlock()
+
+	ts.flush(time.Now().Add(2*time.Hour), false)
+
+	mu.Lock()
+	assert.Equal(t, map[string]int{"1001": 2, "1002": 2, "1003": 2, "1005": 2}, got)
+	mu.Unlock()
+
+	// late span of kept trace sent directly, late span of dropped trace dropped
+	ts.Sample(log, DatakitTrace{{TraceID: "1001", SpanID: "10013", ParentID: "10011", Source: "tail_test", Start: time.Now().UnixNano()}})
+	ts.Sample(log, DatakitTrace{{TraceID: "1004", SpanID: "10043", ParentID: "10041", Source: "tail_test", Start: time.Now().UnixNano()}})
+
+	mu.Lock()
+	assert.Equal(t, 3, got["1001"])
+	assert.Zero(t, got["1004"])
+	mu.Unlock()
+}
+
+func TestTailSamplerMaxTraces(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent int
+	)
+	dkioFeed = func(name, category string, pts []*point.Point, opt *dkio.Option) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent += len(pts)
+
+		return nil
+	}
+	defer func() { dkioFeed = dkio.Feed }()
+
+	ts := &TailSampler{DecisionWait: time.Hour, MaxTraces: 2}
+	require.NoError(t, ts.Start("tail_test", NewAfterGather()))
+	defer ts.Stop()
+
+	log := logger.DefaultSLogger("tail-sampler")
+	ts.Sample(log, tailTestTrace("2001", "web", "/", STATUS_OK, time.Millisecond))
+	ts.Sample(log, tailTestTrace("2002", "web", "/", STATUS_OK, time.Millisecond))
+
+	mu.Lock()
+	assert.Zero(t, sent)
+	mu.Unlock()
+
+	// the oldest trace decided in advance when buffer is full
+	ts.Sample(log, tailTestTrace("2003", "web", "/", STATUS_OK, time.Millisecond))
+
+	mu.Lock()
+	assert.Equal(t, 2, sent)
+	mu.Unlock()
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent int
+	)
+	dkioFeed = func(name, category string, pts []*point.Point, opt *dkio.Option) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent += len(pts)
+
+		return nil
+	}
+	defer func() { dkioFeed = dkio.Feed }()
+
+	ts := &TailSampler{DecisionWait: time.Hour}
+	require.NoError(t, ts.Start("tail_test", NewAfterGather()))
+
+	log := logger.DefaultSLogger("tail-sampler")
+	ts.Sample(log, tailTestTrace("3001", "web", "/", STATUS_OK, time.Millisecond))
+	ts.Sample(log, tailTestTrace("3002", "web", "/", STATUS_OK, time.Millisecond))
+
+	// buffered traces decided on stop
+	ts.Stop()
+	ts.Stop()
+
+	mu.Lock()
+	assert.Equal(t, 4, sent)
+	mu.Unlock()
+
+	// traces after stop decided at once
+	ts.Sample(log, tailTestTrace("3003", "web", "/", STATUS_OK, time.Millisecond))
+
+	mu.Lock()
+	assert.Equal(t, 6, sent)
+	mu.Unlock()
+}
+
+func TestTailSamplerInvalidConfig(t *testing.T) {
+	invalidRate := 2.0
+	assert.Error(t, (&TailSampler{SamplingRate: &invalidRate}).Start("tail_test", NewAfterGather()))
+	assert.Error(t, (&TailSampler{KeepResource: map[string][]string{"*": {"("}}}).Start("tail_test", NewAfterGather()))
+	assert.Error(t, (&TailSampler{}).Start("tail_test", nil))
+}
+
+func TestTailSamplerSamplingRate(t *testing.T) {
+	for _, tc := range []struct {
+		conf string
+		rate float64
+	}{
+		{conf: `keep_error = true`, rate: 1},
+		{conf: `sampling_rate = 0`, rate: 0},
+		{conf: `sampling_rate = 0.5`, rate: 0.5},
+	} {
+		var ts TailSampler
+		_, err := bstoml.Decode(tc.conf, &ts)
+		require.NoError(t, err)
+
+		require.NoError(t, ts.Start("tail_test", NewAfterGather()))
+		assert.Equal(t, tc.rate, ts.samplingRate, tc.conf)
+		ts.Stop()
+	}
+}
@@ -48,4 +48,13 @@ type DataWayCfg struct {
 	Proxy bool `toml:"proxy,omitempty"`
 
 	EnableHTTPTrace bool `toml:"enable_httptrace,omitempty"`
+
+	// ContentEncoding is the encoding of points uploaded to dataway,
+	// line-protocol(default) or protobuf.
+	ContentEncoding string `toml:"content_encoding,omitempty"`
 }
+
+const (
+	ContentEncodingLineProto = "line-protocol"
+	ContentEncodingProtobuf  = "protobuf"
+)
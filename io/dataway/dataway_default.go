@@ -178,6 +178,15 @@ func (dw *DataWayDefault) Apply() error {
 		dw.MaxFails = 20
 	}
 
+	switch dw.ContentEncoding {
+	case "":
+		dw.ContentEncoding = ContentEncodingLineProto
+	case ContentEncodingLineProto, ContentEncodingProtobuf:
+	default:
+		return fmt.Errorf("invalid dataway content_encoding %q, expect %s or %s",
+			dw.ContentEncoding, ContentEncodingLineProto, ContentEncodingProtobuf)
+	}
+
 	timeout, err := time.ParseDuration(dw.HTTPTimeout)
 	if err != nil {
 		return err
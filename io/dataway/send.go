@@ -19,9 +19,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	pbpoint "github.com/GuanceCloud/cliutils/point"
 	"github.com/hashicorp/go-retryablehttp"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -65,7 +67,7 @@ func updateSendFailStats(category string, isOk bool) {
 	log.Debugf("update send fail stats: %+#v", sendFailStats)
 }
 
-func (dc *endPoint) send(category string, data []byte, gz bool) (int, error) {
+func (dc *endPoint) send(category string, data []byte, gz bool, contentType string) (int, error) {
 	var (
 		err        error
 		isSendOk   bool // data sent successfully, http response code is 200
@@ -98,6 +100,9 @@ func (dc *endPoint) send(category string, data []byte, gz bool) (int, error) {
 	if gz {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	for k, v := range ExtraHeaders {
 		req.Header.Set(k, v)
 	}
@@ -264,8 +269,12 @@ func (dw *DataWayDefault) sendReq(req *http.Request) (*http.Response, error) {
 }
 
 func (dw *DataWayDefault) Send(category string, data []byte, gz bool) (statusCode int, err error) {
+	return dw.sendWithContentType(category, data, gz, "")
+}
+
+func (dw *DataWayDefault) sendWithContentType(category string, data []byte, gz bool, contentType string) (statusCode int, err error) {
 	for _, ep := range dw.endPoints {
-		statusCode, err = ep.send(category, data, gz)
+		statusCode, err = ep.send(category, data, gz, contentType)
 		if err != nil {
 			return
 		}
@@ -295,7 +304,7 @@ func (dw *DataWayDefault) Write(category string, pts []*point.Point) (*point.Fai
 	for idx, body := range bodies {
 		log.Debugf("write %dth part(%d bytes, gz: %v, raw: %d) to %s",
 			idx, len(body.buf), body.gzon, body.rawBufBytes, category)
-		if _, err := dw.Send(category, body.buf, body.gzon); err != nil {
+		if _, err := dw.sendWithContentType(category, body.buf, body.gzon, body.contentType); err != nil {
 			if failed == nil {
 				failed = &point.Failed{}
 			}
@@ -313,6 +322,7 @@ func (dw *DataWayDefault) Write(category string, pts []*point.Point) (*point.Fai
 type body struct {
 	buf         []byte
 	gzon        bool
+	contentType string
 	rawBufBytes int64
 	idxRange    [2]int
 }
@@ -351,6 +361,10 @@ func getBody(lines [][]byte, idxBegin, idxEnd, curPartSize int) (*body, error) {
 }
 
 func (dw *DataWayDefault) buildBody(pts []*point.Point) ([]*body, error) {
+	if dw.DataWayCfg != nil && dw.ContentEncoding == ContentEncodingProtobuf {
+		return buildPBBody(pts)
+	}
+
 	lines := [][]byte{}
 	curPartSize := 0
 
@@ -391,3 +405,79 @@ func (dw *DataWayDefault) buildBody(pts []*point.Point) ([]*body, error) {
 		return bodies, nil
 	}
 }
+
+func getPBBody(arr []*pbpoint.PBPoint, idxBegin, idxEnd, curPartSize int) (*body, error) {
+	buf, err := proto.Marshal(&pbpoint.PBPoints{Arr: arr})
+	if err != nil {
+		return nil, err
+	}
+
+	body := &body{
+		buf:         buf,
+		contentType: pbpoint.PBContentType,
+		rawBufBytes: int64(len(buf)),
+		idxRange:    [2]int{idxBegin, idxEnd},
+	}
+
+	if curPartSize >= minGZSize {
+		gzbuf, err := datakit.GZip(body.buf)
+		if err != nil {
+			log.Errorf("gz: %s", err.Error())
+
+			return nil, err
+		}
+
+		log.Debugf("gzip %d/%d(ratio: %f) bytes, %d points ok",
+			len(gzbuf), len(body.buf), float64(len(gzbuf))/float64(len(body.buf)), len(arr))
+		body.buf = gzbuf
+		body.gzon = true
+	}
+
+	return body, nil
+}
+
+// buildPBBody same as buildBody, but points are encoded with protobuf.
+func buildPBBody(pts []*point.Point) ([]*body, error) {
+	var (
+		bodies      []*body
+		arr         []*pbpoint.PBPoint
+		curPartSize int
+		idxBegin    int
+	)
+
+	for idx, pt := range pts {
+		x := pbpoint.FromLP(pt.Point)
+		if x == nil {
+			return nil, fmt.Errorf("invalid point %s", pt.String())
+		}
+
+		pbpt := x.PBPoint()
+		size := proto.Size(pbpt)
+		if len(arr) > 0 && uint64(curPartSize+size) >= maxKodoPack {
+			log.Debugf("merge %d points as body", len(arr))
+
+			body, err := getPBBody(arr, idxBegin, idx, curPartSize)
+			if err != nil {
+				return nil, err
+			}
+
+			idxBegin = idx
+			bodies = append(bodies, body)
+			arr = nil
+			curPartSize = 0
+		}
+
+		arr = append(arr, pbpt)
+		curPartSize += size
+	}
+
+	if len(arr) > 0 {
+		body, err := getPBBody(arr, idxBegin, len(pts), curPartSize)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, body)
+	}
+
+	return bodies, nil
+}
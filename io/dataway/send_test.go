@@ -10,7 +10,9 @@ import (
 
 	lp "github.com/GuanceCloud/cliutils/lineproto"
 	uhttp "github.com/GuanceCloud/cliutils/network/http"
+	pbpoint "github.com/GuanceCloud/cliutils/point"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 )
 
@@ -82,6 +84,49 @@ func TestBuildBody(t *testing.T) {
 	}
 }
 
+func TestBuildPBBody(t *testing.T) {
+	maxKodoPack = uint64(8 * 1024)
+	minGZSize = 1024
+
+	pts := point.RandPoints(1000)
+
+	dw := &DataWayDefault{DataWayCfg: &DataWayCfg{ContentEncoding: ContentEncodingProtobuf}}
+	bodies, err := dw.buildBody(pts)
+	require.NoError(t, err)
+	require.True(t, len(bodies) > 1)
+
+	next := 0
+	for _, b := range bodies {
+		assert.Equal(t, pbpoint.PBContentType, b.contentType)
+		assert.Equal(t, next, b.idxRange[0])
+		next = b.idxRange[1]
+
+		if b.gzon {
+			x, err := uhttp.Unzip(b.buf)
+			require.NoError(t, err)
+			b.buf = x
+		}
+
+		dec := pbpoint.GetDecoder(pbpoint.WithDecEncoding(pbpoint.Protobuf))
+		pbpts, err := dec.Decode(b.buf)
+		pbpoint.PutDecoder(dec)
+		require.NoError(t, err)
+		require.Equal(t, b.idxRange[1]-b.idxRange[0], len(pbpts))
+
+		for i, pbpt := range pbpts {
+			pt := pts[b.idxRange[0]+i]
+			fields, err := pt.Fields()
+			require.NoError(t, err)
+
+			assert.Equal(t, pt.Name(), string(pbpt.Name()))
+			assert.Equal(t, pt.UnixNano(), pbpt.Time().UnixNano())
+			assert.Equal(t, pt.Tags(), pbpt.InfluxTags())
+			assert.Equal(t, fields, pbpt.InfluxFields())
+		}
+	}
+	assert.Equal(t, len(pts), next)
+}
+
 func BenchmarkBuildBody(b *testing.B) {
 	cases := []struct {
 		name string
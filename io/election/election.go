@@ -9,6 +9,7 @@ package election
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/logger"
@@ -60,6 +61,7 @@ type candidate struct {
 	id, namespace                  string
 	dw                             dataway.DataWay
 	plugins                        []inputs.ElectionInput
+	pluginsMtx                     sync.RWMutex
 	ElectedTime                    time.Time
 	nElected, nHeartbeat, nOffline int
 }
@@ -69,11 +71,44 @@ func Start(namespace, id string, dw dataway.DataWay) {
 	defaultCandidate.run(namespace, id, dw)
 }
 
+// ReloadInputs refreshes the election inputs after inputs hot reloaded, new
+// inputs are paused if not elected.
+func ReloadInputs() {
+	defaultCandidate.reloadPlugins()
+}
+
+func (x *candidate) reloadPlugins() {
+	if x.id == "" { // election not started
+		return
+	}
+
+	plugins := inputs.GetElectionInputs()
+
+	x.pluginsMtx.Lock()
+	x.plugins = plugins
+	x.pluginsMtx.Unlock()
+
+	log.Infof("reload %d election inputs", len(plugins))
+
+	if x.status != statusSuccess {
+		x.pausePlugins()
+	}
+}
+
+func (x *candidate) getPlugins() []inputs.ElectionInput {
+	x.pluginsMtx.RLock()
+	defer x.pluginsMtx.RUnlock()
+	return x.plugins
+}
+
 func (x *candidate) run(namespace, id string, dw dataway.DataWay) {
 	x.id = id
 	x.namespace = namespace
 	x.dw = dw
+
+	x.pluginsMtx.Lock()
 	x.plugins = inputs.GetElectionInputs()
+	x.pluginsMtx.Unlock()
 
 	log.Debugf("namespace: %s id: %s", x.namespace, x.id)
 	log.Infof("get %d election inputs", len(x.plugins))
@@ -133,7 +168,7 @@ func (x *candidate) runOnce() int {
 }
 
 func (x *candidate) pausePlugins() {
-	for i, p := range x.plugins {
+	for i, p := range x.getPlugins() {
 		log.Debugf("pause %dth inputs...", i)
 		if err := p.Pause(); err != nil {
 			log.Warn(err)
@@ -142,7 +177,7 @@ func (x *candidate) pausePlugins() {
 }
 
 func (x *candidate) resumePlugins() {
-	for i, p := range x.plugins {
+	for i, p := range x.getPlugins() {
 		log.Debugf("resume %dth inputs...", i)
 		if err := p.Resume(); err != nil {
 			log.Warn(err)
@@ -53,6 +53,7 @@ type Option struct {
 	Blocking bool
 
 	PlScript map[string]string // <measurement>: <script name>
+	PlChain  []string          // scripts run in order after the script of the source
 	PlOption *plscript.Option
 }
 
@@ -141,11 +142,13 @@ func beforeFeed(category string, pts []*dkpt.Point, opt *Option) ([]*dkpt.Point,
 	// run pipeline
 	var plopt *plscript.Option
 	var scriptConfMap map[string]string
+	var chain []string
 	if opt != nil {
 		plopt = opt.PlOption
 		scriptConfMap = opt.PlScript
+		chain = opt.PlChain
 	}
-	after, err := pipeline.RunPl(category, pts, plopt, scriptConfMap)
+	after, err := pipeline.RunPl(category, pts, plopt, scriptConfMap, chain)
 	if err != nil {
 		log.Error(err)
 	}
@@ -13,7 +13,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/GuanceCloud/cliutils/logger"
@@ -34,8 +36,9 @@ var (
 
 func newFilter(dw IDataway) *filter {
 	return &filter{
-		conditions: map[string]parser.WhereConditions{},
-		dw:         dw,
+		rules:    map[string][]*rule{},
+		dryRules: map[string][]*rule{},
+		dw:       dw,
 
 		RWMutex: sync.RWMutex{},
 
@@ -70,10 +73,29 @@ func (dw *datawayImpl) Pull(filters map[string][]string, inDW dataway.DataWay) (
 	}
 }
 
+// rule is a single filter condition string of some category, hits is
+// the count of points matched by the rule, it's kept across rule reloads.
+type rule struct {
+	category string
+	text     string
+	conds    parser.WhereConditions
+	hits     int64
+}
+
 type filter struct {
-	conditions map[string]parser.WhereConditions
-	dw         IDataway
-	md5        string
+	// category => rules, points matched by any rule dropped
+	rules map[string][]*rule
+	// category => dry-run rules, points matched only counted, never dropped
+	dryRules map[string][]*rule
+
+	dw  IDataway
+	md5 string
+
+	// local filters from datakit.conf and from API(POST /v1/filter), the
+	// API ones take precedence, remote pulling used if neither set.
+	confFilters map[string][]string
+	apiFilters  map[string][]string
+	remote      dataway.DataWay
 
 	// Mutex to R/W on rules: rules are updated(Write) from remote center, or
 	// applied to(Read) filter points
@@ -119,19 +141,20 @@ func (f *filter) update(body []byte, dumpdir string) error {
 		f.tick.Reset(f.pullInterval)
 	}
 
-	f.md5 = bodymd5
-	// Clear old conditions: we update all conditions if any changed(new/delete
-	// conditons or update old conditions)
-	f.conditions = map[string]parser.WhereConditions{}
-	for k, v := range fp.Filters {
-		conds, err := GetConds(v)
-		if err != nil {
-			l.Errorf("GetConds failed: %v", err)
-			return err
-		}
-		f.conditions[k] = conds
+	// We update all conditions if any changed(new/delete conditons or update
+	// old conditions), and all conditions are checked before swapping, old
+	// conditions kept on any invalid condition.
+	rules, err := buildRules(fp.Filters, f.rules)
+	if err != nil {
+		l.Errorf("GetConds failed: %v", err)
+		f.stats.LastErr = err.Error()
+		f.stats.LastErrTime = time.Now()
+		return err
 	}
 
+	f.md5 = bodymd5
+	f.rules = rules
+
 	if err := dump(body, dumpdir); err != nil {
 		l.Warnf("dump: %s, ignored", err)
 	}
@@ -174,6 +197,36 @@ func (f *filter) pull(filters map[string][]string, dw dataway.DataWay) {
 	}
 }
 
+// buildRules parses all filters into rules, hits of rule that exists in old
+// rules are kept.
+func buildRules(filters map[string][]string, old map[string][]*rule) (map[string][]*rule, error) {
+	hits := map[string]int64{}
+	for category, arr := range old {
+		for _, r := range arr {
+			hits[category+"\n"+r.text] = atomic.LoadInt64(&r.hits)
+		}
+	}
+
+	rules := map[string][]*rule{}
+	for category, arr := range filters {
+		for _, text := range arr {
+			conds, err := GetConds([]string{text})
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s filter %q: %w", category, text, err)
+			}
+
+			rules[category] = append(rules[category], &rule{
+				category: category,
+				text:     text,
+				conds:    conds,
+				hits:     hits[category+"\n"+text],
+			})
+		}
+	}
+
+	return rules, nil
+}
+
 // GetConds returns Filter's Parser Conditions and error.
 func GetConds(filterArr []string) (parser.WhereConditions, error) {
 	var conds parser.WhereConditions
@@ -190,10 +243,21 @@ func GetConds(filterArr []string) (parser.WhereConditions, error) {
 // CheckPointFiltered returns whether the point matches the fitler rule.
 // If returns true means they are matched.
 func CheckPointFiltered(conds parser.WhereConditions, category string, pt *point.Point) (bool, error) {
+	tags, fields, err := getTagsFields(category, pt)
+	if err != nil {
+		return false, err
+	}
+
+	return filtered(conds, tags, fields), nil
+}
+
+// getTagsFields returns tags and fields of the point, tags are adjusted
+// according to the category.
+func getTagsFields(category string, pt *point.Point) (map[string]string, map[string]interface{}, error) {
 	tags := pt.Tags()
 	fields, err := pt.Fields()
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 
 	// Before checks, should adjust tags under some conditions.
@@ -209,10 +273,10 @@ func CheckPointFiltered(conds parser.WhereConditions, category string, pt *point
 		tags["class"] = pt.Point.Name() // set measurement name as tag `class'
 	default:
 		l.Warnf("unsupport category: %s", category)
-		return false, fmt.Errorf("unsupport category: %s", category)
+		return nil, nil, fmt.Errorf("unsupport category: %s", category)
 	}
 
-	return filtered(conds, tags, fields), nil
+	return tags, fields, nil
 }
 
 func filtered(conds parser.WhereConditions, tags map[string]string, fields map[string]interface{}) bool {
@@ -230,8 +294,8 @@ func (f *filter) doFilter(category string, pts []*point.Point) ([]*point.Point,
 		return pts, 0
 	}
 
-	conds, ok := f.conditions[categoryPureStr]
-	if !ok || conds == nil {
+	rules, dryRules := f.rules[categoryPureStr], f.dryRules[categoryPureStr]
+	if len(rules) == 0 && len(dryRules) == 0 {
 		l.Debugf("no condition filter for %s", categoryPureStr)
 		return pts, 0
 	}
@@ -239,11 +303,28 @@ func (f *filter) doFilter(category string, pts []*point.Point) ([]*point.Point,
 	var after []*point.Point
 
 	for _, pt := range pts {
-		isFiltered, err := CheckPointFiltered(conds, category, pt)
+		tags, fields, err := getTagsFields(category, pt)
 		if err != nil {
 			l.Errorf("pt.Fields: %s, ignored", err.Error())
 			continue // filter it!
 		}
+
+		// dry-run rules never drop points, just count the hits
+		for _, r := range dryRules {
+			if filtered(r.conds, tags, fields) {
+				atomic.AddInt64(&r.hits, 1)
+			}
+		}
+
+		isFiltered := false
+		for _, r := range rules {
+			if filtered(r.conds, tags, fields) {
+				atomic.AddInt64(&r.hits, 1)
+				isFiltered = true
+				break
+			}
+		}
+
 		if !isFiltered { // Pick those points that not matched filter rules.
 			after = append(after, pt)
 		} else if datakit.LogSinkDetail {
@@ -251,7 +332,12 @@ func (f *filter) doFilter(category string, pts []*point.Point) ([]*point.Point,
 		}
 	}
 
-	return after, len(conds)
+	condCount := 0
+	for _, r := range rules {
+		condCount += len(r.conds)
+	}
+
+	return after, condCount
 }
 
 func FilterPts(category string, pts []*point.Point) []*point.Point {
@@ -319,8 +405,17 @@ type ruleStat struct {
 	Conditions   int           `json:"conditions"`
 }
 
+// RuleHit is the hit count of a single filter rule.
+type RuleHit struct {
+	Category string `json:"category"`
+	Rule     string `json:"rule"`
+	Hits     int64  `json:"hits"`
+	DryRun   bool   `json:"dry_run"`
+}
+
 type FilterStats struct {
 	RuleStats map[string]*ruleStat `json:"rule_stats"`
+	RuleHits  []*RuleHit           `json:"rule_hits"`
 
 	PullCount    int           `json:"pull_count"`
 	PullInterval time.Duration `json:"pull_interval"`
@@ -372,6 +467,48 @@ func copyStats(x *FilterStats) *FilterStats {
 	return y
 }
 
+func (f *filter) copyStats() *FilterStats {
+	hits := f.ruleHits()
+
+	f.RWMutex.RLock()
+	defer f.RWMutex.RUnlock()
+
+	s := copyStats(f.stats)
+	s.RuleHits = hits
+	return s
+}
+
+func (f *filter) ruleHits() []*RuleHit {
+	f.RWMutex.RLock()
+	defer f.RWMutex.RUnlock()
+
+	var res []*RuleHit
+	for i, x := range []map[string][]*rule{f.rules, f.dryRules} {
+		for _, arr := range x {
+			for _, r := range arr {
+				res = append(res, &RuleHit{
+					Category: r.category,
+					Rule:     r.text,
+					Hits:     atomic.LoadInt64(&r.hits),
+					DryRun:   i == 1,
+				})
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].DryRun != res[j].DryRun {
+			return !res[i].DryRun
+		}
+		if res[i].Category != res[j].Category {
+			return res[i].Category < res[j].Category
+		}
+		return res[i].Rule < res[j].Rule
+	})
+
+	return res
+}
+
 func (f *filter) updateMetric(m *filterMetric) {
 	ruleStats := defaultFilter.stats.RuleStats
 
@@ -390,23 +527,100 @@ func (f *filter) updateMetric(m *filterMetric) {
 	v.Conditions = m.conditions
 }
 
+// localFilters returns current local filters and the source of them, it
+// should be called with lock held.
+func (f *filter) localFilters() (map[string][]string, string) {
+	switch {
+	case len(f.apiFilters) != 0:
+		return f.apiFilters, "api"
+	case len(f.confFilters) != 0:
+		return f.confFilters, datakit.StrDefaultConfFile
+	default:
+		return nil, "remote"
+	}
+}
+
+func (f *filter) reloadPull() {
+	f.RWMutex.Lock()
+	filters, source := f.localFilters()
+	f.stats.RuleSource = source
+	dw := f.remote
+	f.RWMutex.Unlock()
+
+	f.pull(filters, dw)
+}
+
+// reload checks all filters and applies them. Dry-run filters only count
+// matched points, they replace previous dry-run filters. Otherwise filters
+// replace the local filters from datakit.conf or remote, and empty filters
+// restore the previous ones.
+func (f *filter) reload(filters map[string][]string, dryRun bool) error {
+	for category := range filters {
+		if !isPureCategory(category) {
+			return fmt.Errorf("unknown category %q", category)
+		}
+	}
+
+	if dryRun {
+		f.RWMutex.Lock()
+		defer f.RWMutex.Unlock()
+
+		rules, err := buildRules(filters, f.dryRules)
+		if err != nil {
+			return err
+		}
+		f.dryRules = rules
+		return nil
+	}
+
+	// check before applying
+	if _, err := buildRules(filters, nil); err != nil {
+		return err
+	}
+
+	f.RWMutex.Lock()
+	f.apiFilters = filters
+	f.RWMutex.Unlock()
+
+	f.reloadPull()
+	return nil
+}
+
+func isPureCategory(category string) bool {
+	for _, v := range datakit.CategoryPureMap {
+		if v == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload hot reloads filters without restart, see filter.reload.
+func Reload(filters map[string][]string, dryRun bool) error {
+	if !isStarted {
+		return fmt.Errorf("filter not started")
+	}
+
+	l.Infof("reload filters(dry-run: %v): %+#v", dryRun, filters)
+	return defaultFilter.reload(filters, dryRun)
+}
+
 func (f *filter) start(filters map[string][]string, dw dataway.DataWay) {
 	defer defaultFilter.tick.Stop()
 
-	if len(filters) != 0 {
-		f.stats.RuleSource = datakit.StrDefaultConfFile
-	} else {
-		f.stats.RuleSource = "remote"
-	}
+	f.RWMutex.Lock()
+	f.confFilters = filters
+	f.remote = dw
+	f.RWMutex.Unlock()
 
 	// Try pull rules ASAP.
-	defaultFilter.pull(filters, dw)
+	f.reloadPull()
 
 	for {
 		select {
 		case <-defaultFilter.tick.C:
 			l.Debugf("try pull remote filters...")
-			defaultFilter.pull(filters, dw)
+			f.reloadPull()
 
 		case m := <-defaultFilter.metricCh:
 			l.Debugf("update metrics...")
@@ -417,7 +631,7 @@ func (f *filter) start(filters map[string][]string, dw dataway.DataWay) {
 
 			select {
 			case <-q.ch:
-			case q.ch <- copyStats(defaultFilter.stats):
+			case q.ch <- f.copyStats():
 			default: // pass
 			}
 
@@ -7,6 +7,7 @@ package filter
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -74,8 +75,8 @@ test1,service=test1 f1="1",f2=2i,f3=3 125`,
 	}
 
 	f.pull(nil, nil)
-	for k, v := range f.conditions {
-		t.Logf("%s: %s", k, v)
+	for k, v := range f.rules {
+		t.Logf("%s: %d rules", k, len(v))
 	}
 
 	for _, tc := range cases {
@@ -92,6 +93,79 @@ test1,service=test1 f1="1",f2=2i,f3=3 125`,
 	}
 }
 
+func TestReload(t *testing.T) {
+	f := newFilter(&datawayImpl{})
+	f.confFilters = map[string][]string{
+		"logging": {`{ source = "test1" }`},
+	}
+	f.reloadPull()
+	assert.Equal(t, datakit.StrDefaultConfFile, f.stats.RuleSource)
+
+	getPts := func(t *testing.T) []*point.Point {
+		t.Helper()
+		pts, err := lp.ParsePoints([]byte(`test1 f1="1" 123
+test2 f1="2" 124
+test3 f1="3" 125`), nil)
+		assert.NoError(t, err)
+		return point.WrapPoint(pts)
+	}
+
+	hits := func() map[string]int64 {
+		res := map[string]int64{}
+		for _, h := range f.ruleHits() {
+			res[fmt.Sprintf("%s/%v", h.Rule, h.DryRun)] = h.Hits
+		}
+		return res
+	}
+
+	after, _ := f.doFilter(datakit.Logging, getPts(t))
+	assert.Len(t, after, 2)
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, f.reload(map[string][]string{"logging": {`{ source = `}}, false))
+		assert.Error(t, f.reload(map[string][]string{"no-such-category": {`{ source = "test1" }`}}, false))
+
+		// old rules kept
+		after, _ := f.doFilter(datakit.Logging, getPts(t))
+		assert.Len(t, after, 2)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		assert.NoError(t, f.reload(map[string][]string{
+			"logging": {`{ source = "test1" }`, `{ source = "test2" }`},
+		}, false))
+		assert.Equal(t, "api", f.stats.RuleSource)
+
+		after, _ := f.doFilter(datakit.Logging, getPts(t))
+		assert.Len(t, after, 1)
+
+		// hits of rule test1 kept across reload
+		assert.Equal(t, map[string]int64{
+			`{ source = "test1" }/false`: 3,
+			`{ source = "test2" }/false`: 1,
+		}, hits())
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		assert.NoError(t, f.reload(map[string][]string{
+			"logging": {`{ source = "test3" }`},
+		}, true))
+
+		after, _ := f.doFilter(datakit.Logging, getPts(t))
+		assert.Len(t, after, 1) // test3 not dropped
+		assert.Equal(t, `test3 f1="3" 125`, after[0].String())
+		assert.Equal(t, int64(1), hits()[`{ source = "test3" }/true`])
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		assert.NoError(t, f.reload(nil, false))
+		assert.Equal(t, datakit.StrDefaultConfFile, f.stats.RuleSource)
+
+		after, _ := f.doFilter(datakit.Logging, getPts(t))
+		assert.Len(t, after, 2)
+	})
+}
+
 func TestPull(t *testing.T) {
 	f := newFilter(&dwMock{})
 
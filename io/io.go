@@ -23,6 +23,7 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/filter"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sender"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
 	pb "google.golang.org/protobuf/proto"
 )
 
@@ -135,6 +136,13 @@ func (x *IO) StartIO(recoverable bool) {
 		return nil
 	})
 
+	g.Go(func(_ context.Context) error {
+		plmetric.Start(func(pts []*point.Point) error {
+			return x.doFeed(pts, datakit.Metric, "pipeline/metric", nil)
+		})
+		return nil
+	})
+
 	fn := func(category string, n int) {
 		for i := 0; i < n; i++ {
 			g.Go(func(_ context.Context) error {
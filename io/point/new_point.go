@@ -61,6 +61,10 @@ type PointOption struct {
 
 	Strict           bool
 	MaxFieldValueLen int
+
+	// EnableStringField keeps string field values in metric point, they are
+	// dropped by default.
+	EnableStringField bool
 }
 
 func defaultPointOption() *PointOption {
@@ -138,7 +142,7 @@ func NewPoint(name string,
 		lpOpt.EnablePointInKey = true
 		lpOpt.DisabledTagKeys = DisabledTagKeys[opt.Category]
 		lpOpt.DisabledFieldKeys = DisabledFieldKeys[opt.Category]
-		lpOpt.DisableStringField = true // ingore string field value in metric point
+		lpOpt.DisableStringField = !opt.EnableStringField // ingore string field value in metric point
 	case datakit.Network,
 		datakit.KeyEvent,
 		datakit.Object,
@@ -107,6 +107,22 @@ func TestNewPoint(t *testing.T) {
 			f:      map[string]interface{}{"f1": 12},
 			expect: "abc,t.1=tval1 f1=12i 123",
 		},
+		{
+			tname:  "metric-with-string-field",
+			name:   "abc",
+			ptopt:  &PointOption{Category: datakit.Metric, Time: time.Unix(0, 123)},
+			t:      map[string]string{"t1": "tval1"},
+			f:      map[string]interface{}{"f1": 12, "f2": "str"},
+			expect: "abc,t1=tval1 f1=12i 123",
+		},
+		{
+			tname:  "metric-with-string-field-enabled",
+			name:   "abc",
+			ptopt:  &PointOption{Category: datakit.Metric, Time: time.Unix(0, 123), EnableStringField: true},
+			t:      map[string]string{"t1": "tval1"},
+			f:      map[string]interface{}{"f1": 12, "f2": "str"},
+			expect: `abc,t1=tval1 f1=12i,f2="str" 123`,
+		},
 		{
 			tname: "with-point-in-t/f-key-on-non-metric-type",
 			name:  "abc",
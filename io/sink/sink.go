@@ -20,8 +20,10 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
 	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkdataway"
 	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkinfluxdb"
+	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkkafka"
 	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinklogstash"
 	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkm3db"
+	_ "gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote"
 )
 
 //----------------------------------------------------------------------
@@ -39,7 +41,14 @@ func Write(category string, pts []*point.Point) (*point.Failed, error) {
 	if impls, ok := sinkcommon.SinkCategoryMap[category]; ok {
 		var errKeep error
 		for _, v := range impls {
-			if err := v.Write(category, pts); err != nil { // NOTE: sinker send fail not cached
+			var err error
+			if sc, ok := sinkCaches[v]; ok {
+				err = sc.write(category, pts) // failed points cached and retried later
+			} else {
+				err = v.Write(category, pts)
+			}
+
+			if err != nil {
 				errKeep = err
 			}
 		}
@@ -119,6 +128,8 @@ func Init(sinkcfg []map[string]interface{}, defCall func(string, []*point.Point)
 
 			l.Debugf("SinkCategoryMap = %#v", sinkcommon.SinkCategoryMap)
 
+			startRetry()
+
 			defaultCallPtr = defCall
 
 			isInitSucceeded = true
@@ -302,6 +313,10 @@ func buildSinkImpls(sinkcfg []map[string]interface{}) error {
 			if err := ins.LoadConfig(v); err != nil {
 				return err
 			}
+
+			if err := buildSinkCache(ins, v); err != nil {
+				return fmt.Errorf("build %s cache failed: %w", target, err)
+			}
 		} else {
 			return fmt.Errorf("%s not implemented yet", target)
 		}
@@ -0,0 +1,509 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lp "github.com/GuanceCloud/cliutils/lineproto"
+	"github.com/tidwall/wal"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
+)
+
+const (
+	keyCacheMaxSizeMB  = "cache_max_size_mb"
+	keyRetryBackoff    = "retry_backoff"
+	keyRetryMaxBackoff = "retry_max_backoff"
+
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = time.Minute
+)
+
+// SinkStat is the retry queue stats of a sink instance.
+type SinkStat struct {
+	Target     string        `json:"target"`
+	ID         string        `json:"id"`
+	Cached     bool          `json:"cached"`
+	CacheSize  int64         `json:"cache_size"`
+	CacheCap   int64         `json:"cache_cap"`
+	WriteFail  uint64        `json:"write_fail_pts"`
+	CachedPts  uint64        `json:"cached_pts"`
+	RetryOK    uint64        `json:"retry_ok_pts"`
+	RetryFail  uint64        `json:"retry_fail"`
+	DroppedPts uint64        `json:"dropped_pts"`
+	Backoff    time.Duration `json:"backoff"`
+	LastErr    string        `json:"last_error,omitempty"`
+	LastErrTS  time.Time     `json:"last_error_ts,omitempty"`
+}
+
+// sinkCache is a WAL based retry queue for one sink instance. Points failed to
+// write are dumped to disk as line protocol and retried with exponential backoff,
+// the oldest points are dropped if the cache size exceeds the cap.
+type sinkCache struct {
+	sk   sinkcommon.ISink
+	path string
+	l    *wal.Log
+
+	capacity   int64
+	size       int64 // bytes of entries not sent yet
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	backoff    time.Duration
+	nextRetry  time.Time
+	walGen     int // increased on wal reopened, the indexes restart then
+
+	writeFailPts, cachedPts, retryOKPts, retryFail, droppedPts uint64
+
+	lastErr   string
+	lastErrTS time.Time
+
+	lock sync.Mutex
+}
+
+type sinkCacheOption struct {
+	capacity   int64 // negative means cache disabled, the default
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+func getSinkCacheOption(mConf map[string]interface{}) (*sinkCacheOption, error) {
+	opt := &sinkCacheOption{
+		capacity:   -1, // disk cache should be enabled explicitly
+		minBackoff: defaultRetryBackoff,
+		maxBackoff: defaultRetryMaxBackoff,
+	}
+
+	if val, ok := mConf[keyCacheMaxSizeMB]; ok {
+		n, err := getInt(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", keyCacheMaxSizeMB, err)
+		}
+
+		if n <= 0 {
+			opt.capacity = -1
+		} else {
+			opt.capacity = n * 1024 * 1024
+		}
+	}
+
+	for k, du := range map[string]*time.Duration{
+		keyRetryBackoff:    &opt.minBackoff,
+		keyRetryMaxBackoff: &opt.maxBackoff,
+	} {
+		str, ok := mConf[k].(string)
+		if !ok || str == "" {
+			continue
+		}
+
+		x, err := time.ParseDuration(str)
+		if err != nil || x <= 0 {
+			return nil, fmt.Errorf("invalid %s: %s", k, str)
+		}
+		*du = x
+	}
+
+	if opt.maxBackoff < opt.minBackoff {
+		opt.maxBackoff = opt.minBackoff
+	}
+
+	return opt, nil
+}
+
+// getInt accept int from TOML(int64) and sink ENV(string).
+func getInt(val interface{}) (int64, error) {
+	switch x := val.(type) {
+	case int:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	case float64:
+		return int64(x), nil
+	case string:
+		return strconv.ParseInt(strings.TrimSpace(x), 10, 64)
+	default:
+		return 0, fmt.Errorf("not int: %v", val)
+	}
+}
+
+func newSinkCache(sk sinkcommon.ISink, path string, opt *sinkCacheOption) (*sinkCache, error) {
+	sc := &sinkCache{
+		sk:         sk,
+		path:       path,
+		capacity:   opt.capacity,
+		minBackoff: opt.minBackoff,
+		maxBackoff: opt.maxBackoff,
+		backoff:    opt.minBackoff,
+	}
+
+	if sc.capacity < 0 {
+		return sc, nil
+	}
+
+	log, err := wal.Open(path, &wal.Options{LogFormat: wal.Binary, NoCopy: true})
+	if err != nil {
+		return nil, err
+	}
+	sc.l = log
+
+	// load existing entries left by last running
+	first, err := log.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+	last, err := log.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+	if first > 0 {
+		for idx := first; idx <= last; idx++ {
+			data, err := log.Read(idx)
+			if err != nil {
+				return nil, err
+			}
+			sc.size += int64(len(data))
+		}
+	}
+
+	return sc, nil
+}
+
+func (sc *sinkCache) enabled() bool {
+	return sc.capacity >= 0
+}
+
+// write send pts to the sink, and cache them on failure.
+func (sc *sinkCache) write(category string, pts []*point.Point) error {
+	err := sc.sk.Write(category, pts)
+	if err == nil {
+		return nil
+	}
+
+	atomic.AddUint64(&sc.writeFailPts, uint64(len(pts)))
+	sc.setLastErr(err)
+
+	if !sc.enabled() {
+		return err
+	}
+
+	if cerr := sc.put(category, pts); cerr != nil {
+		l.Errorf("sink %s cache %d pts failed: %s", sc.sk.GetInfo().CreateID, len(pts), cerr)
+	}
+
+	return err
+}
+
+func (sc *sinkCache) setLastErr(err error) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.lastErr = err.Error()
+	sc.lastErrTS = time.Now()
+}
+
+// encodeCache dump points as: category\nline\nline...
+func encodeCache(category string, pts []*point.Point) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(category)
+	for _, pt := range pts {
+		buf.WriteByte('\n')
+		buf.WriteString(pt.String())
+	}
+
+	return buf.Bytes()
+}
+
+func decodeCache(data []byte) (string, []*point.Point, error) {
+	idx := bytes.IndexByte(data, '\n')
+	if idx <= 0 {
+		return "", nil, fmt.Errorf("invalid sink cache data")
+	}
+
+	pts, err := lp.ParsePoints(data[idx+1:], nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(data[:idx]), point.WrapPoint(pts), nil
+}
+
+func cachePoints(data []byte) uint64 {
+	return uint64(bytes.Count(data, []byte{'\n'}))
+}
+
+func (sc *sinkCache) put(category string, pts []*point.Point) error {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	data := encodeCache(category, pts)
+
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if int64(len(data)) > sc.capacity {
+		atomic.AddUint64(&sc.droppedPts, uint64(len(pts)))
+		return fmt.Errorf("%d pts(%d bytes) exceed cache cap %d", len(pts), len(data), sc.capacity)
+	}
+
+	// drop the oldest to make room for new points
+	for sc.size+int64(len(data)) > sc.capacity {
+		if err := sc.truncateFront(true); err != nil {
+			return err
+		}
+	}
+
+	last, err := sc.l.LastIndex()
+	if err != nil {
+		return err
+	}
+
+	if err := sc.l.Write(last+1, data); err != nil {
+		return err
+	}
+
+	sc.size += int64(len(data))
+	atomic.AddUint64(&sc.cachedPts, uint64(len(pts)))
+	return nil
+}
+
+// truncateFront remove the first entry, it should be called with lock held.
+func (sc *sinkCache) truncateFront(drop bool) error {
+	first, err := sc.l.FirstIndex()
+	if err != nil {
+		return err
+	}
+
+	last, err := sc.l.LastIndex()
+	if err != nil {
+		return err
+	}
+
+	if first == 0 { // empty
+		sc.size = 0
+		return nil
+	}
+
+	data, err := sc.l.Read(first)
+	if err != nil {
+		return err
+	}
+
+	sc.size -= int64(len(data))
+	if drop {
+		atomic.AddUint64(&sc.droppedPts, cachePoints(data))
+	}
+
+	if first != last {
+		return sc.l.TruncateFront(first + 1)
+	}
+
+	// the last entry: wal can't truncate all entries, so remove and reopen it.
+	if err := sc.l.Close(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(sc.path); err != nil {
+		return err
+	}
+	log, err := wal.Open(sc.path, &wal.Options{LogFormat: wal.Binary, NoCopy: true})
+	if err != nil {
+		return err
+	}
+
+	sc.l = log
+	sc.size = 0
+	sc.walGen++
+	return nil
+}
+
+// retry resend cached points until the cache is empty or failed. On failure,
+// the retry is delayed by backoff, and the backoff doubled up to maxBackoff.
+// The lock is not held while writing, so slow sinks don't block caching new
+// points. Only one retry should be running for the cache.
+func (sc *sinkCache) retry(now time.Time) {
+	if !sc.enabled() {
+		return
+	}
+
+	for {
+		sc.lock.Lock()
+		if now.Before(sc.nextRetry) {
+			sc.lock.Unlock()
+			return
+		}
+
+		gen := sc.walGen
+		first, data, err := sc.front()
+		if err != nil {
+			sc.lock.Unlock()
+			l.Warnf("sink %s read cache failed: %s", sc.sk.GetInfo().CreateID, err)
+			return
+		}
+
+		if first == 0 { // empty
+			sc.lock.Unlock()
+			return
+		}
+
+		category, pts, err := decodeCache(data)
+		if err != nil {
+			l.Warnf("sink %s decode cache failed: %s, dropped", sc.sk.GetInfo().CreateID, err)
+			err = sc.truncateFront(true)
+			sc.lock.Unlock()
+			if err != nil {
+				return
+			}
+			continue
+		}
+		sc.lock.Unlock()
+
+		werr := sc.sk.Write(category, pts)
+
+		sc.lock.Lock()
+		if werr != nil {
+			atomic.AddUint64(&sc.retryFail, 1)
+			sc.lastErr = werr.Error()
+			sc.lastErrTS = now
+
+			sc.nextRetry = now.Add(sc.backoff)
+			sc.backoff *= 2
+			if sc.backoff > sc.maxBackoff {
+				sc.backoff = sc.maxBackoff
+			}
+			sc.lock.Unlock()
+
+			l.Warnf("sink %s retry %d pts failed: %s, next retry after %s",
+				sc.sk.GetInfo().CreateID, len(pts), werr, sc.nextRetry.Sub(now))
+			return
+		}
+
+		atomic.AddUint64(&sc.retryOKPts, uint64(len(pts)))
+		sc.backoff = sc.minBackoff
+		sc.nextRetry = time.Time{}
+
+		// the entry may have been dropped to make room for new points while writing
+		if cur, err := sc.l.FirstIndex(); err == nil && cur == first && sc.walGen == gen {
+			err = sc.truncateFront(false)
+			if err != nil {
+				sc.lock.Unlock()
+				l.Warnf("sink %s truncate cache failed: %s", sc.sk.GetInfo().CreateID, err)
+				return
+			}
+		}
+		sc.lock.Unlock()
+	}
+}
+
+// front returns the first entry and its index, the index is 0 if the cache is
+// empty. It should be called with lock held.
+func (sc *sinkCache) front() (uint64, []byte, error) {
+	first, err := sc.l.FirstIndex()
+	if err != nil || first == 0 {
+		return 0, nil, err
+	}
+
+	data, err := sc.l.Read(first)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// the wal may reuse the data, copy it before the lock released
+	return first, append([]byte(nil), data...), nil
+}
+
+func (sc *sinkCache) stat() *SinkStat {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	info := sc.sk.GetInfo()
+	return &SinkStat{
+		Target:     info.CreateID,
+		ID:         info.ID,
+		Cached:     sc.enabled(),
+		CacheSize:  sc.size,
+		CacheCap:   sc.capacity,
+		WriteFail:  atomic.LoadUint64(&sc.writeFailPts),
+		CachedPts:  atomic.LoadUint64(&sc.cachedPts),
+		RetryOK:    atomic.LoadUint64(&sc.retryOKPts),
+		RetryFail:  atomic.LoadUint64(&sc.retryFail),
+		DroppedPts: atomic.LoadUint64(&sc.droppedPts),
+		Backoff:    sc.backoff,
+		LastErr:    sc.lastErr,
+		LastErrTS:  sc.lastErrTS,
+	}
+}
+
+//----------------------------------------------------------------------
+
+var (
+	sinkCaches   = map[sinkcommon.ISink]*sinkCache{}
+	retryTicker  = time.Second
+	sinkCacheDir = func() string { return filepath.Join(datakit.CacheDir, "sink") }
+)
+
+func buildSinkCache(sk sinkcommon.ISink, mConf map[string]interface{}) error {
+	opt, err := getSinkCacheOption(mConf)
+	if err != nil {
+		return err
+	}
+
+	info := sk.GetInfo()
+	sc, err := newSinkCache(sk, filepath.Join(sinkCacheDir(), info.CreateID+"_"+info.ID), opt)
+	if err != nil {
+		return err
+	}
+
+	sinkCaches[sk] = sc
+	return nil
+}
+
+func startRetry() {
+	if len(sinkCaches) == 0 {
+		return
+	}
+
+	g := datakit.G("io_sink")
+	g.Go(func(ctx context.Context) error {
+		tick := time.NewTicker(retryTicker)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-datakit.Exit.Wait():
+				l.Info("sink retry exit")
+				return nil
+
+			case now := <-tick.C:
+				for _, sc := range sinkCaches {
+					sc.retry(now)
+				}
+			}
+		}
+	})
+}
+
+// GetSinkStats return the retry queue stats of all sink instances.
+func GetSinkStats() []*SinkStat {
+	var res []*SinkStat
+	for _, sk := range sinkcommon.SinkImpls {
+		if sc, ok := sinkCaches[sk]; ok {
+			res = append(res, sc.stat())
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package sink
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
+)
+
+type mockSink struct {
+	fail    bool
+	written map[string]int
+}
+
+func (s *mockSink) GetInfo() *sinkcommon.SinkInfo {
+	return &sinkcommon.SinkInfo{ID: "mock-id", IDStr: "mock", CreateID: "mock", Categories: []string{"M", "L"}}
+}
+
+func (s *mockSink) LoadConfig(mConf map[string]interface{}) error { return nil }
+
+func (s *mockSink) Write(category string, pts []*point.Point) error {
+	if s.fail {
+		return fmt.Errorf("mock write failed")
+	}
+
+	s.written[category] += len(pts)
+	return nil
+}
+
+func mockPoints(t *testing.T, n int) []*point.Point {
+	t.Helper()
+
+	var pts []*point.Point
+	for i := 0; i < n; i++ {
+		pt, err := point.NewPoint("mock",
+			map[string]string{"host": "abc"},
+			map[string]interface{}{"value": i},
+			&point.PointOption{Time: time.Unix(0, 123), Category: "/v1/write/logging"})
+		require.NoError(t, err)
+		pts = append(pts, pt)
+	}
+
+	return pts
+}
+
+// go test -v -timeout 30s -run ^TestSinkCache$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
+func TestSinkCache(t *testing.T) {
+	sk := &mockSink{fail: true, written: map[string]int{}}
+
+	sc, err := newSinkCache(sk, t.TempDir(), &sinkCacheOption{
+		capacity:   1024 * 1024,
+		minBackoff: time.Second,
+		maxBackoff: 4 * time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, sc.write("/v1/write/logging", mockPoints(t, 3)))
+	assert.Error(t, sc.write("/v1/write/metric", mockPoints(t, 2)))
+
+	st := sc.stat()
+	assert.Equal(t, uint64(5), st.WriteFail)
+	assert.Equal(t, uint64(5), st.CachedPts)
+	assert.True(t, st.CacheSize > 0)
+
+	// retry failed: backoff doubled
+	now := time.Now()
+	sc.retry(now)
+	assert.Equal(t, 2*time.Second, sc.stat().Backoff)
+
+	// within backoff, no retry
+	sc.retry(now.Add(time.Millisecond))
+	assert.Equal(t, uint64(1), sc.stat().RetryFail)
+
+	sc.retry(now.Add(time.Second))
+	sc.retry(now.Add(3 * time.Second))
+	sc.retry(now.Add(7 * time.Second))
+	st = sc.stat()
+	assert.Equal(t, uint64(4), st.RetryFail)
+	assert.Equal(t, 4*time.Second, st.Backoff) // max backoff
+
+	// sink recovered: all cached points sent
+	sk.fail = false
+	sc.retry(now.Add(time.Minute))
+
+	st = sc.stat()
+	assert.Equal(t, uint64(5), st.RetryOK)
+	assert.Equal(t, int64(0), st.CacheSize)
+	assert.Equal(t, time.Second, st.Backoff)
+	assert.Equal(t, map[string]int{"/v1/write/logging": 3, "/v1/write/metric": 2}, sk.written)
+
+	// cache is empty now
+	sc.retry(now.Add(2 * time.Minute))
+	assert.Equal(t, uint64(5), sc.stat().RetryOK)
+}
+
+// go test -v -timeout 30s -run ^TestSinkCacheDrop$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
+func TestSinkCacheDrop(t *testing.T) {
+	sk := &mockSink{fail: true, written: map[string]int{}}
+
+	pts := mockPoints(t, 2)
+	entrySize := int64(len(encodeCache("/v1/write/logging", pts)))
+
+	sc, err := newSinkCache(sk, t.TempDir(), &sinkCacheOption{
+		capacity:   entrySize * 2,
+		minBackoff: time.Second,
+		maxBackoff: time.Second,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, sc.write("/v1/write/logging", mockPoints(t, 2)))
+	}
+
+	st := sc.stat()
+	assert.Equal(t, uint64(2), st.DroppedPts) // the oldest dropped
+	assert.Equal(t, entrySize*2, st.CacheSize)
+
+	// too large to cache
+	assert.Error(t, sc.write("/v1/write/logging", mockPoints(t, 10)))
+	assert.Equal(t, uint64(12), sc.stat().DroppedPts)
+
+	sk.fail = false
+	sc.retry(time.Now())
+	assert.Equal(t, uint64(4), sc.stat().RetryOK)
+}
+
+type blockingSink struct {
+	mockSink
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(category string, pts []*point.Point) error {
+	s.entered <- struct{}{}
+	<-s.release
+	return s.mockSink.Write(category, pts)
+}
+
+// go test -v -timeout 30s -run ^TestSinkCacheRetryNotBlocking$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
+func TestSinkCacheRetryNotBlocking(t *testing.T) {
+	sk := &blockingSink{
+		mockSink: mockSink{written: map[string]int{}},
+		entered:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+
+	sc, err := newSinkCache(sk, t.TempDir(), &sinkCacheOption{
+		capacity:   1024 * 1024,
+		minBackoff: time.Second,
+		maxBackoff: time.Second,
+	})
+	require.NoError(t, err)
+	require.NoError(t, sc.put("/v1/write/logging", mockPoints(t, 3)))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc.retry(time.Now())
+	}()
+
+	<-sk.entered
+
+	// caching and stats not blocked by the hanging sink
+	putDone := make(chan error)
+	go func() {
+		putDone <- sc.put("/v1/write/metric", mockPoints(t, 2))
+	}()
+
+	select {
+	case err := <-putDone:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("put blocked by retry")
+	}
+	assert.Equal(t, uint64(5), sc.stat().CachedPts)
+
+	// release the first and the second entry
+	close(sk.release)
+	go func() {
+		for range sk.entered {
+		}
+	}()
+	<-done
+	close(sk.entered)
+
+	st := sc.stat()
+	assert.Equal(t, uint64(5), st.RetryOK)
+	assert.Equal(t, int64(0), st.CacheSize)
+	assert.Equal(t, map[string]int{"/v1/write/logging": 3, "/v1/write/metric": 2}, sk.written)
+}
+
+// go test -v -timeout 30s -run ^TestSinkCacheReload$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
+func TestSinkCacheReload(t *testing.T) {
+	dir := t.TempDir()
+	sk := &mockSink{fail: true, written: map[string]int{}}
+	opt := &sinkCacheOption{capacity: 1024 * 1024, minBackoff: time.Second, maxBackoff: time.Second}
+
+	sc, err := newSinkCache(sk, dir, opt)
+	require.NoError(t, err)
+	assert.Error(t, sc.write("/v1/write/logging", mockPoints(t, 3)))
+	size := sc.stat().CacheSize
+	require.NoError(t, sc.l.Close())
+
+	// cached points left by last running are sent after restart
+	sc, err = newSinkCache(sk, dir, opt)
+	require.NoError(t, err)
+	assert.Equal(t, size, sc.stat().CacheSize)
+
+	sk.fail = false
+	sc.retry(time.Now())
+	assert.Equal(t, 3, sk.written["/v1/write/logging"])
+}
+
+// go test -v -timeout 30s -run ^TestGetSinkCacheOption$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
+func TestGetSinkCacheOption(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     map[string]interface{}
+		expect *sinkCacheOption
+		fail   bool
+	}{
+		{
+			name:   "default",
+			in:     map[string]interface{}{},
+			expect: &sinkCacheOption{capacity: -1, minBackoff: time.Second, maxBackoff: time.Minute},
+		},
+		{
+			name: "toml",
+			in: map[string]interface{}{
+				"cache_max_size_mb": int64(16), "retry_backoff": "2s", "retry_max_backoff": "30s",
+			},
+			expect: &sinkCacheOption{capacity: 16 * 1024 * 1024, minBackoff: 2 * time.Second, maxBackoff: 30 * time.Second},
+		},
+		{
+			name:   "env-disabled",
+			in:     map[string]interface{}{"cache_max_size_mb": "0"},
+			expect: &sinkCacheOption{capacity: -1, minBackoff: time.Second, maxBackoff: time.Minute},
+		},
+		{
+			name: "invalid-size",
+			in:   map[string]interface{}{"cache_max_size_mb": "abc"},
+			fail: true,
+		},
+		{
+			name: "invalid-backoff",
+			in:   map[string]interface{}{"retry_backoff": "-1s"},
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opt, err := getSinkCacheOption(tc.in)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, opt)
+		})
+	}
+}
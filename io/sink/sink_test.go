@@ -96,6 +96,9 @@ func TestCheckSinksConfig(t *testing.T) {
 
 // go test -v -timeout 30s -run ^TestBuildSinkImpls$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink
 func TestBuildSinkImpls(t *testing.T) {
+	dir := t.TempDir()
+	sinkCacheDir = func() string { return dir }
+
 	cases := []struct {
 		name        string
 		in          []map[string]interface{}
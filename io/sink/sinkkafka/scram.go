@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package sinkkafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// scramClient implements sarama.SCRAMClient.
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+var _ sarama.SCRAMClient = (*scramClient)(nil)
+
+func (x *scramClient) Begin(userName, password, authzID string) (err error) {
+	x.Client, err = x.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	x.ClientConversation = x.Client.NewConversation()
+	return nil
+}
+
+func (x *scramClient) Step(challenge string) (string, error) {
+	return x.ClientConversation.Step(challenge)
+}
+
+func (x *scramClient) Done() bool {
+	return x.ClientConversation.Done()
+}
+
+// scramClientGenerator returns the SCRAM client generator of the mechanism,
+// or nil if it's not SCRAM.
+func scramClientGenerator(mechanism string) func() sarama.SCRAMClient {
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256:
+		return func() sarama.SCRAMClient { return &scramClient{HashGeneratorFcn: sha256.New} }
+	case sarama.SASLTypeSCRAMSHA512:
+		return func() sarama.SCRAMClient { return &scramClient{HashGeneratorFcn: sha512.New} }
+	default:
+		return nil
+	}
+}
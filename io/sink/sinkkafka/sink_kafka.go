@@ -0,0 +1,401 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package sinkkafka contains Kafka sink implement
+package sinkkafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/Shopify/sarama"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/dkstring"
+	dknet "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/net"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/sinkfuncs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
+)
+
+const (
+	creatorID = "kafka"
+
+	defaultTopic   = "datakit"
+	defaultTimeout = 10 * time.Second
+
+	formatLineProtocol = "line_protocol"
+	formatJSON         = "json"
+
+	topicKeyPrefix = "topic_"
+
+	logName = "sink_kafka"
+)
+
+var (
+	_             sinkcommon.ISink = new(SinkKafka)
+	initSucceeded                  = false
+	l                              = logger.DefaultSLogger(logName)
+	onceInit      sync.Once
+
+	newSyncProducer = sarama.NewSyncProducer
+)
+
+type SinkKafka struct {
+	ID    string // sink config identity, unique, automatically generated.
+	IDStr string // MD5 origin string.
+
+	brokers []string // required. eg. 172.16.239.130:9092
+
+	topic           string            // option. default topic of all categories
+	topics          map[string]string // option. category short name(M/L/...) to topic
+	format          string            // option. line_protocol or json
+	partitionKeyTag string            // option. tag value used as message key
+
+	config *sarama.Config
+
+	producer sarama.SyncProducer
+	lock     sync.Mutex
+}
+
+func (s *SinkKafka) LoadConfig(mConf map[string]interface{}) error {
+	onceInit.Do(func() {
+		l = logger.SLogger(logName)
+	})
+
+	if id, str, err := sinkfuncs.GetSinkCreatorID(mConf); err != nil {
+		return err
+	} else {
+		s.ID = id
+		s.IDStr = str
+	}
+
+	if host, err := dkstring.GetMapAssertString("host", mConf); err != nil {
+		return err
+	} else {
+		hostNew, err := dkstring.CheckNotEmpty(host, "host")
+		if err != nil {
+			return err
+		}
+
+		for _, broker := range strings.Split(hostNew, ",") {
+			if broker = strings.TrimSpace(broker); broker != "" {
+				s.brokers = append(s.brokers, broker)
+			}
+		}
+	}
+
+	if topic, err := dkstring.GetMapAssertString("topic", mConf); err != nil {
+		return err
+	} else if topic != "" {
+		s.topic = topic
+	} else {
+		s.topic = defaultTopic
+	}
+
+	s.topics = map[string]string{}
+	for k := range mConf {
+		if !strings.HasPrefix(k, topicKeyPrefix) {
+			continue
+		}
+
+		category := strings.ToUpper(strings.TrimPrefix(k, topicKeyPrefix))
+		if _, ok := datakit.CategoryMapReverse[category]; !ok {
+			return fmt.Errorf("invalid %s: unknown category %s", k, category)
+		}
+
+		topic, err := dkstring.GetMapAssertString(k, mConf)
+		if err != nil {
+			return err
+		}
+		topicNew, err := dkstring.CheckNotEmpty(topic, k)
+		if err != nil {
+			return err
+		}
+		s.topics[category] = topicNew
+	}
+
+	if format, err := dkstring.GetMapAssertString("format", mConf); err != nil {
+		return err
+	} else {
+		switch format {
+		case "", formatLineProtocol:
+			s.format = formatLineProtocol
+		case formatJSON:
+			s.format = formatJSON
+		default:
+			return fmt.Errorf("not support format: %s", format)
+		}
+	}
+
+	if tag, err := dkstring.GetMapAssertString("partition_key_tag", mConf); err != nil {
+		return err
+	} else {
+		s.partitionKeyTag = tag
+	}
+
+	config, err := buildConfig(mConf)
+	if err != nil {
+		return err
+	}
+	s.config = config
+
+	initSucceeded = true
+	sinkcommon.AddImpl(s)
+	return nil
+}
+
+func buildConfig(mConf map[string]interface{}) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true // required by sync producer
+	config.Producer.Return.Errors = true
+	config.Producer.Retry.Max = 3
+	name, _ := os.Hostname()
+	config.ClientID = name
+
+	if ver, err := dkstring.GetMapAssertString("version", mConf); err != nil {
+		return nil, err
+	} else if ver != "" {
+		version, err := sarama.ParseKafkaVersion(ver)
+		if err != nil {
+			return nil, err
+		}
+		config.Version = version
+	}
+
+	if timeout, err := dkstring.GetMapAssertString("timeout", mConf); err != nil {
+		return nil, err
+	} else {
+		td := defaultTimeout
+		if timeout != "" {
+			if td, err = time.ParseDuration(timeout); err != nil {
+				return nil, err
+			}
+		}
+		config.Net.DialTimeout = td
+		config.Net.ReadTimeout = td
+		config.Net.WriteTimeout = td
+		config.Producer.Timeout = td
+	}
+
+	if acks, err := dkstring.GetMapAssertString("required_acks", mConf); err != nil {
+		return nil, err
+	} else {
+		switch acks {
+		case "", "leader":
+			config.Producer.RequiredAcks = sarama.WaitForLocal
+		case "none":
+			config.Producer.RequiredAcks = sarama.NoResponse
+		case "all":
+			config.Producer.RequiredAcks = sarama.WaitForAll
+		default:
+			return nil, fmt.Errorf("not support required_acks: %s", acks)
+		}
+	}
+
+	// SASL
+	if mechanism, err := dkstring.GetMapAssertString("sasl_mechanism", mConf); err != nil {
+		return nil, err
+	} else if mechanism != "" {
+		mechanism = strings.ToUpper(mechanism)
+		switch mechanism {
+		case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+		default:
+			return nil, fmt.Errorf("not support sasl_mechanism: %s", mechanism)
+		}
+
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+		config.Net.SASL.SCRAMClientGeneratorFunc = scramClientGenerator(mechanism)
+		if config.Net.SASL.User, err = dkstring.GetMapAssertString("sasl_username", mConf); err != nil {
+			return nil, err
+		}
+		if config.Net.SASL.Password, err = dkstring.GetMapAssertString("sasl_password", mConf); err != nil {
+			return nil, err
+		}
+	}
+
+	// TLS
+	tlsEnable, err := getMapBool("tls_enable", mConf)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnable {
+		tlsConf := &dknet.TLSClientConfig{}
+		if ca, err := dkstring.GetMapAssertString("tls_ca", mConf); err != nil {
+			return nil, err
+		} else if ca != "" {
+			tlsConf.CaCerts = strings.Split(ca, ",")
+		}
+		if tlsConf.Cert, err = dkstring.GetMapAssertString("tls_cert", mConf); err != nil {
+			return nil, err
+		}
+		if tlsConf.CertKey, err = dkstring.GetMapAssertString("tls_key", mConf); err != nil {
+			return nil, err
+		}
+		if tlsConf.InsecureSkipVerify, err = getMapBool("tls_insecure_skip_verify", mConf); err != nil {
+			return nil, err
+		}
+
+		tc, err := tlsConf.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tc
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// getMapBool accept bool from TOML and string from sink ENV.
+func getMapBool(name string, m map[string]interface{}) (bool, error) {
+	val, ok := m[name]
+	if !ok {
+		return false, nil
+	}
+
+	switch x := val.(type) {
+	case bool:
+		return x, nil
+	case string:
+		if x == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(x)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("invalid %s: not bool", name)
+	}
+}
+
+func (s *SinkKafka) Write(category string, pts []*point.Point) error {
+	if !initSucceeded {
+		return fmt.Errorf("not_init")
+	}
+
+	msgs, err := s.buildMessages(category, pts)
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.producer == nil {
+		producer, err := newSyncProducer(s.brokers, s.config)
+		if err != nil {
+			return err
+		}
+		s.producer = producer
+	}
+
+	if err := s.producer.SendMessages(msgs); err != nil {
+		// reconnect on next write
+		if e := s.producer.Close(); e != nil {
+			l.Warnf("close kafka producer failed: %s", e)
+		}
+		s.producer = nil
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *SinkKafka) getTopic(category string) string {
+	if topic, ok := s.topics[datakit.CategoryMap[category]]; ok {
+		return topic
+	}
+
+	return s.topic
+}
+
+func (s *SinkKafka) buildMessages(category string, pts []*point.Point) ([]*sarama.ProducerMessage, error) {
+	topic := s.getTopic(category)
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(pts))
+	for _, pt := range pts {
+		var value []byte
+
+		switch s.format {
+		case formatJSON:
+			fields, err := pt.Fields()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err = json.Marshal(&sinkcommon.JSONPoint{
+				Measurement: pt.Name(),
+				Tags:        pt.Tags(),
+				Fields:      fields,
+				Time:        pt.Time(),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+		default:
+			value = []byte(pt.String())
+		}
+
+		msg := &sarama.ProducerMessage{
+			Topic: topic,
+			Value: sarama.ByteEncoder(value),
+		}
+
+		if s.partitionKeyTag != "" {
+			if v, ok := pt.Tags()[s.partitionKeyTag]; ok {
+				msg.Key = sarama.StringEncoder(v)
+			}
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+func (s *SinkKafka) GetInfo() *sinkcommon.SinkInfo {
+	return &sinkcommon.SinkInfo{
+		ID:       s.ID,
+		IDStr:    s.IDStr,
+		CreateID: creatorID,
+		Categories: []string{
+			datakit.SinkCategoryMetric,
+			datakit.SinkCategoryNetwork,
+			datakit.SinkCategoryKeyEvent,
+			datakit.SinkCategoryObject,
+			datakit.SinkCategoryCustomObject,
+			datakit.SinkCategoryLogging,
+			datakit.SinkCategoryTracing,
+			datakit.SinkCategoryRUM,
+			datakit.SinkCategorySecurity,
+			datakit.SinkCategoryProfiling,
+		},
+	}
+}
+
+func init() { //nolint:gochecknoinits
+	sinkcommon.AddCreator(creatorID, func() sinkcommon.ISink {
+		return &SinkKafka{}
+	})
+}
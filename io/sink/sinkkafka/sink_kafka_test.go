@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package sinkkafka
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
+)
+
+func getTestPoints(t *testing.T, n int) []*point.Point {
+	t.Helper()
+
+	var pts []*point.Point
+	for i := 0; i < n; i++ {
+		pt, err := point.NewPoint("cpu",
+			map[string]string{"host": "host-1", "region": "us-west1"},
+			map[string]interface{}{"usage": 12.5},
+			&point.PointOption{Time: time.Unix(1, 0), Category: datakit.Metric})
+		require.NoError(t, err)
+		pts = append(pts, pt)
+	}
+
+	return pts
+}
+
+// go test -v -timeout 30s -run ^TestLoadConfig$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkkafka
+func TestLoadConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		in   map[string]interface{}
+		fail bool
+		cb   func(t *testing.T, s *SinkKafka)
+	}{
+		{
+			name: "default",
+			in:   map[string]interface{}{"host": "1.1.1.1:9092, 1.1.1.2:9092"},
+			cb: func(t *testing.T, s *SinkKafka) {
+				t.Helper()
+				assert.Equal(t, []string{"1.1.1.1:9092", "1.1.1.2:9092"}, s.brokers)
+				assert.Equal(t, defaultTopic, s.topic)
+				assert.Equal(t, formatLineProtocol, s.format)
+				assert.Equal(t, sarama.WaitForLocal, s.config.Producer.RequiredAcks)
+			},
+		},
+		{
+			name: "full",
+			in: map[string]interface{}{
+				"host":                     "1.1.1.1:9092",
+				"topic":                    "dk",
+				"topic_m":                  "dk-metric",
+				"topic_L":                  "dk-logging",
+				"format":                   "json",
+				"partition_key_tag":        "host",
+				"required_acks":            "all",
+				"version":                  "2.1.0",
+				"timeout":                  "3s",
+				"sasl_mechanism":           "plain",
+				"sasl_username":            "user",
+				"sasl_password":            "pwd",
+				"tls_enable":               "true",
+				"tls_insecure_skip_verify": true,
+			},
+			cb: func(t *testing.T, s *SinkKafka) {
+				t.Helper()
+				assert.Equal(t, map[string]string{"M": "dk-metric", "L": "dk-logging"}, s.topics)
+				assert.Equal(t, formatJSON, s.format)
+				assert.Equal(t, sarama.WaitForAll, s.config.Producer.RequiredAcks)
+				assert.Equal(t, sarama.V2_1_0_0, s.config.Version)
+				assert.Equal(t, 3*time.Second, s.config.Net.DialTimeout)
+				assert.True(t, s.config.Net.SASL.Enable)
+				assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypePlaintext), s.config.Net.SASL.Mechanism)
+				assert.True(t, s.config.Net.TLS.Enable)
+				assert.True(t, s.config.Net.TLS.Config.InsecureSkipVerify)
+			},
+		},
+		{
+			name: "scram",
+			in: map[string]interface{}{
+				"host":           "1.1.1.1:9092",
+				"sasl_mechanism": "scram-sha-512",
+				"sasl_username":  "user",
+				"sasl_password":  "pwd",
+			},
+			cb: func(t *testing.T, s *SinkKafka) {
+				t.Helper()
+				assert.True(t, s.config.Net.SASL.Enable)
+				assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), s.config.Net.SASL.Mechanism)
+				require.NotNil(t, s.config.Net.SASL.SCRAMClientGeneratorFunc)
+
+				c := s.config.Net.SASL.SCRAMClientGeneratorFunc()
+				require.NoError(t, c.Begin("user", "pwd", ""))
+				msg, err := c.Step("")
+				require.NoError(t, err)
+				assert.Contains(t, msg, "n=user")
+				assert.False(t, c.Done())
+			},
+		},
+		{
+			name: "no-host",
+			in:   map[string]interface{}{},
+			fail: true,
+		},
+		{
+			name: "invalid-category-topic",
+			in:   map[string]interface{}{"host": "1.1.1.1:9092", "topic_x": "abc"},
+			fail: true,
+		},
+		{
+			name: "invalid-format",
+			in:   map[string]interface{}{"host": "1.1.1.1:9092", "format": "xml"},
+			fail: true,
+		},
+		{
+			name: "invalid-acks",
+			in:   map[string]interface{}{"host": "1.1.1.1:9092", "required_acks": "2"},
+			fail: true,
+		},
+		{
+			name: "invalid-sasl",
+			in:   map[string]interface{}{"host": "1.1.1.1:9092", "sasl_mechanism": "GSSAPI"},
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &SinkKafka{}
+			err := s.LoadConfig(tc.in)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tc.cb(t, s)
+		})
+	}
+}
+
+// go test -v -timeout 30s -run ^TestBuildMessages$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkkafka
+func TestBuildMessages(t *testing.T) {
+	s := &SinkKafka{}
+	require.NoError(t, s.LoadConfig(map[string]interface{}{
+		"host":              "1.1.1.1:9092",
+		"topic_m":           "dk-metric",
+		"partition_key_tag": "host",
+	}))
+
+	pts := getTestPoints(t, 2)
+
+	msgs, err := s.buildMessages(datakit.Metric, pts)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "dk-metric", msgs[0].Topic)
+	assert.Equal(t, sarama.StringEncoder("host-1"), msgs[0].Key)
+	assert.Equal(t, sarama.ByteEncoder(pts[0].String()), msgs[0].Value)
+
+	// category without topic mapping goes to the default topic
+	msgs, err = s.buildMessages(datakit.Logging, pts)
+	require.NoError(t, err)
+	assert.Equal(t, defaultTopic, msgs[0].Topic)
+
+	s.format = formatJSON
+	s.partitionKeyTag = "not-exist"
+	msgs, err = s.buildMessages(datakit.Metric, pts)
+	require.NoError(t, err)
+	assert.Nil(t, msgs[0].Key)
+
+	value, err := msgs[0].Value.Encode()
+	require.NoError(t, err)
+
+	var jp sinkcommon.JSONPoint
+	require.NoError(t, json.Unmarshal(value, &jp))
+	assert.Equal(t, "cpu", jp.Measurement)
+	assert.Equal(t, "host-1", jp.Tags["host"])
+	assert.Equal(t, 12.5, jp.Fields["usage"])
+	assert.True(t, time.Unix(1, 0).Equal(jp.Time))
+}
+
+// go test -v -timeout 30s -run ^TestWrite$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkkafka
+func TestWrite(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	produce := sarama.NewMockProduceResponse(t)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("dk-metric", 0, broker.BrokerID()).
+			SetLeader("dk-logging", 0, broker.BrokerID()),
+		"ProduceRequest": produce,
+	})
+
+	s := &SinkKafka{}
+	require.NoError(t, s.LoadConfig(map[string]interface{}{
+		"host":    broker.Addr(),
+		"topic_m": "dk-metric",
+		"topic_l": "dk-logging",
+		"timeout": "1s",
+		"version": "0.8.2.0", // mock produce response is version 0
+	}))
+	s.config.Producer.Retry.Max = 0
+
+	require.NoError(t, s.Write(datakit.Metric, getTestPoints(t, 3)))
+	require.NoError(t, s.Write(datakit.Logging, getTestPoints(t, 2)))
+
+	produced := 0
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+		}
+	}
+	assert.GreaterOrEqual(t, produced, 2) // messages may be split into multiple requests
+
+	// delivery not acked by broker
+	produce.SetError("dk-metric", 0, sarama.ErrNotEnoughReplicas)
+	assert.Error(t, s.Write(datakit.Metric, getTestPoints(t, 1)))
+	assert.Nil(t, s.producer)
+
+	// producer recreated on next write
+	produce.SetError("dk-metric", 0, sarama.ErrNoError)
+	assert.NoError(t, s.Write(datakit.Metric, getTestPoints(t, 1)))
+}
@@ -0,0 +1,351 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package sinkpromremote contains Prometheus remote write sink implement
+package sinkpromremote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/golang/snappy"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/dkstring"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/sinkfuncs"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkcommon"
+)
+
+const (
+	creatorID = "promremote"
+	logName   = "sink_promremote"
+
+	defaultTimeout   = 10 * time.Second
+	defaultBatchSize = 1000
+	defaultUserAgent = "datakit-promremote"
+
+	remoteWriteVersion = "0.1.0"
+	headerPrefix       = "header_"
+)
+
+var (
+	_             sinkcommon.ISink = new(SinkPromRemote)
+	initSucceeded                  = false
+	l                              = logger.DefaultSLogger(logName)
+)
+
+type SinkPromRemote struct {
+	ID    string // sink config identity, unique, automatically generated.
+	IDStr string // MD5 origin string.
+
+	url string // required. eg. http://127.0.0.1:9009/api/v1/push
+
+	timeout     time.Duration     // option.
+	batchSize   int               // option. max series in one request
+	headers     map[string]string // option. extra HTTP headers, such as X-Scope-OrgID
+	username    string            // option. basic auth
+	password    string            // option. basic auth
+	bearerToken string            // option.
+
+	cli *http.Client
+}
+
+func (s *SinkPromRemote) LoadConfig(mConf map[string]interface{}) error {
+	l = logger.SLogger(logName)
+
+	if id, str, err := sinkfuncs.GetSinkCreatorID(mConf); err != nil {
+		return err
+	} else {
+		s.ID = id
+		s.IDStr = str
+	}
+
+	if u, err := dkstring.GetMapAssertString("url", mConf); err != nil {
+		return err
+	} else if u != "" {
+		s.url = u
+	} else {
+		// from sink ENV: promremote://host:port?path=/api/v1/push&scheme=https
+		host, err := dkstring.GetMapAssertString("host", mConf)
+		if err != nil {
+			return err
+		}
+		if host, err = dkstring.CheckNotEmpty(host, "url or host"); err != nil {
+			return err
+		}
+
+		scheme, err := dkstring.GetMapAssertString("scheme", mConf)
+		if err != nil {
+			return err
+		}
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		path, err := dkstring.GetMapAssertString("path", mConf)
+		if err != nil {
+			return err
+		}
+
+		s.url = scheme + "://" + host + path
+	}
+
+	if u, err := url.Parse(s.url); err != nil {
+		return err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url %s: scheme should be http or https", s.url)
+	}
+
+	if timeout, err := dkstring.GetMapAssertString("timeout", mConf); err != nil {
+		return err
+	} else {
+		if timeout != "" {
+			td, err := time.ParseDuration(timeout)
+			if err != nil {
+				return err
+			}
+			s.timeout = td
+		} else {
+			s.timeout = defaultTimeout
+		}
+	}
+
+	s.batchSize = defaultBatchSize
+	if v, ok := mConf["batch_size"]; ok {
+		n, err := strconv.Atoi(fmt.Sprintf("%v", v))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid batch_size: %v", v)
+		}
+		s.batchSize = n
+	}
+
+	var err error
+	if s.username, err = dkstring.GetMapAssertString("username", mConf); err != nil {
+		return err
+	}
+	if s.password, err = dkstring.GetMapAssertString("password", mConf); err != nil {
+		return err
+	}
+	if s.bearerToken, err = dkstring.GetMapAssertString("bearer_token", mConf); err != nil {
+		return err
+	}
+
+	// extra headers: header_X-Scope-OrgID = "tenant-1"
+	s.headers = map[string]string{}
+	for k := range mConf {
+		if !strings.HasPrefix(k, headerPrefix) {
+			continue
+		}
+
+		v, err := dkstring.GetMapAssertString(k, mConf)
+		if err != nil {
+			return err
+		}
+		s.headers[strings.TrimPrefix(k, headerPrefix)] = v
+	}
+
+	s.cli = &http.Client{Timeout: s.timeout}
+
+	initSucceeded = true
+	sinkcommon.AddImpl(s)
+	return nil
+}
+
+func (s *SinkPromRemote) Write(category string, pts []*point.Point) error {
+	if !initSucceeded {
+		return fmt.Errorf("not_init")
+	}
+
+	series := pointsToSeries(pts)
+	if len(series) == 0 {
+		l.Debugf("no series from %d points", len(pts))
+		return nil
+	}
+
+	for start := 0; start < len(series); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(series) {
+			end = len(series)
+		}
+
+		if err := s.send(&prompb.WriteRequest{Timeseries: series[start:end]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SinkPromRemote) send(wr *prompb.WriteRequest) error {
+	data, err := wr.Marshal()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	switch {
+	case s.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+s.bearerToken)
+	case s.username != "":
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("remote write to %s failed, status code: %d, body: %s", s.url, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// pointsToSeries converts each numeric field of the points to a series named
+// measurement_field, tags are converted to labels. String fields are ignored.
+func pointsToSeries(pts []*point.Point) []prompb.TimeSeries {
+	var series []prompb.TimeSeries
+
+	for _, pt := range pts {
+		fields, err := pt.Fields()
+		if err != nil {
+			l.Warnf("get fields failed: %s, ignored", err)
+			continue
+		}
+
+		tagLabels := tagsToLabels(pt.Tags())
+		ts := pt.Time().UnixNano() / int64(time.Millisecond)
+		measurement := pt.Name()
+
+		for k, v := range fields {
+			var val float64
+			switch x := v.(type) {
+			case int64:
+				val = float64(x)
+			case uint64:
+				val = float64(x)
+			case float64:
+				val = x
+			case bool:
+				if x {
+					val = 1
+				}
+			default: // string and others ignored
+				continue
+			}
+
+			labels := make([]prompb.Label, 0, len(tagLabels)+1)
+			labels = append(labels, prompb.Label{
+				Name:  model.MetricNameLabel,
+				Value: sanitizeName(measurement+"_"+k, true),
+			})
+			labels = append(labels, tagLabels...)
+			sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+			series = append(series, prompb.TimeSeries{
+				Labels:  labels,
+				Samples: []prompb.Sample{{Value: val, Timestamp: ts}},
+			})
+		}
+	}
+
+	return series
+}
+
+// tagsToLabels converts tags to labels. Tags whose sanitized name collides
+// with the metric name label or another tag are dropped, and the tag whose
+// name is valid already wins, such as a_b over a.b.
+func tagsToLabels(tags map[string]string) []prompb.Label {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labels := make([]prompb.Label, 0, len(keys))
+	index := map[string]int{} // label name -> index in labels
+
+	for _, k := range keys {
+		name := sanitizeName(k, false)
+		if name == model.MetricNameLabel {
+			l.Debugf("tag %q conflicts with metric name label, dropped", k)
+			continue
+		}
+
+		if i, ok := index[name]; ok {
+			if name == k { // the former one is sanitized
+				labels[i].Value = tags[k]
+			}
+			l.Debugf("tags conflict on label %q, value %q kept", name, labels[i].Value)
+			continue
+		}
+
+		index[name] = len(labels)
+		labels = append(labels, prompb.Label{Name: name, Value: tags[k]})
+	}
+
+	return labels
+}
+
+// sanitizeName replaces characters not allowed in Prometheus metric/label name with '_',
+// colon is only allowed in metric name.
+func sanitizeName(name string, allowColon bool) string {
+	var sb strings.Builder
+	sb.Grow(len(name))
+
+	for i, r := range name {
+		switch {
+		case r == '_' || (r == ':' && allowColon) ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'),
+			r >= '0' && r <= '9' && i > 0:
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	return sb.String()
+}
+
+func (s *SinkPromRemote) GetInfo() *sinkcommon.SinkInfo {
+	return &sinkcommon.SinkInfo{
+		ID:         s.ID,
+		IDStr:      s.IDStr,
+		CreateID:   creatorID,
+		Categories: []string{datakit.SinkCategoryMetric},
+	}
+}
+
+func init() { //nolint:gochecknoinits
+	sinkcommon.AddCreator(creatorID, func() sinkcommon.ISink {
+		return &SinkPromRemote{}
+	})
+}
@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package sinkpromremote
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+func getTestPoint(t *testing.T) *point.Point {
+	t.Helper()
+
+	pt, err := point.NewPoint("cpu",
+		map[string]string{"host": "host-1", "cpu.name": "cpu0"},
+		map[string]interface{}{"usage": 12.5, "cores": 4, "online": true, "model": "xeon"},
+		&point.PointOption{Time: time.Unix(1, 0), Category: datakit.Metric})
+	require.NoError(t, err)
+
+	return pt
+}
+
+// go test -v -timeout 30s -run ^TestPointsToSeries$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote
+func TestPointsToSeries(t *testing.T) {
+	series := pointsToSeries([]*point.Point{getTestPoint(t)})
+	require.Len(t, series, 3) // string field ignored
+
+	got := map[string]float64{}
+	for _, ts := range series {
+		require.Len(t, ts.Samples, 1)
+		assert.Equal(t, int64(1000), ts.Samples[0].Timestamp)
+
+		require.Len(t, ts.Labels, 3)
+		// labels sorted by name
+		assert.Equal(t, "__name__", ts.Labels[0].Name)
+		assert.Equal(t, prompb.Label{Name: "cpu_name", Value: "cpu0"}, ts.Labels[1])
+		assert.Equal(t, prompb.Label{Name: "host", Value: "host-1"}, ts.Labels[2])
+
+		got[ts.Labels[0].Value] = ts.Samples[0].Value
+	}
+
+	assert.Equal(t, map[string]float64{"cpu_usage": 12.5, "cpu_cores": 4, "cpu_online": 1}, got)
+}
+
+// go test -v -timeout 30s -run ^TestPointsToSeriesLabelConflict$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote
+func TestPointsToSeriesLabelConflict(t *testing.T) {
+	pt, err := point.NewPoint("cpu",
+		map[string]string{"a.b": "1", "a-b": "2", "a_b": "3", "c.d": "4", "c-d": "5", "__name__": "x"},
+		map[string]interface{}{"usage": 12.5},
+		&point.PointOption{Time: time.Unix(1, 0), Category: datakit.Metric})
+	require.NoError(t, err)
+
+	series := pointsToSeries([]*point.Point{pt})
+	require.Len(t, series, 1)
+
+	assert.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "cpu_usage"},
+		{Name: "a_b", Value: "3"},
+		{Name: "c_d", Value: "5"},
+	}, series[0].Labels)
+}
+
+// go test -v -timeout 30s -run ^TestSanitizeName$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "abc_def:x_1", sanitizeName("abc.def:x-1", true))
+	assert.Equal(t, "abc_def_x_1", sanitizeName("abc.def:x-1", false))
+	assert.Equal(t, "_abc", sanitizeName("1abc", false))
+}
+
+// go test -v -timeout 30s -run ^TestWrite$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote
+func TestWrite(t *testing.T) {
+	var (
+		reqs    []*prompb.WriteRequest
+		headers []http.Header
+		status  = http.StatusNoContent
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		data, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+
+		var wr prompb.WriteRequest
+		require.NoError(t, wr.Unmarshal(data))
+
+		reqs = append(reqs, &wr)
+		headers = append(headers, r.Header)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	s := &SinkPromRemote{}
+	require.NoError(t, s.LoadConfig(map[string]interface{}{
+		"url":                  ts.URL + "/api/v1/push",
+		"batch_size":           int64(2),
+		"username":             "user",
+		"password":             "pwd",
+		"header_X-Scope-OrgID": "tenant-1",
+	}))
+
+	require.NoError(t, s.Write(datakit.Metric, []*point.Point{getTestPoint(t)}))
+
+	// 3 series split into 2 requests
+	require.Len(t, reqs, 2)
+	assert.Len(t, reqs[0].Timeseries, 2)
+	assert.Len(t, reqs[1].Timeseries, 1)
+
+	h := headers[0]
+	assert.Equal(t, "snappy", h.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", h.Get("Content-Type"))
+	assert.Equal(t, "0.1.0", h.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "tenant-1", h.Get("X-Scope-OrgID"))
+	user, pwd, ok := (&http.Request{Header: h}).BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pwd", pwd)
+
+	status = http.StatusBadRequest
+	assert.Error(t, s.Write(datakit.Metric, []*point.Point{getTestPoint(t)}))
+}
+
+// go test -v -timeout 30s -run ^TestLoadConfig$ gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink/sinkpromremote
+func TestLoadConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		in   map[string]interface{}
+		url  string
+		fail bool
+	}{
+		{
+			name: "url",
+			in:   map[string]interface{}{"url": "http://1.1.1.1:8428/api/v1/write"},
+			url:  "http://1.1.1.1:8428/api/v1/write",
+		},
+		{
+			name: "env",
+			in:   map[string]interface{}{"host": "1.1.1.1:9009", "scheme": "https", "path": "/api/v1/push", "batch_size": "100"},
+			url:  "https://1.1.1.1:9009/api/v1/push",
+		},
+		{
+			name: "no-url",
+			in:   map[string]interface{}{},
+			fail: true,
+		},
+		{
+			name: "invalid-scheme",
+			in:   map[string]interface{}{"url": "udp://1.1.1.1:9009"},
+			fail: true,
+		},
+		{
+			name: "invalid-batch-size",
+			in:   map[string]interface{}{"url": "http://1.1.1.1:9009", "batch_size": "-1"},
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &SinkPromRemote{}
+			err := s.LoadConfig(tc.in)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.url, s.url)
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/dataway"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/sink"
 )
 
 type InputsStat struct {
@@ -147,6 +148,9 @@ type Stats struct {
 	// 0: ok, others: beyond usage
 	BeyondUsage uint64 `json:"beyond_usage"`
 
+	// retry queue stats of each sink instance
+	SinkStats []*sink.SinkStat `json:"sink_stats,omitempty"`
+
 	// TODO: add disk cache stats
 }
 
@@ -191,6 +195,8 @@ func GetIOStats() *Stats {
 
 		FeedDropPts: atomic.LoadUint64(&FeedDropPts),
 		BeyondUsage: atomic.LoadUint64(&dataway.BeyondUsage),
+
+		SinkStats: sink.GetSinkStats(),
 	}
 }
 
@@ -40,9 +40,11 @@ var OtherDocs = map[string]bool{
 	"datakit-sink-dev":                   true,
 	"datakit-sink-guide":                 true,
 	"datakit-sink-influxdb":              true,
+	"datakit-sink-kafka":                 true,
 	"datakit-sink-logstash":              true,
 	"datakit-sink-m3db":                  true,
 	"datakit-sink-otel-jaeger":           true,
+	"datakit-sink-promremote":            true,
 	"datakit-tools-how-to":               true,
 	"datakit-tracing":                    true,
 	"datakit-tracing-introduction":       true,
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package asn implement ASN lookup with the GeoLite2-ASN database.
+package asn
+
+import (
+	"net"
+	"path/filepath"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"github.com/oschwald/geoip2-golang"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb"
+)
+
+const defaultFile = "GeoLite2-ASN.mmdb"
+
+var l = logger.DefaultSLogger("asn")
+
+var openDB = func(f string) (*geoip2.Reader, error) {
+	return geoip2.Open(f)
+}
+
+type DB struct {
+	db *geoip2.Reader
+}
+
+// Init loads the database of config["asn_file"], relative path is based on
+// the directory <dataDir>/ipdb/geolite2.
+func (d *DB) Init(dataDir string, config map[string]string) {
+	l = logger.SLogger("asn")
+
+	f := defaultFile
+	if file, ok := config["asn_file"]; ok && len(file) > 0 {
+		f = file
+	}
+	if !filepath.IsAbs(f) {
+		f = filepath.Join(dataDir, "ipdb", "geolite2", f)
+	}
+
+	if !datakit.FileExist(f) {
+		l.Infof("%v not found, ASN lookup disabled", f)
+		return
+	}
+
+	db, err := openDB(f)
+	if err != nil {
+		l.Warnf("load ASN db %s error: %s", f, err.Error())
+		return
+	}
+	d.db = db
+}
+
+// ASN returns an empty record if the database is not loaded.
+func (d *DB) ASN(ip string) (*ipdb.ASNRecord, error) {
+	record := &ipdb.ASNRecord{}
+	if d.db == nil {
+		return record, nil
+	}
+
+	ipParse := net.ParseIP(ip)
+	if ipParse == nil {
+		return record, nil
+	}
+
+	r, err := d.db.ASN(ipParse)
+	if err != nil {
+		return record, err
+	}
+
+	record.ASN = r.AutonomousSystemNumber
+	record.Org = r.AutonomousSystemOrganization
+	return record, nil
+}
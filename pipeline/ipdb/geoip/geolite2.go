@@ -15,6 +15,7 @@ import (
 	"github.com/oschwald/geoip2-golang"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb/asn"
 )
 
 const INVALIDIP = "Invalid IP address"
@@ -27,7 +28,8 @@ var openDB = func(f string) (*geoip2.Reader, error) {
 }
 
 type Geoip struct {
-	db *geoip2.Reader
+	db  *geoip2.Reader
+	asn asn.DB
 }
 
 func (g *Geoip) loadIPLib(f string) error {
@@ -61,6 +63,8 @@ func (g *Geoip) Init(dataDir string, config map[string]string) {
 	if err := g.loadIPLib(filepath.Join(ipdbDir, ipdbFile)); err != nil {
 		l.Warnf("geolite2 load ip lib error: %s", err.Error())
 	}
+
+	g.asn.Init(dataDir, config)
 }
 
 func (g *Geoip) Geo(ip string) (*ipdb.IPdbRecord, error) {
@@ -112,3 +116,11 @@ func (g *Geoip) get(ip string) (*geoip2.City, error) {
 func (g *Geoip) SearchIsp(ip string) string {
 	return ""
 }
+
+func (g *Geoip) ASN(ip string) (*ipdb.ASNRecord, error) {
+	return g.asn.ASN(ip)
+}
+
+func (g *Geoip) Overlay(ip string) (map[string]string, bool) {
+	return nil, false
+}
@@ -16,6 +16,10 @@ type IPdb interface {
 	Init(dataDir string, config map[string]string)
 	Geo(ip string) (*IPdbRecord, error)
 	SearchIsp(ip string) string
+	// ASN returns the autonomous system of the ip.
+	ASN(ip string) (*ASNRecord, error)
+	// Overlay returns the user-defined attributes of the ip, see OverlayDB.
+	Overlay(ip string) (map[string]string, bool)
 }
 
 type IPdbRecord struct {
@@ -29,6 +33,11 @@ type IPdbRecord struct {
 	Areacode  string
 }
 
+type ASNRecord struct {
+	ASN uint
+	Org string
+}
+
 func (record *IPdbRecord) CheckData() *IPdbRecord {
 	switch record.Country { // #issue 354
 	case "TW":
@@ -17,6 +17,7 @@ import (
 	"github.com/ip2location/ip2location-go"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb/asn"
 )
 
 var l = logger.DefaultSLogger("iploc")
@@ -42,6 +43,7 @@ const (
 type IPloc struct {
 	db    DB
 	ispDB map[string]string
+	asn   asn.DB
 }
 
 func (iploc *IPloc) Init(dataDir string, config map[string]string) {
@@ -71,6 +73,8 @@ func (iploc *IPloc) Init(dataDir string, config map[string]string) {
 	if err := iploc.loadISP(filepath.Join(ipdbDir, ispFile)); err != nil {
 		l.Warnf("isp file load error: %s", err.Error())
 	}
+
+	iploc.asn.Init(dataDir, config)
 }
 
 func (iploc *IPloc) loadIPLib(f string) error {
@@ -143,6 +147,14 @@ func (iploc *IPloc) SearchIsp(ip string) string {
 	return "unknown"
 }
 
+func (iploc *IPloc) ASN(ip string) (*ipdb.ASNRecord, error) {
+	return iploc.asn.ASN(ip)
+}
+
+func (iploc *IPloc) Overlay(ip string) (map[string]string, bool) {
+	return nil, false
+}
+
 func (iploc *IPloc) Geo(ip string) (*ipdb.IPdbRecord, error) {
 	record := &ipdb.IPdbRecord{}
 	if iploc.db == nil {
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ipdb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Attributes of the overlay used by Geo, SearchIsp and ASN, other attributes
+// are user-defined, such as site and zone.
+const (
+	OverlayCountry  = "country"
+	OverlayProvince = "province"
+	OverlayCity     = "city"
+	OverlayIsp      = "isp"
+	OverlayASN      = "asn"
+	OverlayASNOrg   = "asn_org"
+)
+
+type overlayEntry struct {
+	ipNet *net.IPNet
+	ones  int
+	attrs map[string]string
+}
+
+// Overlay maps user-defined CIDRs to attributes, the longest prefix matched
+// is used.
+type Overlay struct {
+	entries []*overlayEntry
+}
+
+// NewOverlay creates the overlay of map[<cidr>]: map[<attr>]: <value>, a single
+// IP is treated as a /32 (or /128) CIDR.
+func NewOverlay(cidrAttrs map[string]map[string]string) (*Overlay, error) {
+	o := &Overlay{}
+
+	for cidr, attrs := range cidrAttrs {
+		ipNet, err := parseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		ones, _ := ipNet.Mask.Size()
+		o.entries = append(o.entries, &overlayEntry{ipNet: ipNet, ones: ones, attrs: attrs})
+	}
+
+	sort.Slice(o.entries, func(i, j int) bool {
+		if o.entries[i].ones != o.entries[j].ones {
+			return o.entries[i].ones > o.entries[j].ones
+		}
+		return o.entries[i].ipNet.String() < o.entries[j].ipNet.String()
+	})
+
+	return o, nil
+}
+
+func parseCIDR(cidr string) (*net.IPNet, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid overlay CIDR %q", cidr)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overlay CIDR %q: %w", cidr, err)
+	}
+	return ipNet, nil
+}
+
+// Lookup returns the attributes of the longest CIDR containing the ip.
+func (o *Overlay) Lookup(ip string) (map[string]string, bool) {
+	if o == nil || len(o.entries) == 0 {
+		return nil, false
+	}
+
+	ipParse := net.ParseIP(ip)
+	if ipParse == nil {
+		return nil, false
+	}
+
+	for _, e := range o.entries {
+		if e.ipNet.Contains(ipParse) {
+			return e.attrs, true
+		}
+	}
+	return nil, false
+}
+
+func (o *Overlay) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.entries)
+}
+
+// LoadOverlayCSV reads the overlay from a CSV file, the first column of the
+// header is the CIDR, the others are the attribute names, e.g.
+//
+//	cidr,site,zone
+//	10.1.0.0/16,shanghai-office,office
+//
+// Empty values are ignored.
+func LoadOverlayCSV(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck,gosec
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read overlay header: %w", err)
+	}
+	if len(header) < 2 {
+		return nil, fmt.Errorf("overlay header expects CIDR and at least one attribute")
+	}
+
+	ret := map[string]map[string]string{}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF { //nolint:errorlint
+				break
+			}
+			return nil, err
+		}
+
+		attrs := map[string]string{}
+		for i := 1; i < len(record) && i < len(header); i++ {
+			if record[i] != "" {
+				attrs[header[i]] = record[i]
+			}
+		}
+		ret[record[0]] = attrs
+	}
+
+	return ret, nil
+}
+
+// OverlayDB is an IPdb whose overlay takes precedence over the wrapped IPdb.
+type OverlayDB struct {
+	IPdb
+	overlay *Overlay
+}
+
+func NewOverlayDB(db IPdb, overlay *Overlay) *OverlayDB {
+	return &OverlayDB{IPdb: db, overlay: overlay}
+}
+
+func (o *OverlayDB) Overlay(ip string) (map[string]string, bool) {
+	return o.overlay.Lookup(ip)
+}
+
+func (o *OverlayDB) Geo(ip string) (*IPdbRecord, error) {
+	attrs, ok := o.overlay.Lookup(ip)
+	if !ok {
+		return o.IPdb.Geo(ip)
+	}
+
+	record, err := o.IPdb.Geo(ip)
+	if err != nil || record == nil {
+		record = &IPdbRecord{}
+	}
+
+	if v, ok := attrs[OverlayCountry]; ok {
+		record.Country = v
+	}
+	if v, ok := attrs[OverlayProvince]; ok {
+		record.Region = v
+	}
+	if v, ok := attrs[OverlayCity]; ok {
+		record.City = v
+	}
+	if v, ok := attrs[OverlayIsp]; ok {
+		record.Isp = v
+	}
+
+	return record, nil
+}
+
+func (o *OverlayDB) SearchIsp(ip string) string {
+	if attrs, ok := o.overlay.Lookup(ip); ok {
+		if v, ok := attrs[OverlayIsp]; ok {
+			return v
+		}
+	}
+	return o.IPdb.SearchIsp(ip)
+}
+
+func (o *OverlayDB) ASN(ip string) (*ASNRecord, error) {
+	attrs, ok := o.overlay.Lookup(ip)
+	if !ok {
+		return o.IPdb.ASN(ip)
+	}
+
+	record, err := o.IPdb.ASN(ip)
+	if err != nil || record == nil {
+		record = &ASNRecord{}
+	}
+
+	if v, ok := attrs[OverlayASN]; ok {
+		v = strings.TrimPrefix(strings.ToUpper(v), "AS")
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			record.ASN = uint(n)
+		}
+	}
+	if v, ok := attrs[OverlayASNOrg]; ok {
+		record.Org = v
+	}
+
+	return record, nil
+}
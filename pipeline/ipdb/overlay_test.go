@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package ipdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDB struct{}
+
+func (m *mockDB) Init(dataDir string, config map[string]string) {}
+func (m *mockDB) SearchIsp(ip string) string                    { return "unknown" }
+func (m *mockDB) Overlay(ip string) (map[string]string, bool)   { return nil, false }
+
+func (m *mockDB) Geo(ip string) (*IPdbRecord, error) {
+	return &IPdbRecord{Country: "CN", Region: "Shanghai", City: "Shanghai"}, nil
+}
+
+func (m *mockDB) ASN(ip string) (*ASNRecord, error) {
+	return &ASNRecord{ASN: 4134, Org: "CHINANET"}, nil
+}
+
+func TestOverlay(t *testing.T) {
+	o, err := NewOverlay(map[string]map[string]string{
+		"10.0.0.0/8":    {"zone": "dc"},
+		"10.1.0.0/16":   {"zone": "office"},
+		"10.1.2.3":      {"zone": "host"},
+		"fd00::/8":      {"zone": "vpc6"},
+		"192.168.0.0/0": {"zone": "all"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, o.Len())
+
+	for ip, zone := range map[string]string{
+		"10.1.2.3": "host",
+		"10.1.2.4": "office",
+		"10.2.0.1": "dc",
+		"fd00::1":  "vpc6",
+		"8.8.8.8":  "all",
+	} {
+		attrs, ok := o.Lookup(ip)
+		assert.True(t, ok, ip)
+		assert.Equal(t, zone, attrs["zone"], ip)
+	}
+
+	_, ok := o.Lookup("not-ip")
+	assert.False(t, ok)
+
+	_, err = NewOverlay(map[string]map[string]string{"10.0.0.0/33": {}})
+	assert.Error(t, err)
+}
+
+func TestLoadOverlayCSV(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "overlay.csv")
+	require.NoError(t, os.WriteFile(f, []byte(`cidr,site,zone
+# comment
+10.1.0.0/16,shanghai-office,office
+10.2.0.0/16,,dc
+`), 0o600))
+
+	m, err := LoadOverlayCSV(f)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"10.1.0.0/16": {"site": "shanghai-office", "zone": "office"},
+		"10.2.0.0/16": {"zone": "dc"},
+	}, m)
+
+	require.NoError(t, os.WriteFile(f, []byte("cidr\n10.1.0.0/16\n"), 0o600))
+	_, err = LoadOverlayCSV(f)
+	assert.Error(t, err)
+}
+
+func TestOverlayDB(t *testing.T) {
+	o, err := NewOverlay(map[string]map[string]string{
+		"10.0.0.0/8": {"province": "Zhejiang", "isp": "office-isp", "asn": "AS64512", "site": "hz"},
+	})
+	require.NoError(t, err)
+
+	db := NewOverlayDB(&mockDB{}, o)
+
+	r, err := db.Geo("10.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, &IPdbRecord{Country: "CN", Region: "Zhejiang", City: "Shanghai", Isp: "office-isp"}, r)
+	assert.Equal(t, "office-isp", db.SearchIsp("10.1.1.1"))
+
+	asn, err := db.ASN("10.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, &ASNRecord{ASN: 64512, Org: "CHINANET"}, asn)
+
+	attrs, ok := db.Overlay("10.1.1.1")
+	assert.True(t, ok)
+	assert.Equal(t, "hz", attrs["site"])
+
+	// not in the overlay
+	r, err = db.Geo("1.2.3.4")
+	require.NoError(t, err)
+	assert.Equal(t, "Shanghai", r.Region)
+	assert.Equal(t, "unknown", db.SearchIsp("1.2.3.4"))
+	_, ok = db.Overlay("1.2.3.4")
+	assert.False(t, ok)
+}
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -26,6 +27,8 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb/geoip"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb/iploc"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput/funcs"
 	plrefertable "gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/refertable"
@@ -57,13 +60,19 @@ func GetIPdb() ipdb.IPdb {
 }
 
 type PipelineCfg struct {
-	IPdbAttr               map[string]string `toml:"ipdb_attr"`
-	IPdbType               string            `toml:"ipdb_type"`
-	RemotePullInterval     string            `toml:"remote_pull_interval"`
-	ReferTableURL          string            `toml:"refer_table_url"`
-	ReferTablePullInterval string            `toml:"refer_table_pull_interval"`
-	UseSQLite              bool              `toml:"use_sqlite"`
-	SQLiteMemMode          bool              `toml:"sqlite_mem_mode"`
+	IPdbAttr               map[string]string            `toml:"ipdb_attr"`
+	IPdbType               string                       `toml:"ipdb_type"`
+	IPdbOverlayFile        string                       `toml:"ipdb_overlay_file"`
+	IPdbOverlay            map[string]map[string]string `toml:"ipdb_overlay"`
+	RemotePullInterval     string                       `toml:"remote_pull_interval"`
+	ReferTableURL          string                       `toml:"refer_table_url"`
+	ReferTablePullInterval string                       `toml:"refer_table_pull_interval"`
+	UseSQLite              bool                         `toml:"use_sqlite"`
+	SQLiteMemMode          bool                         `toml:"sqlite_mem_mode"`
+	MetricInterval         string                       `toml:"metric_interval"`
+	MetricMaxSeries        int                          `toml:"metric_max_series"`
+	KVDefaultTTL           string                       `toml:"kv_default_ttl"`
+	KVMaxBytes             int64                        `toml:"kv_max_bytes"`
 }
 
 func NewPipelineFromFile(category string, path string) (*Pipeline, error) {
@@ -164,6 +173,26 @@ func Init(pipelineCfg *PipelineCfg) error {
 		}
 	}
 
+	metricInterval := plmetric.DefaultInterval
+	if pipelineCfg.MetricInterval != "" {
+		if dur, err := time.ParseDuration(pipelineCfg.MetricInterval); err != nil || dur <= 0 {
+			l.Warnf("invalid metric interval %s, use default %s", pipelineCfg.MetricInterval, metricInterval)
+		} else {
+			metricInterval = dur
+		}
+	}
+	plmetric.Init(metricInterval, pipelineCfg.MetricMaxSeries)
+
+	kvTTL := plkv.DefaultTTL
+	if pipelineCfg.KVDefaultTTL != "" {
+		if dur, err := time.ParseDuration(pipelineCfg.KVDefaultTTL); err != nil || dur <= 0 {
+			l.Warnf("invalid kv default ttl %s, use default %s", pipelineCfg.KVDefaultTTL, kvTTL)
+		} else {
+			kvTTL = dur
+		}
+	}
+	plkv.Init(kvTTL, pipelineCfg.KVMaxBytes)
+
 	if err := loadPatterns(); err != nil {
 		return err
 	}
@@ -177,8 +206,8 @@ func InitIPdb(pipelineCfg *PipelineCfg) (ipdb.IPdb, error) {
 		pipelineCfg = pipelineDefaultCfg
 	}
 	if instance, ok := pipelineIPDbmap[pipelineCfg.IPdbType]; ok {
-		ipdbInstance = instance
-		ipdbInstance.Init(datakit.DataDir, pipelineCfg.IPdbAttr)
+		instance.Init(datakit.DataDir, pipelineCfg.IPdbAttr)
+		ipdbInstance = withOverlay(instance, pipelineCfg)
 		funcs.InitIPdb(ipdbInstance)
 		if pipelineCfg.IPdbType != "geolite2" {
 			ip2isp.InitIPDB(ipdbInstance)
@@ -191,6 +220,42 @@ func InitIPdb(pipelineCfg *PipelineCfg) (ipdb.IPdb, error) {
 	return ipdbInstance, nil
 }
 
+// withOverlay wraps db with the overlay of ipdb_overlay_file and ipdb_overlay,
+// the latter takes precedence for the same CIDR.
+func withOverlay(db ipdb.IPdb, pipelineCfg *PipelineCfg) ipdb.IPdb {
+	cidrAttrs := map[string]map[string]string{}
+
+	if f := pipelineCfg.IPdbOverlayFile; f != "" {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(datakit.DataDir, "ipdb", f)
+		}
+		if m, err := ipdb.LoadOverlayCSV(f); err != nil {
+			l.Warnf("load ipdb overlay file %s: %s", f, err)
+		} else {
+			for k, v := range m {
+				cidrAttrs[k] = v
+			}
+		}
+	}
+
+	for k, v := range pipelineCfg.IPdbOverlay {
+		cidrAttrs[k] = v
+	}
+
+	if len(cidrAttrs) == 0 {
+		return db
+	}
+
+	overlay, err := ipdb.NewOverlay(cidrAttrs)
+	if err != nil {
+		l.Warnf("invalid ipdb overlay: %s, ignored", err)
+		return db
+	}
+
+	l.Infof("ipdb overlay with %d CIDRs", overlay.Len())
+	return ipdb.NewOverlayDB(db, overlay)
+}
+
 func loadPatterns() error {
 	// 从文件加载 pattern
 	loadedPatterns, err := grok.LoadPatternsFromPath(datakit.PipelinePatternDir)
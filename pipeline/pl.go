@@ -17,7 +17,19 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/stats"
 )
 
-func RunPl(category string, pts []*point.Point, plOpt *plscript.Option, scriptMap map[string]string) (ret []*point.Point, retErr error) {
+const (
+	// sourceKey is the tag (or field) key of the data source.
+	sourceKey = "source"
+
+	// disabledScript disables the pipeline of the source in scriptMap.
+	disabledScript = "-"
+)
+
+// RunPl runs the script selected by the source of each point (see scriptName),
+// then the scripts in chain in order.
+func RunPl(category string, pts []*point.Point, plOpt *plscript.Option, scriptMap map[string]string,
+	chain []string,
+) (ret []*point.Point, retErr error) {
 	defer func() {
 		if err := recover(); err != nil {
 			retErr = fmt.Errorf("run pl: %s", err)
@@ -28,6 +40,8 @@ func RunPl(category string, pts []*point.Point, plOpt *plscript.Option, scriptMa
 		return pts, nil
 	}
 
+	chainScripts := queryChain(category, chain)
+
 	ret = []*point.Point{}
 	ptOpt := &point.PointOption{
 		DisableGlobalTags: true,
@@ -40,19 +54,36 @@ func RunPl(category string, pts []*point.Point, plOpt *plscript.Option, scriptMa
 	plPt := ptinput.GetPoint()
 	defer ptinput.PutPoint(plPt)
 
-	var ok bool
+	var ok, disabled bool
 	var script *plscript.PlScript
+	scripts := make([]*plscript.PlScript, 0, len(chainScripts)+1)
 	for _, pt := range pts {
 		// 这里将清理 plPt 并填充 point 到 plPt,
 		// plPt 在函数运行结束后尽量放回对象池
-		script, plPt, ok = getScriptAndFillPlPt(category, pt, scriptMap, plPt)
+		script, plPt, disabled, ok = getScriptAndFillPlPt(category, pt, scriptMap, plPt)
+
+		scripts = scripts[:0]
+		if ok && script != nil {
+			scripts = append(scripts, script)
+		} else if len(chainScripts) > 0 && !disabled {
+			// 没有数据源对应的脚本时，仍然执行 chain 中的脚本；数据源的脚本被禁用时，chain 也不执行
+			plPt, ok = fillPlPt(pt, plPt)
+		}
 
-		if !ok || script == nil {
+		if !ok {
 			ret = append(ret, pt)
 			continue
 		}
+		scripts = append(scripts, chainScripts...)
+
+		var err error
+		for _, s := range scripts {
+			script = s
+			if err = s.Run(plPt, nil, plOpt); err != nil || plPt.Drop {
+				break
+			}
+		}
 
-		err := script.Run(plPt, nil, plOpt)
 		if err != nil {
 			l.Warn(err)
 			ret = append(ret, pt)
@@ -77,130 +108,165 @@ func RunPl(category string, pts []*point.Point, plOpt *plscript.Option, scriptMa
 	return ret, nil
 }
 
+// queryChain returns the scripts of chain, scripts not found are ignored.
+func queryChain(category string, chain []string) []*plscript.PlScript {
+	var scripts []*plscript.PlScript
+	for _, name := range chain {
+		if s, ok := plscript.QueryScript(category, name); ok {
+			scripts = append(scripts, s)
+		} else {
+			l.Debugf("script %s of pipeline chain not found in category %s, ignored", name, category)
+		}
+	}
+	return scripts
+}
+
+// getScriptAndFillPlPt returns the script of the point, the third returned
+// value is true if the script is disabled by scriptMap.
 func getScriptAndFillPlPt(category string, pt *point.Point, scriptMap map[string]string, plpt *ptinput.Point) (
-	*plscript.PlScript, *ptinput.Point, bool,
+	*plscript.PlScript, *ptinput.Point, bool, bool,
 ) {
 	if pt == nil {
-		return nil, plpt, false
-	}
-	if plpt == nil {
-		plpt = &ptinput.Point{}
+		return nil, plpt, false, false
 	}
 
+	var fields map[string]interface{}
 	switch category {
 	case datakit.RUM, datakit.Security, datakit.Tracing, datakit.Profiling:
-		fields, err := pt.Fields()
-		if err != nil {
+		// 这些类型的数据源可能来自 field
+		var err error
+		if fields, err = pt.Fields(); err != nil {
 			l.Debug(err)
-			break
+			return nil, plpt, false, false
 		}
-		ptTime := pt.Time()
-		name := pt.Name()
-		tags := pt.Tags()
+	default:
+	}
 
-		scriptName, ok := scriptName(category, name, tags, fields, scriptMap)
-		if !ok {
-			break
-		}
-		if s, ok := plscript.QueryScript(category, scriptName); ok {
-			fields, err := pt.Fields()
-			if err != nil {
-				l.Errorf("Fields: %s", err)
-				break
-			}
+	scriptName, ok := scriptName(category, pt.Name(), pt.Tags(), fields, scriptMap)
+	if !ok {
+		return nil, plpt, scriptName == disabledScript, false
+	}
 
-			plpt = ptinput.InitPt(plpt, name, tags, fields, ptTime)
+	// 未查询到脚本时跳过解析 Point
+	s, ok := plscript.QueryScript(category, scriptName)
+	if !ok {
+		return nil, plpt, false, false
+	}
 
-			return s, plpt, true
-		}
-	default:
-		name := pt.Name()
-		scriptName, ok := scriptName(category, name, nil, nil, scriptMap)
-		if !ok {
-			break
-		}
-		// 未查询到脚本时条过解析 Point
-		s, ok := plscript.QueryScript(category, scriptName)
-		if !ok {
-			break
-		}
+	if plpt, ok = fillPlPt(pt, plpt); !ok {
+		return nil, plpt, false, false
+	}
 
-		fields, err := pt.Fields()
-		if err != nil {
-			l.Errorf("Fields: %s", err)
-			break
-		}
+	return s, plpt, false, true
+}
 
-		ptTime := pt.Time()
-		tags := pt.Tags()
-		plpt = ptinput.InitPt(plpt, name, tags, fields, ptTime)
-		return s, plpt, true
+func fillPlPt(pt *point.Point, plpt *ptinput.Point) (*ptinput.Point, bool) {
+	if pt == nil {
+		return plpt, false
+	}
+	if plpt == nil {
+		plpt = &ptinput.Point{}
+	}
+
+	fields, err := pt.Fields()
+	if err != nil {
+		l.Errorf("Fields: %s", err)
+		return plpt, false
 	}
 
-	return nil, plpt, false
+	return ptinput.InitPt(plpt, pt.Name(), pt.Tags(), fields, pt.Time()), true
 }
 
-func scriptName(category string, name string, tags map[string]string, fields map[string]interface{},
-	scriptMap map[string]string,
-) (string, bool) {
-	var scriptName string
+// scriptSources returns the sources of the point in order of priority, the
+// script of a source is named `<source>.p` by default.
+func scriptSources(category string, name string, tags map[string]string, fields map[string]interface{}) []string {
+	var sources []string
+	add := func(source string) {
+		if source == "" {
+			return
+		}
+		for _, s := range sources {
+			if s == source {
+				return
+			}
+		}
+		sources = append(sources, source)
+	}
 
-	// tag 优先，key 唯一
 	switch category {
 	case datakit.RUM:
-		if id, ok := tags["app_id"]; ok {
-			scriptName = joinName(id, name)
-		} else if id, ok := fields["app_id"]; ok {
-			switch id := id.(type) {
-			case string:
-				scriptName = joinName(id, name)
-			default:
-			}
+		if id := tagOrField("app_id", tags, fields); id != "" {
+			add(joinName(id, name))
 		}
+		add(tagOrField(sourceKey, tags, fields))
 	case datakit.Security:
-		if scheckCategory, ok := tags["category"]; ok {
-			scriptName = scheckCategory
-		} else if scheckCategory, ok := fields["category"]; ok {
-			switch scheckCategory := scheckCategory.(type) {
-			case string:
-				scriptName = scheckCategory
-			default:
-			}
-		}
+		add(tagOrField("category", tags, fields))
+		add(tagOrField(sourceKey, tags, fields))
 	case datakit.Tracing, datakit.Profiling:
-		if svc, ok := tags["service"]; ok {
-			scriptName = svc
-		} else if svc, ok := fields["service"]; ok {
-			switch svc := svc.(type) {
-			case string:
-				scriptName = svc
-			default:
-			}
-		}
+		add(tagOrField("service", tags, fields))
+		add(tagOrField(sourceKey, tags, fields))
+	case datakit.Network, datakit.KeyEvent, datakit.Object, datakit.CustomObject:
+		add(tags[sourceKey])
+		add(name)
 	default:
-		scriptName = name
+		add(name)
 	}
 
-	if scriptName == "" {
+	return sources
+}
+
+// tagOrField returns the string value of the key, tag 优先.
+func tagOrField(key string, tags map[string]string, fields map[string]interface{}) string {
+	if v, ok := tags[key]; ok {
+		return v
+	}
+	if v, ok := fields[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// scriptName returns the script name of the point. For each source of the
+// point, the script configured in scriptMap is preferred, then the remote
+// relation, then the remote default script of the category, at last the
+// script named after the source. disabledScript and false returned if the
+// script of the source is disabled.
+func scriptName(category string, name string, tags map[string]string, fields map[string]interface{},
+	scriptMap map[string]string,
+) (string, bool) {
+	sources := scriptSources(category, name, tags, fields)
+	if len(sources) == 0 {
 		return "", false
 	}
 
-	// 查找，值 `-` 禁用
-	if sName, ok := scriptMap[scriptName]; ok {
-		switch sName {
-		case "-":
-			return "", false
-		case "":
-		default:
+	for _, source := range sources {
+		// 查找，值 `-` 禁用
+		if sName, ok := scriptMap[source]; ok {
+			switch sName {
+			case disabledScript:
+				return disabledScript, false
+			case "":
+			default:
+				return sName, ok
+			}
+		}
+
+		if sName, ok := relation.QueryRemoteSourceRelation(category, source); ok {
 			return sName, ok
 		}
 	}
 
-	if sName, ok := relation.QueryRemoteRelation(category, scriptName); ok {
+	if sName, ok := relation.QueryRemoteDefaultPl(category); ok {
 		return sName, ok
 	}
 
-	return scriptName + ".p", true
+	for _, source := range sources {
+		if _, ok := plscript.QueryScript(category, source+".p"); ok {
+			return source + ".p", true
+		}
+	}
+
+	return sources[0] + ".p", true
 }
 
 func joinName(name ...string) string {
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	plscript "gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/script"
 )
 
 var scheckTestPointData = []byte(`0144-crontab,category=system,host=localhost.localdomain,level=warn,` +
@@ -164,3 +165,148 @@ func TestSCriptName(t *testing.T) {
 	_, ok = scriptName(datakit.RUM, pt.Name(), pt.Tags(), f, nil)
 	assert.Equal(t, false, ok)
 }
+
+func TestScriptSources(t *testing.T) {
+	cases := []struct {
+		category string
+		name     string
+		tags     map[string]string
+		fields   map[string]interface{}
+		expected []string
+	}{
+		{
+			category: datakit.Tracing,
+			tags:     map[string]string{"service": "svc", "source": "ddtrace"},
+			expected: []string{"svc", "ddtrace"},
+		},
+		{
+			category: datakit.Profiling,
+			fields:   map[string]interface{}{"source": "pyroscope"},
+			expected: []string{"pyroscope"},
+		},
+		{
+			category: datakit.Security,
+			name:     "0144-crontab",
+			tags:     map[string]string{"source": "scheck"},
+			expected: []string{"scheck"},
+		},
+		{
+			category: datakit.RUM,
+			name:     "error",
+			tags:     map[string]string{"app_id": "appid01", "source": "rum"},
+			expected: []string{"appid01_error", "rum"},
+		},
+		{
+			category: datakit.Network,
+			name:     "netflow",
+			tags:     map[string]string{"source": "ebpf"},
+			expected: []string{"ebpf", "netflow"},
+		},
+		{
+			category: datakit.KeyEvent,
+			name:     "datakit",
+			tags:     map[string]string{"source": "datakit"},
+			expected: []string{"datakit"},
+		},
+		{
+			category: datakit.Object,
+			name:     "HOST",
+			expected: []string{"HOST"},
+		},
+		{
+			category: datakit.Logging,
+			name:     "nginx",
+			tags:     map[string]string{"source": "other"},
+			expected: []string{"nginx"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.category, func(t *testing.T) {
+			assert.Equal(t, tc.expected, scriptSources(tc.category, tc.name, tc.tags, tc.fields))
+		})
+	}
+}
+
+func TestRunPlWithChain(t *testing.T) {
+	plscript.LoadScript(datakit.Network, plscript.DefaultScriptNS, map[string]string{
+		"ebpf.p":    `add_key(by_source, true)`,
+		"netflow.p": `add_key(by_name, true)`,
+		"enrich.p":  `add_key(enriched, true)`,
+		"drop.p":    `drop()`,
+	}, nil)
+	defer plscript.LoadScript(datakit.Network, plscript.DefaultScriptNS, nil, nil)
+
+	newPt := func(name string, tags map[string]string) *point.Point {
+		pt, err := point.NewPoint(name, tags, map[string]interface{}{"bytes": int64(1)},
+			&point.PointOption{Category: datakit.Network})
+		assert.NoError(t, err)
+		return pt
+	}
+
+	cases := []struct {
+		name      string
+		pt        *point.Point
+		scriptMap map[string]string
+		chain     []string
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "source-first",
+			pt:       newPt("netflow", map[string]string{"source": "ebpf"}),
+			expected: map[string]interface{}{"bytes": int64(1), "by_source": true},
+		},
+		{
+			name:     "fallback-to-name",
+			pt:       newPt("netflow", map[string]string{"source": "other"}),
+			expected: map[string]interface{}{"bytes": int64(1), "by_name": true},
+		},
+		{
+			name:      "disabled",
+			pt:        newPt("netflow", map[string]string{"source": "ebpf"}),
+			scriptMap: map[string]string{"ebpf": "-"},
+			expected:  map[string]interface{}{"bytes": int64(1)},
+		},
+		{
+			name:     "chain",
+			pt:       newPt("netflow", map[string]string{"source": "ebpf"}),
+			chain:    []string{"not_found.p", "enrich.p"},
+			expected: map[string]interface{}{"bytes": int64(1), "by_source": true, "enriched": true},
+		},
+		{
+			name:     "chain-without-source-script",
+			pt:       newPt("dnsflow", nil),
+			chain:    []string{"enrich.p"},
+			expected: map[string]interface{}{"bytes": int64(1), "enriched": true},
+		},
+		{
+			name:      "chain-disabled",
+			pt:        newPt("netflow", map[string]string{"source": "ebpf"}),
+			scriptMap: map[string]string{"ebpf": "-"},
+			chain:     []string{"enrich.p"},
+			expected:  map[string]interface{}{"bytes": int64(1)},
+		},
+		{
+			name:  "chain-drop",
+			pt:    newPt("netflow", nil),
+			chain: []string{"drop.p", "enrich.p"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := RunPl(datakit.Network, []*point.Point{tc.pt}, nil, tc.scriptMap, tc.chain)
+			assert.NoError(t, err)
+
+			if tc.expected == nil {
+				assert.Empty(t, ret)
+				return
+			}
+
+			assert.Len(t, ret, 1)
+			fields, err := ret[0].Fields()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, fields)
+		})
+	}
+}
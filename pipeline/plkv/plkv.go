@@ -0,0 +1,364 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package plkv is the key-value store of pipeline scripts, it's used to
+// share states between points, such as correlating the start and end logs
+// of a request.
+package plkv
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultTTL      = 10 * time.Minute
+	DefaultMaxBytes = 16 * 1024 * 1024
+
+	// estimated memory of an entry besides the key and value.
+	entryOverhead = 64
+
+	sweepInterval = time.Minute
+)
+
+var defaultManager = NewManager(DefaultTTL, DefaultMaxBytes)
+
+// Init sets the default TTL and the memory cap of all stores.
+func Init(ttl time.Duration, maxBytes int64) {
+	defaultManager.setOption(ttl, maxBytes)
+}
+
+// Acquire returns the store of the key, it's created if not exists. Release
+// should be called once the store is no longer used.
+func Acquire(key string) *Store {
+	return defaultManager.Acquire(key)
+}
+
+// Release releases the store of the key acquired, the store is removed if
+// it's released by all.
+func Release(key string) {
+	defaultManager.Release(key)
+}
+
+// GetStats returns the stats of the store of the key.
+func GetStats(key string) (Stats, bool) {
+	return defaultManager.GetStats(key)
+}
+
+// Stats is the stats of a store.
+type Stats struct {
+	Keys  int   `json:"keys"`
+	Bytes int64 `json:"bytes"`
+
+	// Expired is the number of entries removed due to TTL.
+	Expired uint64 `json:"expired"`
+	// Evicted is the number of entries removed due to the memory cap.
+	Evicted uint64 `json:"evicted"`
+	// Rejected is the number of values larger than the memory cap.
+	Rejected uint64 `json:"rejected"`
+}
+
+// Manager manages the stores of scripts, the memory of all stores is
+// limited by maxBytes.
+type Manager struct {
+	mtx      sync.RWMutex
+	stores   map[string]*Store
+	ttl      time.Duration
+	maxBytes int64
+
+	// memory of all stores, updated atomically
+	bytes int64
+}
+
+func NewManager(ttl time.Duration, maxBytes int64) *Manager {
+	m := &Manager{stores: map[string]*Store{}}
+	m.setOption(ttl, maxBytes)
+	return m
+}
+
+func (m *Manager) setOption(ttl time.Duration, maxBytes int64) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	atomic.StoreInt64(&m.maxBytes, maxBytes)
+	m.ttl = ttl
+	for _, s := range m.stores {
+		s.setOption(ttl, maxBytes)
+	}
+}
+
+// overLimit returns true if the memory of all stores exceeds the cap.
+func (m *Manager) overLimit() bool {
+	return atomic.LoadInt64(&m.bytes) > atomic.LoadInt64(&m.maxBytes)
+}
+
+func (m *Manager) Acquire(key string) *Store {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s, ok := m.stores[key]
+	if !ok {
+		s = NewStore(m.ttl, m.maxBytes)
+		s.mgr = m
+		m.stores[key] = s
+	}
+
+	s.refs++
+	return s
+}
+
+func (m *Manager) Release(key string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s, ok := m.stores[key]
+	if !ok {
+		return
+	}
+
+	if s.refs--; s.refs > 0 {
+		return
+	}
+
+	delete(m.stores, key)
+	s.detach()
+}
+
+// Bytes returns the memory of all stores.
+func (m *Manager) Bytes() int64 {
+	return atomic.LoadInt64(&m.bytes)
+}
+
+func (m *Manager) GetStats(key string) (Stats, bool) {
+	m.mtx.RLock()
+	s, ok := m.stores[key]
+	m.mtx.RUnlock()
+	if !ok {
+		return Stats{}, false
+	}
+	return s.Stats(), true
+}
+
+type entry struct {
+	key      string
+	value    any
+	expireAt time.Time
+	size     int64
+}
+
+// Store is a key-value store with TTL, entries are evicted in LRU order
+// if the memory cap is exceeded.
+type Store struct {
+	mtx sync.Mutex
+
+	// mgr is the manager of the store, nil if not managed. The memory of
+	// the store is also limited by the cap of the manager.
+	mgr  *Manager
+	refs int // guarded by mtx of mgr
+
+	items map[string]*list.Element
+	lru   *list.List // front is the most recently used
+
+	ttl       time.Duration
+	maxBytes  int64
+	bytes     int64
+	lastSweep time.Time
+
+	expired, evicted, rejected uint64
+
+	// for testing
+	now func() time.Time
+}
+
+func NewStore(ttl time.Duration, maxBytes int64) *Store {
+	s := &Store{
+		items: map[string]*list.Element{},
+		lru:   list.New(),
+		now:   time.Now,
+	}
+	s.setOption(ttl, maxBytes)
+	s.lastSweep = s.now()
+	return s
+}
+
+func (s *Store) setOption(ttl time.Duration, maxBytes int64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.ttl, s.maxBytes = ttl, maxBytes
+	s.evict()
+}
+
+// Set sets the value of the key, the default TTL is used if ttl <= 0. It
+// returns false if the value is too large or of unsupported type.
+func (s *Store) Set(key string, value any, ttl time.Duration) bool {
+	size, ok := sizeOf(value)
+	if !ok {
+		return false
+	}
+	size += int64(len(key)) + entryOverhead
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+
+	if size > s.maxBytes {
+		s.rejected++
+		return false
+	}
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+
+	s.items[key] = s.lru.PushFront(&entry{
+		key:      key,
+		value:    value,
+		expireAt: now.Add(ttl),
+		size:     size,
+	})
+	s.addBytes(size)
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+	s.evict()
+
+	// evicted due to the memory of other stores
+	_, ok = s.items[key]
+	return ok
+}
+
+// Get returns the value of the key if it exists and not expired.
+func (s *Store) Get(key string) (any, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry) //nolint:forcetypeassert
+	if !s.now().Before(e.expireAt) {
+		s.remove(elem)
+		s.expired++
+		return nil, false
+	}
+
+	s.lru.MoveToFront(elem)
+	return e.value, true
+}
+
+// Delete deletes the key and returns the value deleted.
+func (s *Store) Delete(key string) (any, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.remove(elem)
+
+	e := elem.Value.(*entry) //nolint:forcetypeassert
+	if !s.now().Before(e.expireAt) {
+		s.expired++
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (s *Store) Stats() Stats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return Stats{
+		Keys:     len(s.items),
+		Bytes:    s.bytes,
+		Expired:  s.expired,
+		Evicted:  s.evicted,
+		Rejected: s.rejected,
+	}
+}
+
+func (s *Store) remove(elem *list.Element) {
+	e := elem.Value.(*entry) //nolint:forcetypeassert
+	s.lru.Remove(elem)
+	delete(s.items, e.key)
+	s.addBytes(-e.size)
+}
+
+func (s *Store) addBytes(n int64) {
+	s.bytes += n
+	if s.mgr != nil {
+		atomic.AddInt64(&s.mgr.bytes, n)
+	}
+}
+
+// detach removes all entries and detaches the store from the manager, the
+// store can still be used by the scripts running, but not managed any more.
+func (s *Store) detach() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for elem := s.lru.Back(); elem != nil; elem = s.lru.Back() {
+		s.remove(elem)
+	}
+	s.mgr = nil
+}
+
+// sweep removes all expired entries.
+func (s *Store) sweep(now time.Time) {
+	for elem := s.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if e := elem.Value.(*entry); !now.Before(e.expireAt) { //nolint:forcetypeassert
+			s.remove(elem)
+			s.expired++
+		}
+		elem = prev
+	}
+	s.lastSweep = now
+}
+
+// evict removes the least recently used entries until the memory cap of the
+// store and the manager is met. Entries of other stores are not evicted.
+func (s *Store) evict() {
+	for s.bytes > s.maxBytes || (s.mgr != nil && s.mgr.overLimit()) {
+		elem := s.lru.Back()
+		if elem == nil {
+			return
+		}
+		s.remove(elem)
+		s.evicted++
+	}
+}
+
+// sizeOf estimates the memory of the value, only scalar values are supported.
+func sizeOf(value any) (int64, bool) {
+	switch v := value.(type) {
+	case nil, bool:
+		return 1, true
+	case int64, float64:
+		return 8, true
+	case string:
+		return int64(len(v)), true
+	default:
+		return 0, false
+	}
+}
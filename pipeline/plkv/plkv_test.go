@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plkv
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestingStore(ttl time.Duration, maxBytes int64) (*Store, *time.Time) {
+	now := time.Unix(1000, 0)
+	s := NewStore(ttl, maxBytes)
+	s.now = func() time.Time { return now }
+	s.lastSweep = now
+	return s, &now
+}
+
+func TestStoreTTL(t *testing.T) {
+	s, now := newTestingStore(time.Minute, 1024)
+
+	assert.True(t, s.Set("a", "1", 0))
+	assert.True(t, s.Set("b", int64(2), time.Second*10))
+
+	*now = now.Add(time.Second * 10)
+	v, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+	_, ok = s.Get("b")
+	assert.False(t, ok)
+
+	*now = now.Add(time.Minute)
+	_, ok = s.Delete("a")
+	assert.False(t, ok)
+
+	assert.Equal(t, Stats{Expired: 2}, s.Stats())
+}
+
+func TestStoreSweep(t *testing.T) {
+	s, now := newTestingStore(time.Second, 1024)
+
+	for _, k := range []string{"a", "b", "c"} {
+		assert.True(t, s.Set(k, true, 0))
+	}
+
+	// expired entries are removed on Set after the sweep interval
+	*now = now.Add(sweepInterval)
+	assert.True(t, s.Set("d", nil, time.Hour))
+
+	st := s.Stats()
+	assert.Equal(t, 1, st.Keys)
+	assert.Equal(t, uint64(3), st.Expired)
+	assert.Equal(t, int64(1+1+entryOverhead), st.Bytes)
+}
+
+func TestStoreEvict(t *testing.T) {
+	size := int64(1 + 8 + entryOverhead)
+	s, _ := newTestingStore(time.Minute, size*3)
+
+	for _, k := range []string{"a", "b", "c"} {
+		assert.True(t, s.Set(k, 1.0, 0))
+	}
+
+	// "a" is the most recently used now
+	_, ok := s.Get("a")
+	assert.True(t, ok)
+
+	assert.True(t, s.Set("d", 2.0, 0))
+	_, ok = s.Get("b")
+	assert.False(t, ok)
+	for _, k := range []string{"a", "c", "d"} {
+		_, ok := s.Get(k)
+		assert.True(t, ok, k)
+	}
+
+	// overwriting does not evict
+	assert.True(t, s.Set("d", 3.0, 0))
+	v, _ := s.Get("d")
+	assert.Equal(t, 3.0, v)
+
+	// too large or unsupported values are rejected
+	assert.False(t, s.Set("e", strings.Repeat("x", int(size*3)), 0))
+	assert.False(t, s.Set("f", []any{1}, 0))
+
+	assert.Equal(t, Stats{Keys: 3, Bytes: size * 3, Evicted: 1, Rejected: 1}, s.Stats())
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager(0, 0)
+	assert.Equal(t, DefaultTTL, m.ttl)
+
+	s := m.Acquire("logging::default::nginx.p")
+	assert.Same(t, s, m.Acquire("logging::default::nginx.p"))
+	assert.NotSame(t, s, m.Acquire("logging::default::redis.p"))
+
+	s.Set("k", "v", 0)
+	st, ok := m.GetStats("logging::default::nginx.p")
+	assert.True(t, ok)
+	assert.Equal(t, 1, st.Keys)
+
+	_, ok = m.GetStats("metric::default::cpu.p")
+	assert.False(t, ok)
+
+	m.setOption(time.Second, 1)
+	st, _ = m.GetStats("logging::default::nginx.p")
+	assert.Equal(t, Stats{Evicted: 1}, st)
+}
+
+func TestManagerRelease(t *testing.T) {
+	m := NewManager(time.Minute, 1024)
+
+	// acquired twice, e.g. the script is reloaded
+	s := m.Acquire("logging::default::nginx.p")
+	assert.Same(t, s, m.Acquire("logging::default::nginx.p"))
+	assert.True(t, s.Set("k", "v", 0))
+
+	m.Release("logging::default::nginx.p")
+	v, ok := s.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", v)
+	assert.Equal(t, int64(1+1+entryOverhead), m.Bytes())
+
+	// released by all, e.g. the script is deleted
+	m.Release("logging::default::nginx.p")
+	_, ok = m.GetStats("logging::default::nginx.p")
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), m.Bytes())
+
+	// still usable by the script running, but not counted
+	assert.True(t, s.Set("k", "v", 0))
+	assert.Equal(t, int64(0), m.Bytes())
+
+	assert.NotSame(t, s, m.Acquire("logging::default::nginx.p"))
+
+	// releasing unknown store is ok
+	m.Release("metric::default::cpu.p")
+}
+
+func TestManagerLimit(t *testing.T) {
+	size := int64(1 + 8 + entryOverhead)
+	m := NewManager(time.Minute, size*3)
+
+	s1 := m.Acquire("logging::default::a.p")
+	s2 := m.Acquire("logging::default::b.p")
+
+	assert.True(t, s1.Set("a", 1.0, 0))
+	assert.True(t, s1.Set("b", 1.0, 0))
+	assert.True(t, s2.Set("c", 1.0, 0))
+	assert.Equal(t, size*3, m.Bytes())
+
+	// entries of the store itself are evicted
+	assert.True(t, s2.Set("d", 1.0, 0))
+	assert.Equal(t, size*3, m.Bytes())
+	_, ok := s2.Get("c")
+	assert.False(t, ok)
+	assert.Equal(t, 2, s1.Stats().Keys)
+
+	// s1 is full, a new store can't write
+	assert.True(t, s1.Set("e", 1.0, 0))
+	s3 := m.Acquire("logging::default::c.p")
+	assert.False(t, s3.Set("f", 1.0, 0))
+	assert.Equal(t, Stats{Evicted: 1}, s3.Stats())
+	assert.LessOrEqual(t, m.Bytes(), size*3)
+}
@@ -0,0 +1,322 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package plmetric aggregates metrics generated by pipeline scripts.
+package plmetric
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+const (
+	DefaultInterval  = 10 * time.Second
+	DefaultMaxSeries = 10000
+)
+
+var (
+	l = logger.DefaultSLogger("pl-metric")
+
+	// DefaultBuckets is the upper bounds of histogram buckets if not specified.
+	DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	defaultAggregator = NewAggregator(DefaultInterval, DefaultMaxSeries)
+)
+
+type kind int
+
+const (
+	kindCounter kind = iota
+	kindGauge
+	kindHistogram
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindCounter:
+		return "counter"
+	case kindGauge:
+		return "gauge"
+	case kindHistogram:
+		return "histogram"
+	default:
+		return "unknown"
+	}
+}
+
+// FeedFunc sends the aggregated metric points.
+type FeedFunc func(pts []*point.Point) error
+
+type value struct {
+	kind kind
+
+	// counter: sum of values, gauge: last value, histogram: sum of observations
+	sum     float64
+	count   uint64
+	buckets []float64
+	counts  []uint64 // count of observations of each bucket, not cumulative
+}
+
+type series struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*value
+}
+
+// Aggregator aggregates metrics over an interval, metrics are reset after
+// flushed, i.e. counters and histograms are deltas of the interval.
+type Aggregator struct {
+	mtx       sync.Mutex
+	series    map[string]*series
+	interval  time.Duration
+	maxSeries int
+
+	dropped uint64
+}
+
+func NewAggregator(interval time.Duration, maxSeries int) *Aggregator {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if maxSeries <= 0 {
+		maxSeries = DefaultMaxSeries
+	}
+
+	return &Aggregator{
+		series:    map[string]*series{},
+		interval:  interval,
+		maxSeries: maxSeries,
+	}
+}
+
+func seriesKey(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, k := range keys {
+		sb.WriteString("\n")
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(tags[k])
+	}
+	return sb.String()
+}
+
+func (a *Aggregator) getValue(name, field string, tags map[string]string, k kind) (*value, error) {
+	key := seriesKey(name, tags)
+
+	s, ok := a.series[key]
+	if !ok {
+		if len(a.series) >= a.maxSeries {
+			a.dropped++
+			return nil, fmt.Errorf("too many metric series(max %d), %s dropped", a.maxSeries, name)
+		}
+
+		s = &series{name: name, tags: tags, fields: map[string]*value{}}
+		a.series[key] = s
+	}
+
+	v, ok := s.fields[field]
+	if !ok {
+		v = &value{kind: k}
+		s.fields[field] = v
+	}
+
+	if v.kind != k {
+		return nil, fmt.Errorf("field %s of metric %s is %s, not %s", field, name, v.kind, k)
+	}
+
+	return v, nil
+}
+
+// Counter adds delta to the counter.
+func (a *Aggregator) Counter(name, field string, tags map[string]string, delta float64) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	v, err := a.getValue(name, field, tags, kindCounter)
+	if err != nil {
+		return err
+	}
+
+	v.sum += delta
+	v.count++
+	return nil
+}
+
+// Gauge sets the gauge to x, the last value of the interval is reported.
+func (a *Aggregator) Gauge(name, field string, tags map[string]string, x float64) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	v, err := a.getValue(name, field, tags, kindGauge)
+	if err != nil {
+		return err
+	}
+
+	v.sum = x
+	v.count++
+	return nil
+}
+
+// Histogram observes x, buckets are upper bounds in increasing order, they
+// are fixed by the first observation of the interval.
+func (a *Aggregator) Histogram(name, field string, tags map[string]string, x float64, buckets []float64) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	v, err := a.getValue(name, field, tags, kindHistogram)
+	if err != nil {
+		return err
+	}
+
+	if v.buckets == nil {
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		v.buckets = buckets
+		v.counts = make([]uint64, len(buckets))
+	}
+
+	v.sum += x
+	v.count++
+	if i := sort.SearchFloat64s(v.buckets, x); i < len(v.buckets) {
+		v.counts[i]++
+	}
+	return nil
+}
+
+// Points returns points of metrics aggregated and resets the aggregator.
+func (a *Aggregator) Points(t time.Time) []*point.Point {
+	a.mtx.Lock()
+	all, dropped := a.series, a.dropped
+	a.series, a.dropped = map[string]*series{}, 0
+	a.mtx.Unlock()
+
+	if dropped > 0 {
+		l.Warnf("%d metrics dropped for too many series", dropped)
+	}
+
+	var pts []*point.Point
+	for _, s := range all {
+		fields := map[string]interface{}{}
+
+		for field, v := range s.fields {
+			switch v.kind {
+			case kindCounter, kindGauge:
+				fields[field] = v.sum
+
+			case kindHistogram:
+				fields[field+"_sum"] = v.sum
+				fields[field+"_count"] = int64(v.count)
+				pts = append(pts, bucketPoints(s, field, v, t)...)
+			}
+		}
+
+		if pt, err := point.NewPoint(s.name, s.tags, fields, &point.PointOption{
+			Time:     t,
+			Category: datakit.Metric,
+		}); err != nil {
+			l.Warnf("make point %s: %s, ignored", s.name, err)
+		} else {
+			pts = append(pts, pt)
+		}
+	}
+
+	return pts
+}
+
+// bucketPoints returns the cumulative count of buckets, one point per bucket
+// with tag le, as the prom input does.
+func bucketPoints(s *series, field string, v *value, t time.Time) []*point.Point {
+	var (
+		pts        []*point.Point
+		cumulative uint64
+	)
+
+	add := func(le string, n uint64) {
+		tags := make(map[string]string, len(s.tags)+1)
+		for k, v := range s.tags {
+			tags[k] = v
+		}
+		tags["le"] = le
+
+		pt, err := point.NewPoint(s.name, tags, map[string]interface{}{field + "_bucket": int64(n)},
+			&point.PointOption{Time: t, Category: datakit.Metric})
+		if err != nil {
+			l.Warnf("make point %s: %s, ignored", s.name, err)
+			return
+		}
+		pts = append(pts, pt)
+	}
+
+	for i, b := range v.buckets {
+		cumulative += v.counts[i]
+		add(strconv.FormatFloat(b, 'f', -1, 64), cumulative)
+	}
+	add("+Inf", v.count)
+
+	return pts
+}
+
+// Run flushes metrics aggregated every interval until datakit exit.
+func (a *Aggregator) Run(feed FeedFunc) {
+	tick := time.NewTicker(a.interval)
+	defer tick.Stop()
+
+	flush := func() {
+		if pts := a.Points(time.Now()); len(pts) > 0 {
+			if err := feed(pts); err != nil {
+				l.Warnf("feed %d metric points: %s, ignored", len(pts), err)
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-tick.C:
+			flush()
+
+		case <-datakit.Exit.Wait():
+			flush()
+			l.Info("pipeline metric exit")
+			return
+		}
+	}
+}
+
+// Init sets up the default aggregator, should be called before Start.
+func Init(interval time.Duration, maxSeries int) {
+	l = logger.SLogger("pl-metric")
+	defaultAggregator = NewAggregator(interval, maxSeries)
+}
+
+// Start runs the default aggregator, it blocks until datakit exit.
+func Start(feed FeedFunc) {
+	defaultAggregator.Run(feed)
+}
+
+// Default returns the default aggregator, whose metrics are sent by Start.
+func Default() *Aggregator {
+	return defaultAggregator
+}
+
+// Points returns points of the default aggregator and resets it.
+func Points(t time.Time) []*point.Point {
+	return defaultAggregator.Points(t)
+}
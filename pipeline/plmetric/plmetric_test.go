@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package plmetric
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pointStrings(t *testing.T, a *Aggregator) []string {
+	t.Helper()
+
+	var res []string
+	for _, pt := range a.Points(time.Unix(0, 123)) {
+		res = append(res, pt.String())
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestAggregator(t *testing.T) {
+	t.Run("counter-gauge", func(t *testing.T) {
+		a := NewAggregator(time.Second, 0)
+
+		tags := map[string]string{"service": "nginx"}
+		assert.NoError(t, a.Counter("http", "errors", tags, 1))
+		assert.NoError(t, a.Counter("http", "errors", map[string]string{"service": "nginx"}, 2))
+		assert.NoError(t, a.Gauge("http", "conns", tags, 10))
+		assert.NoError(t, a.Gauge("http", "conns", tags, 5))
+		assert.NoError(t, a.Counter("http", "errors", map[string]string{"service": "mysql"}, 1))
+
+		// field kind conflict
+		assert.Error(t, a.Gauge("http", "errors", tags, 1))
+
+		assert.Equal(t, []string{
+			"http,service=mysql errors=1 123",
+			"http,service=nginx conns=5,errors=3 123",
+		}, pointStrings(t, a))
+
+		// reset after flushed
+		assert.Empty(t, pointStrings(t, a))
+	})
+
+	t.Run("histogram", func(t *testing.T) {
+		a := NewAggregator(time.Second, 0)
+
+		for _, x := range []float64{0.05, 0.3, 0.3, 2, 100} {
+			assert.NoError(t, a.Histogram("http", "latency", nil, x, []float64{0.1, 0.5, 1}))
+		}
+
+		assert.Equal(t, []string{
+			"http latency_count=5i,latency_sum=102.65 123",
+			"http,le=+Inf latency_bucket=5i 123",
+			"http,le=0.1 latency_bucket=1i 123",
+			"http,le=0.5 latency_bucket=3i 123",
+			"http,le=1 latency_bucket=3i 123",
+		}, pointStrings(t, a))
+	})
+
+	t.Run("max-series", func(t *testing.T) {
+		a := NewAggregator(time.Second, 2)
+
+		assert.NoError(t, a.Counter("m", "f", map[string]string{"t": "1"}, 1))
+		assert.NoError(t, a.Counter("m", "f", map[string]string{"t": "2"}, 1))
+		assert.Error(t, a.Counter("m", "f", map[string]string{"t": "3"}, 1))
+
+		// existing series still updated
+		assert.NoError(t, a.Counter("m", "f", map[string]string{"t": "1"}, 1))
+
+		require.Len(t, pointStrings(t, a), 2)
+
+		assert.NoError(t, a.Counter("m", "f", map[string]string{"t": "3"}, 1))
+	})
+}
@@ -60,6 +60,7 @@ var FuncsMap = map[string]runtime.FuncCall{
 	"cover":                 Cover,
 	"query_refer_table":     QueryReferTable,
 	"mquery_refer_table":    MQueryReferTableMulti,
+	"match_refer_table":     MatchReferTable,
 	"replace":               Replace,
 	"duration_precision":    DurationPrecision,
 	"xml":                   XML,
@@ -68,6 +69,17 @@ var FuncsMap = map[string]runtime.FuncCall{
 	"decode":                Decode,
 	"sample":                Sample,
 	"url_parse":             URLParse,
+	"metric_counter":        MetricCounter,
+	"metric_gauge":          MetricGauge,
+	"metric_histogram":      MetricHistogram,
+	"parse_syslog":          ParseSyslog,
+	"parse_cef":             ParseCEF,
+	"parse_csv":             ParseCSV,
+	"kv_set":                KVSet,
+	"kv_get":                KVGet,
+	"kv_delete":             KVDelete,
+	"ip_asn":                IPASN,
+	"ip_overlay":            IPOverlay,
 	// disable
 	"json_all": JSONAll,
 }
@@ -115,6 +127,7 @@ var FuncsCheckMap = map[string]runtime.FuncCheck{
 	"cover":                 CoverChecking,
 	"query_refer_table":     QueryReferTableChecking,
 	"mquery_refer_table":    MQueryReferTableChecking,
+	"match_refer_table":     MatchReferTableChecking,
 	"replace":               ReplaceChecking,
 	"duration_precision":    DurationPrecisionChecking,
 	"sql_cover":             SQLCoverChecking,
@@ -123,6 +136,17 @@ var FuncsCheckMap = map[string]runtime.FuncCheck{
 	"decode":                DecodeChecking,
 	"url_parse":             URLParseChecking,
 	"sample":                SampleChecking,
+	"metric_counter":        MetricCounterChecking,
+	"metric_gauge":          MetricGaugeChecking,
+	"metric_histogram":      MetricHistogramChecking,
+	"parse_syslog":          ParseSyslogChecking,
+	"parse_cef":             ParseCEFChecking,
+	"parse_csv":             ParseCSVChecking,
+	"kv_set":                KVSetChecking,
+	"kv_get":                KVGetChecking,
+	"kv_delete":             KVDeleteChecking,
+	"ip_asn":                IPASNChecking,
+	"ip_overlay":            IPOverlayChecking,
 	// disable
 	"json_all": JSONAllChecking,
 }
@@ -53,6 +53,7 @@ var PipelineFunctionDocs = map[string]*PLDoc{
 	"query_refer_table()":  &queryReferTableMarkdown,
 	"match()":              &matchMarkdown,
 	"mquery_refer_table()": &mQueryReferTableMarkdown,
+	"match_refer_table()":  &matchReferTableMarkdown,
 	"rename()":             &renameMarkdown,
 	"replace()":            &replaceMarkdown,
 	"set_measurement()":    &setMeasurementMarkdown,
@@ -68,6 +69,17 @@ var PipelineFunctionDocs = map[string]*PLDoc{
 	"sample()":             &sampleMarkdown,
 	"url_parse()":          &urlParseMarkdown,
 	"timestamp()":          &timestampMarkdown,
+	"metric_counter()":     &metricCounterMarkdown,
+	"metric_gauge()":       &metricGaugeMarkdown,
+	"metric_histogram()":   &metricHistogramMarkdown,
+	"parse_syslog()":       &parseSyslogMarkdown,
+	"parse_cef()":          &parseCEFMarkdown,
+	"parse_csv()":          &parseCSVMarkdown,
+	"kv_set()":             &kvSetMarkdown,
+	"kv_get()":             &kvGetMarkdown,
+	"kv_delete()":          &kvDeleteMarkdown,
+	"ip_asn()":             &ipASNMarkdown,
+	"ip_overlay()":         &ipOverlayMarkdown,
 }
 
 var PipelineFunctionDocsEN = map[string]*PLDoc{
@@ -105,6 +117,7 @@ var PipelineFunctionDocsEN = map[string]*PLDoc{
 	"query_refer_table()":  &queryReferTableMarkdownEN,
 	"match()":              &matchMarkdownEN,
 	"mquery_refer_table()": &mQueryReferTableMarkdownEN,
+	"match_refer_table()":  &matchReferTableMarkdownEN,
 	"rename()":             &renameMarkdownEN,
 	"replace()":            &replaceMarkdownEN,
 	"set_measurement()":    &setMeasurementMarkdownEN,
@@ -120,6 +133,17 @@ var PipelineFunctionDocsEN = map[string]*PLDoc{
 	"sample()":             &sampleMarkdownEN,
 	"url_parse()":          &urlParseMarkdownEN,
 	"timestamp()":          &timestampMarkdownEN,
+	"metric_counter()":     &metricCounterMarkdownEN,
+	"metric_gauge()":       &metricGaugeMarkdownEN,
+	"metric_histogram()":   &metricHistogramMarkdownEN,
+	"parse_syslog()":       &parseSyslogMarkdownEN,
+	"parse_cef()":          &parseCEFMarkdownEN,
+	"parse_csv()":          &parseCSVMarkdownEN,
+	"kv_set()":             &kvSetMarkdownEN,
+	"kv_get()":             &kvGetMarkdownEN,
+	"kv_delete()":          &kvDeleteMarkdownEN,
+	"ip_asn()":             &ipASNMarkdownEN,
+	"ip_overlay()":         &ipOverlayMarkdownEN,
 }
 
 // embed docs.
@@ -151,6 +175,9 @@ var (
 	//go:embed md/mquery_refer_table.md
 	docMQueryReferTable string
 
+	//go:embed md/match_refer_table.md
+	docMatchReferTable string
+
 	//go:embed md/match.md
 	docMatch string
 
@@ -270,6 +297,39 @@ var (
 
 	//go:embed md/kv_split.md
 	docKVSplit string
+
+	//go:embed md/metric_counter.md
+	docMetricCounter string
+
+	//go:embed md/metric_gauge.md
+	docMetricGauge string
+
+	//go:embed md/metric_histogram.md
+	docMetricHistogram string
+
+	//go:embed md/parse_syslog.md
+	docParseSyslog string
+
+	//go:embed md/parse_cef.md
+	docParseCEF string
+
+	//go:embed md/parse_csv.md
+	docParseCSV string
+
+	//go:embed md/kv_set.md
+	docKVSet string
+
+	//go:embed md/kv_get.md
+	docKVGet string
+
+	//go:embed md/kv_delete.md
+	docKVDelete string
+
+	//go:embed md/ip_asn.md
+	docIPASN string
+
+	//go:embed md/ip_overlay.md
+	docIPOverlay string
 )
 
 const (
@@ -289,6 +349,7 @@ const (
 	cStringOp        = "字符串操作"
 	cDesensitization = "脱敏"
 	cSample          = "采样"
+	cMetric          = "指标"
 	cOther           = "其他"
 )
 
@@ -497,6 +558,12 @@ var (
 			langTagZhCN: {cOther},
 		},
 	}
+	matchReferTableMarkdown = PLDoc{
+		Doc: docMatchReferTable, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
 	renameMarkdown = PLDoc{
 		Doc: docRename, Deprecated: false,
 		FnCategory: map[string][]string{
@@ -589,4 +656,70 @@ var (
 			langTagZhCN: {cRegExp},
 		},
 	}
+	metricCounterMarkdown = PLDoc{
+		Doc: docMetricCounter, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cMetric},
+		},
+	}
+	metricGaugeMarkdown = PLDoc{
+		Doc: docMetricGauge, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cMetric},
+		},
+	}
+	metricHistogramMarkdown = PLDoc{
+		Doc: docMetricHistogram, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cMetric},
+		},
+	}
+	parseSyslogMarkdown = PLDoc{
+		Doc: docParseSyslog, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cStringOp},
+		},
+	}
+	parseCEFMarkdown = PLDoc{
+		Doc: docParseCEF, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cStringOp},
+		},
+	}
+	parseCSVMarkdown = PLDoc{
+		Doc: docParseCSV, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cStringOp},
+		},
+	}
+	kvSetMarkdown = PLDoc{
+		Doc: docKVSet, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
+	kvGetMarkdown = PLDoc{
+		Doc: docKVGet, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
+	kvDeleteMarkdown = PLDoc{
+		Doc: docKVDelete, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cOther},
+		},
+	}
+	ipASNMarkdown = PLDoc{
+		Doc: docIPASN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cNetwork},
+		},
+	}
+	ipOverlayMarkdown = PLDoc{
+		Doc: docIPOverlay, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagZhCN: {cNetwork},
+		},
+	}
 )
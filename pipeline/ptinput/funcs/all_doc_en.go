@@ -38,6 +38,9 @@ var (
 	//go:embed md/mquery_refer_table.en.md
 	docMQueryReferTableEN string
 
+	//go:embed md/match_refer_table.en.md
+	docMatchReferTableEN string
+
 	//go:embed md/match.en.md
 	docMatchEN string
 
@@ -157,6 +160,39 @@ var (
 
 	//go:embed md/kv_split.en.md
 	docKVSplitEN string
+
+	//go:embed md/metric_counter.en.md
+	docMetricCounterEN string
+
+	//go:embed md/metric_gauge.en.md
+	docMetricGaugeEN string
+
+	//go:embed md/metric_histogram.en.md
+	docMetricHistogramEN string
+
+	//go:embed md/parse_syslog.en.md
+	docParseSyslogEN string
+
+	//go:embed md/parse_cef.en.md
+	docParseCEFEN string
+
+	//go:embed md/parse_csv.en.md
+	docParseCSVEN string
+
+	//go:embed md/kv_set.en.md
+	docKVSetEN string
+
+	//go:embed md/kv_get.en.md
+	docKVGetEN string
+
+	//go:embed md/kv_delete.en.md
+	docKVDeleteEN string
+
+	//go:embed md/ip_asn.en.md
+	docIPASNEN string
+
+	//go:embed md/ip_overlay.en.md
+	docIPOverlayEN string
 )
 
 const (
@@ -176,6 +212,7 @@ const (
 	eStringOp        = "String"
 	eDesensitization = "Desensitization"
 	eSample          = "Sample"
+	eMetric          = "Metric"
 	eOther           = "Other"
 )
 
@@ -385,6 +422,12 @@ var (
 			langTagEnUS: {eOther},
 		},
 	}
+	matchReferTableMarkdownEN = PLDoc{
+		Doc: docMatchReferTableEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
 	renameMarkdownEN = PLDoc{
 		Doc: docRenameEN, Deprecated: false,
 		FnCategory: map[string][]string{
@@ -477,4 +520,70 @@ var (
 			langTagEnUS: {eRegExp},
 		},
 	}
+	metricCounterMarkdownEN = PLDoc{
+		Doc: docMetricCounterEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eMetric},
+		},
+	}
+	metricGaugeMarkdownEN = PLDoc{
+		Doc: docMetricGaugeEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eMetric},
+		},
+	}
+	metricHistogramMarkdownEN = PLDoc{
+		Doc: docMetricHistogramEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eMetric},
+		},
+	}
+	parseSyslogMarkdownEN = PLDoc{
+		Doc: docParseSyslogEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eStringOp},
+		},
+	}
+	parseCEFMarkdownEN = PLDoc{
+		Doc: docParseCEFEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eStringOp},
+		},
+	}
+	parseCSVMarkdownEN = PLDoc{
+		Doc: docParseCSVEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eStringOp},
+		},
+	}
+	kvSetMarkdownEN = PLDoc{
+		Doc: docKVSetEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
+	kvGetMarkdownEN = PLDoc{
+		Doc: docKVGetEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
+	kvDeleteMarkdownEN = PLDoc{
+		Doc: docKVDeleteEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eOther},
+		},
+	}
+	ipASNMarkdownEN = PLDoc{
+		Doc: docIPASNEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eNetwork},
+		},
+	}
+	ipOverlayMarkdownEN = PLDoc{
+		Doc: docIPOverlayEN, Deprecated: false,
+		FnCategory: map[string][]string{
+			langTagEnUS: {eNetwork},
+		},
+	}
 )
@@ -27,6 +27,21 @@ func Geo(ip string) (*ipdb.IPdbRecord, error) {
 	}
 }
 
+func ASN(ip string) (*ipdb.ASNRecord, error) {
+	if ipdbInstance != nil {
+		return ipdbInstance.ASN(ip)
+	} else {
+		return &ipdb.ASNRecord{}, nil
+	}
+}
+
+func Overlay(ip string) (map[string]string, bool) {
+	if ipdbInstance != nil {
+		return ipdbInstance.Overlay(ip)
+	}
+	return nil, false
+}
+
 func InitIPdb(instance ipdb.IPdb) {
 	ipdbInstance = instance
 }
@@ -19,6 +19,15 @@ type mockGEO struct{}
 func (m *mockGEO) Init(dataDir string, config map[string]string) {}
 func (m *mockGEO) SearchIsp(ip string) string                    { return "" }
 
+func (m *mockGEO) Overlay(ip string) (map[string]string, bool) { return nil, false }
+
+func (m *mockGEO) ASN(ip string) (*ipdb.ASNRecord, error) {
+	if ip == "1.1.1.1" {
+		return &ipdb.ASNRecord{ASN: 13335, Org: "CLOUDFLARENET"}, nil
+	}
+	return &ipdb.ASNRecord{}, nil
+}
+
 func (m *mockGEO) Geo(ip string) (*ipdb.IPdbRecord, error) {
 	return &ipdb.IPdbRecord{
 		City: func() string {
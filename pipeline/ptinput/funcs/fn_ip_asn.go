@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+const (
+	fieldASN    = "asn"
+	fieldASNOrg = "asn_org"
+)
+
+func IPASNChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return checkIPKeyArg(ctx, funcExpr)
+}
+
+func IPASN(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	ipStr, ok := getIPKeyArg(ctx, funcExpr)
+	if !ok {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	record, err := ASN(ipStr)
+	if err != nil || record == nil || record.ASN == 0 {
+		if err != nil {
+			l.Debugf("ASN: %s, ignored", err)
+		}
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	if err := addKey2PtWithVal(ctx.InData(), fieldASN, int64(record.ASN), ast.Int,
+		ptinput.KindPtDefault); err != nil {
+		l.Debug(err)
+	}
+	if record.Org != "" {
+		if err := addKey2PtWithVal(ctx.InData(), fieldASNOrg, record.Org, ast.String,
+			ptinput.KindPtDefault); err != nil {
+			l.Debug(err)
+		}
+	}
+
+	ctx.Regs.ReturnAppend(true, ast.Bool)
+	return nil
+}
+
+func IPOverlayChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return checkIPKeyArg(ctx, funcExpr)
+}
+
+func IPOverlay(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	ipStr, ok := getIPKeyArg(ctx, funcExpr)
+	if !ok {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	attrs, ok := Overlay(ipStr)
+	if !ok {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := addKey2PtWithVal(ctx.InData(), k, attrs[k], ast.String,
+			ptinput.KindPtDefault); err != nil {
+			l.Debug(err)
+		}
+	}
+
+	ctx.Regs.ReturnAppend(true, ast.Bool)
+	return nil
+}
+
+func checkIPKeyArg(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if len(funcExpr.Param) != 1 {
+		return runtime.NewRunError(ctx, fmt.Sprintf(
+			"func `%s' expected 1 args", funcExpr.Name), funcExpr.NamePos)
+	}
+
+	if _, err := getKeyName(funcExpr.Param[0]); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	return nil
+}
+
+// getIPKeyArg returns false if the key not found.
+func getIPKeyArg(ctx *runtime.Context, funcExpr *ast.CallExpr) (string, bool) {
+	key, err := getKeyName(funcExpr.Param[0])
+	if err != nil {
+		return "", false
+	}
+
+	ipStr, err := ctx.GetKeyConv2Str(key)
+	if err != nil {
+		l.Debugf("key `%v' not exist, ignored", key)
+		return "", false
+	}
+
+	return ipStr, true
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ipdb"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestIPASNAndOverlay(t *testing.T) {
+	overlay, err := ipdb.NewOverlay(map[string]map[string]string{
+		"10.0.0.0/8":  {"zone": "dc"},
+		"10.1.0.0/16": {"site": "shanghai-office", "zone": "office"},
+		"1.1.1.1":     {"asn_org": "custom"},
+	})
+	require.NoError(t, err)
+
+	old := ipdbInstance
+	defer func() { ipdbInstance = old }()
+	ipdbInstance = ipdb.NewOverlayDB(&mockGEO{}, overlay)
+
+	cases := []struct {
+		name     string
+		pl       string
+		in       map[string]any
+		expected map[string]any
+		fail     bool
+	}{
+		{
+			name:     "asn",
+			pl:       `add_key(found, ip_asn(ip))`,
+			in:       map[string]any{"ip": "1.1.1.1"},
+			expected: map[string]any{"ip": "1.1.1.1", "asn": int64(13335), "asn_org": "custom", "found": true},
+		},
+		{
+			name:     "asn-not-found",
+			pl:       `add_key(found, ip_asn(ip))`,
+			in:       map[string]any{"ip": "10.1.2.3"},
+			expected: map[string]any{"ip": "10.1.2.3", "found": false},
+		},
+		{
+			name:     "overlay-longest-prefix",
+			pl:       `add_key(found, ip_overlay(ip))`,
+			in:       map[string]any{"ip": "10.1.2.3"},
+			expected: map[string]any{"ip": "10.1.2.3", "site": "shanghai-office", "zone": "office", "found": true},
+		},
+		{
+			name:     "overlay",
+			pl:       `add_key(found, ip_overlay(ip))`,
+			in:       map[string]any{"ip": "10.2.0.1"},
+			expected: map[string]any{"ip": "10.2.0.1", "zone": "dc", "found": true},
+		},
+		{
+			name:     "overlay-not-found",
+			pl:       `add_key(found, ip_overlay(ip))`,
+			in:       map[string]any{"ip": "192.168.0.1"},
+			expected: map[string]any{"ip": "192.168.0.1", "found": false},
+		},
+		{
+			name:     "key-not-found",
+			pl:       `add_key(found, ip_overlay(ip))`,
+			in:       map[string]any{},
+			expected: map[string]any{"found": false},
+		},
+		{
+			name: "invalid-args",
+			pl:   `ip_asn(ip, "x")`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			pt := ptinput.GetPoint()
+			defer ptinput.PutPoint(pt)
+			ptinput.InitPt(pt, "test", nil, tc.in, time.Now())
+			require.Nil(t, runScript(runner, pt))
+
+			assert.Equal(t, tc.expected, pt.Fields)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+)
+
+var (
+	// sharedKVStore is used if the point is not run by a script.
+	sharedKVStore = plkv.NewStore(plkv.DefaultTTL, plkv.DefaultMaxBytes)
+
+	kvSetArgs    = []string{"key", "value", "ttl"}
+	kvGetArgs    = []string{"key", "default"}
+	kvDeleteArgs = []string{"key"}
+)
+
+func KVSetChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, kvSetArgs, 2); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if ttl := funcExpr.Param[2]; ttl != nil {
+		if ttl.NodeType != ast.TypeStringLiteral {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param ttl expects StringLiteral, got %s",
+				ttl.NodeType), ttl.StartPos())
+		}
+		dur, err := time.ParseDuration(ttl.StringLiteral.Val)
+		if err != nil || dur <= 0 {
+			return runtime.NewRunError(ctx, fmt.Sprintf("invalid ttl %q", ttl.StringLiteral.Val),
+				ttl.StartPos())
+		}
+		funcExpr.PrivateData = dur
+	}
+
+	return nil
+}
+
+func KVSet(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	key, ok, err := getKVKey(ctx, funcExpr.Param[0])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	val, dtype, err := runtime.RunStmt(ctx, funcExpr.Param[1])
+	if err != nil {
+		return err
+	}
+
+	switch dtype { //nolint:exhaustive
+	case ast.Int, ast.Float, ast.Bool, ast.String, ast.Nil:
+	default:
+		l.Debugf("kv_set(): unsupported value type %s", dtype)
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	// zero means the default TTL of the store
+	ttl, _ := funcExpr.PrivateData.(time.Duration)
+
+	ctx.Regs.ReturnAppend(getKVStore(ctx).Set(key, val, ttl), ast.Bool)
+	return nil
+}
+
+func KVGetChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, kvGetArgs, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+	return nil
+}
+
+func KVGet(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	key, ok, err := getKVKey(ctx, funcExpr.Param[0])
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if val, ok := getKVStore(ctx).Get(key); ok {
+			ctx.Regs.ReturnAppend(val, kvDType(val))
+			return nil
+		}
+	}
+
+	if funcExpr.Param[1] != nil {
+		val, dtype, err := runtime.RunStmt(ctx, funcExpr.Param[1])
+		if err != nil {
+			return err
+		}
+		ctx.Regs.ReturnAppend(val, dtype)
+		return nil
+	}
+
+	ctx.Regs.ReturnAppend(nil, ast.Nil)
+	return nil
+}
+
+func KVDeleteChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, kvDeleteArgs, 1); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+	return nil
+}
+
+func KVDelete(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	key, ok, err := getKVKey(ctx, funcExpr.Param[0])
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if val, ok := getKVStore(ctx).Delete(key); ok {
+			ctx.Regs.ReturnAppend(val, kvDType(val))
+			return nil
+		}
+	}
+
+	ctx.Regs.ReturnAppend(nil, ast.Nil)
+	return nil
+}
+
+// getKVStore returns the store of the running script, the shared store is
+// used if the point is not run by a script, e.g. in tests.
+func getKVStore(ctx *runtime.Context) *plkv.Store {
+	if pt, err := getPoint(ctx.InData()); err == nil && pt.KV != nil {
+		return pt.KV
+	}
+	return sharedKVStore
+}
+
+// getKVKey returns false if the key is not a string, e.g. the key not found
+// in the point.
+func getKVKey(ctx *runtime.Context, node *ast.Node) (string, bool, *errchain.PlError) {
+	val, dtype, err := runtime.RunStmt(ctx, node)
+	if err != nil {
+		return "", false, err
+	}
+
+	if key, ok := val.(string); ok && dtype == ast.String {
+		return key, true, nil
+	}
+
+	l.Debugf("kv key %v(%s) is not a string, ignored", val, dtype)
+	return "", false, nil
+}
+
+func kvDType(val any) ast.DType {
+	switch val.(type) {
+	case int64:
+		return ast.Int
+	case float64:
+		return ast.Float
+	case bool:
+		return ast.Bool
+	case string:
+		return ast.String
+	default:
+		return ast.Nil
+	}
+}
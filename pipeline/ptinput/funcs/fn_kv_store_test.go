@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestKVStore(t *testing.T) {
+	cases := []struct {
+		name     string
+		pl       string
+		in       []map[string]any
+		expected []map[string]any
+		fail     bool
+	}{
+		{
+			name: "duration",
+			pl: `
+if event == "start" {
+	kv_set(req_id, ts, "1m")
+} elif event == "end" {
+	start = kv_delete(req_id)
+	if start != nil {
+		add_key(duration, ts - start)
+	}
+}`,
+			in: []map[string]any{
+				{"req_id": "a", "event": "start", "ts": int64(1000)},
+				{"req_id": "b", "event": "start", "ts": int64(1100)},
+				{"req_id": "a", "event": "end", "ts": int64(1250)},
+				{"req_id": "a", "event": "end", "ts": int64(1300)},
+			},
+			expected: []map[string]any{
+				{"req_id": "a", "event": "start", "ts": int64(1000)},
+				{"req_id": "b", "event": "start", "ts": int64(1100)},
+				{"req_id": "a", "event": "end", "ts": int64(1250), "duration": int64(250)},
+				{"req_id": "a", "event": "end", "ts": int64(1300)},
+			},
+		},
+		{
+			name: "last-user",
+			pl: `
+if user != nil {
+	kv_set(session, user)
+} else {
+	add_key(user, kv_get(session, "unknown"))
+}`,
+			in: []map[string]any{
+				{"session": "s1", "user": "alice"},
+				{"session": "s1"},
+				{"session": "s2"},
+			},
+			expected: []map[string]any{
+				{"session": "s1", "user": "alice"},
+				{"session": "s1", "user": "alice"},
+				{"session": "s2", "user": "unknown"},
+			},
+		},
+		{
+			name: "value-types",
+			pl: `
+add_key(ok_int, kv_set("int", 1))
+add_key(ok_float, kv_set(key = "float", value = 1.5))
+add_key(ok_list, kv_set("list", [1, 2]))
+add_key(ok_missing_key, kv_set(missing, 1))
+add_key(v_int, kv_get("int"))
+add_key(v_float, kv_get("float"))
+add_key(v_list, kv_get("list"))`,
+			in: []map[string]any{{}},
+			expected: []map[string]any{{
+				"ok_int": true, "ok_float": true, "ok_list": false, "ok_missing_key": false,
+				"v_int": int64(1), "v_float": 1.5, "v_list": nil,
+			}},
+		},
+		{
+			name: "invalid-ttl",
+			pl:   `kv_set("k", "v", "1x")`,
+			fail: true,
+		},
+		{
+			name: "ttl-not-literal",
+			pl:   `kv_set("k", "v", ttl)`,
+			fail: true,
+		},
+		{
+			name: "get-without-key",
+			pl:   `kv_get()`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			store := plkv.NewStore(time.Minute, 1024)
+			for i, in := range tc.in {
+				pt := ptinput.GetPoint()
+				ptinput.InitPt(pt, "test", nil, in, time.Now())
+				pt.KV = store
+				require.Nil(t, runScript(runner, pt))
+				assert.Equal(t, tc.expected[i], pt.Fields)
+				ptinput.PutPoint(pt)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
+)
+
+var (
+	metricCounterArgs   = []string{"name", "field", "value", "tags"}
+	metricGaugeArgs     = []string{"name", "field", "value", "tags"}
+	metricHistogramArgs = []string{"name", "field", "value", "buckets", "tags"}
+)
+
+func MetricCounterChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, metricCounterArgs, 2); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+	return checkMetricArgs(ctx, funcExpr, 3)
+}
+
+func MetricCounter(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val := float64(1)
+	if funcExpr.Param[2] != nil {
+		v, ok, err := getMetricValue(ctx, funcExpr.Param[2])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		val = v
+	}
+
+	name, field := funcExpr.Param[0].StringLiteral.Val, funcExpr.Param[1].StringLiteral.Val
+	if err := getAggregator(ctx).Counter(name, field, getMetricTags(ctx, funcExpr.Param[3]), val); err != nil {
+		l.Debug(err)
+	}
+	return nil
+}
+
+func MetricGaugeChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, metricGaugeArgs, 3); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+	return checkMetricArgs(ctx, funcExpr, 3)
+}
+
+func MetricGauge(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, ok, err := getMetricValue(ctx, funcExpr.Param[2])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	name, field := funcExpr.Param[0].StringLiteral.Val, funcExpr.Param[1].StringLiteral.Val
+	if err := getAggregator(ctx).Gauge(name, field, getMetricTags(ctx, funcExpr.Param[3]), val); err != nil {
+		l.Debug(err)
+	}
+	return nil
+}
+
+func MetricHistogramChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, metricHistogramArgs, 3); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if funcExpr.Param[3] != nil {
+		if _, err := getBuckets(funcExpr.Param[3]); err != nil {
+			return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[3].StartPos())
+		}
+	}
+
+	return checkMetricArgs(ctx, funcExpr, 4)
+}
+
+func MetricHistogram(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	val, ok, err := getMetricValue(ctx, funcExpr.Param[2])
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	var buckets []float64
+	if funcExpr.Param[3] != nil {
+		buckets, _ = getBuckets(funcExpr.Param[3])
+	}
+
+	name, field := funcExpr.Param[0].StringLiteral.Val, funcExpr.Param[1].StringLiteral.Val
+	if err := getAggregator(ctx).Histogram(name, field, getMetricTags(ctx, funcExpr.Param[4]), val, buckets); err != nil {
+		l.Debug(err)
+	}
+	return nil
+}
+
+func getAggregator(ctx *runtime.Context) *plmetric.Aggregator {
+	if pt, err := getPoint(ctx.InData()); err == nil && pt.Metric != nil {
+		return pt.Metric
+	}
+	return plmetric.Default()
+}
+
+// checkMetricArgs checks name, field and tags of the metric functions.
+func checkMetricArgs(ctx *runtime.Context, funcExpr *ast.CallExpr, tagsIdx int) *errchain.PlError {
+	for _, p := range funcExpr.Param[:2] {
+		if p.NodeType != ast.TypeStringLiteral {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param expects StringLiteral, got %s",
+				p.NodeType), p.StartPos())
+		}
+		if p.StringLiteral.Val == "" {
+			return runtime.NewRunError(ctx, "param should not be empty", p.StartPos())
+		}
+	}
+
+	if tags := funcExpr.Param[tagsIdx]; tags != nil {
+		if tags.NodeType != ast.TypeListInitExpr {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param tags expects ListInitExpr, got %s",
+				tags.NodeType), tags.StartPos())
+		}
+		for _, item := range tags.ListInitExpr.List {
+			if _, err := getKeyName(item); err != nil {
+				return runtime.NewRunError(ctx, err.Error(), item.StartPos())
+			}
+		}
+	}
+
+	return nil
+}
+
+// getMetricValue returns false if the value is not a number, e.g. the key
+// not found in the point.
+func getMetricValue(ctx *runtime.Context, node *ast.Node) (float64, bool, *errchain.PlError) {
+	val, dtype, err := runtime.RunStmt(ctx, node)
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch dtype { //nolint:exhaustive
+	case ast.Int:
+		if v, ok := val.(int64); ok {
+			return float64(v), true, nil
+		}
+	case ast.Float:
+		if v, ok := val.(float64); ok {
+			return v, true, nil
+		}
+	}
+
+	l.Debugf("metric value %v(%s) is not a number, ignored", val, dtype)
+	return 0, false, nil
+}
+
+// getMetricTags returns tags with the keys in the point, keys not found are ignored.
+func getMetricTags(ctx *runtime.Context, node *ast.Node) map[string]string {
+	tags := map[string]string{}
+	if node == nil {
+		return tags
+	}
+
+	for _, item := range node.ListInitExpr.List {
+		key, err := getKeyName(item)
+		if err != nil {
+			continue
+		}
+
+		if v, err := ctx.GetKeyConv2Str(key); err == nil {
+			tags[key] = v
+		}
+	}
+
+	return tags
+}
+
+func getBuckets(node *ast.Node) ([]float64, error) {
+	if node.NodeType != ast.TypeListInitExpr {
+		return nil, fmt.Errorf("param buckets expects ListInitExpr, got %s", node.NodeType)
+	}
+
+	var buckets []float64
+	for _, item := range node.ListInitExpr.List {
+		switch item.NodeType { //nolint:exhaustive
+		case ast.TypeIntegerLiteral:
+			buckets = append(buckets, float64(item.IntegerLiteral.Val))
+		case ast.TypeFloatLiteral:
+			buckets = append(buckets, item.FloatLiteral.Val)
+		default:
+			return nil, fmt.Errorf("bucket expects NumberLiteral, got %s", item.NodeType)
+		}
+	}
+
+	if !sort.Float64sAreSorted(buckets) {
+		return nil, fmt.Errorf("buckets should be in increasing order")
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestMetric(t *testing.T) {
+	cases := []struct {
+		name     string
+		pl       string
+		in       []map[string]any
+		expected []string
+		fail     bool
+	}{
+		{
+			name: "counter",
+			pl: `
+if status == "error" {
+	metric_counter("nginx_log", "errors", tags = [service, host])
+}
+metric_counter("nginx_log", "bytes", bytes, [service])
+drop()`,
+			in: []map[string]any{
+				{"status": "error", "service": "nginx", "host": "h1", "bytes": int64(100)},
+				{"status": "error", "service": "nginx", "host": "h1", "bytes": int64(200)},
+				{"status": "info", "service": "nginx", "host": "h2", "bytes": 0.5},
+				{"status": "error", "service": "nginx", "host": "h2"},
+			},
+			expected: []string{
+				"nginx_log,host=h1,service=nginx errors=2 123",
+				"nginx_log,host=h2,service=nginx errors=1 123",
+				"nginx_log,service=nginx bytes=300.5 123",
+			},
+		},
+		{
+			name: "gauge",
+			pl:   `metric_gauge("queue", "size", size)`,
+			in: []map[string]any{
+				{"size": int64(3)},
+				{"size": "unknown"},
+				{"size": int64(5)},
+			},
+			expected: []string{"queue size=5 123"},
+		},
+		{
+			name: "histogram",
+			pl: `cast(cost, "float")
+metric_histogram("req", "cost", cost / 1000, [0.1, 1], tags = [path])`,
+			in: []map[string]any{
+				{"cost": "50", "path": "/"},
+				{"cost": "500", "path": "/"},
+				{"cost": "5000", "path": "/"},
+			},
+			expected: []string{
+				"req,le=+Inf,path=/ cost_bucket=3i 123",
+				"req,le=0.1,path=/ cost_bucket=1i 123",
+				"req,le=1,path=/ cost_bucket=2i 123",
+				"req,path=/ cost_count=3i,cost_sum=5.55 123",
+			},
+		},
+		{
+			name: "invalid-name",
+			pl:   `metric_counter(name, "errors")`,
+			fail: true,
+		},
+		{
+			name: "gauge-without-value",
+			pl:   `metric_gauge("queue", "size")`,
+			fail: true,
+		},
+		{
+			name: "invalid-buckets",
+			pl:   `metric_histogram("req", "cost", cost, [1, 0.1])`,
+			fail: true,
+		},
+		{
+			name: "invalid-tags",
+			pl:   `metric_counter("req", "count", tags = "path")`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, in := range tc.in {
+				pt := ptinput.GetPoint()
+				ptinput.InitPt(pt, "test", nil, in, time.Now())
+				require.Nil(t, runScript(runner, pt))
+				ptinput.PutPoint(pt)
+			}
+
+			var got []string
+			for _, pt := range plmetric.Points(time.Unix(0, 123)) {
+				got = append(got, pt.String())
+			}
+			sort.Strings(got)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"strings"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+// cefHeaders are the names of CEF header fields after "CEF:".
+var cefHeaders = []string{
+	"cef_version", "device_vendor", "device_product", "device_version",
+	"device_event_class_id", "name", "severity",
+}
+
+func ParseCEFChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return checkParserFuncArgs(ctx, funcExpr, []string{"key", "prefix"}, 1)
+}
+
+func ParseCEF(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return runParserFunc(ctx, funcExpr, 1, parseCEF)
+}
+
+// parseCEF parses ArcSight CEF message, the syslog header before "CEF:"
+// is ignored:
+//
+//	CEF:Version|Device Vendor|Device Product|Device Version|Device Event Class ID|Name|Severity|[Extension]
+//
+// '|' and '\' are escaped in header fields, '=', '\', '\n' and '\r' are escaped
+// in extension values.
+func parseCEF(s string) (map[string]any, bool) {
+	start := strings.Index(s, "CEF:")
+	if start < 0 {
+		return nil, false
+	}
+	s = s[start+len("CEF:"):]
+
+	result := map[string]any{}
+	for _, name := range cefHeaders {
+		var (
+			sb     strings.Builder
+			closed bool
+		)
+
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+				sb.WriteByte(s[i+1])
+				i++
+				continue
+			}
+			if c == '|' {
+				s = s[i+1:]
+				closed = true
+				break
+			}
+			sb.WriteByte(c)
+		}
+
+		if !closed {
+			return nil, false
+		}
+		result[name] = sb.String()
+	}
+
+	for k, v := range parseCEFExtension(s) {
+		result[k] = v
+	}
+
+	return result, true
+}
+
+// parseCEFExtension parses space separated key=value pairs, values may
+// contain spaces, so a value ends at the key of the next pair.
+func parseCEFExtension(s string) map[string]string {
+	result := map[string]string{}
+
+	var (
+		key      string
+		valStart = -1
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // escaped
+		case '=':
+			keyStart := strings.LastIndexAny(s[:i], " \t") + 1
+			if keyStart < valStart || keyStart == i {
+				// '=' not escaped in value, it's part of the value
+				continue
+			}
+
+			if valStart >= 0 {
+				result[key] = unescapeCEFValue(strings.TrimRight(s[valStart:keyStart], " \t"))
+			}
+			key, valStart = s[keyStart:i], i+1
+		}
+	}
+
+	if valStart >= 0 {
+		result[key] = unescapeCEFValue(strings.TrimRight(s[valStart:], " \t\r\n"))
+	}
+
+	return result
+}
+
+func unescapeCEFValue(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '=', '\\':
+				c = s[i+1]
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			default:
+				sb.WriteByte(c)
+				continue
+			}
+			i++
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
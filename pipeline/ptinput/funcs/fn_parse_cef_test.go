@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestParseCEF(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     string
+		expect map[string]any
+		fail   bool
+	}{
+		{
+			name: "with-syslog-header",
+			in: `Sep 19 08:26:10 host CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|` +
+				`src=10.0.0.1 dst=2.1.2.2 spt=1232 msg=Detected a threat. No action needed cs1Label=rule cs1=a\=b c\\d`,
+			expect: map[string]any{
+				"cef_version": "0", "device_vendor": "Security", "device_product": "threatmanager",
+				"device_version": "1.0", "device_event_class_id": "100", "name": "worm successfully stopped",
+				"severity": "10", "src": "10.0.0.1", "dst": "2.1.2.2", "spt": "1232",
+				"msg": "Detected a threat. No action needed", "cs1Label": "rule", "cs1": `a=b c\d`,
+			},
+		},
+		{
+			name: "escaped-header",
+			in:   `CEF:0|Vendor\|A|Product\\B|1.0|100|detected a \| in message|High|msg=line1\nline2 act=blocked`,
+			expect: map[string]any{
+				"cef_version": "0", "device_vendor": "Vendor|A", "device_product": `Product\B`,
+				"device_version": "1.0", "device_event_class_id": "100", "name": "detected a | in message",
+				"severity": "High", "msg": "line1\nline2", "act": "blocked",
+			},
+		},
+		{
+			name: "unescaped-equal-in-value",
+			in:   `CEF:0|V|P|1|2|N|3|request=http://a.com/?x=1&y=2 requestMethod=GET`,
+			expect: map[string]any{
+				"cef_version": "0", "device_vendor": "V", "device_product": "P",
+				"device_version": "1", "device_event_class_id": "2", "name": "N", "severity": "3",
+				"request": "http://a.com/?x=1&y=2", "requestMethod": "GET",
+			},
+		},
+		{
+			name: "no-extension",
+			in:   `CEF:1|V|P|1|2|N|3|`,
+			expect: map[string]any{
+				"cef_version": "1", "device_vendor": "V", "device_product": "P",
+				"device_version": "1", "device_event_class_id": "2", "name": "N", "severity": "3",
+			},
+		},
+		{name: "incomplete-header", in: `CEF:0|V|P|1|2|N`, fail: true},
+		{name: "not-cef", in: `LEEF:1.0|V|P|1|2|`, fail: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, ok := parseCEF(tc.in)
+			if tc.fail {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestParseCEFFunc(t *testing.T) {
+	runner, err := NewTestingRunner(`ok = parse_cef(_, "cef.")
+add_key(parsed, ok)`)
+	require.NoError(t, err)
+
+	pt := ptinput.GetPoint()
+	defer ptinput.PutPoint(pt)
+	ptinput.InitPt(pt, "test", nil, map[string]any{
+		"message": `CEF:0|V|P|1|2|N|3|src=10.0.0.1`,
+	}, time.Now())
+	require.Nil(t, runScript(runner, pt))
+
+	assert.Equal(t, "10.0.0.1", pt.Fields["cef.src"])
+	assert.Equal(t, "3", pt.Fields["cef.severity"])
+	assert.Equal(t, true, pt.Fields["parsed"])
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+)
+
+type csvParser struct {
+	columns []string
+	comma   rune
+}
+
+func ParseCSVChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	if err := checkParserFuncArgs(ctx, funcExpr, []string{"key", "columns", "delimiter", "prefix"}, 2); err != nil {
+		return err
+	}
+
+	p := &csvParser{comma: ','}
+
+	if funcExpr.Param[1].NodeType != ast.TypeListInitExpr {
+		return runtime.NewRunError(ctx, fmt.Sprintf("param columns expect ListInitExpr, got %s",
+			funcExpr.Param[1].NodeType), funcExpr.Param[1].StartPos())
+	}
+	for _, v := range funcExpr.Param[1].ListInitExpr.List {
+		if v.NodeType != ast.TypeStringLiteral {
+			return runtime.NewRunError(ctx, fmt.Sprintf("expect StringLiteral in columns, got %s",
+				v.NodeType), v.StartPos())
+		}
+		p.columns = append(p.columns, v.StringLiteral.Val)
+	}
+
+	if d := funcExpr.Param[2]; d != nil {
+		if d.NodeType != ast.TypeStringLiteral {
+			return runtime.NewRunError(ctx, fmt.Sprintf("param delimiter expect StringLiteral, got %s",
+				d.NodeType), d.StartPos())
+		}
+
+		r, size := utf8.DecodeRuneInString(d.StringLiteral.Val)
+		if size == 0 || size != len(d.StringLiteral.Val) ||
+			r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return runtime.NewRunError(ctx, fmt.Sprintf("invalid delimiter %q", d.StringLiteral.Val),
+				d.StartPos())
+		}
+		p.comma = r
+	}
+
+	funcExpr.PrivateData = p
+
+	return nil
+}
+
+func ParseCSV(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	p, ok := funcExpr.PrivateData.(*csvParser)
+	if !ok {
+		return runtime.NewRunError(ctx, "unexpected private data", funcExpr.NamePos)
+	}
+
+	return runParserFunc(ctx, funcExpr, 3, p.parse)
+}
+
+// parse parses the first record of s, values are added to the columns in
+// order, empty column names and values without column are ignored.
+func (p *csvParser) parse(s string) (map[string]any, bool) {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comma = p.comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	record, err := r.Read()
+	if err != nil {
+		l.Debugf("parse csv: %v", err)
+		return nil, false
+	}
+
+	result := map[string]any{}
+	for i, v := range record {
+		if i >= len(p.columns) {
+			break
+		}
+		if p.columns[i] != "" {
+			result[p.columns[i]] = v
+		}
+	}
+
+	return result, true
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestParseCSV(t *testing.T) {
+	cases := []struct {
+		name     string
+		pl       string
+		in       string
+		expected map[string]any
+		fail     bool
+	}{
+		{
+			name: "quoted",
+			pl:   `parse_csv(_, ["time", "user", "action", "detail"])`,
+			in:   `2023-02-05 17:32:18,"admin, root","login","said ""hi"""`,
+			expected: map[string]any{
+				"time": "2023-02-05 17:32:18", "user": "admin, root", "action": "login", "detail": `said "hi"`,
+			},
+		},
+		{
+			name: "delimiter-and-skip",
+			pl:   `parse_csv(_, ["time", "", "action"], delimiter = "\t", prefix = "audit_")`,
+			in:   "2023-02-05\tadmin\tlogout\textra",
+			expected: map[string]any{
+				"audit_time": "2023-02-05", "audit_action": "logout",
+			},
+		},
+		{
+			name:     "less-values",
+			pl:       `parse_csv(_, ["a", "b", "c"])`,
+			in:       `1,2`,
+			expected: map[string]any{"a": "1", "b": "2"},
+		},
+		{
+			name:     "multiline-value",
+			pl:       `parse_csv(_, ["a", "b"], "|")`,
+			in:       "x|\"line1\nline2\"",
+			expected: map[string]any{"a": "x", "b": "line1\nline2"},
+		},
+		{
+			name: "columns-not-list",
+			pl:   `parse_csv(_, "a")`,
+			fail: true,
+		},
+		{
+			name: "columns-not-literal",
+			pl:   `parse_csv(_, [a])`,
+			fail: true,
+		},
+		{
+			name: "invalid-delimiter",
+			pl:   `parse_csv(_, ["a"], delimiter = ",,")`,
+			fail: true,
+		},
+		{
+			name: "quote-delimiter",
+			pl:   `parse_csv(_, ["a"], delimiter = "\"")`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			pt := ptinput.GetPoint()
+			defer ptinput.PutPoint(pt)
+			ptinput.InitPt(pt, "test", nil, map[string]any{"message": tc.in}, time.Now())
+			require.Nil(t, runScript(runner, pt))
+
+			delete(pt.Fields, "message")
+			assert.Equal(t, tc.expected, pt.Fields)
+		})
+	}
+}
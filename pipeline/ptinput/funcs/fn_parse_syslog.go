@@ -0,0 +1,297 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+const syslogNilValue = "-"
+
+// the day is space padded or not.
+var rfc3164Stamps = []string{time.Stamp, "Jan 2 15:04:05"}
+
+// app[pid]: or app:
+var rfc3164Tag = regexp.MustCompile(`^([^\s\[\]:]+)(?:\[([^\]]*)\])?:\s?`)
+
+func ParseSyslogChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return checkParserFuncArgs(ctx, funcExpr, []string{"key", "prefix"}, 1)
+}
+
+func ParseSyslog(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	return runParserFunc(ctx, funcExpr, 1, parseSyslog)
+}
+
+// parseSyslog parses RFC5424 or RFC3164 syslog message, the PRI of RFC3164
+// is optional as it's usually removed in log files.
+func parseSyslog(s string) (map[string]any, bool) {
+	result := map[string]any{}
+
+	if strings.HasPrefix(s, "<") {
+		end := strings.IndexByte(s, '>')
+		if end < 2 || end > 4 {
+			return nil, false
+		}
+
+		pri, err := strconv.ParseInt(s[1:end], 10, 64)
+		if err != nil || pri > 191 {
+			return nil, false
+		}
+
+		result["priority"] = pri
+		result["facility"] = pri / 8
+		result["severity"] = pri % 8
+		s = s[end+1:]
+
+		// VERSION SP, only RFC5424 has it
+		if i := strings.IndexByte(s, ' '); i > 0 && i <= 2 {
+			if version, err := strconv.ParseInt(s[:i], 10, 64); err == nil {
+				result["version"] = version
+				if !parseRFC5424(s[i+1:], result) {
+					return nil, false
+				}
+				return result, true
+			}
+		}
+	}
+
+	if !parseRFC3164(s, result) {
+		return nil, false
+	}
+	return result, true
+}
+
+// parseRFC5424 parses the message after VERSION SP:
+//
+//	TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parseRFC5424(s string, result map[string]any) bool {
+	for _, name := range []string{"timestamp", "hostname", "appname", "procid", "msgid"} {
+		i := strings.IndexByte(s, ' ')
+		if i <= 0 {
+			return false
+		}
+		if v := s[:i]; v != syslogNilValue {
+			result[name] = v
+		}
+		s = s[i+1:]
+	}
+
+	if s == "" {
+		return false
+	}
+
+	if s[0] == '-' {
+		s = s[1:]
+	} else {
+		rest, ok := parseStructuredData(s, result)
+		if !ok {
+			return false
+		}
+		s = rest
+	}
+
+	if s != "" {
+		if s[0] != ' ' {
+			return false
+		}
+		// the UTF-8 BOM of MSG
+		if msg := strings.TrimPrefix(s[1:], "\xEF\xBB\xBF"); msg != "" {
+			result["msg"] = msg
+		}
+	}
+
+	return true
+}
+
+// parseStructuredData parses SD-ELEMENTs, SD-PARAMs are added as
+// <SD-ID>.<PARAM-NAME>, it returns the rest of s.
+func parseStructuredData(s string, result map[string]any) (string, bool) {
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+
+		end := strings.IndexAny(s, " ]")
+		if end <= 0 {
+			return "", false
+		}
+		id := s[:end]
+		s = s[end:]
+
+		for {
+			if s == "" {
+				return "", false
+			}
+			if s[0] == ']' {
+				s = s[1:]
+				break
+			}
+			if s[0] != ' ' {
+				return "", false
+			}
+			s = s[1:]
+
+			eq := strings.IndexByte(s, '=')
+			if eq <= 0 || len(s) < eq+2 || s[eq+1] != '"' {
+				return "", false
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			// PARAM-VALUE with '"', '\' and ']' escaped
+			var sb strings.Builder
+			closed := false
+			for i := 0; i < len(s); i++ {
+				c := s[i]
+				if c == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '"', '\\', ']':
+						sb.WriteByte(s[i+1])
+						i++
+						continue
+					}
+				}
+				if c == '"' {
+					s = s[i+1:]
+					closed = true
+					break
+				}
+				sb.WriteByte(c)
+			}
+			if !closed {
+				return "", false
+			}
+
+			result[id+"."+name] = sb.String()
+		}
+	}
+
+	return s, true
+}
+
+// parseRFC3164 parses the message after PRI:
+//
+//	TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG
+//
+// TIMESTAMP is in form of "Mmm dd hh:mm:ss" or RFC3339.
+func parseRFC3164(s string, result map[string]any) bool {
+	for _, layout := range rfc3164Stamps {
+		if len(s) < len(layout) {
+			continue
+		}
+		if _, err := time.Parse(layout, s[:len(layout)]); err == nil {
+			result["timestamp"] = s[:len(layout)]
+			s = s[len(layout):]
+			break
+		}
+	}
+
+	if _, ok := result["timestamp"]; !ok {
+		i := strings.IndexByte(s, ' ')
+		if i <= 0 {
+			return false
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s[:i]); err != nil {
+			return false
+		}
+		result["timestamp"] = s[:i]
+		s = s[i:]
+	}
+
+	s = strings.TrimLeft(s, " ")
+	i := strings.IndexByte(s, ' ')
+	if i <= 0 {
+		return false
+	}
+	result["hostname"] = s[:i]
+	s = s[i+1:]
+
+	if m := rfc3164Tag.FindStringSubmatch(s); m != nil {
+		result["appname"] = m[1]
+		if m[2] != "" {
+			result["procid"] = m[2]
+		}
+		s = s[len(m[0]):]
+	}
+
+	if s != "" {
+		result["msg"] = s
+	}
+
+	return true
+}
+
+// checkParserFuncArgs checks the arguments of parser functions, the first
+// is the key and the last is the prefix.
+func checkParserFuncArgs(ctx *runtime.Context, funcExpr *ast.CallExpr, args []string,
+	required int,
+) *errchain.PlError {
+	if err := reindexFuncArgs(funcExpr, args, required); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if _, err := getKeyName(funcExpr.Param[0]); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	if p := funcExpr.Param[len(args)-1]; p != nil && p.NodeType != ast.TypeStringLiteral {
+		return runtime.NewRunError(ctx, fmt.Sprintf("param prefix expect StringLiteral, got %s",
+			p.NodeType), p.StartPos())
+	}
+
+	return nil
+}
+
+// runParserFunc parses the value of the key and adds the result with
+// prefix, the last argument is the prefix.
+func runParserFunc(ctx *runtime.Context, funcExpr *ast.CallExpr, prefixIdx int,
+	parse func(string) (map[string]any, bool),
+) *errchain.PlError {
+	key, err := getKeyName(funcExpr.Param[0])
+	if err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	val, err := ctx.GetKeyConv2Str(key)
+	if err != nil {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	result, ok := parse(val)
+	if !ok {
+		ctx.Regs.ReturnAppend(false, ast.Bool)
+		return nil
+	}
+
+	var prefix string
+	if p := funcExpr.Param[prefixIdx]; p != nil {
+		prefix = p.StringLiteral.Val
+	}
+
+	for k, v := range result {
+		var dtype ast.DType
+		switch v.(type) {
+		case int64:
+			dtype = ast.Int
+		default:
+			dtype = ast.String
+		}
+		if err := addKey2PtWithVal(ctx.InData(), prefix+k, v, dtype, ptinput.KindPtDefault); err != nil {
+			l.Debug(err)
+		}
+	}
+
+	ctx.Regs.ReturnAppend(true, ast.Bool)
+	return nil
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+)
+
+func TestParseSyslog(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect map[string]any
+		fail   bool
+	}{
+		{
+			in: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"]` +
+				`[examplePriority@32473 class="high \"x\" \] \\ \a"] ` + "\xEF\xBB\xBF" + `An application event`,
+			expect: map[string]any{
+				"priority": int64(165), "facility": int64(20), "severity": int64(5), "version": int64(1),
+				"timestamp": "2003-10-11T22:14:15.003Z", "hostname": "mymachine.example.com",
+				"appname": "evntslog", "msgid": "ID47",
+				"exampleSDID@32473.iut":         "3",
+				"exampleSDID@32473.eventSource": "Application",
+				"exampleSDID@32473.eventID":     "1011",
+				"examplePriority@32473.class":   `high "x" ] \ \a`,
+				"msg":                           "An application event",
+			},
+		},
+		{
+			in: `<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed`,
+			expect: map[string]any{
+				"priority": int64(34), "facility": int64(4), "severity": int64(2), "version": int64(1),
+				"timestamp": "2003-10-11T22:14:15.003Z", "hostname": "mymachine.example.com",
+				"appname": "su", "msgid": "ID47", "msg": "'su root' failed",
+			},
+		},
+		{
+			in: `<13>1 - - - - - -`,
+			expect: map[string]any{
+				"priority": int64(13), "facility": int64(1), "severity": int64(5), "version": int64(1),
+			},
+		},
+		{
+			in: `<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`,
+			expect: map[string]any{
+				"priority": int64(34), "facility": int64(4), "severity": int64(2),
+				"timestamp": "Oct 11 22:14:15", "hostname": "mymachine",
+				"appname": "su", "msg": "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			in: `Feb  5 17:32:18 web-01 sshd[1234]: Accepted publickey for root`,
+			expect: map[string]any{
+				"timestamp": "Feb  5 17:32:18", "hostname": "web-01",
+				"appname": "sshd", "procid": "1234", "msg": "Accepted publickey for root",
+			},
+		},
+		{
+			in: `<13>2023-02-05T17:32:18.123+08:00 web-01 kernel message without tag`,
+			expect: map[string]any{
+				"priority": int64(13), "facility": int64(1), "severity": int64(5),
+				"timestamp": "2023-02-05T17:32:18.123+08:00", "hostname": "web-01",
+				"msg": "kernel message without tag",
+			},
+		},
+		{in: `<13>1 2003-10-11T22:14:15.003Z host app - ID [id a="1"`, fail: true},
+		{in: `<13>1 2003-10-11T22:14:15.003Z host app - ID [id a=1]`, fail: true},
+		{in: `<200>Oct 11 22:14:15 mymachine su: x`, fail: true},
+		{in: `not a syslog message`, fail: true},
+		{in: ``, fail: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			result, ok := parseSyslog(tc.in)
+			if tc.fail {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestParseSyslogFunc(t *testing.T) {
+	runner, err := NewTestingRunner(`
+if parse_syslog(_, prefix = "syslog_") {
+	drop_key(message)
+}
+`)
+	require.NoError(t, err)
+
+	pt := ptinput.GetPoint()
+	defer ptinput.PutPoint(pt)
+	ptinput.InitPt(pt, "test", nil, map[string]any{
+		"message": `<86>Feb  5 17:32:18 web-01 sshd[1234]: Accepted publickey`,
+	}, time.Now())
+	require.Nil(t, runScript(runner, pt))
+
+	assert.Equal(t, map[string]any{
+		"syslog_priority": int64(86), "syslog_facility": int64(10), "syslog_severity": int64(6),
+		"syslog_timestamp": "Feb  5 17:32:18", "syslog_hostname": "web-01",
+		"syslog_appname": "sshd", "syslog_procid": "1234", "syslog_msg": "Accepted publickey",
+	}, pt.Fields)
+
+	_, err = NewTestingRunner(`parse_syslog(_, prefix = 1)`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"fmt"
+
+	"github.com/GuanceCloud/platypus/pkg/ast"
+	"github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"github.com/GuanceCloud/platypus/pkg/errchain"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+	plrefertable "gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/refertable"
+)
+
+func MatchReferTableChecking(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	err := reindexFuncArgs(funcExpr, []string{"table_name", "mode", "key", "value"}, 4)
+	if err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.NamePos)
+	}
+
+	if _, err := getKeyName(funcExpr.Param[0]); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[0].StartPos())
+	}
+
+	if funcExpr.Param[1].NodeType != ast.TypeStringLiteral {
+		return runtime.NewRunError(ctx, fmt.Sprintf("param mode expects StringLiteral, got %s",
+			funcExpr.Param[1].NodeType), funcExpr.Param[1].StartPos())
+	}
+	mode := funcExpr.Param[1].StringLiteral.Val
+
+	var colName []string
+	switch funcExpr.Param[2].NodeType { //nolint:exhaustive
+	case ast.TypeStringLiteral:
+		colName = append(colName, funcExpr.Param[2].StringLiteral.Val)
+	case ast.TypeListInitExpr:
+		for _, v := range funcExpr.Param[2].ListInitExpr.List {
+			if v.NodeType != ast.TypeStringLiteral {
+				return runtime.NewRunError(ctx, fmt.Sprintf(
+					"expect StringLiteral in list, got %s", v.NodeType), v.StartPos())
+			}
+			colName = append(colName, v.StringLiteral.Val)
+		}
+	default:
+		return runtime.NewRunError(ctx, fmt.Sprintf("param key expects StringLiteral or list, got %s",
+			funcExpr.Param[2].NodeType), funcExpr.Param[2].StartPos())
+	}
+
+	if err := plrefertable.CheckMatch(mode, colName); err != nil {
+		return runtime.NewRunError(ctx, err.Error(), funcExpr.Param[1].StartPos())
+	}
+
+	funcExpr.PrivateData = colName
+
+	return nil
+}
+
+func MatchReferTable(ctx *runtime.Context, funcExpr *ast.CallExpr) *errchain.PlError {
+	colName, ok := funcExpr.PrivateData.([]string)
+	if !ok {
+		return runtime.NewRunError(ctx, "unexpected private data", funcExpr.NamePos)
+	}
+
+	tname, dtype, err := runtime.RunStmt(ctx, funcExpr.Param[0])
+	if err != nil {
+		return err
+	}
+	if dtype != ast.String {
+		return runtime.NewRunError(ctx, "param expect string", funcExpr.Param[0].StartPos())
+	}
+
+	value, _, err := runtime.RunStmt(ctx, funcExpr.Param[3])
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return nil
+	}
+
+	if vMap, ok := plrefertable.QueryReferTableMatch(tname.(string),
+		funcExpr.Param[1].StringLiteral.Val, colName, value, nil); ok {
+		for k, v := range vMap {
+			var dtype ast.DType
+			switch v.(type) {
+			case string:
+				dtype = ast.String
+			case bool:
+				dtype = ast.Bool
+			case int64:
+				dtype = ast.Int
+			case float64:
+				dtype = ast.Float
+			default:
+				continue
+			}
+			_ = addKey2PtWithVal(ctx.InData(), k, v, dtype, ptinput.KindPtDefault)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package funcs
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/refertable"
+)
+
+func TestMatchReferTable(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sites.csv":  "cidr,site\n10.0.0.0/8,dc\n10.1.0.0/16,dc-1\n",
+		"ports.csv":  "low:int,high:int,usage\n0,1024,system\n8000,9000,http-alt\n",
+		"routes.csv": "prefix,service\n/api/,api\n/api/v1/,api-v1\n",
+	}
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+	}
+
+	require.NoError(t, refertable.InitReferTableRunner("file://"+filepath.ToSlash(dir), time.Second*10, false, false))
+	require.Eventually(t, func() bool {
+		stats := refertable.Stats()
+		return stats != nil && len(stats.Name) == len(files)
+	}, time.Second*5, time.Millisecond*10)
+
+	cases := []struct {
+		name     string
+		pl       string
+		in       map[string]any
+		expected map[string]any
+		fail     bool
+	}{
+		{
+			name: "cidr",
+			pl:   `match_refer_table("sites", "cidr", "cidr", ip)`,
+			in:   map[string]any{"ip": "10.1.2.3"},
+			expected: map[string]any{
+				"ip": "10.1.2.3", "cidr": "10.1.0.0/16", "site": "dc-1",
+			},
+		},
+		{
+			name: "range",
+			pl:   `match_refer_table("ports", mode = "range", key = ["low", "high"], value = port)`,
+			in:   map[string]any{"port": int64(8080)},
+			expected: map[string]any{
+				"port": int64(8080), "low": int64(8000), "high": int64(9000), "usage": "http-alt",
+			},
+		},
+		{
+			name: "prefix",
+			pl:   `match_refer_table("routes", "prefix", "prefix", path)`,
+			in:   map[string]any{"path": "/api/v1/users"},
+			expected: map[string]any{
+				"path": "/api/v1/users", "prefix": "/api/v1/", "service": "api-v1",
+			},
+		},
+		{
+			name:     "not-matched",
+			pl:       `match_refer_table("sites", "cidr", "cidr", ip)`,
+			in:       map[string]any{"ip": "192.168.0.1"},
+			expected: map[string]any{"ip": "192.168.0.1"},
+		},
+		{
+			name:     "key-not-found",
+			pl:       `match_refer_table("sites", "cidr", "cidr", ip)`,
+			in:       map[string]any{"host": "h1"},
+			expected: map[string]any{"host": "h1"},
+		},
+		{
+			name: "invalid-mode",
+			pl:   `match_refer_table("sites", "regex", "cidr", ip)`,
+			fail: true,
+		},
+		{
+			name: "mode-not-literal",
+			pl:   `match_refer_table("sites", mode, "cidr", ip)`,
+			fail: true,
+		},
+		{
+			name: "range-one-column",
+			pl:   `match_refer_table("ports", "range", "low", port)`,
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner, err := NewTestingRunner(tc.pl)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			pt := ptinput.GetPoint()
+			defer ptinput.PutPoint(pt)
+			ptinput.InitPt(pt, "test", nil, tc.in, time.Now())
+			require.Nil(t, runScript(runner, pt))
+
+			assert.Equal(t, tc.expected, pt.Fields)
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/GuanceCloud/platypus/pkg/ast"
 	plruntime "github.com/GuanceCloud/platypus/pkg/engine/runtime"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
 
 	"github.com/spf13/cast"
 )
@@ -64,6 +66,13 @@ type Point struct {
 
 	Drop bool
 	Meta map[string]*TFMeta // [DType, PtFlag]
+
+	// KV is the key-value store of the running script
+	KV *plkv.Store
+
+	// Metric is the aggregator of metrics generated by the running script,
+	// nil for the default aggregator.
+	Metric *plmetric.Aggregator
 }
 
 func InitPt(pt *Point, m string, t map[string]string, f map[string]any, tn time.Time) *Point {
@@ -81,6 +90,8 @@ func InitPt(pt *Point, m string, t map[string]string, f map[string]any, tn time.
 	pt.Time = tn
 	pt.Drop = false
 	pt.Meta = map[string]*TFMeta{}
+	pt.KV = nil
+	pt.Metric = nil
 
 	for k, v := range f {
 		if v == nil {
@@ -314,6 +325,8 @@ func PutPoint(pt *Point) {
 	}
 
 	pt.Drop = false
+	pt.KV = nil
+	pt.Metric = nil
 
 	pointPool.Put(pt)
 }
@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	fileExtJSON    = ".json"
+	fileExtCSV     = ".csv"
+	fileExtDB      = ".db"
+	fileExtSQLite  = ".sqlite"
+	fileExtSQLite3 = ".sqlite3"
+)
+
+var windowsDrivePath = regexp.MustCompile(`^/[a-zA-Z]:`)
+
+// filePath returns the local path of file:// URL.
+func filePath(tableURL string) string {
+	p := tableURL[len(SchemeFile+"://"):]
+
+	// file:///C:/path/to/tables
+	if runtime.GOOS == "windows" && windowsDrivePath.MatchString(p) {
+		p = p[1:]
+	}
+
+	return filepath.Clean(filepath.FromSlash(p))
+}
+
+// listTableFiles returns table files of path, if path is a directory,
+// the table files under it(not recursively) are returned.
+func listTableFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case fileExtJSON, fileExtCSV, fileExtDB, fileExtSQLite, fileExtSQLite3:
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// filesSignature identifies the version of the files, it changes if any
+// file is added, removed or modified.
+func filesSignature(files []string) (string, error) {
+	var sb strings.Builder
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d\n", f, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return sb.String(), nil
+}
+
+// readTableFile reads tables from file:
+//   - .json: the same as the data of HTTP(S) URL.
+//   - .csv: one table named after the file name, the header is column names
+//     with type in form of name:type, type is string if not set.
+//   - .db/.sqlite/.sqlite3: all tables of the SQLite database file.
+func readTableFile(path string) ([]referTable, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case fileExtJSON:
+		data, err := ioutil.ReadFile(path) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		return decodeJSONData(data)
+
+	case fileExtCSV:
+		f, err := os.Open(path) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() //nolint:errcheck,gosec
+
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		table, err := decodeCSVData(name, f)
+		if err != nil {
+			return nil, err
+		}
+		return []referTable{*table}, nil
+
+	case fileExtDB, fileExtSQLite, fileExtSQLite3:
+		return readSQLiteFile(path)
+
+	default:
+		return nil, fmt.Errorf("unsupported table file %s", path)
+	}
+}
+
+func decodeCSVData(name string, r io.Reader) (*referTable, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("table: %s, read header: %w", name, err)
+	}
+
+	table := &referTable{TableName: name}
+	for _, col := range header {
+		colName, colType := strings.TrimSpace(col), columnTypeStr
+		if idx := strings.LastIndexByte(colName, ':'); idx >= 0 {
+			colName, colType = colName[:idx], strings.ToLower(colName[idx+1:])
+		}
+		table.ColumnName = append(table.ColumnName, colName)
+		table.ColumnType = append(table.ColumnType, colType)
+	}
+
+	if err := table.check(); err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("table: %s, error: %w", name, err)
+		}
+
+		row := make([]any, len(record))
+		for i, v := range record {
+			// empty cell is zero value of the column type
+			if row[i], err = convFileValue(v, table.ColumnType[i]); err != nil {
+				return nil, fmt.Errorf("table: %s, row: %d, col: %d, cast error: %w",
+					name, len(table.RowData), i, err)
+			}
+		}
+		table.RowData = append(table.RowData, row)
+	}
+
+	return table, nil
+}
+
+// convFileValue converts value of table file, nil and empty string are
+// converted to the zero value, numbers are converted to bool as SQLite does.
+func convFileValue(v any, dtype string) (any, error) {
+	if s, ok := v.(string); (ok && s == "") || v == nil {
+		switch dtype {
+		case columnTypeInt:
+			return int64(0), nil
+		case columnTypeFloat:
+			return float64(0), nil
+		case columnTypeBool:
+			return false, nil
+		}
+	}
+
+	if dtype == columnTypeBool {
+		switch x := v.(type) {
+		case int64:
+			return x != 0, nil
+		case float64:
+			return x != 0, nil
+		}
+	}
+
+	return conv(v, dtype)
+}
+
+func readTableFiles(files []string) ([]referTable, error) {
+	var tables []referTable
+	for _, f := range files {
+		t, err := readTableFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read table file %s: %w", f, err)
+		}
+		tables = append(tables, t...)
+	}
+	return tables, nil
+}
+
+func fileWkr(plRefTables PlReferTables, runner *Runner, ch <-chan any) error {
+	ticker := time.NewTicker(runner.inConfig.Interval)
+	defer ticker.Stop()
+
+	path := filePath(runner.inConfig.URL)
+	var signature string
+
+	for {
+		signature = loadAndUpdate(plRefTables, runner, path, signature)
+		select {
+		case <-ticker.C:
+		case <-ch:
+			return nil
+		}
+	}
+}
+
+// loadAndUpdate reloads tables if files changed, it returns the signature
+// of files loaded.
+func loadAndUpdate(plRefTables PlReferTables, runner *Runner, path, signature string) string {
+	defer runner.markInitFinished()
+
+	files, err := listTableFiles(path)
+	if err != nil {
+		l.Errorf("list table files: %v", err)
+		return signature
+	}
+
+	sig, err := filesSignature(files)
+	if err != nil {
+		l.Errorf("stat table files: %v", err)
+		return signature
+	}
+
+	if sig == signature {
+		return signature
+	}
+
+	tables, err := readTableFiles(files)
+	if err != nil {
+		l.Errorf("get table data from file: %v", err)
+		return signature
+	}
+
+	if err := plRefTables.updateAll(tables); err != nil {
+		l.Errorf("failed to update tables: %v", err)
+		return signature
+	}
+
+	l.Infof("%d tables loaded from %d files of %s", len(tables), len(files), path)
+	return sig
+}
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCSVData(t *testing.T) {
+	cases := []struct {
+		name   string
+		data   string
+		expect *referTable
+		fail   bool
+	}{
+		{
+			name: "typed",
+			data: `ip,host:string,port:int,weight:float,online:bool
+1.1.1.1,h1,80,0.5,true
+"2.2.2.2", "h2,a", , ,
+`,
+			expect: &referTable{
+				TableName:  "typed",
+				ColumnName: []string{"ip", "host", "port", "weight", "online"},
+				ColumnType: []string{"string", "string", "int", "float", "bool"},
+				RowData: [][]any{
+					{"1.1.1.1", "h1", int64(80), 0.5, true},
+					{"2.2.2.2", "h2,a", int64(0), float64(0), false},
+				},
+			},
+		},
+		{
+			name: "header-only",
+			data: `ip,host`,
+			expect: &referTable{
+				TableName:  "header-only",
+				ColumnName: []string{"ip", "host"},
+				ColumnType: []string{"string", "string"},
+			},
+		},
+		{
+			name: "invalid-type",
+			data: "ip:ipv4\n1.1.1.1",
+			fail: true,
+		},
+		{
+			name: "invalid-value",
+			data: "port:int\nhttp",
+			fail: true,
+		},
+		{
+			name: "column-mismatch",
+			data: "ip,host\n1.1.1.1",
+			fail: true,
+		},
+		{
+			name: "empty",
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			table, err := decodeCSVData(tc.name, strings.NewReader(tc.data))
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, table)
+		})
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	assert.Equal(t, filepath.FromSlash("/etc/datakit/tables"), filePath("file:///etc/datakit/tables/"))
+	assert.Equal(t, filepath.FromSlash("tables/a.csv"), filePath("file://tables/a.csv"))
+}
+
+func TestLoadAndUpdate(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+	}
+
+	write("a.json", testTableData)
+	write("hosts.csv", "ip,host,port:int\n1.1.1.1,h1,80\n2.2.2.2,h2,8080\n")
+	write("readme.txt", "ignored")
+
+	plReferTable := &PlReferTablesInMemory{}
+	runner := &Runner{initFinished: make(chan struct{})}
+
+	sig := loadAndUpdate(plReferTable, runner, dir, "")
+	assert.NotEmpty(t, sig)
+	assert.True(t, runner.InitFinished(time.Millisecond))
+
+	assert.Equal(t, &ReferTableStats{
+		Name: []string{"table1", "table2", "hosts"},
+		Row:  []int{3, 2, 2},
+	}, plReferTable.stats())
+
+	v, ok := plReferTable.query("hosts", []string{"ip"}, []any{"2.2.2.2"}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"ip": "2.2.2.2", "host": "h2", "port": int64(8080)}, v)
+
+	// not changed
+	assert.Equal(t, sig, loadAndUpdate(plReferTable, runner, dir, sig))
+
+	// invalid file, the tables loaded are kept
+	write("hosts.csv", "ip,host,port:int\n1.1.1.1,h1,http\n")
+	assert.Equal(t, sig, loadAndUpdate(plReferTable, runner, dir, sig))
+	_, ok = plReferTable.query("hosts", []string{"ip"}, []any{"2.2.2.2"}, nil)
+	assert.True(t, ok)
+
+	// removed and modified
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.json")))
+	write("hosts.csv", "ip,host,port:int\n3.3.3.3,h3,443\n")
+	newSig := loadAndUpdate(plReferTable, runner, dir, sig)
+	assert.NotEqual(t, sig, newSig)
+
+	assert.Equal(t, &ReferTableStats{Name: []string{"hosts"}, Row: []int{1}}, plReferTable.stats())
+	v, ok = plReferTable.query("hosts", []string{"port"}, []any{int64(443)}, []string{"host"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"host": "h3"}, v)
+
+	// single file
+	_ = loadAndUpdate(plReferTable, runner, filepath.Join(dir, "hosts.csv"), "")
+	assert.Equal(t, &ReferTableStats{Name: []string{"hosts"}, Row: []int{1}}, plReferTable.stats())
+}
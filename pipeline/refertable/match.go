@@ -0,0 +1,368 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+)
+
+// Match modes of QueryReferTableMatch.
+const (
+	// MatchCIDR matches IP against CIDR blocks of a string column, the
+	// longest(most specific) block is matched.
+	MatchCIDR = "cidr"
+
+	// MatchRange matches number against [low, high) of two numeric columns,
+	// the narrowest range is matched.
+	MatchRange = "range"
+
+	// MatchPrefix matches string against prefixes of a string column, the
+	// longest prefix is matched.
+	MatchPrefix = "prefix"
+)
+
+// CheckMatch checks the mode and the number of columns.
+func CheckMatch(mode string, colName []string) error {
+	switch mode {
+	case MatchCIDR, MatchPrefix:
+		if len(colName) != 1 {
+			return fmt.Errorf("match mode %s expects 1 column, got %d", mode, len(colName))
+		}
+	case MatchRange:
+		if len(colName) != 2 {
+			return fmt.Errorf("match mode %s expects 2 columns(low and high), got %d", mode, len(colName))
+		}
+	default:
+		return fmt.Errorf("unsupported match mode %q", mode)
+	}
+	return nil
+}
+
+// matchIndexes caches the indexes of match modes, they are built on first
+// query since the columns used are unknown before.
+type matchIndexes struct {
+	mtx     sync.Mutex
+	indexes map[string]matchIndex
+}
+
+type matchIndex interface {
+	// match returns the row matched.
+	match(value any) (int, bool)
+}
+
+func (table *referTable) matchIndex(mode string, colName []string) (matchIndex, error) {
+	key := mode + ":" + strings.Join(colName, ",")
+
+	table.matchIdx.mtx.Lock()
+	defer table.matchIdx.mtx.Unlock()
+
+	if idx, ok := table.matchIdx.indexes[key]; ok {
+		return idx, nil
+	}
+
+	colIdx := make([]int, len(colName))
+	for i, name := range colName {
+		idx, ok := table.colIndex[name]
+		if !ok {
+			return nil, fmt.Errorf("table: %s, column %s not found", table.TableName, name)
+		}
+		colIdx[i] = idx
+	}
+
+	var (
+		idx matchIndex
+		err error
+	)
+
+	switch mode {
+	case MatchCIDR:
+		idx, err = buildCIDRIndex(table, colIdx[0])
+	case MatchPrefix:
+		idx, err = buildPrefixIndex(table, colIdx[0])
+	case MatchRange:
+		idx, err = buildRangeIndex(table, colIdx[0], colIdx[1])
+	default:
+		err = fmt.Errorf("unsupported match mode %q", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table.matchIdx.indexes[key] = idx
+	return idx, nil
+}
+
+type cidrKey struct {
+	bits int
+	ip   string
+}
+
+// cidrIndex indexes networks by prefix length, lookup masks the IP with
+// each prefix length from long to short.
+type cidrIndex struct {
+	networks map[cidrKey]int
+	bitsV4   []int // in descending order
+	bitsV6   []int
+}
+
+// parseCIDR parses CIDR in canonical form(such as 10.0.0.0/8 and 2001:db8::/32),
+// plain IP is treated as a single host network. Non-canonical CIDR is rejected
+// so that in-memory and SQLite tables match the same rows.
+func parseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil || ip.String() != s {
+			return nil, fmt.Errorf("invalid IP %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, ipnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, err
+	}
+	if ipnet.String() != s {
+		return nil, fmt.Errorf("CIDR %q is not in canonical form %q", s, ipnet.String())
+	}
+	return ipnet, nil
+}
+
+func buildCIDRIndex(table *referTable, col int) (*cidrIndex, error) {
+	if table.ColumnType[col] != columnTypeStr {
+		return nil, fmt.Errorf("table: %s, CIDR column %s should be string",
+			table.TableName, table.ColumnName[col])
+	}
+
+	idx := &cidrIndex{networks: map[cidrKey]int{}}
+	v4, v6 := map[int]bool{}, map[int]bool{}
+
+	for rowIdx, row := range table.RowData {
+		s, _ := row[col].(string)
+		ipnet, err := parseCIDR(s)
+		if err != nil {
+			l.Debugf("table: %s, row: %d, invalid CIDR %q, ignored", table.TableName, rowIdx, s)
+			continue
+		}
+
+		ones, bits := ipnet.Mask.Size()
+		key := cidrKey{bits: ones, ip: ipnet.IP.String()}
+		if _, ok := idx.networks[key]; ok {
+			continue // the first row is matched
+		}
+		idx.networks[key] = rowIdx
+
+		if bits == 32 {
+			v4[ones] = true
+		} else {
+			v6[ones] = true
+		}
+	}
+
+	for bits := range v4 {
+		idx.bitsV4 = append(idx.bitsV4, bits)
+	}
+	for bits := range v6 {
+		idx.bitsV6 = append(idx.bitsV6, bits)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.bitsV4)))
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.bitsV6)))
+
+	return idx, nil
+}
+
+func (idx *cidrIndex) match(value any) (int, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return 0, false
+	}
+
+	allBits, total := idx.bitsV6, 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, allBits, total = ip4, idx.bitsV4, 32
+	}
+
+	for _, bits := range allBits {
+		network := ip.Mask(net.CIDRMask(bits, total))
+		if rowIdx, ok := idx.networks[cidrKey{bits: bits, ip: network.String()}]; ok {
+			return rowIdx, true
+		}
+	}
+
+	return 0, false
+}
+
+// prefixIndex indexes prefixes by length, lookup checks prefixes of the value
+// from long to short.
+type prefixIndex struct {
+	prefixes map[string]int
+	lens     []int // in descending order
+}
+
+func buildPrefixIndex(table *referTable, col int) (*prefixIndex, error) {
+	if table.ColumnType[col] != columnTypeStr {
+		return nil, fmt.Errorf("table: %s, prefix column %s should be string",
+			table.TableName, table.ColumnName[col])
+	}
+
+	idx := &prefixIndex{prefixes: map[string]int{}}
+	lens := map[int]bool{}
+
+	for rowIdx, row := range table.RowData {
+		s, _ := row[col].(string)
+		if _, ok := idx.prefixes[s]; ok {
+			continue
+		}
+		idx.prefixes[s] = rowIdx
+		lens[len(s)] = true
+	}
+
+	for n := range lens {
+		idx.lens = append(idx.lens, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(idx.lens)))
+
+	return idx, nil
+}
+
+func (idx *prefixIndex) match(value any) (int, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+
+	for _, n := range idx.lens {
+		if n > len(s) {
+			continue
+		}
+		if rowIdx, ok := idx.prefixes[s[:n]]; ok {
+			return rowIdx, true
+		}
+	}
+
+	return 0, false
+}
+
+type interval struct {
+	low, high float64
+	row       int
+}
+
+// rangeIndex sorts intervals by low, maxHigh[i] is the max high of
+// intervals[:i+1], so the search stops once no interval before could
+// contain the value.
+type rangeIndex struct {
+	intervals []interval
+	maxHigh   []float64
+}
+
+func buildRangeIndex(table *referTable, lowCol, highCol int) (*rangeIndex, error) {
+	for _, col := range []int{lowCol, highCol} {
+		switch table.ColumnType[col] {
+		case columnTypeInt, columnTypeFloat:
+		default:
+			return nil, fmt.Errorf("table: %s, range column %s should be int or float",
+				table.TableName, table.ColumnName[col])
+		}
+	}
+
+	idx := &rangeIndex{}
+	for rowIdx, row := range table.RowData {
+		low, high := cast.ToFloat64(row[lowCol]), cast.ToFloat64(row[highCol])
+		if low >= high {
+			l.Debugf("table: %s, row: %d, empty range [%v, %v), ignored", table.TableName, rowIdx, low, high)
+			continue
+		}
+		idx.intervals = append(idx.intervals, interval{low: low, high: high, row: rowIdx})
+	}
+
+	sort.SliceStable(idx.intervals, func(i, j int) bool {
+		return idx.intervals[i].low < idx.intervals[j].low
+	})
+
+	idx.maxHigh = make([]float64, len(idx.intervals))
+	for i, it := range idx.intervals {
+		idx.maxHigh[i] = it.high
+		if i > 0 && idx.maxHigh[i-1] > it.high {
+			idx.maxHigh[i] = idx.maxHigh[i-1]
+		}
+	}
+
+	return idx, nil
+}
+
+func (idx *rangeIndex) match(value any) (int, bool) {
+	var x float64
+	switch v := value.(type) {
+	case int64, float64, int:
+		x = cast.ToFloat64(v)
+	default:
+		return 0, false
+	}
+
+	// intervals[:n] are those with low <= x
+	n := sort.Search(len(idx.intervals), func(i int) bool {
+		return idx.intervals[i].low > x
+	})
+
+	var (
+		found bool
+		best  interval
+	)
+
+	for i := n - 1; i >= 0 && idx.maxHigh[i] > x; i-- {
+		it := idx.intervals[i]
+		if it.high <= x {
+			continue
+		}
+
+		if !found ||
+			it.high-it.low < best.high-best.low ||
+			(it.high-it.low == best.high-best.low && it.row < best.row) {
+			best, found = it, true
+		}
+	}
+
+	return best.row, found
+}
+
+func (plrefer *PlReferTablesInMemory) queryMatch(tableName, mode string, colName []string, value any,
+	kGet []string,
+) (map[string]any, bool) {
+	plrefer.queryRWmutex.RLock()
+	defer plrefer.queryRWmutex.RUnlock()
+
+	table := plrefer.tables[tableName]
+	if table == nil {
+		return nil, false
+	}
+
+	idx, err := table.matchIndex(mode, colName)
+	if err != nil {
+		l.Errorf("build %s index: %v", mode, err)
+		return nil, false
+	}
+
+	rowIdx, ok := idx.match(value)
+	if !ok {
+		return nil, false
+	}
+
+	return table.selectRow(table.RowData[rowIdx], kGet), true
+}
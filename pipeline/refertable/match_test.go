@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package refertable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matchTestTables() []referTable {
+	return []referTable{
+		{
+			TableName:  "sites",
+			ColumnName: []string{"cidr", "site"},
+			ColumnType: []string{"string", "string"},
+			RowData: [][]any{
+				{"10.0.0.0/8", "dc"},
+				{"10.1.0.0/16", "dc-1"},
+				{"10.1.2.0/24", "dc-1-rack-2"},
+				{"10.1.2.3", "gateway"},
+				{"10.2.0.1/16", "non-canonical"},
+				{"2001:db8::/32", "dc-v6"},
+				{"0.0.0.0/0", "internet"},
+				{"invalid", "invalid"},
+			},
+		},
+		{
+			TableName:  "ports",
+			ColumnName: []string{"low", "high", "usage"},
+			ColumnType: []string{"int", "int", "string"},
+			RowData: [][]any{
+				{0, 1024, "system"},
+				{1024, 49152, "registered"},
+				{49152, 65536, "dynamic"},
+				{8000, 9000, "http-alt"},
+				{8080, 8081, "proxy"},
+				{100, 100, "empty"},
+			},
+		},
+		{
+			TableName:  "routes",
+			ColumnName: []string{"prefix", "service"},
+			ColumnType: []string{"string", "string"},
+			RowData: [][]any{
+				{"/api/", "api"},
+				{"/api/v1/users", "users"},
+				{"/", "web"},
+				{"/api/v1/", "api-v1"},
+			},
+		},
+	}
+}
+
+var matchTestCases = []struct {
+	name    string
+	table   string
+	mode    string
+	colName []string
+	value   any
+	kGet    []string
+	want    map[string]any
+}{
+	{"cidr-longest", "sites", MatchCIDR, []string{"cidr"}, "10.1.2.100", []string{"site"}, map[string]any{"site": "dc-1-rack-2"}},
+	{"cidr-host", "sites", MatchCIDR, []string{"cidr"}, "10.1.2.3", []string{"site"}, map[string]any{"site": "gateway"}},
+	{"cidr-16", "sites", MatchCIDR, []string{"cidr"}, "10.1.3.1", []string{"site"}, map[string]any{"site": "dc-1"}},
+	{"cidr-non-canonical", "sites", MatchCIDR, []string{"cidr"}, "10.2.0.1", []string{"site"}, map[string]any{"site": "dc"}},
+	{"cidr-default", "sites", MatchCIDR, []string{"cidr"}, "8.8.8.8", []string{"site"}, map[string]any{"site": "internet"}},
+	{"cidr-v6", "sites", MatchCIDR, []string{"cidr"}, "2001:db8::1", []string{"site"}, map[string]any{"site": "dc-v6"}},
+	{"cidr-v6-miss", "sites", MatchCIDR, []string{"cidr"}, "2002::1", nil, nil},
+	{"cidr-invalid-ip", "sites", MatchCIDR, []string{"cidr"}, "10.1.2", nil, nil},
+	{"cidr-not-string", "sites", MatchCIDR, []string{"cidr"}, int64(1), nil, nil},
+
+	{"range-narrowest", "ports", MatchRange, []string{"low", "high"}, int64(8080), []string{"usage"}, map[string]any{"usage": "proxy"}},
+	{"range-nested", "ports", MatchRange, []string{"low", "high"}, int64(8081), []string{"usage"}, map[string]any{"usage": "http-alt"}},
+	{"range-low-inclusive", "ports", MatchRange, []string{"low", "high"}, int64(1024), []string{"usage"}, map[string]any{"usage": "registered"}},
+	{"range-float", "ports", MatchRange, []string{"low", "high"}, 80.5, []string{"usage"}, map[string]any{"usage": "system"}},
+	{"range-high-exclusive", "ports", MatchRange, []string{"low", "high"}, int64(65536), nil, nil},
+	{"range-not-number", "ports", MatchRange, []string{"low", "high"}, "80", nil, nil},
+
+	{"prefix-longest", "routes", MatchPrefix, []string{"prefix"}, "/api/v1/users/1", []string{"service"}, map[string]any{"service": "users"}},
+	{"prefix-api-v1", "routes", MatchPrefix, []string{"prefix"}, "/api/v1/orders", []string{"service"}, map[string]any{"service": "api-v1"}},
+	{"prefix-root", "routes", MatchPrefix, []string{"prefix"}, "/index.html", []string{"service"}, map[string]any{"service": "web"}},
+	{"prefix-miss", "routes", MatchPrefix, []string{"prefix"}, "index.html", nil, nil},
+
+	{"all-columns", "routes", MatchPrefix, []string{"prefix"}, "/api/v2", nil, map[string]any{"prefix": "/api/", "service": "api"}},
+	{"unknown-table", "unknown", MatchPrefix, []string{"prefix"}, "/", nil, nil},
+	{"unknown-column", "routes", MatchPrefix, []string{"path"}, "/", nil, nil},
+	{"invalid-column-type", "ports", MatchCIDR, []string{"low"}, "10.0.0.1", nil, nil},
+}
+
+func TestPlReferTablesInMemory_queryMatch(t *testing.T) {
+	plReferTable := &PlReferTablesInMemory{}
+	require.NoError(t, plReferTable.updateAll(matchTestTables()))
+
+	for _, tc := range matchTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := plReferTable.queryMatch(tc.table, tc.mode, tc.colName, tc.value, tc.kGet)
+			assert.Equal(t, tc.want != nil, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCheckMatch(t *testing.T) {
+	assert.NoError(t, CheckMatch(MatchCIDR, []string{"cidr"}))
+	assert.NoError(t, CheckMatch(MatchPrefix, []string{"prefix"}))
+	assert.NoError(t, CheckMatch(MatchRange, []string{"low", "high"}))
+
+	assert.Error(t, CheckMatch(MatchCIDR, []string{"a", "b"}))
+	assert.Error(t, CheckMatch(MatchRange, []string{"low"}))
+	assert.Error(t, CheckMatch("regex", []string{"a"}))
+}
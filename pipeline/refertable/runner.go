@@ -34,6 +34,7 @@ var (
 const (
 	SchemeHTTP  = "http"
 	SchemeHTTPS = "https"
+	SchemeFile  = "file"
 )
 
 func QueryReferTable(tableName string, colName []string, colValue []any,
@@ -52,6 +53,29 @@ func QueryReferTable(tableName string, colName []string, colValue []any,
 	return _plReferTables.query(tableName, colName, colValue, selected)
 }
 
+// QueryReferTableMatch queries the row matched by value in mode MatchCIDR,
+// MatchRange or MatchPrefix, see CheckMatch for the columns required.
+func QueryReferTableMatch(tableName, mode string, colName []string, value any,
+	selected []string,
+) (map[string]any, bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			l.Error(fmt.Errorf("run pl: %s", err))
+		}
+	}()
+
+	if _plReferTables == nil {
+		return nil, false
+	}
+
+	if err := CheckMatch(mode, colName); err != nil {
+		l.Error(err)
+		return nil, false
+	}
+
+	return _plReferTables.queryMatch(tableName, mode, colName, value, selected)
+}
+
 func Stats() *ReferTableStats {
 	if _plReferTables == nil {
 		return nil
@@ -92,6 +116,17 @@ func (r *Runner) InitFinished(interval time.Duration) bool {
 	}
 }
 
+// markInitFinished notifies the first update finished.
+func (r *Runner) markInitFinished() {
+	select {
+	case <-r.initFinished:
+	default:
+		if r.initFinished != nil {
+			close(r.initFinished)
+		}
+	}
+}
+
 func InitLog() {
 	l = logger.SLogger("refer-table")
 }
@@ -153,6 +188,10 @@ func initReferTableRunner(runner *Runner, plRefTables PlReferTables, tableURL st
 		runner.g.Go(func(ctx context.Context) error {
 			return httpGetWkr(plRefTables, runner, datakit.Exit.Wait())
 		})
+	case SchemeFile:
+		runner.g.Go(func(ctx context.Context) error {
+			return fileWkr(plRefTables, runner, datakit.Exit.Wait())
+		})
 	}
 
 	return nil
@@ -166,7 +205,7 @@ func checkURL(tableURL string) (string, error) {
 	}
 	scheme := strings.ToLower(u.Scheme)
 	switch scheme {
-	case SchemeHTTP, SchemeHTTPS:
+	case SchemeHTTP, SchemeHTTPS, SchemeFile:
 	default:
 		return "", fmt.Errorf("url: %s, unsupported scheme %s",
 			tableURL, scheme)
@@ -195,13 +234,7 @@ func getAndUpdate(plRefTables PlReferTables, runner *Runner) {
 		}
 	}
 
-	select {
-	case <-runner.initFinished:
-	default:
-		if runner.initFinished != nil {
-			close(runner.initFinished)
-		}
-	}
+	runner.markInitFinished()
 }
 
 func httpGet(cli *retryablehttp.Client, url string) ([]referTable, error) {
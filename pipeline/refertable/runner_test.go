@@ -38,6 +38,10 @@ func TestCheckUrl(t *testing.T) {
 			url:    "http://localhost/aa?a",
 			scheme: "http",
 		},
+		{
+			url:    "file:///usr/local/datakit/data/tables",
+			scheme: "file",
+		},
 		{
 			url:    "oss://localhost/aa?a",
 			scheme: "oss",
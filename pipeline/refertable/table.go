@@ -24,6 +24,7 @@ const (
 
 type PlReferTables interface {
 	query(tableName string, colName []string, colValue []any, kGet []string) (map[string]any, bool)
+	queryMatch(tableName, mode string, colName []string, value any, kGet []string) (map[string]any, bool)
 	updateAll(tables []referTable) (retErr error)
 	stats() *ReferTableStats
 }
@@ -64,6 +65,11 @@ func (plrefer *PlReferTablesInMemory) query(tableName string, colName []string,
 		return nil, false
 	}
 
+	return table.selectRow(row, kGet), true
+}
+
+// selectRow returns columns kGet of row, all columns are returned if kGet is empty.
+func (table *referTable) selectRow(row []any, kGet []string) map[string]any {
 	result := map[string]any{}
 
 	if len(kGet) != 0 {
@@ -80,7 +86,7 @@ func (plrefer *PlReferTablesInMemory) query(tableName string, colName []string,
 		}
 	}
 
-	return result, true
+	return result
 }
 
 func (plrefer *PlReferTablesInMemory) updateAll(tables []referTable) (retErr error) {
@@ -138,6 +144,8 @@ type referTable struct {
 	index map[string]map[any][]int
 
 	colIndex map[string]int
+
+	matchIdx *matchIndexes
 }
 
 func (table *referTable) check() error {
@@ -183,6 +191,7 @@ func (table *referTable) buildTableIndex() error {
 
 	table.index = map[string]map[any][]int{}
 	table.colIndex = map[string]int{}
+	table.matchIdx = &matchIndexes{indexes: map[string]matchIndex{}}
 
 	// 遍历行
 	for rowIdx, row := range table.RowData {
@@ -12,8 +12,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	_ "modernc.org/sqlite"
@@ -24,6 +26,10 @@ var dbPath = filepath.Join(datakit.DataDir, "reftable_sqlite")
 type PlReferTablesSqlite struct {
 	tableNames []string
 	db         *sql.DB
+
+	// indexes created for match queries, they are dropped with tables
+	indexMtx sync.Mutex
+	indexes  map[string]bool
 }
 
 func (p *PlReferTablesSqlite) query(tableName string, colName []string, colValue []any, kGet []string) (map[string]any, bool) {
@@ -130,6 +136,11 @@ func (p *PlReferTablesSqlite) updateAll(tables []referTable) (retErr error) {
 		}
 	}
 
+	// Indexes are dropped with tables.
+	p.indexMtx.Lock()
+	p.indexes = nil
+	p.indexMtx.Unlock()
+
 	// Update table list.
 	p.tableNames = []string{}
 	for _, t := range tables {
@@ -157,6 +168,175 @@ func (p *PlReferTablesSqlite) stats() *ReferTableStats {
 	return &res
 }
 
+func (p *PlReferTablesSqlite) queryMatch(tableName, mode string, colName []string, value any,
+	kGet []string,
+) (map[string]any, bool) {
+	if p.db == nil {
+		return nil, false
+	}
+
+	query, params, ok := buildMatchStmt(tableName, mode, colName, value, kGet)
+	if !ok {
+		return nil, false
+	}
+
+	if err := p.createIndex(tableName, colName); err != nil {
+		l.Errorf("failed to create index: %v", err)
+		return nil, false
+	}
+
+	l.Debugf("got SQL statement '%s' with params %v", query, params)
+
+	result, err := p.db.Query(query, params...)
+	if err != nil {
+		l.Errorf("Query returned: %v", err)
+		return nil, false
+	}
+	defer result.Close() //nolint:errcheck
+
+	cols, err := result.Columns()
+	if err != nil {
+		l.Errorf("failed to get column names: %v", err)
+		return nil, false
+	}
+
+	if !result.Next() {
+		return nil, false
+	}
+
+	its := make([]interface{}, len(cols))
+	itAddrs := make([]interface{}, len(cols))
+	for i := range its {
+		itAddrs[i] = &its[i]
+	}
+	if err := result.Scan(itAddrs...); err != nil {
+		l.Errorf("failed to scan query result: %v", err)
+		return nil, false
+	}
+
+	ret := make(map[string]any, len(cols))
+	for i, col := range cols {
+		ret[col] = its[i]
+	}
+	return ret, true
+}
+
+// createIndex creates index on columns used by match query once.
+func (p *PlReferTablesSqlite) createIndex(tableName string, colName []string) error {
+	name := "idx_" + tableName + "_" + strings.Join(colName, "_")
+
+	p.indexMtx.Lock()
+	defer p.indexMtx.Unlock()
+
+	if p.indexes[name] {
+		return nil
+	}
+
+	stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+		name, tableName, strings.Join(colName, ", "))
+	if _, err := p.db.Exec(stmt); err != nil {
+		return fmt.Errorf("failed to execute '%s': %w", stmt, err)
+	}
+
+	if p.indexes == nil {
+		p.indexes = map[string]bool{}
+	}
+	p.indexes[name] = true
+	return nil
+}
+
+// buildMatchStmt builds the statement of match query, the first row of the
+// result is the best match:
+//   - cidr: the networks containing the IP are enumerated and the longest one is matched.
+//   - prefix: the prefixes of the value are enumerated and the longest one is matched.
+//   - range: the narrowest range containing the value is matched.
+func buildMatchStmt(tableName, mode string, colName []string, value any,
+	kGet []string,
+) (string, []any, bool) {
+	if err := CheckMatch(mode, colName); err != nil {
+		l.Errorf("%v", err)
+		return "", nil, false
+	}
+
+	items := "*"
+	if len(kGet) > 0 {
+		items = strings.Join(kGet, ", ")
+	}
+
+	var (
+		candidates []string
+		col        = colName[0]
+	)
+
+	switch mode {
+	case MatchRange:
+		switch value.(type) {
+		case int64, float64, int:
+		default:
+			return "", nil, false
+		}
+		return fmt.Sprintf("SELECT %s FROM %s WHERE %s <= ? AND %s > ? ORDER BY %s - %s, rowid LIMIT 1",
+			items, tableName, col, colName[1], colName[1], col), []any{value, value}, true
+
+	case MatchPrefix:
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, false
+		}
+		for n := len(s); n >= 0; n-- {
+			candidates = append(candidates, s[:n])
+		}
+
+	case MatchCIDR:
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, false
+		}
+		if candidates = cidrCandidates(s); candidates == nil {
+			return "", nil, false
+		}
+	}
+
+	// candidates are in order of preference
+	var in, orderBy strings.Builder
+	params := make([]any, 0, 2*len(candidates))
+	for i, c := range candidates {
+		if i != 0 {
+			in.WriteString(", ")
+		}
+		in.WriteByte('?')
+		params = append(params, c)
+	}
+	for i, c := range candidates {
+		fmt.Fprintf(&orderBy, " WHEN ? THEN %d", i)
+		params = append(params, c)
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s) ORDER BY CASE %s%s END, rowid LIMIT 1",
+		items, tableName, col, in.String(), col, orderBy.String()), params, true
+}
+
+// cidrCandidates returns networks containing the IP from the longest to the
+// shortest, the IP itself is the first one.
+func cidrCandidates(s string) []string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+
+	total := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, total = ip4, 32
+	}
+
+	candidates := []string{ip.String()}
+	for bits := total; bits >= 0; bits-- {
+		network := ip.Mask(net.CIDRMask(bits, total))
+		candidates = append(candidates, fmt.Sprintf("%s/%d", network, bits))
+	}
+	return candidates
+}
+
 func buildSelectStmt(tableName string, colName []string, kGet []string) string {
 	var query, items string
 
@@ -224,8 +404,122 @@ func ColType2SqliteType(typeName string) string {
 	case columnTypeInt:
 		return "INTEGER"
 	case columnTypeBool:
-		return "NUMERIC"
+		return "BOOLEAN"
 	default:
 		return ""
 	}
 }
+
+// readSQLiteFile reads all tables of the SQLite database file, column types
+// are mapped from the declared types of columns.
+func readSQLiteFile(path string) ([]referTable, error) {
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close() //nolint:errcheck
+
+	names, err := sqliteTableNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []referTable
+	for _, name := range names {
+		table, err := readSQLiteTable(db, name)
+		if err != nil {
+			return nil, fmt.Errorf("table: %s, error: %w", name, err)
+		}
+		tables = append(tables, *table)
+	}
+
+	return tables, nil
+}
+
+func sqliteTableNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func readSQLiteTable(db *sql.DB, name string) (*referTable, error) {
+	table := &referTable{TableName: name}
+
+	colRows, err := db.Query(fmt.Sprintf("SELECT name, type FROM pragma_table_info('%s')",
+		strings.ReplaceAll(name, "'", "''")))
+	if err != nil {
+		return nil, err
+	}
+	for colRows.Next() {
+		var colName, colType string
+		if err := colRows.Scan(&colName, &colType); err != nil {
+			colRows.Close() //nolint:errcheck,gosec
+			return nil, err
+		}
+		table.ColumnName = append(table.ColumnName, colName)
+		table.ColumnType = append(table.ColumnType, SqliteType2ColType(colType))
+	}
+	colRows.Close() //nolint:errcheck,gosec
+
+	rows, err := db.Query(buildSelectStmt(name, nil, table.ColumnName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		row := make([]any, len(table.ColumnName))
+		addrs := make([]any, len(row))
+		for i := range row {
+			addrs[i] = &row[i]
+		}
+		if err := rows.Scan(addrs...); err != nil {
+			return nil, err
+		}
+
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			if row[i], err = convFileValue(v, table.ColumnType[i]); err != nil {
+				return nil, fmt.Errorf("row: %d, col: %d, cast error: %w", len(table.RowData), i, err)
+			}
+		}
+		table.RowData = append(table.RowData, row)
+	}
+
+	return table, rows.Err()
+}
+
+// SqliteType2ColType maps SQLite declared type to column type by the rules
+// of SQLite type affinity, except BOOL(the type of bool column created) is
+// bool, and NUMERIC/DECIMAL is float.
+func SqliteType2ColType(typeName string) string {
+	typeName = strings.ToUpper(typeName)
+	switch {
+	case strings.Contains(typeName, "INT"):
+		return columnTypeInt
+	case strings.Contains(typeName, "BOOL"):
+		return columnTypeBool
+	case strings.Contains(typeName, "REAL"),
+		strings.Contains(typeName, "FLOA"),
+		strings.Contains(typeName, "DOUB"),
+		strings.Contains(typeName, "NUMERIC"),
+		strings.Contains(typeName, "DECIMAL"):
+		return columnTypeFloat
+	default:
+		return columnTypeStr
+	}
+}
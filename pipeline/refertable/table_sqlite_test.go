@@ -10,9 +10,12 @@ package refertable
 
 import (
 	"database/sql"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	_ "modernc.org/sqlite"
 )
 
@@ -41,7 +44,7 @@ func Test_buildCreateTableStmt(t *testing.T) {
 				ColumnName: []string{"c1", "c2"},
 				ColumnType: []string{columnTypeFloat, columnTypeBool},
 			}},
-			want: "CREATE TABLE employee (c1 REAL, c2 NUMERIC)",
+			want: "CREATE TABLE employee (c1 REAL, c2 BOOLEAN)",
 		},
 	}
 	for _, tt := range tests {
@@ -213,3 +216,83 @@ func TestPlReferTablesSqlite_query(t *testing.T) {
 		})
 	}
 }
+
+func TestReadSQLiteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cmdb.db")
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		"CREATE TABLE hosts (ip TEXT, port INTEGER, weight REAL, online BOOL, owner VARCHAR(32), cost DECIMAL(10,2), load NUMERIC)",
+		"INSERT INTO hosts VALUES ('1.1.1.1', 80, 0.5, 1, 'ops', 12.34, 2)",
+		"INSERT INTO hosts VALUES ('2.2.2.2', 8080, NULL, 0, NULL, 0, 0.75)",
+		"CREATE TABLE apps (name TEXT, level BIGINT)",
+		"INSERT INTO apps VALUES ('web', 1)",
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	tables, err := readSQLiteFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []referTable{
+		{
+			TableName:  "apps",
+			ColumnName: []string{"name", "level"},
+			ColumnType: []string{"string", "int"},
+			RowData:    [][]any{{"web", int64(1)}},
+		},
+		{
+			TableName:  "hosts",
+			ColumnName: []string{"ip", "port", "weight", "online", "owner", "cost", "load"},
+			ColumnType: []string{"string", "int", "float", "bool", "string", "float", "float"},
+			RowData: [][]any{
+				{"1.1.1.1", int64(80), 0.5, true, "ops", 12.34, float64(2)},
+				{"2.2.2.2", int64(8080), float64(0), false, "", float64(0), 0.75},
+			},
+		},
+	}, tables)
+
+	// served by SQLite backend
+	memDB, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer memDB.Close() //nolint:errcheck
+
+	plReferTable := &PlReferTablesSqlite{db: memDB}
+	runner := &Runner{initFinished: make(chan struct{})}
+	assert.NotEmpty(t, loadAndUpdate(plReferTable, runner, dir, ""))
+
+	v, ok := plReferTable.query("hosts", []string{"ip"}, []any{"1.1.1.1"}, []string{"port", "owner"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"port": int64(80), "owner": "ops"}, v)
+
+	_, err = readSQLiteFile(filepath.Join(dir, "not-exist.db"))
+	assert.Error(t, err)
+}
+
+func TestPlReferTablesSqlite_queryMatch(t *testing.T) {
+	memDB, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer memDB.Close() //nolint:errcheck
+
+	memDB.SetMaxOpenConns(1)
+
+	p := &PlReferTablesSqlite{db: memDB}
+	require.NoError(t, p.updateAll(matchTestTables()))
+
+	for _, tc := range matchTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := p.queryMatch(tc.table, tc.mode, tc.colName, tc.value, tc.kGet)
+			assert.Equal(t, tc.want != nil, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	// indexes are recreated after tables updated
+	require.NoError(t, p.updateAll(matchTestTables()))
+	got, ok := p.queryMatch("routes", MatchPrefix, []string{"prefix"}, "/api/v1/x", []string{"service"})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]any{"service": "api-v1"}, got)
+}
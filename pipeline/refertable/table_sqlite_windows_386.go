@@ -10,6 +10,7 @@ package refertable
 
 import (
 	"database/sql"
+	"fmt"
 	"path/filepath"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
@@ -28,6 +29,13 @@ func (p *PlReferTablesSqlite) query(tableName string, colName []string, colValue
 	return nil, false
 }
 
+func (p *PlReferTablesSqlite) queryMatch(tableName, mode string, colName []string, value any,
+	kGet []string,
+) (map[string]any, bool) {
+	l.Errorf("windows-386 does not support query using SQLite")
+	return nil, false
+}
+
 func (p *PlReferTablesSqlite) updateAll(tables []referTable) (retErr error) {
 	l.Errorf("windows-386 does not support query using SQLite")
 	return nil
@@ -37,3 +45,7 @@ func (p *PlReferTablesSqlite) stats() *ReferTableStats {
 	l.Errorf("windows-386 does not support query using SQLite")
 	return nil
 }
+
+func readSQLiteFile(path string) ([]referTable, error) {
+	return nil, fmt.Errorf("windows-386 does not support SQLite file %s", path)
+}
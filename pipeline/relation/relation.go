@@ -86,6 +86,15 @@ func (relation *PipelineRelation) UpdateRelation(updateAt int64, rel map[string]
 }
 
 func (relation *PipelineRelation) query(category, source string) (string, bool) {
+	if name, ok := relation.querySource(category, source); ok {
+		return name, true
+	}
+
+	// defaultPl
+	return relation.queryDefault(category)
+}
+
+func (relation *PipelineRelation) querySource(category, source string) (string, bool) {
 	relation.rwMutex.RLock()
 	defer relation.rwMutex.RUnlock()
 
@@ -95,18 +104,32 @@ func (relation *PipelineRelation) query(category, source string) (string, bool)
 		}
 	}
 
-	// defaultPl
-	if v, ok := relation.defaultScript[category]; ok {
-		return v, true
-	}
-
 	return "", false
 }
 
+func (relation *PipelineRelation) queryDefault(category string) (string, bool) {
+	relation.rwMutex.RLock()
+	defer relation.rwMutex.RUnlock()
+
+	v, ok := relation.defaultScript[category]
+	return v, ok
+}
+
 func QueryRemoteRelation(category, source string) (string, bool) {
 	return remoteRelation.query(category, source)
 }
 
+// QueryRemoteSourceRelation queries the script of the source, the default
+// script of the category is not returned.
+func QueryRemoteSourceRelation(category, source string) (string, bool) {
+	return remoteRelation.querySource(category, source)
+}
+
+// QueryRemoteDefaultPl queries the default script of the category.
+func QueryRemoteDefaultPl(category string) (string, bool) {
+	return remoteRelation.queryDefault(category)
+}
+
 func RelationRemoteUpdateAt() int64 {
 	return remoteRelation.UpdateAt()
 }
@@ -14,6 +14,8 @@ import (
 	plruntime "github.com/GuanceCloud/platypus/pkg/engine/runtime"
 
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput/funcs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/stats"
@@ -35,6 +37,12 @@ type PlScript struct {
 
 	proc *plruntime.Script
 
+	// key-value store shared by points of the script
+	kv *plkv.Store
+
+	// aggregator of metrics generated by the script, nil for the default one
+	metric *plmetric.Aggregator
+
 	updateTS int64
 }
 
@@ -69,6 +77,20 @@ func NewScripts(scripts map[string]string, scriptPath map[string]string, ns, cat
 			sPath = scriptPath[name]
 		}
 
+		// scripts without namespace are not loaded into the store, such as
+		// the scripts being debugged, they use stores and aggregators of
+		// their own, metrics of them are never sent.
+		var (
+			kv     *plkv.Store
+			metric *plmetric.Aggregator
+		)
+		if ns != "" {
+			kv = plkv.Acquire(stats.StatsKey(category, ns, name))
+		} else {
+			kv = plkv.NewStore(plkv.DefaultTTL, plkv.DefaultMaxBytes)
+			metric = plmetric.NewAggregator(plmetric.DefaultInterval, plmetric.DefaultMaxSeries)
+		}
+
 		retScipt[name] = &PlScript{
 			script:   scripts[name],
 			name:     name,
@@ -76,6 +98,8 @@ func NewScripts(scripts map[string]string, scriptPath map[string]string, ns, cat
 			ns:       ns,
 			category: category,
 			proc:     ng,
+			kv:       kv,
+			metric:   metric,
 			updateTS: time.Now().UnixNano(),
 		}
 	}
@@ -83,6 +107,13 @@ func NewScripts(scripts map[string]string, scriptPath map[string]string, ns, cat
 	return retScipt, retErr
 }
 
+// release releases the resources of the script once it's unloaded.
+func (script *PlScript) release() {
+	if script.ns != "" {
+		plkv.Release(stats.StatsKey(script.category, script.ns, script.name))
+	}
+}
+
 func (script *PlScript) Engine() *plruntime.Script {
 	return script.proc
 }
@@ -94,6 +125,9 @@ func (script *PlScript) Run(plpt *ptinput.Point, signal plruntime.Signal, opt *O
 		return fmt.Errorf("no script")
 	}
 
+	plpt.KV = script.kv
+	plpt.Metric = script.metric
+
 	err := plengine.RunScriptWithRMapIn(script.proc, plpt, signal)
 	if err != nil {
 		stats.WriteScriptStats(script.category, script.ns, script.name, 1, 0, 1, int64(time.Since(startTime)), err)
@@ -11,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plmetric"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/ptinput"
 )
 
@@ -64,6 +65,26 @@ func TestScript(t *testing.T) {
 	}
 }
 
+func TestScriptMetric(t *testing.T) {
+	pl := map[string]string{"metric.p": `metric_counter("nginx_log", "errors")`}
+	plmetric.Points(time.Now())
+
+	// scripts being debugged
+	ret, retErr := NewScripts(pl, nil, "", datakit.Logging)
+	assert.Empty(t, retErr)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, ret["metric.p"].Run(ptinput.InitPt(&ptinput.Point{}, "d", nil, nil, time.Now()), nil, nil))
+	}
+	assert.Empty(t, plmetric.Points(time.Now()))
+
+	ret, retErr = NewScripts(pl, nil, DefaultScriptNS, datakit.Logging)
+	assert.Empty(t, retErr)
+	assert.NoError(t, ret["metric.p"].Run(ptinput.InitPt(&ptinput.Point{}, "d", nil, nil, time.Now()), nil, nil))
+	pts := plmetric.Points(time.Unix(0, 123))
+	assert.Len(t, pts, 1)
+	assert.Equal(t, "nginx_log errors=1 123", pts[0].String())
+}
+
 func TestNewScript(t *testing.T) {
 	for category := range datakit.CategoryDirName() {
 		if ret, retErr := NewScripts(map[string]string{"abc": "if true{}"}, nil, DefaultScriptNS, category); len(retErr) > 0 {
@@ -276,6 +276,18 @@ func (store *ScriptStore) UpdateScriptsWithNS(ns string, namedScript map[string]
 		store.storage.scripts[ns] = map[string]*PlScript{}
 	}
 
+	// the scripts replaced or deleted are released after the new ones loaded,
+	// so states of the scripts reloaded are kept.
+	oldScripts := make([]*PlScript, 0, len(store.storage.scripts[ns]))
+	for _, script := range store.storage.scripts[ns] {
+		oldScripts = append(oldScripts, script)
+	}
+	defer func() {
+		for _, script := range oldScripts {
+			script.release()
+		}
+	}()
+
 	retScripts, retErr := NewScripts(namedScript, scriptPath, ns, store.category)
 
 	for name, err := range retErr {
@@ -14,6 +14,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/stats"
 )
 
 func TestScriptLoadFunc(t *testing.T) {
@@ -340,3 +342,26 @@ func TestPlDirStruct(t *testing.T) {
 
 	assert.Equal(t, expt, act)
 }
+
+func TestPlScriptStoreKV(t *testing.T) {
+	store := NewScriptStore(datakit.Logging)
+	key := stats.StatsKey(datakit.Logging, DefaultScriptNS, "kv.p")
+
+	assert.Nil(t, store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"kv.p": "add_key(a, 1)"}, nil))
+	s, ok := store.Get("kv.p")
+	assert.True(t, ok)
+	s.kv.Set("k", "v", 0)
+
+	// states are kept after reloaded
+	assert.Nil(t, store.UpdateScriptsWithNS(DefaultScriptNS, map[string]string{"kv.p": "add_key(a, 2)"}, nil))
+	s, ok = store.Get("kv.p")
+	assert.True(t, ok)
+	v, ok := s.kv.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", v)
+
+	// released after deleted
+	assert.Nil(t, store.UpdateScriptsWithNS(DefaultScriptNS, nil, nil))
+	_, ok = plkv.GetStats(key)
+	assert.False(t, ok)
+}
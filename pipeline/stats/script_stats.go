@@ -10,6 +10,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/plkv"
 )
 
 type ScriptMeta struct {
@@ -61,6 +63,9 @@ type ScriptStatsROnly struct {
 	Enable       bool
 	Deleted      bool
 	CompileError string
+
+	// KV is the stats of the key-value store of the script
+	KV plkv.Stats
 }
 
 func (statsR ScriptStatsROnly) String() string {
@@ -122,6 +127,8 @@ func (stats *ScriptStats) Read() *ScriptStatsROnly {
 
 	ret.MetaTS = stats.meta.metaUpdateTS
 
+	ret.KV, _ = plkv.GetStats(StatsKey(ret.Category, ret.NS, ret.Name))
+
 	stats.lastRunErr.RLock()
 	defer stats.lastRunErr.RUnlock()
 	last100 := []string{}
@@ -153,7 +153,7 @@ func addInputs(confdInputs map[string][]*ConfdInfo, handles []handle, errs *[]er
 
 		// Append all confd data
 		for i := 0; i < len(confdInputs[h.name]); i++ {
-			newInput := &inputInfo{confdInputs[h.name][i].Input}
+			newInput := &inputInfo{input: confdInputs[h.name][i].Input, name: h.name}
 
 			if inp, ok := newInput.input.(HTTPInput); ok {
 				inp.RegHTTPHandler()
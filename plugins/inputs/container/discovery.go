@@ -15,8 +15,10 @@ import (
 	"sync"
 	"time"
 
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/config"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/prom"
 	"golang.org/x/exp/slices"
@@ -369,6 +371,7 @@ func (d *discovery) fetchPromInputsForPodMonitors() []*discoveryRunner {
 				}
 				promInput.Tags["namespace"] = pod.Namespace
 				promInput.Tags["service"] = pod.Name
+				promInput.MetricRelabelConfigs = toRelabelConfigs(metricsEndpoints.MetricRelabelConfigs)
 
 				if d.prometheusMonitoringExtraConfig != nil {
 					l.Debugf("autodiscovery: matching promConfig %#v", d.prometheusMonitoringExtraConfig)
@@ -468,6 +471,7 @@ func (d *discovery) fetchPromInputsForServiceMonitors() []*discoveryRunner {
 				}
 				promInput.Tags["namespace"] = service.Namespace
 				promInput.Tags["service"] = service.Name
+				promInput.MetricRelabelConfigs = toRelabelConfigs(endpoint.MetricRelabelConfigs)
 
 				if d.prometheusMonitoringExtraConfig != nil {
 					l.Debugf("autodiscovery: matching promConfig %#v", d.prometheusMonitoringExtraConfig)
@@ -782,6 +786,35 @@ func (p *prometheusMonitoringExtraConfig) matchPromConfig(targetLabels map[strin
 	return nil
 }
 
+// toRelabelConfigs converts metricRelabelings of PodMonitor/ServiceMonitor,
+// empty separator and replacement are treated as the defaults.
+func toRelabelConfigs(cfgs []*monitoringv1.RelabelConfig) iprom.RelabelConfigs {
+	var res iprom.RelabelConfigs
+	for _, c := range cfgs {
+		if c == nil {
+			continue
+		}
+
+		rc := &iprom.RelabelConfig{
+			SourceLabels: c.SourceLabels,
+			Regex:        c.Regex,
+			Modulus:      c.Modulus,
+			TargetLabel:  c.TargetLabel,
+			Action:       c.Action,
+		}
+		if c.Separator != "" {
+			sep := c.Separator
+			rc.Separator = &sep
+		}
+		if c.Replacement != "" {
+			replacement := c.Replacement
+			rc.Replacement = &replacement
+		}
+		res = append(res, rc)
+	}
+	return res
+}
+
 func mergePromConfig(c1 *prom.Input, c2 *promConfig) *prom.Input {
 	c3 := &prom.Input{}
 
@@ -806,6 +839,11 @@ func mergePromConfig(c1 *prom.Input, c2 *promConfig) *prom.Input {
 	c3.IgnoreTagKV = c2.IgnoreTagKV
 	c3.HTTPHeaders = c2.HTTPHeaders
 	c3.Auth = c2.Auth
+	c3.ScrapeProtocols = c2.ScrapeProtocols
+
+	// rules of the monitor go first
+	c3.MetricRelabelConfigs = append(append(iprom.RelabelConfigs{},
+		c1.MetricRelabelConfigs...), c2.MetricRelabelConfigs...)
 
 	if len(c2.MetricTypes) != 0 {
 		c3.MetricTypes = c2.MetricTypes
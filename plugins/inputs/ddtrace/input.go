@@ -68,6 +68,31 @@ const (
   # [inputs.ddtrace.sampler]
     # sampling_rate = 1.0
 
+  ## Tail sampler config buffers spans of the same trace for a decision window, then keeps or
+  ## drops the whole trace. If configured, error penetration, rare resource keeper and sampler
+  ## above will be replaced by the policies here.
+  ## decision_wait is the time window to wait for spans of a trace.
+  ## max_traces is the max number of buffered traces, the oldest one is decided in advance if exceeded.
+  ## keep_error keeps traces which contain error spans.
+  ## latency_threshold keeps traces whose duration exceed the threshold.
+  ## sampling_rate is the sampling rate of traces not hit by the policies above, default 1.0(all kept).
+  ## keep_resource keeps traces which contain resources matching the regular expressions, like
+  ## service:[resources...], service "*" stands for all services.
+  ## service_rate_limit is the max traces per second kept for service of the root span,
+  ## service "*" stands for every service.
+  # [inputs.ddtrace.tail_sampler]
+    # decision_wait = "10s"
+    # max_traces = 50000
+    # keep_error = true
+    # latency_threshold = "3s"
+    # sampling_rate = 0.1
+    # [inputs.ddtrace.tail_sampler.keep_resource]
+      # service1 = ["resource1", "resource2", ...]
+      # "*" = ["keep_resource_under_all_services"]
+    # [inputs.ddtrace.tail_sampler.service_rate_limit]
+      # service1 = 10.0
+      # "*" = 100.0
+
   # [inputs.ddtrace.tags]
     # key1 = "value1"
     # key2 = "value2"
@@ -112,6 +137,7 @@ type Input struct {
 	OmitErrStatus    []string                     `toml:"omit_err_status"`
 	CloseResource    map[string][]string          `toml:"close_resource"`
 	Sampler          *itrace.Sampler              `toml:"sampler"`
+	TailSampler      *itrace.TailSampler          `toml:"tail_sampler"`
 	Tags             map[string]string            `toml:"tags"`
 	WPConfig         *workerpool.WorkerPoolConfig `toml:"threads"`
 	LocalCacheConfig *storage.StorageConfig       `toml:"storage"`
@@ -198,6 +224,14 @@ func (ipt *Input) RegHTTPHandler() {
 	}
 	// add RespectUserRule filter to obey client priority rules.
 	afterGather.AppendFilter(itrace.RespectUserRule)
+	// add tail sampler, it holds traces and skips all filters afterwards
+	if ipt.TailSampler != nil {
+		if err := ipt.TailSampler.Start(inputName, afterGather); err != nil {
+			log.Errorf("### start tail sampler failed: %s", err.Error())
+		} else {
+			afterGather.AppendFilter(ipt.TailSampler.Sample)
+		}
+	}
 	// add error status penetration
 	afterGather.AppendFilter(itrace.PenetrateErrorTracing)
 	// add omit certain error status list
@@ -270,7 +304,7 @@ func (ipt *Input) Run() {
 	ipt.Terminate()
 }
 
-func (*Input) Terminate() {
+func (ipt *Input) Terminate() {
 	if wkpool != nil {
 		wkpool.Shutdown()
 		log.Debug("### workerpool closed")
@@ -281,6 +315,10 @@ func (*Input) Terminate() {
 		}
 		log.Debug("### local storage closed")
 	}
+	if ipt.TailSampler != nil {
+		ipt.TailSampler.Stop()
+		log.Debug("### tail sampler stopped")
+	}
 }
 
 func init() { //nolint:gochecknoinits
@@ -136,7 +136,7 @@ func (d *dialer) feedIO() error {
 
 	urlStr := u.String()
 	switch d.task.Class() {
-	case dt.ClassHTTP, dt.ClassTCP, dt.ClassICMP, dt.ClassWebsocket:
+	case dt.ClassHTTP, dt.ClassTCP, dt.ClassICMP, dt.ClassWebsocket, dt.ClassDNS:
 		d.category = urlStr
 		return d.pointsFeed(urlStr)
 	case dt.ClassHeadless:
@@ -0,0 +1,709 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dialtesting
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	dt "github.com/GuanceCloud/cliutils/dialtesting"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	defaultDNSPort    = "53"
+	maxDNSMessageSize = 65535
+)
+
+var (
+	_ dt.Task = (*DNSTask)(nil)
+
+	dnsQueryTypes = map[string]dnsmessage.Type{
+		"A":     dnsmessage.TypeA,
+		"AAAA":  dnsmessage.TypeAAAA,
+		"CNAME": dnsmessage.TypeCNAME,
+		"MX":    dnsmessage.TypeMX,
+		"TXT":   dnsmessage.TypeTXT,
+	}
+
+	dnsRCodes = map[dnsmessage.RCode]string{
+		dnsmessage.RCodeSuccess:        "NOERROR",
+		dnsmessage.RCodeFormatError:    "FORMERR",
+		dnsmessage.RCodeServerFailure:  "SERVFAIL",
+		dnsmessage.RCodeNameError:      "NXDOMAIN",
+		dnsmessage.RCodeNotImplemented: "NOTIMP",
+		dnsmessage.RCodeRefused:        "REFUSED",
+	}
+)
+
+// DNSSuccessOption used to check string values(rcode/answer), the JSON
+// keys are the same as dt.SuccessOption.
+type DNSSuccessOption struct {
+	Is    string `json:"is,omitempty"`
+	IsNot string `json:"is_not,omitempty"`
+
+	MatchRegex          string `json:"match_regex,omitempty"`
+	NotMatchRegex       string `json:"not_match_regex,omitempty"`
+	matchRe, notMatchRe *regexp.Regexp
+
+	Contains    string `json:"contains,omitempty"`
+	NotContains string `json:"not_contains,omitempty"`
+}
+
+func (s *DNSSuccessOption) init() (err error) {
+	if s.MatchRegex != "" {
+		if s.matchRe, err = regexp.Compile(s.MatchRegex); err != nil {
+			return err
+		}
+	}
+
+	if s.NotMatchRegex != "" {
+		if s.notMatchRe, err = regexp.Compile(s.NotMatchRegex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *DNSSuccessOption) check(val string) error {
+	switch {
+	case s.Is != "" && s.Is != val:
+		return fmt.Errorf("expect to be `%s', got `%s'", s.Is, val)
+	case s.IsNot != "" && s.IsNot == val:
+		return fmt.Errorf("should not be `%s'", s.IsNot)
+	case s.matchRe != nil && !s.matchRe.MatchString(val):
+		return fmt.Errorf("regex `%s' match `%s' failed", s.MatchRegex, val)
+	case s.notMatchRe != nil && s.notMatchRe.MatchString(val):
+		return fmt.Errorf("regex `%s' should not match `%s'", s.NotMatchRegex, val)
+	case s.Contains != "" && !strings.Contains(val, s.Contains):
+		return fmt.Errorf("do not contains `%s', got `%s'", s.Contains, val)
+	case s.NotContains != "" && strings.Contains(val, s.NotContains):
+		return fmt.Errorf("should not contains `%s', got `%s'", s.NotContains, val)
+	}
+
+	return nil
+}
+
+// checkAny check if any of the values pass the option.
+func (s *DNSSuccessOption) checkAny(vals []string) error {
+	if len(vals) == 0 {
+		return fmt.Errorf("no answer")
+	}
+
+	var err error
+	for _, v := range vals {
+		if err = s.check(v); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+type DNSValueSuccess struct {
+	Op     string  `json:"op,omitempty"`
+	Target float64 `json:"target,omitempty"`
+}
+
+func (v *DNSValueSuccess) check(val float64) error {
+	switch v.Op {
+	case "eq":
+		if val != v.Target {
+			return fmt.Errorf("%v is not equal to target %v", val, v.Target)
+		}
+	case "lt":
+		if val >= v.Target {
+			return fmt.Errorf("%v is greater equal than target %v", val, v.Target)
+		}
+	case "leq":
+		if val > v.Target {
+			return fmt.Errorf("%v is greater than target %v", val, v.Target)
+		}
+	case "gt":
+		if val <= v.Target {
+			return fmt.Errorf("%v is less equal than target %v", val, v.Target)
+		}
+	case "geq":
+		if val < v.Target {
+			return fmt.Errorf("%v is less than target %v", val, v.Target)
+		}
+	}
+
+	return nil
+}
+
+type DNSResponseTime struct {
+	Target string `json:"target"`
+
+	targetTime time.Duration
+}
+
+type DNSSuccess struct {
+	ResponseTime []*DNSResponseTime  `json:"response_time,omitempty"`
+	RCode        []*DNSSuccessOption `json:"rcode,omitempty"`
+	Answer       []*DNSSuccessOption `json:"answer,omitempty"`
+	AnswerCount  []*DNSValueSuccess  `json:"answer_count,omitempty"`
+}
+
+// dnsResult is the query result on a single nameserver.
+type dnsResult struct {
+	nameserver string
+	rcode      string
+	answers    []string
+	cost       time.Duration
+	reqError   string
+}
+
+// DNSTask query the domain on each nameserver, and check the answers.
+type DNSTask struct {
+	Domain           string            `json:"domain"`
+	QueryType        string            `json:"query_type"`
+	Nameservers      []string          `json:"nameservers"`
+	Timeout          string            `json:"timeout"`
+	SuccessWhen      []*DNSSuccess     `json:"success_when"`
+	SuccessWhenLogic string            `json:"success_when_logic"`
+	ExternalID       string            `json:"external_id"`
+	Name             string            `json:"name"`
+	AK               string            `json:"access_key"`
+	PostURL          string            `json:"post_url"`
+	CurStatus        string            `json:"status"`
+	Frequency        string            `json:"frequency"`
+	Region           string            `json:"region"`
+	OwnerExternalID  string            `json:"owner_external_id"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	Labels           []string          `json:"labels,omitempty"`
+	UpdateTime       int64             `json:"update_time,omitempty"`
+
+	qtype   dnsmessage.Type
+	timeout time.Duration
+	ticker  *time.Ticker
+	results []*dnsResult
+}
+
+func (t *DNSTask) InitDebug() error {
+	return t.init(true)
+}
+
+func (t *DNSTask) Init() error {
+	return t.init(false)
+}
+
+func (t *DNSTask) init(debug bool) error {
+	if len(t.Timeout) == 0 {
+		t.timeout = 10 * time.Second
+	} else {
+		if timeout, err := time.ParseDuration(t.Timeout); err != nil {
+			return err
+		} else {
+			t.timeout = timeout
+		}
+	}
+
+	if !debug {
+		du, err := time.ParseDuration(t.Frequency)
+		if err != nil {
+			return err
+		}
+		if t.ticker != nil {
+			t.ticker.Stop()
+		}
+		t.ticker = time.NewTicker(du)
+	}
+
+	if strings.EqualFold(t.CurStatus, dt.StatusStop) {
+		return nil
+	}
+
+	if t.QueryType == "" {
+		t.QueryType = "A"
+	}
+	t.QueryType = strings.ToUpper(t.QueryType)
+
+	qtype, ok := dnsQueryTypes[t.QueryType]
+	if !ok {
+		return fmt.Errorf("unsupported query type: %s", t.QueryType)
+	}
+	t.qtype = qtype
+
+	if len(t.Nameservers) == 0 {
+		return fmt.Errorf("nameservers should not be empty")
+	}
+
+	for i, ns := range t.Nameservers {
+		if _, _, err := net.SplitHostPort(ns); err != nil {
+			t.Nameservers[i] = net.JoinHostPort(ns, defaultDNSPort)
+		}
+	}
+
+	if len(t.SuccessWhen) == 0 {
+		return fmt.Errorf(`no any check rule`)
+	}
+
+	for _, checker := range t.SuccessWhen {
+		for _, v := range checker.ResponseTime {
+			du, err := time.ParseDuration(v.Target)
+			if err != nil {
+				return err
+			}
+			v.targetTime = du
+		}
+
+		for _, v := range checker.RCode {
+			if err := v.init(); err != nil {
+				return err
+			}
+		}
+
+		for _, v := range checker.Answer {
+			if err := v.init(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *DNSTask) Check() error {
+	if t.ExternalID == "" {
+		return fmt.Errorf("external ID missing")
+	}
+
+	if len(t.Domain) == 0 {
+		return fmt.Errorf("domain should not be empty")
+	}
+
+	return t.Init()
+}
+
+// checkResult check the result on a single nameserver.
+func (t *DNSTask) checkResult(r *dnsResult) (reasons []string, succFlag bool) {
+	rcodeChecked := false
+
+	for _, chk := range t.SuccessWhen {
+		for _, v := range chk.ResponseTime {
+			if r.cost >= v.targetTime {
+				reasons = append(reasons,
+					fmt.Sprintf("DNS response time(%v) larger equal than %v", r.cost, v.targetTime))
+			} else if v.targetTime > 0 {
+				succFlag = true
+			}
+		}
+
+		for _, v := range chk.RCode {
+			rcodeChecked = true
+			if err := v.check(r.rcode); err != nil {
+				reasons = append(reasons, fmt.Sprintf("DNS rcode: %s", err.Error()))
+			} else {
+				succFlag = true
+			}
+		}
+
+		for _, v := range chk.Answer {
+			if err := v.checkAny(r.answers); err != nil {
+				reasons = append(reasons, fmt.Sprintf("DNS answer: %s", err.Error()))
+			} else {
+				succFlag = true
+			}
+		}
+
+		for _, v := range chk.AnswerCount {
+			if err := v.check(float64(len(r.answers))); err != nil {
+				reasons = append(reasons, fmt.Sprintf("DNS answer count: %s", err.Error()))
+			} else {
+				succFlag = true
+			}
+		}
+	}
+
+	// without any rcode rule, all rcode other than NOERROR(such as NXDOMAIN/SERVFAIL)
+	// are treated as failure
+	if !rcodeChecked && r.reqError == "" && r.rcode != dnsRCodes[dnsmessage.RCodeSuccess] {
+		reasons = append(reasons, fmt.Sprintf("DNS rcode: %s", r.rcode))
+	}
+
+	return reasons, succFlag
+}
+
+func (t *DNSTask) resultOK(r *dnsResult) (bool, []string) {
+	reasons, succFlag := t.checkResult(r)
+	if r.reqError != "" {
+		reasons = append(reasons, r.reqError)
+	}
+
+	switch t.SuccessWhenLogic {
+	case "or":
+		return succFlag && r.reqError == "", reasons
+	default:
+		return r.reqError == "" && len(reasons) == 0, reasons
+	}
+}
+
+// CheckResult check results on all nameservers, any failure on nameserver
+// will fail the task.
+func (t *DNSTask) CheckResult() (reasons []string, succFlag bool) {
+	succFlag = len(t.results) > 0
+	for _, r := range t.results {
+		ok, rs := t.resultOK(r)
+		if !ok {
+			succFlag = false
+		}
+		for _, reason := range rs {
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", r.nameserver, reason))
+		}
+	}
+
+	return reasons, succFlag
+}
+
+func (t *DNSTask) baseTags() map[string]string {
+	tags := map[string]string{
+		"name":       t.Name,
+		"domain":     t.Domain,
+		"query_type": t.QueryType,
+		"status":     "FAIL",
+		"proto":      "dns",
+	}
+
+	for k, v := range t.Tags {
+		tags[k] = v
+	}
+
+	return tags
+}
+
+func buildDNSResultFields(fields map[string]interface{}, message map[string]interface{}, reasons []string, ok bool) {
+	if ok {
+		fields["success"] = int64(1)
+	} else if len(reasons) != 0 {
+		message[`fail_reason`] = strings.Join(reasons, `;`)
+		fields[`fail_reason`] = strings.Join(reasons, `;`)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		fields[`message`] = err.Error()
+		return
+	}
+
+	if len(data) > dt.MaxMsgSize {
+		fields[`message`] = string(data[:dt.MaxMsgSize])
+	} else {
+		fields[`message`] = string(data)
+	}
+}
+
+// GetResults return the overall result of all nameservers. The response
+// time is the max response time among them.
+func (t *DNSTask) GetResults() (tags map[string]string, fields map[string]interface{}) {
+	tags = t.baseTags()
+
+	var maxCost time.Duration
+	nameservers := []string{}
+	results := map[string]interface{}{}
+	for _, r := range t.results {
+		if r.cost > maxCost {
+			maxCost = r.cost
+		}
+		nameservers = append(nameservers, r.nameserver)
+		results[r.nameserver] = map[string]interface{}{
+			"rcode":         r.rcode,
+			"answers":       r.answers,
+			"response_time": int64(r.cost) / 1000, // us
+		}
+	}
+	tags["nameserver"] = strings.Join(nameservers, ",")
+
+	responseTime := int64(maxCost) / 1000 // us
+	fields = map[string]interface{}{
+		"response_time": responseTime,
+		"success":       int64(-1),
+	}
+
+	message := map[string]interface{}{
+		"response_time": responseTime,
+		"results":       results,
+	}
+
+	reasons, succFlag := t.CheckResult()
+	if succFlag {
+		tags["status"] = "OK"
+	}
+
+	buildDNSResultFields(fields, message, reasons, succFlag)
+
+	return tags, fields
+}
+
+// GetNameserverResults return result for each nameserver.
+func (t *DNSTask) GetNameserverResults() (res []*taskResult) {
+	for _, r := range t.results {
+		tags := t.baseTags()
+		tags["nameserver"] = r.nameserver
+		tags["rcode"] = r.rcode
+
+		responseTime := int64(r.cost) / 1000 // us
+		fields := map[string]interface{}{
+			"response_time": responseTime,
+			"answer_count":  int64(len(r.answers)),
+			"answers":       strings.Join(r.answers, ";"),
+			"success":       int64(-1),
+		}
+
+		message := map[string]interface{}{
+			"response_time": responseTime,
+			"answers":       r.answers,
+		}
+
+		ok, reasons := t.resultOK(r)
+		if ok {
+			tags["status"] = "OK"
+		}
+
+		buildDNSResultFields(fields, message, reasons, ok)
+
+		res = append(res, &taskResult{tags: tags, fields: fields})
+	}
+
+	return res
+}
+
+func (t *DNSTask) MetricName() string {
+	return `dns_dial_testing`
+}
+
+func (t *DNSTask) Clear() {
+	t.results = nil
+}
+
+func (t *DNSTask) Run() error {
+	t.Clear()
+
+	for _, ns := range t.Nameservers {
+		t.results = append(t.results, t.query(ns))
+	}
+
+	return nil
+}
+
+func (t *DNSTask) query(ns string) *dnsResult {
+	r := &dnsResult{nameserver: ns}
+
+	name, err := dnsmessage.NewName(dnsFQDN(t.Domain))
+	if err != nil {
+		r.reqError = err.Error()
+		return r
+	}
+
+	id := uint16(rand.Intn(1 << 16)) //nolint:gosec
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		r.reqError = err.Error()
+		return r
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: t.qtype, Class: dnsmessage.ClassINET}); err != nil {
+		r.reqError = err.Error()
+		return r
+	}
+	req, err := b.Finish()
+	if err != nil {
+		r.reqError = err.Error()
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := dnsExchange(ctx, "udp", ns, req)
+	if err == nil && resp.Header.Truncated { // retry with TCP on truncated response
+		resp, err = dnsExchange(ctx, "tcp", ns, req)
+	}
+	r.cost = time.Since(start)
+
+	if err != nil {
+		r.reqError = err.Error()
+		return r
+	}
+
+	if resp.Header.ID != id {
+		r.reqError = fmt.Sprintf("DNS response ID mismatch: expect %d, got %d", id, resp.Header.ID)
+		return r
+	}
+
+	if rcode, ok := dnsRCodes[resp.Header.RCode]; ok {
+		r.rcode = rcode
+	} else {
+		r.rcode = resp.Header.RCode.String()
+	}
+
+	for _, ans := range resp.Answers {
+		if v := dnsResourceString(&ans); v != "" {
+			r.answers = append(r.answers, v)
+		}
+	}
+
+	return r
+}
+
+func dnsExchange(ctx context.Context, network, ns string, req []byte) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, ns)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf []byte
+	if network == "tcp" {
+		// DNS over TCP prefix the message with 2-byte length
+		msg := make([]byte, 2+len(req))
+		binary.BigEndian.PutUint16(msg, uint16(len(req)))
+		copy(msg[2:], req)
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+
+		var l [2]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(l[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		buf = make([]byte, maxDNSMessageSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func dnsFQDN(domain string) string {
+	if strings.HasSuffix(domain, ".") {
+		return domain
+	}
+	return domain + "."
+}
+
+func dnsResourceString(r *dnsmessage.Resource) string {
+	switch b := r.Body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(b.CNAME.String(), ".")
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, strings.TrimSuffix(b.MX.String(), "."))
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, "")
+	default:
+		return ""
+	}
+}
+
+func (t *DNSTask) Stop() error {
+	return nil
+}
+
+func (t *DNSTask) UpdateTimeUs() int64 {
+	return t.UpdateTime
+}
+
+func (t *DNSTask) ID() string {
+	if t.ExternalID == `` {
+		return cliutils.XID("dtst_")
+	}
+	return fmt.Sprintf("%s_%s", t.AK, t.ExternalID)
+}
+
+func (t *DNSTask) GetOwnerExternalID() string {
+	return t.OwnerExternalID
+}
+
+func (t *DNSTask) SetOwnerExternalID(exid string) {
+	t.OwnerExternalID = exid
+}
+
+func (t *DNSTask) SetRegionID(regionID string) {
+	t.Region = regionID
+}
+
+func (t *DNSTask) SetAk(ak string) {
+	t.AK = ak
+}
+
+func (t *DNSTask) SetStatus(status string) {
+	t.CurStatus = status
+}
+
+func (t *DNSTask) SetUpdateTime(ts int64) {
+	t.UpdateTime = ts
+}
+
+func (t *DNSTask) Status() string {
+	return t.CurStatus
+}
+
+func (t *DNSTask) Ticker() *time.Ticker {
+	return t.ticker
+}
+
+func (t *DNSTask) Class() string {
+	return dt.ClassDNS
+}
+
+func (t *DNSTask) GetFrequency() string {
+	return t.Frequency
+}
+
+func (t *DNSTask) GetLineData() string {
+	return ""
+}
+
+func (t *DNSTask) RegionName() string {
+	return t.Region
+}
+
+func (t *DNSTask) PostURLStr() string {
+	return t.PostURL
+}
+
+func (t *DNSTask) AccessKey() string {
+	return t.AK
+}
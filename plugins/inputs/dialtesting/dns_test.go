@@ -0,0 +1,189 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package dialtesting
+
+import (
+	"net"
+	"testing"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// runMockDNSServer start a UDP DNS server that answer A record of
+// ok.example.com, and NXDOMAIN for any other domain.
+func runMockDNSServer(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) != 1 {
+				continue
+			}
+
+			q := req.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.Header.ID, Response: true},
+				Questions: req.Questions,
+			}
+
+			if q.Name.String() == "ok.example.com." && q.Type == dnsmessage.TypeA {
+				resp.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: [4]byte{1, 2, 3, 4}},
+				}}
+			} else {
+				resp.Header.RCode = dnsmessage.RCodeNameError
+			}
+
+			data, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(data, addr) //nolint:errcheck,gosec
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSTask(t *testing.T) {
+	ns := runMockDNSServer(t)
+
+	cases := []struct {
+		name     string
+		t        *DNSTask
+		initFail bool
+		status   string
+		rcode    string
+		answers  string
+	}{
+		{
+			name: "ok",
+			t: &DNSTask{
+				Domain:      "ok.example.com",
+				Nameservers: []string{ns},
+				SuccessWhen: []*DNSSuccess{
+					{
+						ResponseTime: []*DNSResponseTime{{Target: "1s"}},
+						Answer:       []*DNSSuccessOption{{Is: "1.2.3.4"}},
+						AnswerCount:  []*DNSValueSuccess{{Op: "eq", Target: 1}},
+					},
+				},
+			},
+			status:  "OK",
+			rcode:   "NOERROR",
+			answers: "1.2.3.4",
+		},
+
+		{
+			name: "answer-mismatch",
+			t: &DNSTask{
+				Domain:      "ok.example.com",
+				Nameservers: []string{ns},
+				SuccessWhen: []*DNSSuccess{
+					{Answer: []*DNSSuccessOption{{MatchRegex: `^10\.`}}},
+				},
+			},
+			status:  "FAIL",
+			rcode:   "NOERROR",
+			answers: "1.2.3.4",
+		},
+
+		{
+			name: "nxdomain-as-failure",
+			t: &DNSTask{
+				Domain:      "nx.example.com",
+				Nameservers: []string{ns},
+				SuccessWhen: []*DNSSuccess{
+					{ResponseTime: []*DNSResponseTime{{Target: "1s"}}},
+				},
+			},
+			status: "FAIL",
+			rcode:  "NXDOMAIN",
+		},
+
+		{
+			name: "nxdomain-expected",
+			t: &DNSTask{
+				Domain:      "nx.example.com",
+				Nameservers: []string{ns},
+				SuccessWhen: []*DNSSuccess{
+					{RCode: []*DNSSuccessOption{{Is: "NXDOMAIN"}}},
+				},
+			},
+			status: "OK",
+			rcode:  "NXDOMAIN",
+		},
+
+		{
+			name: "invalid-query-type",
+			t: &DNSTask{
+				Domain:      "ok.example.com",
+				QueryType:   "PTR",
+				Nameservers: []string{ns},
+				SuccessWhen: []*DNSSuccess{{}},
+			},
+			initFail: true,
+		},
+
+		{
+			name: "no-nameserver",
+			t: &DNSTask{
+				Domain:      "ok.example.com",
+				SuccessWhen: []*DNSSuccess{{}},
+			},
+			initFail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.t.ExternalID = cliutils.XID("dtst_")
+			tc.t.Timeout = "1s"
+
+			err := tc.t.InitDebug()
+			if tc.initFail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NoError(t, tc.t.Run())
+
+			results := tc.t.GetNameserverResults()
+			require.Len(t, results, 1)
+
+			r := results[0]
+			assert.Equal(t, ns, r.tags["nameserver"])
+			assert.Equal(t, tc.status, r.tags["status"])
+			assert.Equal(t, tc.rcode, r.tags["rcode"])
+			assert.Equal(t, tc.answers, r.fields["answers"])
+
+			tags, fields := tc.t.GetResults()
+			assert.Equal(t, tc.status, tags["status"])
+			if tc.status == "OK" {
+				assert.Equal(t, int64(1), fields["success"])
+			} else {
+				assert.Equal(t, int64(-1), fields["success"])
+				assert.NotEmpty(t, fields["fail_reason"])
+			}
+		})
+	}
+}
@@ -117,6 +117,7 @@ func (*Input) SampleMeasurement() []inputs.Measurement {
 		&tcpMeasurement{},
 		&icmpMeasurement{},
 		&websocketMeasurement{},
+		&dnsMeasurement{},
 	}
 }
 
@@ -390,9 +391,7 @@ func (d *Input) dispatchTasks(j []byte) error {
 			case dt.ClassHTTP:
 				t = &dt.HTTPTask{Option: map[string]string{"userAgent": fmt.Sprintf("DataKit/%s dialtesting", datakit.Version)}}
 			case dt.ClassDNS:
-				// TODO
-				l.Warnf("DNS task deprecated, ignored")
-				continue
+				t = &DNSTask{}
 			case dt.ClassTCP:
 				t = &dt.TCPTask{}
 			case dt.ClassWebsocket:
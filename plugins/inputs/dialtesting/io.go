@@ -11,33 +11,51 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
 )
 
+// taskResult is a single result of the task.
+type taskResult struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// multiResultsTask is the task that generates multiple results on each run,
+// such as DNS task which queries on multiple nameservers.
+type multiResultsTask interface {
+	GetNameserverResults() []*taskResult
+}
+
 func (d *dialer) pointsFeed(urlStr string) error {
 	// 获取此次任务执行的基本信息
-	tags, fields := d.task.GetResults()
-
-	for k, v := range d.tags {
-		if _, ok := tags[k]; !ok {
-			tags[k] = v
-		} else {
-			l.Warnf("ignore dialer tag %s: %s", k, v)
-		}
-	}
-	data, err := point.NewPoint(d.task.MetricName(), tags, fields, point.LOpt())
-	if err != nil {
-		l.Warnf("make metric failed: %s", err.Error)
-		return err
+	var results []*taskResult
+	if mt, ok := d.task.(multiResultsTask); ok {
+		results = mt.GetNameserverResults()
+	} else {
+		tags, fields := d.task.GetResults()
+		results = append(results, &taskResult{tags: tags, fields: fields})
 	}
 
 	pts := []*point.Point{}
-	pts = append(pts, data)
+	for _, r := range results {
+		for k, v := range d.tags {
+			if _, ok := r.tags[k]; !ok {
+				r.tags[k] = v
+			} else {
+				l.Warnf("ignore dialer tag %s: %s", k, v)
+			}
+		}
+		data, err := point.NewPoint(d.task.MetricName(), r.tags, r.fields, point.LOpt())
+		if err != nil {
+			l.Warnf("make metric failed: %s", err.Error)
+			return err
+		}
 
-	err = Feed(inputName, datakit.Logging, pts, &io.Option{
-		HTTPHost: urlStr,
-	})
+		pts = append(pts, data)
 
-	l.Debugf(`url:%s, tags: %+#v, fs: %+#v`, urlStr, tags, fields)
+		l.Debugf(`url:%s, tags: %+#v, fs: %+#v`, urlStr, r.tags, r.fields)
+	}
 
-	return err
+	return Feed(inputName, datakit.Logging, pts, &io.Option{
+		HTTPHost: urlStr,
+	})
 }
 
 func Feed(name, category string, pts []*point.Point, opt *io.Option) error {
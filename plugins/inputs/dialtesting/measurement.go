@@ -344,3 +344,72 @@ func (m *websocketMeasurement) Info() *inputs.MeasurementInfo {
 		},
 	}
 }
+
+type dnsMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+func (m *dnsMeasurement) LineProto() (*point.Point, error) {
+	return point.NewPoint(m.name, m.tags, m.fields, point.LOpt())
+}
+
+//nolint:lll
+func (m *dnsMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "dns_dial_testing",
+		Tags: map[string]interface{}{
+			"name":       &inputs.TagInfo{Desc: "示例 拨测名称,百度测试"},
+			"domain":     &inputs.TagInfo{Desc: "示例 www.baidu.com"},
+			"query_type": &inputs.TagInfo{Desc: "查询类型，A/AAAA/CNAME/MX/TXT"},
+			"nameserver": &inputs.TagInfo{Desc: "示例 DNS 服务器地址，如 8.8.8.8:53"},
+			"rcode":      &inputs.TagInfo{Desc: "DNS 响应码，如 NOERROR/NXDOMAIN/SERVFAIL"},
+			"country":    &inputs.TagInfo{Desc: "示例 中国"},
+			"province":   &inputs.TagInfo{Desc: "示例 浙江"},
+			"city":       &inputs.TagInfo{Desc: "示例 杭州"},
+			"internal":   &inputs.TagInfo{Desc: "示例 true（国内 true /海外 false）"},
+			"isp":        &inputs.TagInfo{Desc: "示例 电信/移动/联通"},
+			"status":     &inputs.TagInfo{Desc: "示例 OK/FAIL 两种状态 "},
+			"proto":      &inputs.TagInfo{Desc: "示例 dns"},
+		},
+		Fields: map[string]interface{}{
+			"message": &inputs.FieldInfo{
+				DataType: inputs.String,
+				Type:     inputs.Gauge,
+				Unit:     inputs.UnknownUnit,
+				Desc:     "包括响应时间(response_time)/解析结果(answers)/错误原因(fail_reason)",
+			},
+			"fail_reason": &inputs.FieldInfo{
+				DataType: inputs.String,
+				Type:     inputs.Gauge,
+				Unit:     inputs.UnknownUnit,
+				Desc:     "拨测失败原因",
+			},
+			"answers": &inputs.FieldInfo{
+				DataType: inputs.String,
+				Type:     inputs.Gauge,
+				Unit:     inputs.UnknownUnit,
+				Desc:     "解析结果，多个结果以 `;` 分隔",
+			},
+			"answer_count": &inputs.FieldInfo{
+				DataType: inputs.Int,
+				Type:     inputs.Gauge,
+				Unit:     inputs.NCount,
+				Desc:     "解析结果个数",
+			},
+			"response_time": &inputs.FieldInfo{
+				DataType: inputs.Int,
+				Type:     inputs.Gauge,
+				Unit:     inputs.DurationUS,
+				Desc:     "DNS 解析时间",
+			},
+			"success": &inputs.FieldInfo{
+				DataType: inputs.Int,
+				Type:     inputs.Gauge,
+				Unit:     inputs.UnknownUnit,
+				Desc:     "只有 1/-1 两种状态, 1 表示成功, -1 表示失败",
+			},
+		},
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/GuanceCloud/cliutils/logger"
 	"github.com/GuanceCloud/cliutils/system/rtpanic"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/tailer"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
@@ -42,6 +43,9 @@ var (
 )
 
 func GetElectionInputs() []ElectionInput {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
 	res := []ElectionInput{}
 	for k, arr := range InputsInfo {
 		for _, x := range arr {
@@ -155,6 +159,18 @@ func Add(name string, creator Creator) {
 
 type inputInfo struct {
 	input Input
+
+	name        string
+	source      string // config file the input loaded from
+	fingerprint string // checksum of the input config
+}
+
+// LoadedInput is the input loaded from config file.
+type LoadedInput struct {
+	Name        string
+	Input       Input
+	Source      string
+	Fingerprint string
 }
 
 func (ii *inputInfo) Run() {
@@ -236,18 +252,29 @@ func DeleteConfigInfoPath(inputName, fp string) {
 }
 
 func AddInput(name string, input Input) {
+	AddLoadedInput(&LoadedInput{Name: name, Input: input})
+}
+
+// AddLoadedInput adds the input with its config file and checksum, which are
+// used to diff inputs on hot reload.
+func AddLoadedInput(x *LoadedInput) {
 	mtx.Lock()
 	defer mtx.Unlock()
 
 	// 单例采集器只添加一次
-	if _, ok := input.(Singleton); ok {
-		if len(InputsInfo[name]) > 0 {
+	if _, ok := x.Input.(Singleton); ok {
+		if len(InputsInfo[x.Name]) > 0 {
 			return
 		}
 	}
-	InputsInfo[name] = append(InputsInfo[name], &inputInfo{input: input})
+	InputsInfo[x.Name] = append(InputsInfo[x.Name], &inputInfo{
+		input:       x.Input,
+		name:        x.Name,
+		source:      x.Source,
+		fingerprint: x.Fingerprint,
+	})
 
-	l.Debugf("add input %s, total %d", name, len(InputsInfo[name]))
+	l.Debugf("add input %s, total %d", x.Name, len(InputsInfo[x.Name]))
 }
 
 func RemoveInput(name string, input Input) {
@@ -332,30 +359,32 @@ func RunInputs() error {
 				inp.RegHTTPHandler()
 			}
 
-			if inp, ok := ii.input.(PipelineInput); ok {
-				inp.RunPipeline()
-			}
-
-			if inp, ok := ii.input.(ReadEnv); ok && datakit.Docker {
-				inp.ReadEnv(envs)
-			}
-
-			func(name string, ii *inputInfo) {
-				g.Go(func(ctx context.Context) error {
-					// NOTE: 让每个采集器间歇运行，防止每个采集器扎堆启动，导致主机资源消耗出现规律性的峰值
-					time.Sleep(time.Duration(rand.Int63n(int64(10 * time.Second)))) //nolint:gosec
-					l.Infof("starting input %s ...", name)
-
-					protectRunningInput(name, ii)
-					l.Infof("input %s exited", name)
-					return nil
-				})
-			}(name, ii)
+			// NOTE: 让每个采集器间歇运行，防止每个采集器扎堆启动，导致主机资源消耗出现规律性的峰值
+			runInput(g, name, ii, envs, time.Duration(rand.Int63n(int64(10*time.Second)))) //nolint:gosec
 		}
 	}
 	return nil
 }
 
+func runInput(g *goroutine.Group, name string, ii *inputInfo, envs map[string]string, delay time.Duration) {
+	if inp, ok := ii.input.(PipelineInput); ok {
+		inp.RunPipeline()
+	}
+
+	if inp, ok := ii.input.(ReadEnv); ok && datakit.Docker {
+		inp.ReadEnv(envs)
+	}
+
+	g.Go(func(ctx context.Context) error {
+		time.Sleep(delay)
+		l.Infof("starting input %s ...", name)
+
+		protectRunningInput(name, ii)
+		l.Infof("input %s exited", name)
+		return nil
+	})
+}
+
 func RunInputExtra() error {
 	mtx.RLock()
 	defer mtx.RUnlock()
@@ -0,0 +1,550 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+// Package journald collects systemd journal logs.
+package journald
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/logger"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+	iod "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline/script"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+)
+
+const (
+	inputName = "journald"
+
+	defaultInterval   = time.Second
+	warnInterval      = time.Minute
+	maxEntriesPerRead = 1000
+	registerKeyPrefix = "journald::"
+
+	sampleCfg = `
+[[inputs.journald]]
+  ## Journal directories or files, default /var/log/journal and /run/log/journal.
+  ## Journal files(*.journal) under the directories and their sub-directories are read.
+  # paths = ["/var/log/journal", "/run/log/journal"]
+
+  ## Only collect entries of these units, ".service" is appended if no unit type set.
+  # units = ["nginx", "sshd.service"]
+
+  ## Only collect entries with priority not lower than this,
+  ## "emerg","alert","critical","error","warning","notice","info","debug" or 0~7.
+  # priority = "debug"
+
+  ## Matches in form of "FIELD=value", values of same field are ORed, fields are ANDed.
+  # matches = ["_TRANSPORT=syslog", "_TRANSPORT=journal"]
+
+  ## Journal fields used as tags, tag key is the lower-case field name without leading '_'.
+  # tag_fields = ["_SYSTEMD_UNIT", "_HOSTNAME", "SYSLOG_IDENTIFIER", "_TRANSPORT"]
+
+  ## Journal fields used as fields, field key is the same as tag_fields.
+  # fields = ["_PID", "_COMM"]
+
+  ## Read journal from beginning if no position recorded.
+  from_beginning = false
+
+  ## Your logging source, if it's empty, use 'journald'.
+  source = "journald"
+
+  ## Add service tag, if it's empty, use $source.
+  service = ""
+
+  ## Pipeline script name.
+  pipeline = ""
+
+  ## Scripts run in order after the pipeline above.
+  # pipeline_chain = []
+
+  ## optional status:
+  ##   "emerg","alert","critical","error","warning","notice","info","debug"
+  ignore_status = []
+
+  ## If the data sent failure, will retry forevery.
+  blocking_mode = true
+
+  [inputs.journald.tags]
+  # some_tag = "some_value"
+  # more_tag = "some_other_value"
+`
+)
+
+var (
+	l = logger.DefaultSLogger(inputName)
+
+	defaultPaths     = []string{"/var/log/journal", "/run/log/journal"}
+	defaultTagFields = []string{"_SYSTEMD_UNIT", "_HOSTNAME", "SYSLOG_IDENTIFIER", "_TRANSPORT"}
+
+	// journal PRIORITY to status.
+	priorityStatus = []string{"emerg", "alert", "critical", "error", "warning", "notice", "info", "debug"}
+
+	feed = iod.Feed
+)
+
+type Input struct {
+	Paths         []string          `toml:"paths"`
+	Units         []string          `toml:"units"`
+	Priority      string            `toml:"priority"`
+	Matches       []string          `toml:"matches"`
+	TagFields     []string          `toml:"tag_fields"`
+	Fields        []string          `toml:"fields"`
+	FromBeginning bool              `toml:"from_beginning"`
+	Source        string            `toml:"source"`
+	Service       string            `toml:"service"`
+	Pipeline      string            `toml:"pipeline"`
+	PipelineChain []string          `toml:"pipeline_chain"`
+	IgnoreStatus  []string          `toml:"ignore_status"`
+	BlockingMode  bool              `toml:"blocking_mode"`
+	Tags          map[string]string `toml:"tags"`
+
+	units       map[string]bool
+	maxPriority int
+	matches     map[string]map[string]bool
+	tags        map[string]string
+
+	// journal file ID => file
+	files map[string]*journalFile
+	// the first scan, files found are read from the end if not from_beginning
+	firstScan bool
+	// key => time of the last warning logged
+	warned map[string]time.Time
+
+	semStop *cliutils.Sem // start stop signal
+}
+
+func (ipt *Input) Run() {
+	l = logger.SLogger(inputName)
+
+	if err := ipt.setup(); err != nil {
+		l.Errorf("invalid config: %s", err)
+		return
+	}
+
+	_ = logtail.InitDefault()
+
+	tick := time.NewTicker(defaultInterval)
+	defer tick.Stop()
+	defer ipt.closeFiles()
+
+	// read again immediately if there are more entries
+	now := make(chan time.Time)
+	close(now)
+
+	for {
+		var next <-chan time.Time = tick.C
+		if ipt.collect() {
+			next = now
+		}
+
+		select {
+		case <-datakit.Exit.Wait():
+			l.Infof("%s exit", inputName)
+			return
+
+		case <-ipt.semStop.Wait():
+			l.Infof("%s terminate", inputName)
+			return
+
+		case <-next:
+		}
+	}
+}
+
+func (ipt *Input) setup() error {
+	if len(ipt.Paths) == 0 {
+		ipt.Paths = defaultPaths
+	}
+	if len(ipt.TagFields) == 0 {
+		ipt.TagFields = defaultTagFields
+	}
+	if ipt.Source == "" {
+		ipt.Source = inputName
+	}
+	if ipt.Service == "" {
+		ipt.Service = ipt.Source
+	}
+
+	ipt.units = map[string]bool{}
+	for _, unit := range ipt.Units {
+		if !strings.Contains(unit, ".") {
+			unit += ".service"
+		}
+		ipt.units[unit] = true
+	}
+
+	ipt.maxPriority = len(priorityStatus) - 1
+	if ipt.Priority != "" {
+		p, err := parsePriority(ipt.Priority)
+		if err != nil {
+			return err
+		}
+		ipt.maxPriority = p
+	}
+
+	ipt.matches = map[string]map[string]bool{}
+	for _, m := range ipt.Matches {
+		idx := strings.IndexByte(m, '=')
+		if idx <= 0 {
+			return fmt.Errorf("invalid match %q, should be FIELD=value", m)
+		}
+		field, value := m[:idx], m[idx+1:]
+		if ipt.matches[field] == nil {
+			ipt.matches[field] = map[string]bool{}
+		}
+		ipt.matches[field][value] = true
+	}
+
+	ipt.tags = map[string]string{}
+	for k, v := range ipt.Tags {
+		ipt.tags[k] = v
+	}
+	ipt.tags["service"] = ipt.Service
+
+	ipt.files = map[string]*journalFile{}
+	ipt.firstScan = true
+	ipt.warned = map[string]time.Time{}
+	return nil
+}
+
+func parsePriority(s string) (int, error) {
+	for i, status := range priorityStatus {
+		if strings.EqualFold(s, status) {
+			return i, nil
+		}
+	}
+
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 0 || p >= len(priorityStatus) {
+		return 0, fmt.Errorf("invalid priority %q", s)
+	}
+	return p, nil
+}
+
+// scan finds journal files under paths, new files are opened and positioned.
+func (ipt *Input) scan() {
+	var list []string
+	for _, p := range ipt.Paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			l.Debugf("stat %s: %s, ignored", p, err)
+			continue
+		}
+
+		if !fi.IsDir() {
+			list = append(list, p)
+			continue
+		}
+
+		// journal files are located at <dir>/<machine-id>/*.journal
+		for _, pattern := range []string{"*.journal", "*/*.journal"} {
+			matches, err := filepath.Glob(filepath.Join(p, pattern))
+			if err != nil {
+				l.Warnf("glob %s: %s, ignored", p, err)
+				continue
+			}
+			list = append(list, matches...)
+		}
+	}
+
+	for _, path := range list {
+		if ipt.opened(path) {
+			continue
+		}
+
+		jf, err := openJournalFile(path)
+		if err != nil {
+			ipt.warnf(path, "open journal file: %s, ignored", err)
+			continue
+		}
+
+		if old, ok := ipt.files[jf.header.fileID]; ok {
+			// the active file is renamed on archiving
+			l.Debugf("journal file %s renamed to %s", old.path, path)
+			old.path = path
+			_ = jf.close() //nolint:errcheck,gosec
+			continue
+		}
+
+		if err := ipt.position(jf); err != nil {
+			l.Warnf("position journal file %s: %s, ignored", path, err)
+			_ = jf.close() //nolint:errcheck,gosec
+			continue
+		}
+
+		l.Infof("new journal file %s, offset %d", path, jf.next)
+		ipt.files[jf.header.fileID] = jf
+	}
+
+	// remove files deleted
+	for id, jf := range ipt.files {
+		if _, err := os.Stat(jf.path); err != nil {
+			l.Infof("journal file %s removed", jf.path)
+			_ = jf.close() //nolint:errcheck,gosec
+			delete(ipt.files, id)
+		}
+	}
+
+	ipt.firstScan = false
+}
+
+func (ipt *Input) opened(path string) bool {
+	for _, jf := range ipt.files {
+		if jf.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// position sets the read offset of the journal file by the priority:
+//   - the offset recorded in the register.
+//   - the end of the file if it exists on startup and from_beginning is false.
+//   - the beginning of the file.
+func (ipt *Input) position(jf *journalFile) error {
+	end, err := jf.end()
+	if err != nil {
+		return err
+	}
+
+	if data := register.Get(registerKeyPrefix + jf.header.fileID); data != nil {
+		if offset := uint64(data.Offset); offset >= jf.header.headerSize && offset <= end {
+			jf.next = offset
+			return nil
+		}
+		l.Infof("invalid offset %d of journal file %s, ignored", data.Offset, jf.path)
+	}
+
+	if ipt.firstScan && !ipt.FromBeginning {
+		jf.next = end
+	}
+
+	return nil
+}
+
+// collect reads entries of all journal files, returns true if there are more
+// entries to read.
+func (ipt *Input) collect() bool {
+	ipt.scan()
+
+	var more bool
+	for id, jf := range ipt.files {
+		var pts []*point.Point
+
+		offset := jf.next
+		hasMore, err := jf.readEntries(maxEntriesPerRead, func(entry *journalEntry) {
+			if !ipt.match(entry) {
+				return
+			}
+
+			pt, err := ipt.buildPoint(entry)
+			if err != nil {
+				l.Warnf("build point: %s, ignored", err)
+				return
+			}
+			pts = append(pts, pt)
+		})
+		if err != nil {
+			ipt.warnf(jf.path, "read journal file %s: %s", jf.path, err)
+			if jf.next == offset {
+				// no progress, such as the header corrupted, reopened on next scan
+				_ = jf.close() //nolint:errcheck,gosec
+				delete(ipt.files, id)
+			}
+		}
+
+		ipt.feed(pts)
+
+		if err := register.Set(registerKeyPrefix+id, &register.MetaData{
+			Source: ipt.Source,
+			Offset: int64(jf.next),
+		}); err != nil {
+			l.Debugf("recording position of %s: %s, ignored", jf.path, err)
+		}
+
+		more = more || hasMore
+	}
+
+	return more
+}
+
+// warnf logs the warning of key at most once every warnInterval, since
+// corrupted or unsupported journal files are retried on every collect.
+func (ipt *Input) warnf(key, format string, args ...interface{}) {
+	if t, ok := ipt.warned[key]; ok && time.Since(t) < warnInterval {
+		return
+	}
+	ipt.warned[key] = time.Now()
+	l.Warnf(format, args...)
+}
+
+func (ipt *Input) match(entry *journalEntry) bool {
+	if len(ipt.units) != 0 && !ipt.units[entry.fields["_SYSTEMD_UNIT"]] {
+		return false
+	}
+
+	if p, ok := entry.fields["PRIORITY"]; ok {
+		if n, err := strconv.Atoi(p); err == nil && n > ipt.maxPriority {
+			return false
+		}
+	}
+
+	for field, values := range ipt.matches {
+		if v, ok := entry.fields[field]; !ok || !values[v] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func fieldKey(field string) string {
+	return strings.ToLower(strings.TrimLeft(field, "_"))
+}
+
+func getStatus(entry *journalEntry) string {
+	if p, err := strconv.Atoi(entry.fields["PRIORITY"]); err == nil && p >= 0 && p < len(priorityStatus) {
+		return priorityStatus[p]
+	}
+	return "info"
+}
+
+func (ipt *Input) buildPoint(entry *journalEntry) (*point.Point, error) {
+	tags := make(map[string]string, len(ipt.tags)+len(ipt.TagFields))
+	for k, v := range ipt.tags {
+		tags[k] = v
+	}
+	for _, field := range ipt.TagFields {
+		if v, ok := entry.fields[field]; ok {
+			tags[fieldKey(field)] = v
+		}
+	}
+
+	message := entry.fields["MESSAGE"]
+	fields := map[string]interface{}{
+		pipeline.FieldMessage: message,
+		pipeline.FieldStatus:  getStatus(entry),
+		"message_length":      len(message),
+		"seqnum":              int64(entry.seqnum),
+	}
+	for _, field := range ipt.Fields {
+		if v, ok := entry.fields[field]; ok {
+			fields[fieldKey(field)] = v
+		}
+	}
+
+	return point.NewPoint(ipt.Source, tags, fields, &point.PointOption{
+		Time:     time.Unix(0, int64(entry.realtime)*int64(time.Microsecond)),
+		Category: datakit.Logging,
+		Strict:   true,
+	})
+}
+
+func (ipt *Input) feed(pts []*point.Point) {
+	if len(pts) == 0 {
+		return
+	}
+
+	// journal entries of different files may be out of order
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].Time().Before(pts[j].Time()) })
+
+	if err := feed(inputName+"/"+ipt.Source, datakit.Logging, pts, &iod.Option{
+		PlScript: map[string]string{ipt.Source: ipt.Pipeline},
+		PlChain:  ipt.PipelineChain,
+		PlOption: &script.Option{
+			IgnoreStatus: ipt.IgnoreStatus,
+		},
+		Blocking: ipt.BlockingMode,
+	}); err != nil {
+		l.Errorf("feed %d pts failed: %s, logging block-mode off, ignored", len(pts), err)
+	}
+}
+
+func (ipt *Input) closeFiles() {
+	for id, jf := range ipt.files {
+		_ = jf.close() //nolint:errcheck,gosec
+		delete(ipt.files, id)
+	}
+}
+
+func (ipt *Input) Terminate() {
+	if ipt.semStop != nil {
+		ipt.semStop.Close()
+	}
+}
+
+func (*Input) Catalog() string {
+	return "log"
+}
+
+func (*Input) SampleConfig() string {
+	return sampleCfg
+}
+
+func (*Input) AvailableArchs() []string {
+	return []string{datakit.OSLabelLinux, datakit.LabelK8s}
+}
+
+func (*Input) SampleMeasurement() []inputs.Measurement {
+	return []inputs.Measurement{
+		&journaldMeasurement{},
+	}
+}
+
+type journaldMeasurement struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+func (m *journaldMeasurement) LineProto() (*point.Point, error) {
+	return point.NewPoint(m.name, m.tags, m.fields, point.LOpt())
+}
+
+//nolint:lll
+func (*journaldMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Name: "journald",
+		Type: "logging",
+		Desc: "Use the `source` of the config, default is `journald`",
+		Tags: map[string]interface{}{
+			"host":              inputs.NewTagInfo(`Host name`),
+			"service":           inputs.NewTagInfo("Use the `service` of the config."),
+			"systemd_unit":      inputs.NewTagInfo("Journal field `_SYSTEMD_UNIT`, the systemd unit of the process."),
+			"hostname":          inputs.NewTagInfo("Journal field `_HOSTNAME`, the host name of the journal entry."),
+			"syslog_identifier": inputs.NewTagInfo("Journal field `SYSLOG_IDENTIFIER`, the identifier of the program."),
+			"transport":         inputs.NewTagInfo("Journal field `_TRANSPORT`, how the entry was received by journald."),
+		},
+		Fields: map[string]interface{}{
+			"message":        &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Journal field `MESSAGE`."},
+			"status":         &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "The status of the logging, mapped from journal field `PRIORITY`, default is `info`."},
+			"message_length": &inputs.FieldInfo{DataType: inputs.SizeByte, Unit: inputs.NCount, Desc: "The length of the message content."},
+			"seqnum":         &inputs.FieldInfo{DataType: inputs.Int, Unit: inputs.UnknownUnit, Desc: "The sequence number of the journal entry."},
+		},
+	}
+}
+
+func init() { //nolint:gochecknoinits
+	inputs.Add(inputName, func() inputs.Input {
+		return &Input{
+			Tags:         make(map[string]string),
+			BlockingMode: true,
+			semStop:      cliutils.NewSem(),
+		}
+	})
+}
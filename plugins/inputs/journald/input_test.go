@@ -0,0 +1,369 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/logtail/register"
+	iod "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+)
+
+const testHeaderSize = 256
+
+// writeJournal writes a minimal journal file with file ID derived from path,
+// each entry refers to its own data objects. MESSAGE of compressed journal
+// file is zstd compressed.
+func writeJournal(t *testing.T, path string, compact, compress bool, entries []map[string]string) {
+	t.Helper()
+
+	var incompatible uint32
+	if compact {
+		incompatible |= incompatibleCompact
+	}
+	if compress {
+		incompatible |= incompatibleCompressedZSTD
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	buf := make([]byte, testHeaderSize)
+	var tail uint64
+
+	writeObject := func(typ, flags uint8, body []byte) uint64 {
+		offset := uint64(len(buf))
+		obj := make([]byte, objectHeaderSize+len(body))
+		obj[0], obj[1] = typ, flags
+		binary.LittleEndian.PutUint64(obj[8:], uint64(len(obj)))
+		copy(obj[objectHeaderSize:], body)
+		buf = append(buf, obj...)
+		for len(buf)%8 != 0 {
+			buf = append(buf, 0)
+		}
+		tail = offset
+		return offset
+	}
+
+	keys := func(m map[string]string) []string {
+		var res []string
+		for k := range m {
+			res = append(res, k)
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	for i, entry := range entries {
+		var offsets []uint64
+		for _, k := range keys(entry) {
+			payload := []byte(k + "=" + entry[k])
+			var flags uint8
+			if compress && k == "MESSAGE" {
+				payload = enc.EncodeAll(payload, nil)
+				flags = objectCompressedZSTD
+			}
+
+			head := dataPayloadOffset - objectHeaderSize
+			if compact {
+				head = dataPayloadOffsetCompt - objectHeaderSize
+			}
+			offsets = append(offsets, writeObject(objectData, flags, append(make([]byte, head), payload...)))
+		}
+
+		body := make([]byte, entryItemsOffset-objectHeaderSize)
+		binary.LittleEndian.PutUint64(body[0:], uint64(i+1))                   // seqnum
+		binary.LittleEndian.PutUint64(body[8:], uint64(1600000000000000+i*10)) // realtime
+		for _, offset := range offsets {
+			if compact {
+				item := make([]byte, 4)
+				binary.LittleEndian.PutUint32(item, uint32(offset))
+				body = append(body, item...)
+			} else {
+				item := make([]byte, 16)
+				binary.LittleEndian.PutUint64(item, offset)
+				body = append(body, item...)
+			}
+		}
+		writeObject(objectEntry, 0, body)
+	}
+
+	copy(buf, journalSignature)
+	binary.LittleEndian.PutUint32(buf[hdrIncompatibleFlags:], incompatible)
+	id := md5.Sum([]byte(path)) //nolint:gosec
+	copy(buf[hdrFileID:], id[:])
+	copy(buf[hdrSeqnumID:], "fedcba9876543210")
+	binary.LittleEndian.PutUint64(buf[hdrHeaderSize:], testHeaderSize)
+	binary.LittleEndian.PutUint64(buf[hdrArenaSize:], uint64(len(buf)-testHeaderSize))
+	binary.LittleEndian.PutUint64(buf[hdrTailObjectOffset:], tail)
+	binary.LittleEndian.PutUint64(buf[hdrNEntries:], uint64(len(entries)))
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, ioutil.WriteFile(path, buf, 0o600))
+}
+
+// corruptJournal modifies the journal file by fn with offsets of entries.
+func corruptJournal(t *testing.T, path string, fn func(data []byte, entries []uint64)) {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var entries []uint64
+	for offset := uint64(testHeaderSize); offset < uint64(len(data)); {
+		if data[offset] == objectEntry {
+			entries = append(entries, offset)
+		}
+		offset = align8(offset + binary.LittleEndian.Uint64(data[offset+8:]))
+	}
+
+	fn(data, entries)
+	require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+}
+
+var testEntries = []map[string]string{
+	{"MESSAGE": "nginx started", "PRIORITY": "6", "_SYSTEMD_UNIT": "nginx.service", "_HOSTNAME": "host1"},
+	{"MESSAGE": "nginx failed", "PRIORITY": "3", "_SYSTEMD_UNIT": "nginx.service", "_HOSTNAME": "host1", "_PID": "123"},
+	{"MESSAGE": "sshd warning", "PRIORITY": "4", "_SYSTEMD_UNIT": "sshd.service", "_HOSTNAME": "host1"},
+	{"MESSAGE": "nginx critical", "PRIORITY": "2", "_SYSTEMD_UNIT": "nginx.service", "_HOSTNAME": "host1"},
+}
+
+func TestJournalFile(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		compact, compress bool
+	}{
+		{name: "regular"},
+		{name: "compact", compact: true},
+		{name: "compressed", compact: true, compress: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "system.journal")
+			writeJournal(t, path, tc.compact, tc.compress, testEntries[:3])
+
+			jf, err := openJournalFile(path)
+			require.NoError(t, err)
+			defer jf.close() //nolint:errcheck
+
+			var got []*journalEntry
+			more, err := jf.readEntries(2, func(e *journalEntry) { got = append(got, e) })
+			require.NoError(t, err)
+			assert.True(t, more)
+
+			more, err = jf.readEntries(2, func(e *journalEntry) { got = append(got, e) })
+			require.NoError(t, err)
+			assert.False(t, more)
+
+			require.Len(t, got, 3)
+			for i, e := range got {
+				assert.Equal(t, uint64(i+1), e.seqnum)
+				assert.Equal(t, testEntries[i], e.fields)
+			}
+
+			// appended
+			writeJournal(t, path, tc.compact, tc.compress, testEntries)
+			got = nil
+			_, err = jf.readEntries(10, func(e *journalEntry) { got = append(got, e) })
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, "nginx critical", got[0].fields["MESSAGE"])
+		})
+	}
+
+	t.Run("xz", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "system.journal")
+		writeJournal(t, path, false, false, testEntries)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		binary.LittleEndian.PutUint32(data[hdrIncompatibleFlags:], incompatibleCompressedXZ)
+		require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+
+		_, err = openJournalFile(path)
+		assert.ErrorContains(t, err, "xz compressed")
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "system.journal")
+		writeJournal(t, path, false, false, testEntries)
+
+		// the 2nd entry is too small
+		corruptJournal(t, path, func(data []byte, entries []uint64) {
+			binary.LittleEndian.PutUint64(data[entries[1]+8:], objectHeaderSize)
+		})
+
+		jf, err := openJournalFile(path)
+		require.NoError(t, err)
+		defer jf.close() //nolint:errcheck
+
+		var got []string
+		read := func(e *journalEntry) { got = append(got, e.fields["MESSAGE"]) }
+
+		_, err = jf.readEntries(10, read)
+		assert.Error(t, err)
+		_, err = jf.readEntries(10, read)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"nginx started"}, got)
+
+		// read on from the end of the file on corruption
+		writeJournal(t, path, false, false, append(testEntries[:4:4], map[string]string{"MESSAGE": "appended"}))
+		_, err = jf.readEntries(10, read)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"nginx started", "appended"}, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.journal")
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, 512), 0o600))
+		_, err := openJournalFile(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestInput(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, register.Init(filepath.Join(dir, "logtail.history")))
+
+	var got []*point.Point
+	feed = func(name, category string, pts []*point.Point, opt *iod.Option) error {
+		got = append(got, pts...)
+		return nil
+	}
+	defer func() { feed = iod.Feed }()
+
+	path := filepath.Join(dir, "journal", "machine-id", "system.journal")
+	writeJournal(t, path, true, false, testEntries[:3])
+
+	newInput := func(fromBeginning bool) *Input {
+		ipt := &Input{
+			Paths:         []string{filepath.Join(dir, "journal")},
+			Units:         []string{"nginx"},
+			Priority:      "error",
+			Fields:        []string{"_PID"},
+			FromBeginning: fromBeginning,
+			Tags:          map[string]string{"foo": "bar"},
+		}
+		require.NoError(t, ipt.setup())
+		return ipt
+	}
+
+	ipt := newInput(true)
+	assert.False(t, ipt.collect())
+
+	// nginx.service and priority <= error
+	require.Len(t, got, 1)
+	fields, err := got[0].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, "nginx failed", fields["message"])
+	assert.Equal(t, "error", fields["status"])
+	assert.Equal(t, "123", fields["pid"])
+	assert.Equal(t, map[string]string{
+		"foo":          "bar",
+		"service":      "journald",
+		"systemd_unit": "nginx.service",
+		"hostname":     "host1",
+	}, got[0].Tags())
+	assert.Equal(t, "journald", got[0].Name())
+
+	// appended
+	got = nil
+	writeJournal(t, path, true, false, testEntries)
+	ipt.collect()
+	require.Len(t, got, 1)
+	fields, err = got[0].Fields()
+	require.NoError(t, err)
+	assert.Equal(t, "nginx critical", fields["message"])
+	assert.Equal(t, "critical", fields["status"])
+	ipt.closeFiles()
+
+	// restart from the position recorded
+	got = nil
+	ipt = newInput(true)
+	ipt.collect()
+	assert.Empty(t, got)
+	ipt.closeFiles()
+}
+
+func TestInputFromEnd(t *testing.T) {
+	dir := t.TempDir()
+
+	var got []*point.Point
+	feed = func(name, category string, pts []*point.Point, opt *iod.Option) error {
+		got = append(got, pts...)
+		return nil
+	}
+	defer func() { feed = iod.Feed }()
+
+	path := filepath.Join(dir, "user-1000.journal")
+	writeJournal(t, path, false, false, testEntries[:2])
+
+	ipt := &Input{Paths: []string{path}, Source: "user"}
+	require.NoError(t, ipt.setup())
+	defer ipt.closeFiles()
+
+	// entries exist before started are skipped
+	ipt.collect()
+	assert.Empty(t, got)
+
+	writeJournal(t, path, false, false, testEntries)
+	ipt.collect()
+	require.Len(t, got, 2)
+	assert.Equal(t, "user", got[0].Name())
+	assert.Equal(t, "sshd.service", got[0].Tags()["systemd_unit"])
+	assert.Equal(t, "user", got[1].Tags()["service"])
+
+	// removed
+	require.NoError(t, os.Remove(path))
+	ipt.collect()
+	assert.Empty(t, ipt.files)
+}
+
+func TestInputCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, register.Init(filepath.Join(dir, "logtail.history")))
+
+	var got []string
+	feed = func(name, category string, pts []*point.Point, opt *iod.Option) error {
+		for _, pt := range pts {
+			fields, err := pt.Fields()
+			require.NoError(t, err)
+			got = append(got, fields["message"].(string))
+		}
+		return nil
+	}
+	defer func() { feed = iod.Feed }()
+
+	path := filepath.Join(dir, "system.journal")
+	writeJournal(t, path, false, false, testEntries)
+	corruptJournal(t, path, func(data []byte, entries []uint64) {
+		binary.LittleEndian.PutUint64(data[entries[1]+8:], objectHeaderSize)
+	})
+
+	ipt := &Input{Paths: []string{path}, FromBeginning: true}
+	require.NoError(t, ipt.setup())
+	defer ipt.closeFiles()
+
+	ipt.collect()
+	ipt.collect()
+	assert.Equal(t, []string{"nginx started"}, got)
+	assert.Len(t, ipt.files, 1)
+	assert.Len(t, ipt.warned, 1)
+
+	// entries appended are read after the corrupted one
+	writeJournal(t, path, false, false, append(testEntries[:4:4], map[string]string{"MESSAGE": "appended"}))
+	ipt.collect()
+	assert.Equal(t, []string{"nginx started", "appended"}, got)
+}
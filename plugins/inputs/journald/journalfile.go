@@ -0,0 +1,354 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package journald
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+)
+
+// Journal file format, see https://systemd.io/JOURNAL_FILE_FORMAT/
+const (
+	journalSignature = "LPKSHHRH"
+
+	// header fields offset.
+	hdrIncompatibleFlags   = 12
+	hdrFileID              = 24
+	hdrSeqnumID            = 72
+	hdrHeaderSize          = 88
+	hdrArenaSize           = 96
+	hdrTailObjectOffset    = 136
+	hdrNEntries            = 152
+	hdrTailEntrySeqnum     = 160
+	hdrTailEntryRealtime   = 192
+	minHeaderSize          = 208
+	objectHeaderSize       = 16
+	entryItemsOffset       = 64
+	dataPayloadOffset      = 64
+	dataPayloadOffsetCompt = 72
+
+	objectData  = 1
+	objectEntry = 3
+
+	objectCompressedXZ   = 1 << 0
+	objectCompressedLZ4  = 1 << 1
+	objectCompressedZSTD = 1 << 2
+
+	incompatibleCompressedXZ   = 1 << 0
+	incompatibleCompressedLZ4  = 1 << 1
+	incompatibleKeyedHash      = 1 << 2
+	incompatibleCompressedZSTD = 1 << 3
+	incompatibleCompact        = 1 << 4
+	incompatibleSupported      = incompatibleCompressedLZ4 | incompatibleKeyedHash |
+		incompatibleCompressedZSTD | incompatibleCompact
+
+	// avoid huge allocation on corrupted file.
+	maxObjectSize = 64 * 1024 * 1024
+
+	maxDataCache = 4096
+)
+
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+type journalHeader struct {
+	incompatibleFlags uint32
+	fileID            string
+	seqnumID          string
+	headerSize        uint64
+	arenaSize         uint64
+	tailObjectOffset  uint64
+	nEntries          uint64
+	tailEntrySeqnum   uint64
+	tailEntryRealtime uint64
+}
+
+func (h *journalHeader) compact() bool {
+	return h.incompatibleFlags&incompatibleCompact != 0
+}
+
+type journalEntry struct {
+	seqnum   uint64
+	realtime uint64 // microseconds since epoch
+	fields   map[string]string
+}
+
+// journalFile reads entries of a journal file in the order they were appended.
+type journalFile struct {
+	path   string
+	f      *os.File
+	header journalHeader
+
+	// offset of next object to read
+	next uint64
+
+	// data objects are shared by entries, cache them by offset
+	dataCache map[uint64]string
+}
+
+func openJournalFile(path string) (*journalFile, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	jf := &journalFile{path: path, f: f, dataCache: map[uint64]string{}}
+	if err := jf.readHeader(); err != nil {
+		_ = f.Close() //nolint:errcheck,gosec
+		return nil, err
+	}
+
+	jf.next = jf.header.headerSize
+	return jf, nil
+}
+
+func (jf *journalFile) close() error {
+	return jf.f.Close()
+}
+
+func (jf *journalFile) readHeader() error {
+	buf := make([]byte, minHeaderSize)
+	if _, err := jf.f.ReadAt(buf, 0); err != nil {
+		return fmt.Errorf("read journal header of %s: %w", jf.path, err)
+	}
+
+	if string(buf[:8]) != journalSignature {
+		return fmt.Errorf("invalid journal file %s", jf.path)
+	}
+
+	h := journalHeader{
+		incompatibleFlags: binary.LittleEndian.Uint32(buf[hdrIncompatibleFlags:]),
+		fileID:            hex.EncodeToString(buf[hdrFileID : hdrFileID+16]),
+		seqnumID:          hex.EncodeToString(buf[hdrSeqnumID : hdrSeqnumID+16]),
+		headerSize:        binary.LittleEndian.Uint64(buf[hdrHeaderSize:]),
+		arenaSize:         binary.LittleEndian.Uint64(buf[hdrArenaSize:]),
+		tailObjectOffset:  binary.LittleEndian.Uint64(buf[hdrTailObjectOffset:]),
+		nEntries:          binary.LittleEndian.Uint64(buf[hdrNEntries:]),
+		tailEntrySeqnum:   binary.LittleEndian.Uint64(buf[hdrTailEntrySeqnum:]),
+		tailEntryRealtime: binary.LittleEndian.Uint64(buf[hdrTailEntryRealtime:]),
+	}
+
+	if h.incompatibleFlags&incompatibleCompressedXZ != 0 {
+		return fmt.Errorf("unsupported journal file %s, xz compressed", jf.path)
+	}
+
+	if h.incompatibleFlags&^incompatibleSupported != 0 {
+		return fmt.Errorf("unsupported journal file %s, incompatible flags %#x", jf.path, h.incompatibleFlags)
+	}
+
+	if h.headerSize < minHeaderSize {
+		return fmt.Errorf("invalid journal file %s, header size %d", jf.path, h.headerSize)
+	}
+
+	jf.header = h
+	return nil
+}
+
+// end returns the offset after the tail object.
+func (jf *journalFile) end() (uint64, error) {
+	if jf.header.tailObjectOffset == 0 {
+		return jf.header.headerSize, nil
+	}
+
+	_, size, err := jf.readObjectHeader(jf.header.tailObjectOffset)
+	if err != nil {
+		return 0, err
+	}
+
+	return align8(jf.header.tailObjectOffset + size), nil
+}
+
+func (jf *journalFile) readObjectHeader(offset uint64) (uint8, uint64, error) {
+	buf := make([]byte, objectHeaderSize)
+	if _, err := jf.f.ReadAt(buf, int64(offset)); err != nil {
+		return 0, 0, err
+	}
+
+	size := binary.LittleEndian.Uint64(buf[8:])
+	if size < objectHeaderSize || size > maxObjectSize {
+		return 0, 0, fmt.Errorf("invalid object size %d at %d of %s", size, offset, jf.path)
+	}
+
+	return buf[0], size, nil
+}
+
+func (jf *journalFile) readObject(offset, size uint64) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := jf.f.ReadAt(buf, int64(offset)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readEntries reads at most limit entries appended since last read, fn is
+// called on each entry. It returns true if there are more entries to read.
+// On corrupted objects, objects following can't be located reliably, the
+// position is moved to the end of the file, so the next read goes on with
+// entries appended later instead of failing on the same object.
+func (jf *journalFile) readEntries(limit int, fn func(*journalEntry)) (bool, error) {
+	if err := jf.readHeader(); err != nil {
+		return false, err
+	}
+
+	for n := 0; jf.header.tailObjectOffset != 0 && jf.next <= jf.header.tailObjectOffset; {
+		if n >= limit {
+			return true, nil
+		}
+
+		typ, size, err := jf.readObjectHeader(jf.next)
+		if err != nil {
+			jf.resync()
+			return false, err
+		}
+
+		if typ == objectEntry {
+			entry, err := jf.readEntry(jf.next, size)
+			if err != nil {
+				jf.resync()
+				return false, err
+			}
+			fn(entry)
+			n++
+		}
+
+		jf.next = align8(jf.next + size)
+	}
+
+	return false, nil
+}
+
+// resync moves the position to the end of the file, it's kept if the end
+// can't be read.
+func (jf *journalFile) resync() {
+	if end, err := jf.end(); err == nil && end > jf.next {
+		jf.next = end
+	}
+}
+
+func (jf *journalFile) readEntry(offset, size uint64) (*journalEntry, error) {
+	if size < entryItemsOffset {
+		return nil, fmt.Errorf("invalid entry object size %d at %d of %s", size, offset, jf.path)
+	}
+
+	obj, err := jf.readObject(offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &journalEntry{
+		seqnum:   binary.LittleEndian.Uint64(obj[16:]),
+		realtime: binary.LittleEndian.Uint64(obj[24:]),
+		fields:   map[string]string{},
+	}
+
+	itemSize := uint64(16)
+	if jf.header.compact() {
+		itemSize = 4
+	}
+
+	for i := uint64(entryItemsOffset); i+itemSize <= size; i += itemSize {
+		var dataOffset uint64
+		if jf.header.compact() {
+			dataOffset = uint64(binary.LittleEndian.Uint32(obj[i:]))
+		} else {
+			dataOffset = binary.LittleEndian.Uint64(obj[i:])
+		}
+
+		if dataOffset == 0 {
+			continue
+		}
+
+		payload, err := jf.readData(dataOffset)
+		if err != nil {
+			l.Debugf("read data object at %d of %s: %s, ignored", dataOffset, jf.path, err)
+			continue
+		}
+
+		if idx := strings.IndexByte(payload, '='); idx > 0 {
+			entry.fields[payload[:idx]] = payload[idx+1:]
+		}
+	}
+
+	return entry, nil
+}
+
+func (jf *journalFile) readData(offset uint64) (string, error) {
+	if payload, ok := jf.dataCache[offset]; ok {
+		return payload, nil
+	}
+
+	typ, size, err := jf.readObjectHeader(offset)
+	if err != nil {
+		return "", err
+	}
+	if typ != objectData {
+		return "", fmt.Errorf("unexpected object type %d", typ)
+	}
+
+	payloadOffset := uint64(dataPayloadOffset)
+	if jf.header.compact() {
+		payloadOffset = dataPayloadOffsetCompt
+	}
+	if size < payloadOffset {
+		return "", fmt.Errorf("invalid data object size %d", size)
+	}
+
+	obj, err := jf.readObject(offset, size)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := decompress(obj[1], obj[payloadOffset:])
+	if err != nil {
+		return "", err
+	}
+
+	if len(jf.dataCache) >= maxDataCache {
+		jf.dataCache = map[uint64]string{}
+	}
+	jf.dataCache[offset] = string(payload)
+
+	return string(payload), nil
+}
+
+func decompress(flags uint8, data []byte) ([]byte, error) {
+	switch {
+	case flags&objectCompressedZSTD != 0:
+		return zstdDecoder.DecodeAll(data, nil)
+
+	case flags&objectCompressedLZ4 != 0:
+		// little endian uint64 of uncompressed size followed by LZ4 block
+		if len(data) < 8 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		size := binary.LittleEndian.Uint64(data)
+		if size > maxObjectSize {
+			return nil, fmt.Errorf("invalid lz4 uncompressed size %d", size)
+		}
+		buf := make([]byte, size)
+		n, err := lz4.UncompressBlock(data[8:], buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+
+	case flags&objectCompressedXZ != 0:
+		return nil, fmt.Errorf("xz compressed data not supported")
+
+	default:
+		return data, nil
+	}
+}
+
+func align8(x uint64) uint64 {
+	return (x + 7) &^ 7
+}
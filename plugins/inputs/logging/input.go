@@ -52,6 +52,10 @@ const (
   ## Grok pipeline script name.
   pipeline = ""
 
+  ## Scripts run in order after the pipeline above, for the enrichment
+  ## shared across sources.
+  # pipeline_chain = ["common_enrich.p"]
+
   ## optional status:
   ##   "emerg","alert","critical","error","warning","info","debug","OK"
   ignore_status = []
@@ -67,6 +71,11 @@ const (
   auto_multiline_detection = true
   auto_multiline_extra_patterns = []
 
+  ## Learn the line-start pattern from the first lines of each file and lock
+  ## it, the extra patterns are preferred candidates.
+  # auto_multiline_lock_pattern = false
+  # auto_multiline_detection_lines = 100
+
   ## Removes ANSI escape codes from text strings.
   remove_ansi_escape_codes = false
 
@@ -83,6 +92,21 @@ const (
   ## Read file from beginning.
   from_beginning = false
 
+  ## Read the compressed files(.gz/.zst/.bz2) that exist on first discovery.
+  backfill_archives = false
+
+  ## Max lines per second of each file, 0 means no limit.
+  ## The lines over limit are "drop" or "sample", a summary log with field
+  ## suppressed_lines is sent for the lines dropped.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
+  # rate_limit_mode = "drop"
+  # rate_limit_sample_rate = 0.1
+
+  ## Collapse identical consecutive lines within the window into one log
+  ## with field repeat_count, "0s" means no dedup.
+  # dedup_window = "0s"
+
   [inputs.logging.tags]
   # some_tag = "some_value"
   # more_tag = "some_other_value"
@@ -96,15 +120,24 @@ type Input struct {
 	Source                     string            `toml:"source"`
 	Service                    string            `toml:"service"`
 	Pipeline                   string            `toml:"pipeline"`
+	PipelineChain              []string          `toml:"pipeline_chain,omitempty"`
 	IgnoreStatus               []string          `toml:"ignore_status"`
 	CharacterEncoding          string            `toml:"character_encoding"`
 	MultilineMatch             string            `toml:"multiline_match"`
 	AutoMultilineDetection     bool              `toml:"auto_multiline_detection"`
 	AutoMultilineExtraPatterns []string          `toml:"auto_multiline_extra_patterns"`
+	AutoMultilineLockPattern   bool              `toml:"auto_multiline_lock_pattern"`
+	AutoMultilineDetectLines   int               `toml:"auto_multiline_detection_lines"`
 	RemoveAnsiEscapeCodes      bool              `toml:"remove_ansi_escape_codes"`
 	Tags                       map[string]string `toml:"tags"`
 	BlockingMode               bool              `toml:"blocking_mode"`
 	FromBeginning              bool              `toml:"from_beginning,omitempty"`
+	BackfillArchives           bool              `toml:"backfill_archives,omitempty"`
+	RateLimit                  float64           `toml:"rate_limit,omitempty"`
+	RateLimitBurst             int               `toml:"rate_limit_burst,omitempty"`
+	RateLimitMode              string            `toml:"rate_limit_mode,omitempty"`
+	RateLimitSampleRate        float64           `toml:"rate_limit_sample_rate,omitempty"`
+	DedupWindow                string            `toml:"dedup_window,omitempty"`
 	EnableDiskCache            bool              `toml:"enable_diskcache,omitempty"`
 	DockerMode                 bool              `toml:"docker_mode,omitempty"`
 	IgnoreDeadLog              string            `toml:"ignore_dead_log"`
@@ -147,19 +180,35 @@ func (ipt *Input) Run() {
 		ignoreDuration = dur
 	}
 
+	var dedupWindow time.Duration
+	if ipt.DedupWindow != "" {
+		if dur, err := timex.ParseDuration(ipt.DedupWindow); err != nil {
+			l.Warnf("invalid dedup_window %q: %s, ignored", ipt.DedupWindow, err)
+		} else {
+			dedupWindow = dur
+		}
+	}
+
 	opt := &tailer.Option{
 		Source:                ipt.Source,
 		Service:               ipt.Service,
 		Pipeline:              ipt.Pipeline,
+		PipelineChain:         ipt.PipelineChain,
 		Sockets:               ipt.Sockets,
 		IgnoreStatus:          ipt.IgnoreStatus,
 		FromBeginning:         ipt.FromBeginning,
+		BackfillArchives:      ipt.BackfillArchives,
 		CharacterEncoding:     ipt.CharacterEncoding,
 		RemoveAnsiEscapeCodes: ipt.RemoveAnsiEscapeCodes,
 		IgnoreDeadLog:         ignoreDuration,
 		GlobalTags:            ipt.Tags,
 		BlockingMode:          ipt.BlockingMode,
 		EnableDiskCache:       ipt.EnableDiskCache,
+		RateLimit:             ipt.RateLimit,
+		RateLimitBurst:        ipt.RateLimitBurst,
+		RateLimitMode:         ipt.RateLimitMode,
+		RateLimitSampleRate:   ipt.RateLimitSampleRate,
+		DedupWindow:           dedupWindow,
 		Done:                  ipt.semStop.Wait(),
 	}
 
@@ -170,12 +219,18 @@ func (ipt *Input) Run() {
 	if ipt.MultilineMatch != "" {
 		opt.MultilinePatterns = []string{ipt.MultilineMatch}
 	} else if ipt.AutoMultilineDetection {
-		if len(ipt.AutoMultilineExtraPatterns) != 0 {
+		if ipt.AutoMultilineLockPattern {
+			// the default candidates are used while detecting
+			opt.MultilinePatterns = ipt.AutoMultilineExtraPatterns
+			opt.AutoMultilineLockPattern = true
+			opt.AutoMultilineDetectionLines = ipt.AutoMultilineDetectLines
+			l.Infof("source %s automatic-multiline on, lock pattern, extra patterns %v", ipt.Source, ipt.AutoMultilineExtraPatterns)
+		} else if len(ipt.AutoMultilineExtraPatterns) != 0 {
 			opt.MultilinePatterns = ipt.AutoMultilineExtraPatterns
 			l.Infof("source %s automatic-multiline on, patterns %v", ipt.Source, ipt.AutoMultilineExtraPatterns)
 		} else {
 			opt.MultilinePatterns = multiline.GlobalPatterns
-			l.Info("source %s automatic-multiline on, use default patterns", ipt.Source)
+			l.Infof("source %s automatic-multiline on, use default patterns", ipt.Source)
 		}
 	}
 
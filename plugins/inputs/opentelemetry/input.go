@@ -68,6 +68,31 @@ const (
   # [inputs.opentelemetry.sampler]
     # sampling_rate = 1.0
 
+  ## Tail sampler config buffers spans of the same trace for a decision window, then keeps or
+  ## drops the whole trace. If configured, error penetration, rare resource keeper and sampler
+  ## above will be replaced by the policies here.
+  ## decision_wait is the time window to wait for spans of a trace.
+  ## max_traces is the max number of buffered traces, the oldest one is decided in advance if exceeded.
+  ## keep_error keeps traces which contain error spans.
+  ## latency_threshold keeps traces whose duration exceed the threshold.
+  ## sampling_rate is the sampling rate of traces not hit by the policies above, default 1.0(all kept).
+  ## keep_resource keeps traces which contain resources matching the regular expressions, like
+  ## service:[resources...], service "*" stands for all services.
+  ## service_rate_limit is the max traces per second kept for service of the root span,
+  ## service "*" stands for every service.
+  # [inputs.opentelemetry.tail_sampler]
+    # decision_wait = "10s"
+    # max_traces = 50000
+    # keep_error = true
+    # latency_threshold = "3s"
+    # sampling_rate = 0.1
+    # [inputs.opentelemetry.tail_sampler.keep_resource]
+      # service1 = ["resource1", "resource2", ...]
+      # "*" = ["keep_resource_under_all_services"]
+    # [inputs.opentelemetry.tail_sampler.service_rate_limit]
+      # service1 = 10.0
+      # "*" = 100.0
+
   # [inputs.opentelemetry.tags]
     # key1 = "value1"
     # key2 = "value2"
@@ -87,10 +112,20 @@ const (
     # path = "./otel_storage"
     # capacity = 5120
 
-  ## OTEL agent HTTP config for trace and metrics
-  ## If enable set to be true, trace and metrics will be received on path respectively:
+  ## OTEL logs config.
+  ## source is the logging source(measurement name) of received logs, if not set, the
+  ## resource attribute 'service.name' will be used, or fallback to 'opentelemetry'.
+  ## pipeline is the pipeline script name applied on received logs, if not set, the
+  ## script named as <source>.p will be used.
+  # [inputs.opentelemetry.logging]
+    # source = "opentelemetry"
+    # pipeline = "opentelemetry.p"
+
+  ## OTEL agent HTTP config for trace, metrics and logs
+  ## If enable set to be true, trace, metrics and logs will be received on path respectively:
   ## trace : /otel/v1/trace
   ## metric: /otel/v1/metric
+  ## logs  : /otel/v1/logs
   ## and the client side should be configured properly with Datakit listening port(default: 9529)
   ## for example http://127.0.0.1:9529/otel/v1/trace
   ## The acceptable http_status_ok values will be 200 or 202.
@@ -98,12 +133,13 @@ const (
    enable = true
    http_status_ok = 200
 
-  ## OTEL agent GRPC config for trace and metrics.
-  ## GRPC services for trace and metrics can be enabled respectively as setting either to be true.
+  ## OTEL agent GRPC config for trace, metrics and logs.
+  ## GRPC services for trace, metrics and logs can be enabled respectively as setting either to be true.
   ## add is the listening on address for GRPC server.
   [inputs.opentelemetry.grpc]
    trace_enable = true
    metric_enable = true
+   log_enable = false
    addr = "127.0.0.1:4317"
 
   ## If 'expectedHeaders' is well configed, then the obligation of sending certain wanted HTTP headers is on the client side,
@@ -137,6 +173,7 @@ type httpConfig struct {
 type grpcConfig struct {
 	TraceEnabled  bool   `toml:"trace_enable"`
 	MetricEnabled bool   `toml:"metric_enable"`
+	LogEnabled    bool   `toml:"log_enable"`
 	Address       string `toml:"addr"`
 }
 
@@ -150,9 +187,11 @@ type Input struct {
 	CloseResource       map[string][]string          `toml:"close_resource"`
 	OmitErrStatus       []string                     `toml:"omit_err_status"`
 	Sampler             *itrace.Sampler              `toml:"sampler"`
+	TailSampler         *itrace.TailSampler          `toml:"tail_sampler"`
 	Tags                map[string]string            `toml:"tags"`
 	WPConfig            *workerpool.WorkerPoolConfig `toml:"threads"`
 	LocalCacheConfig    *storage.StorageConfig       `toml:"storage"`
+	LogConfig           *logConfig                   `toml:"logging"`
 }
 
 func (*Input) Catalog() string { return inputName }
@@ -162,7 +201,7 @@ func (*Input) AvailableArchs() []string { return datakit.AllOS }
 func (*Input) SampleConfig() string { return sampleConfig }
 
 func (*Input) SampleMeasurement() []inputs.Measurement {
-	return []inputs.Measurement{&itrace.TraceMeasurement{Name: inputName}}
+	return []inputs.Measurement{&itrace.TraceMeasurement{Name: inputName}, &logMeasurement{}}
 }
 
 func (ipt *Input) RegHTTPHandler() {
@@ -235,6 +274,14 @@ func (ipt *Input) RegHTTPHandler() {
 		closeResource.UpdateIgnResList(ipt.CloseResource)
 		afterGather.AppendFilter(closeResource.Close)
 	}
+	// add tail sampler, it holds traces and skips all filters afterwards
+	if ipt.TailSampler != nil {
+		if err := ipt.TailSampler.Start(inputName, afterGather); err != nil {
+			log.Errorf("### start tail sampler failed: %s", err.Error())
+		} else {
+			afterGather.AppendFilter(ipt.TailSampler.Sample)
+		}
+	}
 	// add error status penetration
 	afterGather.AppendFilter(itrace.PenetrateErrorTracing)
 	// add rare resource keeper
@@ -272,29 +319,37 @@ func (ipt *Input) RegHTTPHandler() {
 
 	log.Debugf("### register handler for /otel/v1/metric of agent %s", inputName)
 	dkhttp.RegHTTPHandler("POST", "/otel/v1/metric", ihttp.CheckExpectedHeaders(handleOTElMetrics, log, expectedHeaders))
+
+	log.Debugf("### register handler for /otel/v1/logs of agent %s", inputName)
+	dkhttp.RegHTTPHandler("POST", "/otel/v1/logs", ihttp.CheckExpectedHeaders(handleOTELLogs, log, expectedHeaders))
 }
 
 func (ipt *Input) Run() {
 	if (ipt.HTTPConfig == nil || !ipt.HTTPConfig.Enabled) &&
-		(ipt.GRPCConfig == nil || (!ipt.GRPCConfig.MetricEnabled && !ipt.GRPCConfig.TraceEnabled)) {
+		(ipt.GRPCConfig == nil || (!ipt.GRPCConfig.MetricEnabled && !ipt.GRPCConfig.TraceEnabled && !ipt.GRPCConfig.LogEnabled)) {
 		log.Debugf("### All OpenTelemetry web protocol are not enabled")
 
 		return
 	}
 
 	tags = ipt.Tags
+	if ipt.LogConfig != nil {
+		logCfg = ipt.LogConfig
+	}
 	for i := range ipt.IgnoreAttributeKeys {
 		ignoreKeyRegExps = append(ignoreKeyRegExps, regexp.MustCompile(ipt.IgnoreAttributeKeys[i]))
 	}
 	getAttribute = getAttrWrapper(ignoreKeyRegExps)
 	extractAtrribute = extractAttrWrapper(ignoreKeyRegExps)
 
-	g := goroutine.NewGroup(goroutine.Option{Name: "inputs_opentelemetry"})
-	g.Go(func(ctx context.Context) error {
-		runGRPCV1(ipt.GRPCConfig.Address)
+	if ipt.GRPCConfig != nil {
+		g := goroutine.NewGroup(goroutine.Option{Name: "inputs_opentelemetry"})
+		g.Go(func(ctx context.Context) error {
+			runGRPCV1(ipt.GRPCConfig)
 
-		return nil
-	})
+			return nil
+		})
+	}
 
 	log.Debugf("### %s agent is running...", inputName)
 
@@ -316,6 +371,10 @@ func (ipt *Input) Terminate() {
 	if otelSvr != nil {
 		otelSvr.GracefulStop()
 	}
+	if ipt.TailSampler != nil {
+		ipt.TailSampler.Stop()
+		log.Info("### tail sampler stopped")
+	}
 }
 
 func init() { //nolint:gochecknoinits
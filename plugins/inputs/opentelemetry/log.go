@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package opentelemetry
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	itrace "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/trace"
+	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/pipeline"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
+	commonpb "gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/common"
+	logspb "gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/logs"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// logConfig used to define how OTEL logs are turned into datakit logging.
+type logConfig struct {
+	Source   string `toml:"source"`
+	Pipeline string `toml:"pipeline"`
+}
+
+var logCfg = &logConfig{}
+
+type logMeasurement struct{}
+
+func (*logMeasurement) LineProto() (*point.Point, error) { return nil, nil }
+
+//nolint:lll
+func (*logMeasurement) Info() *inputs.MeasurementInfo {
+	return &inputs.MeasurementInfo{
+		Type: "logging",
+		Name: "opentelemetry",
+		Desc: "The measurement name is the configured `source`, or the resource attribute `service.name`, or `opentelemetry` by default. All resource, scope and log record attributes are added as tags(or fields if the value is not string).",
+		Tags: map[string]interface{}{
+			itrace.TAG_SERVICE: inputs.NewTagInfo("Service name from resource attribute `service.name`"),
+		},
+		Fields: map[string]interface{}{
+			pipeline.FieldMessage: &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Log body, non-string body is encoded as JSON"},
+			pipeline.FieldStatus:  &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Log status converted from OTEL severity"},
+			itrace.FIELD_TRACEID:  &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Trace ID of the log record"},
+			itrace.FIELD_SPANID:   &inputs.FieldInfo{DataType: inputs.String, Unit: inputs.UnknownUnit, Desc: "Span ID of the log record"},
+		},
+	}
+}
+
+// otelSeverityText map OTEL severity text to datakit logging status, only used
+// when severity number is unspecified.
+var otelSeverityText = map[string]string{
+	"trace":    "debug",
+	"debug":    "debug",
+	"info":     "info",
+	"notice":   "notice",
+	"warn":     "warning",
+	"warning":  "warning",
+	"error":    "error",
+	"critical": "critical",
+	"fatal":    "critical",
+	"alert":    "alert",
+	"emerg":    "emerg",
+}
+
+// getDKLogStatus convert OTEL log severity to datakit logging status.
+// See https://opentelemetry.io/docs/reference/specification/logs/data-model/#field-severitynumber
+func getDKLogStatus(number logspb.SeverityNumber, text string) string {
+	switch {
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_FATAL:
+		return "critical"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_ERROR:
+		return "error"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_WARN:
+		return "warning"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_INFO:
+		return "info"
+	case number >= logspb.SeverityNumber_SEVERITY_NUMBER_TRACE:
+		return "debug"
+	}
+
+	if status, ok := otelSeverityText[strings.ToLower(text)]; ok {
+		return status
+	}
+
+	return pipeline.DefaultStatus
+}
+
+// getLogBody convert OTEL log body to logging message. Non-string body will
+// be encoded as JSON.
+func getLogBody(body *commonpb.AnyValue) string {
+	if body == nil {
+		return ""
+	}
+
+	if v, ok := body.Value.(*commonpb.AnyValue_StringValue); ok {
+		return v.StringValue
+	}
+
+	buf, err := protojson.Marshal(body)
+	if err != nil {
+		log.Warn(err.Error())
+
+		return ""
+	}
+
+	return string(buf)
+}
+
+func parseResourceLogs(reslogs []*logspb.ResourceLogs) []*point.Point {
+	var pts []*point.Point
+	for _, reslog := range reslogs {
+		var service string
+		if reslog.Resource != nil {
+			if attr, ok := getAttribute(otelResourceServiceKey, reslog.Resource.Attributes); ok {
+				service = attr.Value.GetStringValue()
+			}
+		}
+
+		restags, resfields := extractAtrribute(reslog.Resource.GetAttributes())
+		restags = itrace.MergeTags(tags, restags)
+
+		for _, scopelogs := range reslog.ScopeLogs {
+			scopetags, scopefields := extractAtrribute(scopelogs.Scope.GetAttributes())
+			scopetags = itrace.MergeTags(restags, scopetags)
+			scopefields = itrace.MergeFields(resfields, scopefields)
+
+			for _, record := range scopelogs.LogRecords {
+				logtags, logfields := extractAtrribute(record.Attributes)
+				logtags = itrace.MergeTags(scopetags, logtags)
+				logfields = itrace.MergeFields(scopefields, logfields)
+
+				if service != "" {
+					logtags[itrace.TAG_SERVICE] = service
+				}
+				if len(record.TraceId) != 0 {
+					logfields[itrace.FIELD_TRACEID] = hex.EncodeToString(record.TraceId)
+				}
+				if len(record.SpanId) != 0 {
+					logfields[itrace.FIELD_SPANID] = hex.EncodeToString(record.SpanId)
+				}
+				logfields[pipeline.FieldMessage] = getLogBody(record.Body)
+				logfields[pipeline.FieldStatus] = getDKLogStatus(record.SeverityNumber, record.SeverityText)
+
+				var tm time.Time
+				switch {
+				case record.TimeUnixNano != 0:
+					tm = time.Unix(0, int64(record.TimeUnixNano))
+				case record.ObservedTimeUnixNano != 0:
+					tm = time.Unix(0, int64(record.ObservedTimeUnixNano))
+				default:
+					tm = time.Now()
+				}
+
+				// same as tracing, the '.' within attribute keys are replaced with '_'
+				ptTags := make(map[string]string, len(logtags))
+				for k, v := range logtags {
+					ptTags[strings.ReplaceAll(k, ".", "_")] = v
+				}
+				ptFields := make(map[string]interface{}, len(logfields))
+				for k, v := range logfields {
+					ptFields[strings.ReplaceAll(k, ".", "_")] = v
+				}
+
+				if pt, err := point.NewPoint(logSource(service), ptTags, ptFields,
+					&point.PointOption{Time: tm, Category: datakit.Logging}); err != nil {
+					log.Debugf(err.Error())
+				} else {
+					pts = append(pts, pt)
+				}
+			}
+		}
+	}
+
+	return pts
+}
+
+// logSource return the configured logging source, if not configured, the
+// service name of resource used, or fallback to input name.
+func logSource(service string) string {
+	switch {
+	case logCfg.Source != "":
+		return logCfg.Source
+	case service != "":
+		return service
+	default:
+		return inputName
+	}
+}
+
+func feedLogs(pts []*point.Point) error {
+	if len(pts) == 0 {
+		return nil
+	}
+
+	// logs from different services may have different source, apply the
+	// same configured pipeline on all of them.
+	var opt *dkio.Option
+	if logCfg.Pipeline != "" {
+		opt = &dkio.Option{PlScript: map[string]string{}}
+		for _, pt := range pts {
+			opt.PlScript[pt.Name()] = logCfg.Pipeline
+		}
+	}
+
+	return dkio.Feed(inputName, datakit.Logging, pts, opt)
+}
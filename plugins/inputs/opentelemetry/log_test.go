@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package opentelemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/common"
+	logspb "gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/logs"
+	resourcepb "gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/resource"
+)
+
+func strAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func TestGetDKLogStatus(t *testing.T) {
+	cases := []struct {
+		number logspb.SeverityNumber
+		text   string
+		expect string
+	}{
+		{logspb.SeverityNumber_SEVERITY_NUMBER_TRACE2, "", "debug"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, "", "debug"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_INFO4, "", "info"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "", "warning"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_ERROR3, "", "error"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, "", "critical"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "INFO", "error"}, // number takes precedence
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "WARN", "warning"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "Fatal", "critical"},
+		{logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "", "unknown"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expect, getDKLogStatus(tc.number, tc.text), "%v/%q", tc.number, tc.text)
+	}
+}
+
+func TestParseResourceLogs(t *testing.T) {
+	getAttribute = getAttrWrapper(nil)
+	extractAtrribute = extractAttrWrapper(nil)
+	tags = map[string]string{"from": "otel"}
+
+	reslogs := []*logspb.ResourceLogs{
+		{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+				strAttr(otelResourceServiceKey, "checkout"),
+				strAttr("host.name", "node-1"),
+			}},
+			ScopeLogs: []*logspb.ScopeLogs{
+				{
+					Scope: &commonpb.InstrumentationScope{Name: "logger"},
+					LogRecords: []*logspb.LogRecord{
+						{
+							TimeUnixNano:   1670000000000000000,
+							SeverityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+							Body:           &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "payment failed"}},
+							Attributes:     []*commonpb.KeyValue{strAttr("order", "1234")},
+							TraceId:        []byte{0x01, 0x02},
+							SpanId:         []byte{0x03},
+						},
+						{
+							SeverityText: "info",
+							Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{
+								Values: []*commonpb.KeyValue{strAttr("k", "v")},
+							}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("source-from-service", func(t *testing.T) {
+		logCfg = &logConfig{}
+
+		pts := parseResourceLogs(reslogs)
+		require.Len(t, pts, 2)
+
+		pt := pts[0]
+		assert.Equal(t, "checkout", pt.Name())
+		assert.Equal(t, int64(1670000000000000000), pt.Time().UnixNano())
+
+		ptTags := pt.Tags()
+		assert.Equal(t, "checkout", ptTags["service"])
+		assert.Equal(t, "node-1", ptTags["host_name"])
+		assert.Equal(t, "1234", ptTags["order"])
+		assert.Equal(t, "otel", ptTags["from"])
+
+		fields, err := pt.Fields()
+		require.NoError(t, err)
+		assert.Equal(t, "payment failed", fields["message"])
+		assert.Equal(t, "error", fields["status"])
+		assert.Equal(t, "0102", fields["trace_id"])
+		assert.Equal(t, "03", fields["span_id"])
+
+		fields, err = pts[1].Fields()
+		require.NoError(t, err)
+		assert.Equal(t, "info", fields["status"])
+		assert.Contains(t, fields["message"], `"k"`)
+	})
+
+	t.Run("source-from-config", func(t *testing.T) {
+		logCfg = &logConfig{Source: "otel-logs"}
+		defer func() { logCfg = &logConfig{} }()
+
+		pts := parseResourceLogs(reslogs)
+		require.Len(t, pts, 2)
+		assert.Equal(t, "otel-logs", pts[0].Name())
+	})
+}
@@ -12,24 +12,32 @@ import (
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/logs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/metrics"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/trace"
 	"google.golang.org/grpc"
 	_ "google.golang.org/grpc/encoding/gzip"
 )
 
-func runGRPCV1(addr string) {
-	listener, err := net.Listen("tcp", addr)
+func runGRPCV1(cfg *grpcConfig) {
+	listener, err := net.Listen("tcp", cfg.Address)
 	if err != nil {
-		log.Errorf("### opentelemetry grpc server v1 listening on %s failed: %v", addr, err.Error())
+		log.Errorf("### opentelemetry grpc server v1 listening on %s failed: %v", cfg.Address, err.Error())
 
 		return
 	}
-	log.Debugf("### opentelemetry grpc v1 listening on: %s", addr)
+	log.Debugf("### opentelemetry grpc v1 listening on: %s", cfg.Address)
 
 	otelSvr = grpc.NewServer()
-	trace.RegisterTraceServiceServer(otelSvr, &TraceServiceServer{})
-	metrics.RegisterMetricsServiceServer(otelSvr, &MetricsServiceServer{})
+	if cfg.TraceEnabled {
+		trace.RegisterTraceServiceServer(otelSvr, &TraceServiceServer{})
+	}
+	if cfg.MetricEnabled {
+		metrics.RegisterMetricsServiceServer(otelSvr, &MetricsServiceServer{})
+	}
+	if cfg.LogEnabled {
+		logs.RegisterLogsServiceServer(otelSvr, &LogsServiceServer{})
+	}
 
 	if err = otelSvr.Serve(listener); err != nil {
 		log.Error(err.Error())
@@ -76,3 +84,17 @@ func (mss *MetricsServiceServer) Export(ctx context.Context, msreq *metrics.Expo
 
 	return &metrics.ExportMetricsServiceResponse{}, nil
 }
+
+type LogsServiceServer struct {
+	logs.UnimplementedLogsServiceServer
+}
+
+func (lss *LogsServiceServer) Export(ctx context.Context, lsreq *logs.ExportLogsServiceRequest) (
+	*logs.ExportLogsServiceResponse, error,
+) {
+	if err := feedLogs(parseResourceLogs(lsreq.ResourceLogs)); err != nil {
+		log.Error(err.Error())
+	}
+
+	return &logs.ExportLogsServiceResponse{}, nil
+}
@@ -12,6 +12,7 @@ import (
 	itrace "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/trace"
 	dkio "gitlab.jiagouyun.com/cloudcare-tools/datakit/io"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/logs"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/metrics"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs/opentelemetry/compiled/v1/collector/trace"
 	"google.golang.org/protobuf/encoding/protojson"
@@ -114,3 +115,36 @@ func handleOTElMetrics(resp http.ResponseWriter, req *http.Request) {
 		}
 	}
 }
+
+func handleOTELLogs(resp http.ResponseWriter, req *http.Request) {
+	media, _, buf, err := itrace.ParseTracerRequest(req)
+	if err != nil {
+		log.Error(err.Error())
+		resp.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	lsreq := &logs.ExportLogsServiceRequest{}
+	switch media {
+	case "application/x-protobuf":
+		err = proto.Unmarshal(buf, lsreq)
+	case "application/json":
+		err = protojson.Unmarshal(buf, lsreq)
+	default:
+		log.Error("unrecognized Content-Type")
+		resp.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	if err != nil {
+		log.Error(err.Error())
+		resp.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	if err = feedLogs(parseResourceLogs(lsreq.ResourceLogs)); err != nil {
+		log.Error(err.Error())
+	}
+}
@@ -10,9 +10,33 @@ const sampleCfg = `
   # Exporter URLs.
   # urls = ["http://127.0.0.1:9100/metrics", "http://127.0.0.1:9200/metrics"]
 
+  ## Discover targets from Prometheus file_sd files(JSON/YAML), glob pattern supported.
+  # Files are reloaded once changed, targets are added and removed at runtime.
+  # Labels of the targets are added as tags.
+  # [inputs.prom.file_sd]
+  #   files = ["/path/to/targets/*.json", "/path/to/targets/*.yaml"]
+  #   refresh_interval = "5m"
+  #   scheme = "http"
+  #   metrics_path = "/metrics"
+
+  ## Discover targets by DNS records, type is one of SRV(default), A and AAAA.
+  # Port is required for A/AAAA records.
+  # [inputs.prom.dns_sd]
+  #   names = ["_node-exporter._tcp.example.com"]
+  #   type = "SRV"
+  #   port = 9100
+  #   refresh_interval = "30s"
+  #   scheme = "http"
+  #   metrics_path = "/metrics"
+
   # Unix Domain Socket URL. Using socket to request data when not empty.
   uds_path = ""
 
+  ## Exposition formats negotiated in order, optional: protobuf, openmetrics, text.
+  # Native histograms and exemplars are only exposed in protobuf/openmetrics.
+  # Not negotiated if empty, and the response is parsed as text format.
+  # scrape_protocols = ["protobuf", "openmetrics", "text"]
+
   # Ignore URL request errors.
   ignore_req_err = false
 
@@ -31,7 +55,7 @@ const sampleCfg = `
   # Default is 32MB.
   # max_file_size = 0
 
-  ## Metrics type whitelist. Optional: counter, gauge, histogram, summary
+  ## Metrics type whitelist. Optional: counter, gauge, histogram, summary, gaugehistogram
   # Default only collect 'counter' and 'gauge'.
   # Collect all if empty.
   metric_types = ["counter", "gauge"]
@@ -82,6 +106,20 @@ const sampleCfg = `
   # prefix = "mem_"
   # name = "mem"
 
+  ## Prometheus metric_relabel_configs, applied to each sample in order with
+  # the metric name as label '__name__'.
+  # Actions: replace(default), keep, drop, keepequal, dropequal, hashmod, labelmap, labeldrop, labelkeep, lowercase, uppercase.
+  # [[inputs.prom.metric_relabel_configs]]
+  #   source_labels = ["__name__"]
+  #   regex = "go_.*"
+  #   action = "drop"
+
+  ## Prometheus relabel_configs, applied to the targets of file_sd/dns_sd before scraping.
+  # [[inputs.prom.relabel_configs]]
+  #   source_labels = ["__meta_dns_srv_record_target"]
+  #   regex = "([^.]+)\\..*"
+  #   target_label = "node"
+
   # Not collecting those data when tag matched.
   [inputs.prom.ignore_tag_kv_match]
   # key1 = [ "val1.*", "val2.*"]
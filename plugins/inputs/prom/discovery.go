@@ -0,0 +1,615 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/io/point"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	defaultFileSDRefreshInterval = 5 * time.Minute
+	defaultDNSSDRefreshInterval  = 30 * time.Second
+	dnsLookupTimeout             = 10 * time.Second
+
+	// maxDiscoveredScrapes is the max number of discovered targets scraped at the same time.
+	maxDiscoveredScrapes = 16
+
+	defaultScheme      = "http"
+	defaultMetricsPath = "/metrics"
+
+	// Labels prefixed by reservedLabelPrefix are used to build the target
+	// and are not kept as tags, same as Prometheus.
+	reservedLabelPrefix = "__"
+
+	labelAddress     = "__address__"
+	labelScheme      = "__scheme__"
+	labelMetricsPath = "__metrics_path__"
+
+	labelDNSName      = "__meta_dns_name"
+	labelDNSSRVTarget = "__meta_dns_srv_record_target"
+	labelDNSSRVPort   = "__meta_dns_srv_record_port"
+
+	tagInstance = "instance"
+
+	dnsTypeSRV  = "SRV"
+	dnsTypeA    = "A"
+	dnsTypeAAAA = "AAAA"
+)
+
+// FileSD discovers targets from JSON/YAML files of Prometheus file_sd format:
+//
+//	[
+//	  {
+//	    "targets": ["10.0.0.1:9100", "10.0.0.2:9100"],
+//	    "labels": {"env": "prod"}
+//	  }
+//	]
+type FileSD struct {
+	// Files are paths of target files, glob pattern supported.
+	Files []string `toml:"files" json:"files"`
+	// Files are checked on each collection and reloaded once changed, they
+	// are also reloaded every RefreshInterval.
+	RefreshInterval string `toml:"refresh_interval" json:"refresh_interval"`
+	Scheme          string `toml:"scheme" json:"scheme"`
+	MetricsPath     string `toml:"metrics_path" json:"metrics_path"`
+}
+
+// DNSSD discovers targets by DNS SRV or A/AAAA records.
+type DNSSD struct {
+	Names []string `toml:"names" json:"names"`
+	// Type is one of SRV, A and AAAA, default SRV.
+	Type string `toml:"type" json:"type"`
+	// Port is required for A/AAAA records.
+	Port            int    `toml:"port" json:"port"`
+	RefreshInterval string `toml:"refresh_interval" json:"refresh_interval"`
+	Scheme          string `toml:"scheme" json:"scheme"`
+	MetricsPath     string `toml:"metrics_path" json:"metrics_path"`
+}
+
+// target is a discovered scrape target.
+type target struct {
+	url    string
+	labels map[string]string
+}
+
+// newTarget builds the URL of the target from the address and labels
+// __scheme__ and __metrics_path__, the address may be a full URL.
+func newTarget(addr string, labels map[string]string, scheme, metricsPath string) (*target, error) {
+	ls := map[string]string{}
+	for k, v := range labels {
+		ls[k] = v
+	}
+	ls[labelAddress] = addr
+
+	if _, ok := ls[labelScheme]; !ok {
+		ls[labelScheme] = scheme
+	}
+	if _, ok := ls[labelMetricsPath]; !ok {
+		ls[labelMetricsPath] = metricsPath
+	}
+
+	if ls[labelScheme] == "" {
+		ls[labelScheme] = defaultScheme
+	}
+	if ls[labelMetricsPath] == "" {
+		ls[labelMetricsPath] = defaultMetricsPath
+	}
+
+	if strings.Contains(addr, "://") {
+		if u, err := url.Parse(addr); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid target URL %q", addr)
+		}
+		return &target{url: addr, labels: ls}, nil
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid target address %q: %w", addr, err)
+	}
+
+	path := ls[labelMetricsPath]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return &target{url: ls[labelScheme] + "://" + addr + path, labels: ls}, nil
+}
+
+// tags returns the labels not reserved, the instance tag is the address of
+// the target if not set.
+func (t *target) tags() map[string]string {
+	tags := map[string]string{}
+	for k, v := range t.labels {
+		if !strings.HasPrefix(k, reservedLabelPrefix) {
+			tags[k] = v
+		}
+	}
+
+	if _, ok := tags[tagInstance]; !ok {
+		tags[tagInstance] = t.labels[labelAddress]
+	}
+	return tags
+}
+
+// key identifies the target, a target with labels changed is a new one.
+func (t *target) key() string {
+	tags := t.tags()
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(t.url)
+	for _, k := range keys {
+		sb.WriteString("," + k + "=" + tags[k])
+	}
+	return sb.String()
+}
+
+type discoverer interface {
+	// discover returns all targets, targets of the last discovery are kept
+	// on error.
+	discover(now time.Time) ([]*target, error)
+}
+
+func newDiscoverers(i *Input) ([]discoverer, error) {
+	var res []discoverer
+
+	if i.FileSD != nil && len(i.FileSD.Files) > 0 {
+		d, err := newFileDiscoverer(i.FileSD)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+
+	if i.DNSSD != nil && len(i.DNSSD.Names) > 0 {
+		d, err := newDNSDiscoverer(i.DNSSD)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+
+	return res, nil
+}
+
+func parseRefreshInterval(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+
+	du, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh_interval %q: %w", s, err)
+	}
+	if du <= 0 {
+		return def, nil
+	}
+	return du, nil
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+type fileDiscoverer struct {
+	cfg      *FileSD
+	interval time.Duration
+
+	lastRefresh time.Time
+	stats       map[string]fileStat
+	targets     map[string][]*target // targets of each file
+}
+
+func newFileDiscoverer(cfg *FileSD) (*fileDiscoverer, error) {
+	interval, err := parseRefreshInterval(cfg.RefreshInterval, defaultFileSDRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range cfg.Files {
+		if _, err := filepath.Match(f, ""); err != nil {
+			return nil, fmt.Errorf("invalid file_sd pattern %q: %w", f, err)
+		}
+	}
+
+	return &fileDiscoverer{
+		cfg:      cfg,
+		interval: interval,
+		stats:    map[string]fileStat{},
+		targets:  map[string][]*target{},
+	}, nil
+}
+
+func (d *fileDiscoverer) discover(now time.Time) ([]*target, error) {
+	stats := map[string]fileStat{}
+	for _, pattern := range d.cfg.Files {
+		files, _ := filepath.Glob(pattern) // the pattern is checked on creating
+		for _, f := range files {
+			fi, err := os.Stat(f)
+			if err != nil || fi.IsDir() {
+				continue
+			}
+			stats[f] = fileStat{modTime: fi.ModTime(), size: fi.Size()}
+		}
+	}
+
+	force := now.Sub(d.lastRefresh) >= d.interval
+	if force {
+		d.lastRefresh = now
+	}
+
+	var lastErr error
+
+	// files removed
+	for f := range d.stats {
+		if _, ok := stats[f]; !ok {
+			delete(d.targets, f)
+			delete(d.stats, f)
+		}
+	}
+
+	for f, st := range stats {
+		if old, ok := d.stats[f]; ok && old == st && !force {
+			continue
+		}
+
+		// not retried until the file changed
+		d.stats[f] = st
+
+		targets, err := d.readFile(f)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		d.targets[f] = targets
+	}
+
+	return d.allTargets(), lastErr
+}
+
+func (d *fileDiscoverer) allTargets() []*target {
+	files := make([]string, 0, len(d.targets))
+	for f := range d.targets {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	var res []*target
+	for _, f := range files {
+		res = append(res, d.targets[f]...)
+	}
+	return res
+}
+
+// targetGroup is the content of file_sd files.
+type targetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+}
+
+func (d *fileDiscoverer) readFile(f string) ([]*target, error) {
+	data, err := os.ReadFile(filepath.Clean(f))
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*targetGroup
+	switch ext := strings.ToLower(filepath.Ext(f)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &groups)
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, &groups)
+	default:
+		return nil, fmt.Errorf("file_sd %s: unsupported file extension %q", f, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file_sd %s: %w", f, err)
+	}
+
+	var res []*target
+	for _, g := range groups {
+		if g == nil {
+			continue
+		}
+		for _, addr := range g.Targets {
+			t, err := newTarget(addr, g.Labels, d.cfg.Scheme, d.cfg.MetricsPath)
+			if err != nil {
+				return nil, fmt.Errorf("file_sd %s: %w", f, err)
+			}
+			res = append(res, t)
+		}
+	}
+
+	return res, nil
+}
+
+type dnsDiscoverer struct {
+	cfg      *DNSSD
+	qtype    string
+	interval time.Duration
+
+	lastRefresh time.Time
+	targets     map[string][]*target // targets of each name
+
+	lookupSRV func(ctx context.Context, name string) ([]*net.SRV, error)
+	lookupIP  func(ctx context.Context, network, name string) ([]net.IP, error)
+}
+
+func newDNSDiscoverer(cfg *DNSSD) (*dnsDiscoverer, error) {
+	interval, err := parseRefreshInterval(cfg.RefreshInterval, defaultDNSSDRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	qtype := strings.ToUpper(cfg.Type)
+	switch qtype {
+	case "":
+		qtype = dnsTypeSRV
+	case dnsTypeSRV:
+	case dnsTypeA, dnsTypeAAAA:
+		if cfg.Port <= 0 || cfg.Port > 65535 {
+			return nil, fmt.Errorf("dns_sd: invalid port %d for %s records", cfg.Port, qtype)
+		}
+	default:
+		return nil, fmt.Errorf("dns_sd: unsupported type %q", cfg.Type)
+	}
+
+	return &dnsDiscoverer{
+		cfg:      cfg,
+		qtype:    qtype,
+		interval: interval,
+		targets:  map[string][]*target{},
+		lookupSRV: func(ctx context.Context, name string) ([]*net.SRV, error) {
+			_, srvs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+			return srvs, err
+		},
+		lookupIP: net.DefaultResolver.LookupIP,
+	}, nil
+}
+
+func (d *dnsDiscoverer) discover(now time.Time) ([]*target, error) {
+	if !d.lastRefresh.IsZero() && now.Sub(d.lastRefresh) < d.interval {
+		return d.allTargets(), nil
+	}
+	d.lastRefresh = now
+
+	var lastErr error
+	for _, name := range d.cfg.Names {
+		targets, err := d.resolve(name)
+		if err != nil {
+			lastErr = fmt.Errorf("dns_sd %s: %w", name, err)
+			continue
+		}
+		d.targets[name] = targets
+	}
+
+	return d.allTargets(), lastErr
+}
+
+func (d *dnsDiscoverer) allTargets() []*target {
+	var res []*target
+	for _, name := range d.cfg.Names {
+		res = append(res, d.targets[name]...)
+	}
+	return res
+}
+
+func (d *dnsDiscoverer) resolve(name string) ([]*target, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	var res []*target
+
+	if d.qtype == dnsTypeSRV {
+		srvs, err := d.lookupSRV(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, srv := range srvs {
+			host := strings.TrimSuffix(srv.Target, ".")
+			port := strconv.Itoa(int(srv.Port))
+			t, err := newTarget(net.JoinHostPort(host, port), map[string]string{
+				labelDNSName:      name,
+				labelDNSSRVTarget: host,
+				labelDNSSRVPort:   port,
+			}, d.cfg.Scheme, d.cfg.MetricsPath)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, t)
+		}
+		return res, nil
+	}
+
+	network := "ip4"
+	if d.qtype == dnsTypeAAAA {
+		network = "ip6"
+	}
+
+	ips, err := d.lookupIP(ctx, network, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ip := range ips {
+		t, err := newTarget(net.JoinHostPort(ip.String(), strconv.Itoa(d.cfg.Port)),
+			map[string]string{labelDNSName: name}, d.cfg.Scheme, d.cfg.MetricsPath)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// relabelTarget applies the relabel configs to labels of the target, false is
+// returned if the target is dropped.
+func relabelTarget(t *target, cfgs iprom.RelabelConfigs) (*target, bool) {
+	labels := make(map[string]string, len(t.labels))
+	for k, v := range t.labels {
+		labels[k] = v
+	}
+
+	if !cfgs.Process(labels) {
+		return nil, false
+	}
+
+	addr, ok := labels[labelAddress]
+	if !ok {
+		return nil, false
+	}
+
+	res, err := newTarget(addr, labels, "", "")
+	if err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// sdTarget is a discovered target being scraped.
+type sdTarget struct {
+	*target
+	pm *iprom.Prom
+}
+
+// refreshTargets adds new targets discovered and removes the missing ones.
+func (i *Input) refreshTargets() {
+	now := time.Now()
+	targets := map[string]*target{}
+	for _, d := range i.discoverers {
+		ts, err := d.discover(now)
+		if err != nil {
+			i.l.Warnf("service discovery: %s", err)
+		}
+		for _, t := range ts {
+			if len(i.RelabelConfigs) > 0 {
+				var ok bool
+				if t, ok = relabelTarget(t, i.RelabelConfigs); !ok {
+					continue
+				}
+			}
+			targets[t.key()] = t
+		}
+	}
+
+	for k, t := range i.sdTargets {
+		if _, ok := targets[k]; !ok {
+			i.l.Infof("target %s removed", t.url)
+			delete(i.sdTargets, k)
+		}
+	}
+
+	for k, t := range targets {
+		if _, ok := i.sdTargets[k]; ok {
+			continue
+		}
+
+		pm, err := i.newTargetProm(t)
+		if err != nil {
+			i.l.Warnf("target %s: %s, ignored", t.url, err)
+			continue
+		}
+
+		i.l.Infof("target %s added, tags: %v", t.url, t.tags())
+		i.sdTargets[k] = &sdTarget{target: t, pm: pm}
+	}
+}
+
+// newTargetProm creates the Prom of the target, tags of the target take
+// precedence over the configured tags.
+func (i *Input) newTargetProm(t *target) (*iprom.Prom, error) {
+	opt := *i.opt
+	opt.URL = ""
+	opt.URLs = []string{t.url}
+
+	opt.Tags = map[string]string{}
+	for k, v := range i.opt.Tags {
+		opt.Tags[k] = v
+	}
+	for k, v := range t.tags() {
+		opt.Tags[k] = v
+	}
+
+	return iprom.NewProm(&opt)
+}
+
+// collectDiscovered collects from discovered targets concurrently, at most
+// maxDiscoveredScrapes targets are scraped at the same time.
+func (i *Input) collectDiscovered() ([]*point.Point, error) {
+	if len(i.discoverers) == 0 {
+		return nil, nil
+	}
+
+	i.refreshTargets()
+
+	keys := make([]string, 0, len(i.sdTargets))
+	for k := range i.sdTargets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var (
+		results = make([][]*point.Point, len(keys))
+		errs    = make([]error, len(keys))
+		g       = goroutine.NewGroup(goroutine.Option{Name: "inputs_prom"})
+	)
+
+	n := len(keys)
+	if n > maxDiscoveredScrapes {
+		n = maxDiscoveredScrapes
+	}
+	if n > 0 {
+		g.GOMAXPROCS(n)
+	}
+
+	for idx, k := range keys {
+		idx, t := idx, i.sdTargets[k]
+		g.Go(func(ctx context.Context) error {
+			pts, err := t.pm.CollectFromHTTP(t.url)
+			if err != nil {
+				// other targets are not affected
+				errs[idx] = fmt.Errorf("collect from target %s: %w", t.url, err)
+				return nil
+			}
+			results[idx] = pts
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var (
+		points  []*point.Point
+		allErrs []error
+	)
+	for idx := range keys {
+		points = append(points, results[idx]...)
+		if errs[idx] != nil {
+			allErrs = append(allErrs, errs[idx])
+		}
+	}
+
+	return points, combineErrors(allErrs)
+}
@@ -0,0 +1,329 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package prom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
+)
+
+func TestNewTarget(t *testing.T) {
+	cases := []struct {
+		name        string
+		addr        string
+		labels      map[string]string
+		scheme      string
+		metricsPath string
+		url         string
+		tags        map[string]string
+		fail        bool
+	}{
+		{
+			name: "default",
+			addr: "10.0.0.1:9100",
+			url:  "http://10.0.0.1:9100/metrics",
+			tags: map[string]string{"instance": "10.0.0.1:9100"},
+		},
+		{
+			name:        "config",
+			addr:        "10.0.0.1:9100",
+			scheme:      "https",
+			metricsPath: "stats",
+			labels:      map[string]string{"env": "prod"},
+			url:         "https://10.0.0.1:9100/stats",
+			tags:        map[string]string{"instance": "10.0.0.1:9100", "env": "prod"},
+		},
+		{
+			name:   "labels",
+			addr:   "[::1]:9100",
+			scheme: "https",
+			labels: map[string]string{
+				"__scheme__":       "http",
+				"__metrics_path__": "/prom",
+				"__meta_xx":        "xx",
+				"instance":         "node-1",
+			},
+			url:  "http://[::1]:9100/prom",
+			tags: map[string]string{"instance": "node-1"},
+		},
+		{
+			name: "url",
+			addr: "https://example.com/federate?match[]=up",
+			url:  "https://example.com/federate?match[]=up",
+			tags: map[string]string{"instance": "https://example.com/federate?match[]=up"},
+		},
+		{
+			name: "no-port",
+			addr: "10.0.0.1",
+			fail: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tg, err := newTarget(tc.addr, tc.labels, tc.scheme, tc.metricsPath)
+			if tc.fail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.url, tg.url)
+			assert.Equal(t, tc.tags, tg.tags())
+		})
+	}
+}
+
+func targetURLs(targets []*target) []string {
+	var res []string
+	for _, t := range targets {
+		res = append(res, t.url)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestFileDiscoverer(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "a.json")
+	yamlFile := filepath.Join(dir, "b.yaml")
+
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[
+  {"targets": ["10.0.0.1:9100", "10.0.0.2:9100"], "labels": {"env": "prod"}}
+]`), 0o600))
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+- targets: ["10.0.1.1:8080"]
+  labels:
+    env: test
+    __metrics_path__: /stats
+`), 0o600))
+
+	d, err := newFileDiscoverer(&FileSD{Files: []string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yaml")}})
+	require.NoError(t, err)
+
+	now := time.Now()
+	targets, err := d.discover(now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"http://10.0.0.1:9100/metrics",
+		"http://10.0.0.2:9100/metrics",
+		"http://10.0.1.1:8080/stats",
+	}, targetURLs(targets))
+
+	// changed
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[{"targets": ["10.0.0.3:9100"]}]`), 0o600))
+	require.NoError(t, os.Chtimes(jsonFile, now.Add(time.Second), now.Add(time.Second)))
+	targets, err = d.discover(now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"http://10.0.0.3:9100/metrics",
+		"http://10.0.1.1:8080/stats",
+	}, targetURLs(targets))
+
+	// invalid content, last targets kept
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[{"targets": `), 0o600))
+	require.NoError(t, os.Chtimes(jsonFile, now.Add(2*time.Second), now.Add(2*time.Second)))
+	targets, err = d.discover(now.Add(2 * time.Second))
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"http://10.0.0.3:9100/metrics",
+		"http://10.0.1.1:8080/stats",
+	}, targetURLs(targets))
+
+	// removed
+	require.NoError(t, os.Remove(jsonFile))
+	targets, err = d.discover(now.Add(3 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.1.1:8080/stats"}, targetURLs(targets))
+
+	_, err = newFileDiscoverer(&FileSD{Files: []string{"[a-"}})
+	assert.Error(t, err)
+}
+
+func TestDNSDiscoverer(t *testing.T) {
+	t.Run("srv", func(t *testing.T) {
+		d, err := newDNSDiscoverer(&DNSSD{Names: []string{"_node._tcp.example.com"}, RefreshInterval: "1m"})
+		require.NoError(t, err)
+
+		srvs := []*net.SRV{{Target: "node-1.example.com.", Port: 9100}}
+		var lookupErr error
+		d.lookupSRV = func(_ context.Context, name string) ([]*net.SRV, error) {
+			return srvs, lookupErr
+		}
+
+		now := time.Now()
+		targets, err := d.discover(now)
+		require.NoError(t, err)
+		require.Len(t, targets, 1)
+		assert.Equal(t, "http://node-1.example.com:9100/metrics", targets[0].url)
+		assert.Equal(t, "_node._tcp.example.com", targets[0].labels[labelDNSName])
+		assert.Equal(t, map[string]string{"instance": "node-1.example.com:9100"}, targets[0].tags())
+
+		// not refreshed within the interval
+		srvs = append(srvs, &net.SRV{Target: "node-2.example.com.", Port: 9100})
+		targets, err = d.discover(now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Len(t, targets, 1)
+
+		targets, err = d.discover(now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"http://node-1.example.com:9100/metrics",
+			"http://node-2.example.com:9100/metrics",
+		}, targetURLs(targets))
+
+		// lookup failed, last targets kept
+		lookupErr = errors.New("no such host")
+		targets, err = d.discover(now.Add(2 * time.Minute))
+		assert.Error(t, err)
+		assert.Len(t, targets, 2)
+	})
+
+	t.Run("a", func(t *testing.T) {
+		d, err := newDNSDiscoverer(&DNSSD{Names: []string{"nodes.example.com"}, Type: "a", Port: 9100})
+		require.NoError(t, err)
+
+		d.lookupIP = func(_ context.Context, network, name string) ([]net.IP, error) {
+			assert.Equal(t, "ip4", network)
+			return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, nil
+		}
+
+		targets, err := d.discover(time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"http://10.0.0.1:9100/metrics",
+			"http://10.0.0.2:9100/metrics",
+		}, targetURLs(targets))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newDNSDiscoverer(&DNSSD{Names: []string{"x"}, Type: "A"})
+		assert.Error(t, err)
+
+		_, err = newDNSDiscoverer(&DNSSD{Names: []string{"x"}, Type: "MX"})
+		assert.Error(t, err)
+	})
+}
+
+func TestCollectDiscovered(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# TYPE node_load1 gauge\nnode_load1{cpu=\"0\"} 1.5\n")
+	}))
+	defer srv.Close()
+
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	f := filepath.Join(t.TempDir(), "targets.json")
+	require.NoError(t, os.WriteFile(f, []byte(fmt.Sprintf(`[
+  {"targets": ["%s"], "labels": {"env": "prod", "cluster": "c1"}}
+]`, addr)), 0o600))
+
+	i := NewProm()
+	i.Tags = map[string]string{"env": "default", "team": "ops"}
+	i.FileSD = &FileSD{Files: []string{f}}
+	require.NoError(t, i.Init())
+	assert.Nil(t, i.pm)
+
+	pts, err := i.Collect()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+	assert.Equal(t, "node", pts[0].Name())
+	assert.Equal(t, map[string]string{
+		"cpu":      "0",
+		"env":      "prod",
+		"team":     "ops",
+		"cluster":  "c1",
+		"instance": addr,
+	}, pts[0].Tags())
+
+	// target removed at runtime
+	require.NoError(t, os.WriteFile(f, []byte(`[]`), 0o600))
+	require.NoError(t, os.Chtimes(f, time.Now().Add(time.Second), time.Now().Add(time.Second)))
+	pts, err = i.Collect()
+	require.NoError(t, err)
+	assert.Len(t, pts, 0)
+	assert.Len(t, i.sdTargets, 0)
+}
+
+func TestCollectPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# TYPE node_load1 gauge\nnode_load1{cpu=\"0\"} 1.5\n")
+	}))
+	defer srv.Close()
+
+	// closed at once, scraping it fails
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var targets []string
+	for n := 0; n < maxDiscoveredScrapes+4; n++ {
+		targets = append(targets, fmt.Sprintf(`"%s"`, strings.TrimPrefix(srv.URL, "http://")))
+	}
+	targets = append(targets, fmt.Sprintf(`"%s"`, strings.TrimPrefix(down.URL, "http://")))
+
+	// the same address with different labels are different targets
+	var groups []string
+	for n, target := range targets {
+		groups = append(groups, fmt.Sprintf(`{"targets": [%s], "labels": {"idx": "%d"}}`, target, n))
+	}
+
+	f := filepath.Join(t.TempDir(), "targets.json")
+	require.NoError(t, os.WriteFile(f, []byte("["+strings.Join(groups, ",")+"]"), 0o600))
+
+	i := NewProm()
+	i.URLs = []string{down.URL + "/metrics", srv.URL + "/metrics"}
+	i.FileSD = &FileSD{Files: []string{f}}
+	require.NoError(t, i.Init())
+
+	pts, err := i.Collect()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2 errors")
+	assert.Len(t, pts, len(targets)) // 1 from URLs and the others from targets
+}
+
+func TestRelabelTarget(t *testing.T) {
+	cfgs := iprom.RelabelConfigs{
+		// only scrape SRV targets of port 9100
+		{SourceLabels: []string{"__meta_dns_srv_record_port"}, Regex: "9100", Action: "keep"},
+		{SourceLabels: []string{"__meta_dns_srv_record_target"}, Regex: `([^.]+)\..*`, TargetLabel: "node"},
+		{SourceLabels: []string{"__address__"}, Regex: `(.*):\d+`, TargetLabel: "__address__", Replacement: strPtr("$1:9200")},
+		{TargetLabel: "__metrics_path__", Replacement: strPtr("/stats")},
+	}
+	require.NoError(t, cfgs.Compile())
+
+	tg, err := newTarget("node-1.example.com:9100", map[string]string{
+		labelDNSSRVTarget: "node-1.example.com",
+		labelDNSSRVPort:   "9100",
+	}, "", "")
+	require.NoError(t, err)
+
+	res, ok := relabelTarget(tg, cfgs)
+	require.True(t, ok)
+	assert.Equal(t, "http://node-1.example.com:9200/stats", res.url)
+	assert.Equal(t, map[string]string{"node": "node-1", "instance": "node-1.example.com:9200"}, res.tags())
+
+	// the original target is not changed
+	assert.Equal(t, "http://node-1.example.com:9100/metrics", tg.url)
+
+	tg, err = newTarget("node-2.example.com:8080", map[string]string{labelDNSSRVPort: "8080"}, "", "")
+	require.NoError(t, err)
+	_, ok = relabelTarget(tg, cfgs)
+	assert.False(t, ok)
+}
+
+func strPtr(s string) *string { return &s }
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/GuanceCloud/cliutils"
@@ -70,7 +71,20 @@ type Input struct {
 
 	Auth map[string]string `toml:"auth" json:"auth"`
 
-	pm *iprom.Prom
+	MetricRelabelConfigs iprom.RelabelConfigs `toml:"metric_relabel_configs" json:"metric_relabel_configs"`
+	ScrapeProtocols      []string             `toml:"scrape_protocols" json:"scrape_protocols"`
+
+	// Targets discovered are scraped besides URLs, they are relabeled by
+	// RelabelConfigs before scraping.
+	FileSD         *FileSD              `toml:"file_sd" json:"file_sd"`
+	DNSSD          *DNSSD               `toml:"dns_sd" json:"dns_sd"`
+	RelabelConfigs iprom.RelabelConfigs `toml:"relabel_configs" json:"relabel_configs"`
+
+	opt *iprom.Option
+	pm  *iprom.Prom
+
+	discoverers []discoverer
+	sdTargets   map[string]*sdTarget
 
 	Election bool `toml:"election"`
 	chPause  chan bool
@@ -112,7 +126,7 @@ func (i *Input) Run() {
 		return
 	}
 
-	tick := time.NewTicker(i.pm.Option().GetIntervalDuration())
+	tick := time.NewTicker(i.opt.GetIntervalDuration())
 	defer tick.Stop()
 
 	i.l.Info("prom start")
@@ -152,7 +166,7 @@ func (i *Input) GetIntervalDuration() time.Duration {
 			return defaultIntervalDuration
 		}
 	}
-	return i.pm.Option().GetIntervalDuration()
+	return i.opt.GetIntervalDuration()
 }
 
 func (i *Input) RunningCollect() error {
@@ -188,7 +202,7 @@ func (i *Input) RunningCollect() error {
 }
 
 func (i *Input) doCollect() []*point.Point {
-	i.l.Debugf("collect URLs %v", i.URLs)
+	i.l.Debugf("collect URLs %v, %d targets discovered", i.URLs, len(i.sdTargets))
 
 	// If Output is configured, data is written to local file specified by Output.
 	// Data will no more be written to datakit io.
@@ -212,7 +226,7 @@ func (i *Input) doCollect() []*point.Point {
 			}
 		}
 
-		return nil
+		// points collected from the others are still fed
 	}
 
 	if len(pts) == 0 {
@@ -263,6 +277,18 @@ func (i *Input) Init() error {
 		i.urls = append(i.urls, uu)
 	}
 
+	discoverers, err := newDiscoverers(i)
+	if err != nil {
+		i.l.Warnf("service discovery: %s", err)
+		return err
+	}
+	if err := i.RelabelConfigs.Compile(); err != nil {
+		i.l.Warnf("relabel_configs: %s", err)
+		return err
+	}
+	i.discoverers = discoverers
+	i.sdTargets = map[string]*sdTarget{}
+
 	kvIgnore := iprom.IgnoreTagKeyValMatch{}
 	for k, arr := range i.IgnoreTagKV {
 		for _, x := range arr {
@@ -309,30 +335,68 @@ func (i *Input) Init() error {
 		MaxFileSize: i.MaxFileSize,
 		Auth:        i.Auth,
 
+		MetricRelabelConfigs: i.MetricRelabelConfigs,
+		ScrapeProtocols:      i.ScrapeProtocols,
+
 		Election: i.Election,
 	}
 
-	pm, err := iprom.NewProm(opt)
-	if err != nil {
-		i.l.Warnf("prom.NewProm: %s, ignored", err)
-		return err
+	// URLs may be empty if targets are discovered
+	if len(i.URLs) > 0 || len(i.discoverers) == 0 {
+		pm, err := iprom.NewProm(opt)
+		if err != nil {
+			i.l.Warnf("prom.NewProm: %s, ignored", err)
+			return err
+		}
+		i.pm = pm
 	}
-	i.pm = pm
+
+	i.opt = opt
 	i.isInitialized = true
 
 	return nil
 }
 
-// Collect collects metrics from all URLs.
+// Collect collects from all URLs and discovered targets. Points collected
+// are returned even if some of them failed, errors of them are combined.
 func (i *Input) Collect() ([]*point.Point, error) {
-	if i.pm == nil {
+	if i.pm == nil && len(i.discoverers) == 0 {
 		return nil, nil
 	}
-	var points []*point.Point
+
+	var (
+		points []*point.Point
+		errs   []error
+	)
+
+	if i.pm != nil {
+		pts, err := i.collectURLs()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		points = append(points, pts...)
+	}
+
+	pts, err := i.collectDiscovered()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	points = append(points, pts...)
+
+	return points, combineErrors(errs)
+}
+
+func (i *Input) collectURLs() ([]*point.Point, error) {
+	var (
+		points []*point.Point
+		errs   []error
+	)
+
 	for _, u := range i.URLs {
 		uu, err := url.Parse(u)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 		var pts []*point.Point
 		if uu.Scheme != "http" && uu.Scheme != "https" {
@@ -341,11 +405,29 @@ func (i *Input) Collect() ([]*point.Point, error) {
 			pts, err = i.CollectFromHTTP(u)
 		}
 		if err != nil {
-			return nil, err
+			errs = append(errs, fmt.Errorf("collect from %s: %w", u, err))
+			continue
 		}
 		points = append(points, pts...)
 	}
-	return points, nil
+
+	return points, combineErrors(errs)
+}
+
+// combineErrors combines errs into one error, nil returned if errs is empty.
+func combineErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("%d errors: %s", len(errs), strings.Join(msgs, "; "))
+	}
 }
 
 func (i *Input) CollectFromHTTP(u string) ([]*point.Point, error) {
@@ -356,10 +438,22 @@ func (i *Input) CollectFromHTTP(u string) ([]*point.Point, error) {
 }
 
 func (i *Input) CollectFromFile(filepath string) ([]*point.Point, error) {
-	if i.pm == nil {
-		return nil, nil
+	pm := i.pm
+	if pm == nil {
+		if i.opt == nil {
+			return nil, nil
+		}
+
+		// no URLs configured, all targets are discovered
+		opt := *i.opt
+		opt.URLs = []string{filepath}
+
+		var err error
+		if pm, err = iprom.NewProm(&opt); err != nil {
+			return nil, err
+		}
 	}
-	return i.pm.CollectFromFile(filepath)
+	return pm.CollectFromFile(filepath)
 }
 
 // WriteMetricText2File collects from all URLs and then
@@ -371,8 +465,21 @@ func (i *Input) WriteMetricText2File() error {
 			return err
 		}
 	}
-	for _, u := range i.URLs {
-		if err := i.pm.WriteMetricText2File(u); err != nil {
+	writers := map[string]*iprom.Prom{}
+	if i.pm != nil {
+		for _, u := range i.URLs {
+			writers[u] = i.pm
+		}
+	}
+	if len(i.discoverers) > 0 {
+		i.refreshTargets()
+		for _, t := range i.sdTargets {
+			writers[t.url] = t.pm
+		}
+	}
+
+	for u, pm := range writers {
+		if err := pm.WriteMetricText2File(u); err != nil {
 			return err
 		}
 		stat, err := os.Stat(i.Output)
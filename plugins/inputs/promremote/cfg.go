@@ -54,6 +54,13 @@ const (
   # measurement_prefix will be added to the start of measurement_name
   # measurement_name = "prom_remote_write"
 
+  ## Prometheus metric_relabel_configs, applied to each series in order with
+  # the metric name as label '__name__', before metric_name_filter and measurement_name_filter.
+  # [[inputs.prom_remote_write.metric_relabel_configs]]
+  #   source_labels = ["__name__"]
+  #   regex = "go_.*"
+  #   action = "drop"
+
   ## max body size in bytes, default set to 500MB
   # max_body_size = 0
 
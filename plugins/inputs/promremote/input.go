@@ -59,6 +59,10 @@ func (h *Input) RegHTTPHandler() {
 	if h.Path == "" {
 		h.Path = defaultRemoteWritePath
 	}
+	if err := h.MetricRelabelConfigs.Compile(); err != nil {
+		l.Errorf("metric_relabel_configs: %s, relabeling disabled", err)
+		h.MetricRelabelConfigs = nil
+	}
 	for _, m := range h.Methods {
 		dkhttp.RegHTTPHandler(m, h.Path, h.ServeHTTP)
 	}
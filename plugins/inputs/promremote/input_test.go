@@ -9,7 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
 )
 
 func TestAddTags(t *testing.T) {
@@ -92,3 +96,46 @@ func TestRenameTags(t *testing.T) {
 		})
 	}
 }
+
+func TestParseWithRelabel(t *testing.T) {
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "go_goroutines"},
+					{Name: "job", Value: "app"},
+				},
+				Samples: []prompb.Sample{{Value: 10, Timestamp: 1}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "http_requests_total"},
+					{Name: "job", Value: "app"},
+					{Name: "instance", Value: "10.0.0.1:8080"},
+				},
+				Samples: []prompb.Sample{{Value: 3, Timestamp: 1}},
+			},
+		},
+	}
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	ipt := NewInput()
+	ipt.MetricRelabelConfigs = iprom.RelabelConfigs{
+		{SourceLabels: []string{"__name__"}, Regex: "go_.*", Action: "drop"},
+		{SourceLabels: []string{"instance"}, Regex: "(.*):.*", TargetLabel: "host"},
+		{Regex: "instance", Action: "labeldrop"},
+	}
+	ipt.RegHTTPHandler()
+	require.NotNil(t, ipt.MetricRelabelConfigs)
+
+	ms, err := ipt.Parse(data)
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+
+	m, ok := ms[0].(*Measurement)
+	require.True(t, ok)
+	assert.Equal(t, "http", m.name)
+	assert.Equal(t, map[string]string{"job": "app", "host": "10.0.0.1"}, m.tags)
+	assert.Equal(t, map[string]interface{}{"requests_total": float64(3)}, m.fields)
+}
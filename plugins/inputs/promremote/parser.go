@@ -8,11 +8,13 @@ package promremote
 import (
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
+	iprom "gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/prom"
 	"gitlab.jiagouyun.com/cloudcare-tools/datakit/plugins/inputs"
 )
 
@@ -21,6 +23,8 @@ type Parser struct {
 	MeasurementNameFilter []string `toml:"measurement_name_filter"`
 	MeasurementPrefix     string   `toml:"measurement_prefix"`
 	MeasurementName       string   `toml:"measurement_name"`
+
+	MetricRelabelConfigs iprom.RelabelConfigs `toml:"metric_relabel_configs"`
 }
 
 // Parse parses given byte as protocol buffer. it performs necessary
@@ -44,6 +48,22 @@ func (p *Parser) Parse(buf []byte) ([]inputs.Measurement, error) {
 		if metric == "" {
 			return nil, fmt.Errorf("metric name %q not found in tag-set or empty", model.MetricNameLabel)
 		}
+
+		if len(p.MetricRelabelConfigs) > 0 {
+			if !p.MetricRelabelConfigs.Process(tags) {
+				continue
+			}
+			if metric = tags[model.MetricNameLabel]; metric == "" {
+				continue
+			}
+
+			// labels prefixed with "__" are only used for relabeling
+			for k := range tags {
+				if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+					delete(tags, k)
+				}
+			}
+		}
 		delete(tags, model.MetricNameLabel)
 
 		if !p.shouldFilterThroughMetricName(metric) {
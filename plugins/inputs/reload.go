@@ -0,0 +1,237 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package inputs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit"
+	"gitlab.jiagouyun.com/cloudcare-tools/datakit/internal/goroutine"
+)
+
+// ReloadStat is the hot reload result of a config file.
+type ReloadStat struct {
+	Source    string   `json:"source"`
+	Started   []string `json:"started,omitempty"`
+	Stopped   []string `json:"stopped,omitempty"`
+	Unchanged int      `json:"unchanged"`
+
+	// Pending are inputs changed or removed but not InputV2, they can't be
+	// terminated and keep running until DataKit restarted.
+	Pending []string `json:"pending,omitempty"`
+
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+
+	httpChanged bool
+}
+
+// HTTPChanged returns true if any HTTP input started or stopped, then the
+// HTTP routes should be registered again.
+func (s *ReloadStat) HTTPChanged() bool {
+	return s.httpChanged
+}
+
+var (
+	reloadMtx   sync.Mutex
+	reloadStats = map[string]*ReloadStat{}
+	reloadG     *goroutine.Group
+)
+
+// ReloadSource hot reloads inputs of the config file source with the inputs
+// loaded from it. Inputs with the same config keep running, changed or
+// removed inputs are terminated and new inputs started.
+func ReloadSource(source string, loaded []*LoadedInput) *ReloadStat {
+	reloadMtx.Lock()
+	defer reloadMtx.Unlock()
+
+	stat := &ReloadStat{Source: source, Time: time.Now()}
+
+	mtx.Lock()
+
+	kept := map[*inputInfo]bool{}
+	var toStart []*inputInfo
+
+	for _, x := range loaded {
+		if ii := findRunning(InputsInfo[x.Name], source, x.Fingerprint, kept); ii != nil {
+			kept[ii] = true
+			stat.Unchanged++
+			continue
+		}
+
+		toStart = append(toStart, &inputInfo{
+			input:       x.Input,
+			name:        x.Name,
+			source:      source,
+			fingerprint: x.Fingerprint,
+		})
+	}
+
+	var toStop []*inputInfo
+	pending := map[string]bool{}
+
+	for name, arr := range InputsInfo {
+		newList := make([]*inputInfo, 0, len(arr))
+		for _, ii := range arr {
+			if ii.source != source || kept[ii] {
+				newList = append(newList, ii)
+				continue
+			}
+
+			if _, ok := ii.input.(InputV2); !ok {
+				pending[name] = true
+				stat.Pending = append(stat.Pending, name)
+				newList = append(newList, ii)
+				continue
+			}
+
+			toStop = append(toStop, ii)
+		}
+
+		if len(newList) == 0 {
+			delete(InputsInfo, name)
+		} else {
+			InputsInfo[name] = newList
+		}
+	}
+
+	var started []*inputInfo
+	for _, ii := range toStart {
+		// the old one still running, or there will be duplicated inputs
+		if pending[ii.name] {
+			continue
+		}
+
+		if _, ok := ii.input.(Singleton); ok && len(InputsInfo[ii.name]) > 0 {
+			l.Warnf("the collector [%s] is singleton, allow only one instant running", ii.name)
+			continue
+		}
+
+		InputsInfo[ii.name] = append(InputsInfo[ii.name], ii)
+		started = append(started, ii)
+	}
+
+	remains := map[string]bool{}
+	for name, arr := range InputsInfo {
+		for _, ii := range arr {
+			if ii.source == source {
+				remains[name] = true
+			}
+		}
+	}
+
+	mtx.Unlock()
+
+	// stop old inputs first, new inputs may listen on the same port
+	for _, ii := range toStop {
+		l.Infof("terminate input %s from %s", ii.name, source)
+		ii.input.(InputV2).Terminate()
+		stat.Stopped = append(stat.Stopped, ii.name)
+
+		if _, ok := ii.input.(HTTPInput); ok {
+			stat.httpChanged = true
+		}
+
+		if !remains[ii.name] {
+			DeleteConfigInfoPath(ii.name, source)
+		}
+	}
+
+	if reloadG == nil {
+		reloadG = datakit.G("inputs_reload")
+	}
+
+	envs := getEnvs()
+	for _, ii := range started {
+		l.Infof("start input %s from %s", ii.name, source)
+
+		// HTTP handlers are registered on all HTTP inputs after reload
+		if _, ok := ii.input.(HTTPInput); ok {
+			stat.httpChanged = true
+		}
+
+		runInput(reloadG, ii.name, ii, envs, 0)
+		stat.Started = append(stat.Started, ii.name)
+		AddConfigInfoPath(ii.name, source, 1)
+	}
+
+	// 修改未加载
+	for name := range pending {
+		AddConfigInfoPath(name, source, 2)
+	}
+
+	sort.Strings(stat.Started)
+	sort.Strings(stat.Stopped)
+	sort.Strings(stat.Pending)
+
+	reloadStats[source] = stat
+	return stat
+}
+
+func findRunning(arr []*inputInfo, source, fingerprint string, kept map[*inputInfo]bool) *inputInfo {
+	for _, ii := range arr {
+		if ii.source == source && ii.fingerprint == fingerprint && !kept[ii] {
+			return ii
+		}
+	}
+	return nil
+}
+
+// ReloadFailed records the config file failed to load, inputs of the file
+// keep running.
+func ReloadFailed(source string, err error) *ReloadStat {
+	reloadMtx.Lock()
+	defer reloadMtx.Unlock()
+
+	stat := &ReloadStat{Source: source, Time: time.Now(), Error: err.Error()}
+	reloadStats[source] = stat
+	return stat
+}
+
+// Sources returns config files of the running inputs.
+func Sources() []string {
+	mtx.RLock()
+	defer mtx.RUnlock()
+
+	set := map[string]bool{}
+	for _, arr := range InputsInfo {
+		for _, ii := range arr {
+			if ii.source != "" {
+				set[ii.source] = true
+			}
+		}
+	}
+
+	res := make([]string, 0, len(set))
+	for s := range set {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// GetReloadStats returns the last hot reload of each config file, the latest
+// first.
+func GetReloadStats() []*ReloadStat {
+	reloadMtx.Lock()
+	defer reloadMtx.Unlock()
+
+	res := make([]*ReloadStat, 0, len(reloadStats))
+	for _, s := range reloadStats {
+		x := *s
+		res = append(res, &x)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Time.Equal(res[j].Time) {
+			return res[i].Time.After(res[j].Time)
+		}
+		return res[i].Source < res[j].Source
+	})
+	return res
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package inputs
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reloadInput struct {
+	running    int32
+	terminated chan struct{}
+}
+
+func newReloadInput() *reloadInput {
+	return &reloadInput{terminated: make(chan struct{})}
+}
+
+func (*reloadInput) Catalog() string                  { return "test" }
+func (*reloadInput) SampleConfig() string             { return "" }
+func (*reloadInput) SampleMeasurement() []Measurement { return nil }
+func (*reloadInput) AvailableArchs() []string         { return nil }
+func (i *reloadInput) Run() {
+	atomic.StoreInt32(&i.running, 1)
+	<-i.terminated
+	atomic.StoreInt32(&i.running, 0)
+}
+func (i *reloadInput) Terminate() { close(i.terminated) }
+
+// legacyInput is not InputV2, it can't be terminated.
+type legacyInput struct{}
+
+func (*legacyInput) Catalog() string      { return "test" }
+func (*legacyInput) SampleConfig() string { return "" }
+func (*legacyInput) Run()                 {}
+
+func isRunning(i *reloadInput) bool {
+	return atomic.LoadInt32(&i.running) == 1
+}
+
+func TestReloadSource(t *testing.T) {
+	ResetInputs()
+	defer ResetInputs()
+
+	const src = "/usr/local/datakit/conf.d/host/cpu.conf"
+
+	a, b := newReloadInput(), newReloadInput()
+	other := newReloadInput()
+
+	AddLoadedInput(&LoadedInput{Name: "cpu", Input: a, Source: src, Fingerprint: "fa"})
+	AddLoadedInput(&LoadedInput{Name: "cpu", Input: b, Source: src, Fingerprint: "fb"})
+	AddLoadedInput(&LoadedInput{Name: "cpu", Input: other, Source: "/other.conf", Fingerprint: "fa"})
+	AddLoadedInput(&LoadedInput{Name: "legacy", Input: &legacyInput{}, Source: src, Fingerprint: "fl"})
+
+	assert.Equal(t, []string{"/other.conf", src}, Sources())
+
+	// a unchanged, b changed to c, legacy removed
+	c := newReloadInput()
+	stat := ReloadSource(src, []*LoadedInput{
+		{Name: "cpu", Input: newReloadInput(), Fingerprint: "fa"},
+		{Name: "cpu", Input: c, Fingerprint: "fc"},
+	})
+
+	assert.Equal(t, 1, stat.Unchanged)
+	assert.Equal(t, []string{"cpu"}, stat.Started)
+	assert.Equal(t, []string{"cpu"}, stat.Stopped)
+	assert.Equal(t, []string{"legacy"}, stat.Pending)
+	assert.False(t, stat.HTTPChanged())
+
+	select {
+	case <-b.terminated:
+	default:
+		t.Fatal("b not terminated")
+	}
+
+	require.Eventually(t, func() bool { return isRunning(c) }, 5*time.Second, 10*time.Millisecond)
+
+	mtx.RLock()
+	var running []Input
+	for _, ii := range InputsInfo["cpu"] {
+		running = append(running, ii.input)
+	}
+	mtx.RUnlock()
+	assert.ElementsMatch(t, []Input{a, c, other}, running)
+	assert.Equal(t, 1, InputEnabled("legacy"))
+
+	// file removed
+	stat = ReloadSource(src, nil)
+	assert.Equal(t, []string{"cpu", "cpu"}, stat.Stopped)
+	assert.Equal(t, 1, InputEnabled("cpu"))
+
+	select {
+	case <-c.terminated:
+	default:
+		t.Fatal("c not terminated")
+	}
+	require.Eventually(t, func() bool { return !isRunning(c) }, 5*time.Second, 10*time.Millisecond)
+
+	stats := GetReloadStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, src, stats[0].Source)
+	assert.Empty(t, stats[0].Error)
+}
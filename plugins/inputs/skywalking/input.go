@@ -59,6 +59,31 @@ const (
   # [inputs.skywalking.sampler]
     # sampling_rate = 1.0
 
+  ## Tail sampler config buffers spans of the same trace for a decision window, then keeps or
+  ## drops the whole trace. If configured, error penetration, rare resource keeper and sampler
+  ## above will be replaced by the policies here.
+  ## decision_wait is the time window to wait for spans of a trace.
+  ## max_traces is the max number of buffered traces, the oldest one is decided in advance if exceeded.
+  ## keep_error keeps traces which contain error spans.
+  ## latency_threshold keeps traces whose duration exceed the threshold.
+  ## sampling_rate is the sampling rate of traces not hit by the policies above, default 1.0(all kept).
+  ## keep_resource keeps traces which contain resources matching the regular expressions, like
+  ## service:[resources...], service "*" stands for all services.
+  ## service_rate_limit is the max traces per second kept for service of the root span,
+  ## service "*" stands for every service.
+  # [inputs.skywalking.tail_sampler]
+    # decision_wait = "10s"
+    # max_traces = 50000
+    # keep_error = true
+    # latency_threshold = "3s"
+    # sampling_rate = 0.1
+    # [inputs.skywalking.tail_sampler.keep_resource]
+      # service1 = ["resource1", "resource2", ...]
+      # "*" = ["keep_resource_under_all_services"]
+    # [inputs.skywalking.tail_sampler.service_rate_limit]
+      # service1 = 10.0
+      # "*" = 100.0
+
   # [inputs.skywalking.tags]
     # key1 = "value1"
     # key2 = "value2"
@@ -90,6 +115,7 @@ type Input struct {
 	KeepRareResource bool                   `toml:"keep_rare_resource"`
 	CloseResource    map[string][]string    `toml:"close_resource"`
 	Sampler          *itrace.Sampler        `toml:"sampler"`
+	TailSampler      *itrace.TailSampler    `toml:"tail_sampler"`
 	Tags             map[string]string      `toml:"tags"`
 	LocalCacheConfig *storage.StorageConfig `toml:"storage"`
 }
@@ -108,7 +134,7 @@ func (ipt *Input) Run() {
 	log = logger.SLogger(inputName)
 
 	api = skywalkingapi.InitApiPluginAges(ipt.Plugins, ipt.LocalCacheConfig, ipt.CloseResource,
-		ipt.KeepRareResource, ipt.Sampler, ipt.CustomerTags, ipt.Tags, inputName)
+		ipt.KeepRareResource, ipt.Sampler, ipt.TailSampler, ipt.CustomerTags, ipt.Tags, inputName)
 	log.Debug("start skywalking grpc v3 server")
 
 	// start up grpc v3 routine
@@ -133,6 +159,9 @@ func (ipt *Input) Terminate() {
 	if api != nil {
 		api.CloseLocalCache()
 	}
+	if ipt.TailSampler != nil {
+		ipt.TailSampler.Stop()
+	}
 }
 
 func init() { //nolint:gochecknoinits
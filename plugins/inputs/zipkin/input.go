@@ -62,6 +62,31 @@ const (
   # [inputs.zipkin.sampler]
     # sampling_rate = 1.0
 
+  ## Tail sampler config buffers spans of the same trace for a decision window, then keeps or
+  ## drops the whole trace. If configured, error penetration, rare resource keeper and sampler
+  ## above will be replaced by the policies here.
+  ## decision_wait is the time window to wait for spans of a trace.
+  ## max_traces is the max number of buffered traces, the oldest one is decided in advance if exceeded.
+  ## keep_error keeps traces which contain error spans.
+  ## latency_threshold keeps traces whose duration exceed the threshold.
+  ## sampling_rate is the sampling rate of traces not hit by the policies above, default 1.0(all kept).
+  ## keep_resource keeps traces which contain resources matching the regular expressions, like
+  ## service:[resources...], service "*" stands for all services.
+  ## service_rate_limit is the max traces per second kept for service of the root span,
+  ## service "*" stands for every service.
+  # [inputs.zipkin.tail_sampler]
+    # decision_wait = "10s"
+    # max_traces = 50000
+    # keep_error = true
+    # latency_threshold = "3s"
+    # sampling_rate = 0.1
+    # [inputs.zipkin.tail_sampler.keep_resource]
+      # service1 = ["resource1", "resource2", ...]
+      # "*" = ["keep_resource_under_all_services"]
+    # [inputs.zipkin.tail_sampler.service_rate_limit]
+      # service1 = 10.0
+      # "*" = 100.0
+
   # [inputs.zipkin.tags]
     # key1 = "value1"
     # key2 = "value2"
@@ -102,6 +127,7 @@ type Input struct {
 	KeepRareResource bool                         `toml:"keep_rare_resource"`
 	CloseResource    map[string][]string          `toml:"close_resource"`
 	Sampler          *itrace.Sampler              `toml:"sampler"`
+	TailSampler      *itrace.TailSampler          `toml:"tail_sampler"`
 	Tags             map[string]string            `toml:"tags"`
 	WPConfig         *workerpool.WorkerPoolConfig `toml:"threads"`
 	LocalCacheConfig *storage.StorageConfig       `toml:"storage"`
@@ -218,6 +244,14 @@ func (ipt *Input) RegHTTPHandler() {
 		closeResource.UpdateIgnResList(ipt.CloseResource)
 		afterGather.AppendFilter(closeResource.Close)
 	}
+	// add tail sampler, it holds traces and skips all filters afterwards
+	if ipt.TailSampler != nil {
+		if err := ipt.TailSampler.Start(inputName, afterGather); err != nil {
+			log.Errorf("### start tail sampler failed: %s", err.Error())
+		} else {
+			afterGather.AppendFilter(ipt.TailSampler.Sample)
+		}
+	}
 	// add error status penetration
 	afterGather.AppendFilter(itrace.PenetrateErrorTracing)
 	// add rare resource keeper
@@ -262,7 +296,7 @@ func (ipt *Input) Run() {
 	ipt.Terminate()
 }
 
-func (*Input) Terminate() {
+func (ipt *Input) Terminate() {
 	if wkpool != nil {
 		wkpool.Shutdown()
 		log.Debug("### workerpool closed")
@@ -273,6 +307,10 @@ func (*Input) Terminate() {
 		}
 		log.Debug("### storage closed")
 	}
+	if ipt.TailSampler != nil {
+		ipt.TailSampler.Stop()
+		log.Debug("### tail sampler stopped")
+	}
 }
 
 func init() { //nolint:gochecknoinits